@@ -0,0 +1,272 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Ebonsignori/gh-search-docs/searchdocs"
+)
+
+// splitList splits repeated, comma-separated flag values into a deduplicated list
+func splitList(values []string) []string {
+	var list []string
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" && !slices.Contains(list, part) {
+				list = append(list, part)
+			}
+		}
+	}
+	return list
+}
+
+// checkTranslations annotates each hit with whether it exists in the requested languages
+func checkTranslations(stderr io.Writer, client *http.Client, opts *options, languages []string, hits []SearchItem) {
+	paths := make([]string, len(hits))
+	for i, item := range hits {
+		paths[i] = item.URL
+	}
+
+	checker := searchdocs.NewTranslationChecker(client, searchdocs.DocsBaseURL, opts.concurrency)
+	results, err := checker.Check(paths, languages)
+	if err != nil {
+		fmt.Fprintf(stderr, "Warning: some translation checks failed: %v\n", err)
+	}
+	for i := range hits {
+		hits[i].Translations = results[i]
+	}
+}
+
+// translationLine describes which requested translations exist for a hit, e.g.
+// "ja: ✓  ko: ✗ (English only)". Languages whose check failed are shown as unknown ("es: ?").
+func translationLine(languages []string, item SearchItem) string {
+	if item.Translations == nil {
+		return ""
+	}
+
+	parts := make([]string, 0, len(languages))
+	for _, language := range languages {
+		translated, known := item.Translations[language]
+		switch {
+		case !known:
+			parts = append(parts, language+": ?")
+		case translated:
+			parts = append(parts, language+": ✓")
+		default:
+			parts = append(parts, language+": ✗ (English only)")
+		}
+	}
+	return strings.Join(parts, "  ")
+}
+
+// printTranslationCoverage writes a summary of how many hits exist in each requested language.
+// Hits whose check failed are reported as unknown rather than counted as missing.
+func printTranslationCoverage(w io.Writer, languages []string, hits []SearchItem) {
+	if len(languages) == 0 || len(hits) == 0 {
+		return
+	}
+
+	parts := make([]string, 0, len(languages))
+	for _, language := range languages {
+		translated, checked := 0, 0
+		for _, item := range hits {
+			if exists, known := item.Translations[language]; known {
+				checked++
+				if exists {
+					translated++
+				}
+			}
+		}
+		part := fmt.Sprintf("%s %d/%d", language, translated, checked)
+		if unknown := len(hits) - checked; unknown > 0 {
+			part += fmt.Sprintf(" (%d unknown)", unknown)
+		}
+		parts = append(parts, part)
+	}
+	fmt.Fprintf(w, "Translation coverage: %s\n", strings.Join(parts, ", "))
+}
+
+// checkAvailability annotates each hit with whether it exists for each plan
+func checkAvailability(stderr io.Writer, client *http.Client, opts *options, hits []SearchItem) {
+	paths := make([]string, len(hits))
+	for i, item := range hits {
+		paths[i] = item.URL
+	}
+
+	checker := searchdocs.NewAvailabilityChecker(client, searchdocs.DocsBaseURL, opts.concurrency)
+	results, err := checker.Check(paths, searchdocs.AvailabilityPlans())
+	if err != nil {
+		fmt.Fprintf(stderr, "Warning: some availability checks failed: %v\n", err)
+	}
+	for i := range hits {
+		hits[i].Availability = results[i]
+	}
+}
+
+// availabilityBadges describes which plans a hit exists for, e.g. "[FPT ✓ GHEC ✓ GHES ✗]".
+// Plans whose check failed are shown as unknown ("GHES ?").
+func availabilityBadges(item SearchItem) string {
+	if item.Availability == nil {
+		return ""
+	}
+
+	plans := searchdocs.AvailabilityPlans()
+	parts := make([]string, 0, len(plans))
+	for _, plan := range plans {
+		exists, known := item.Availability[plan.Label]
+		switch {
+		case !known:
+			parts = append(parts, plan.Label+" ?")
+		case exists:
+			parts = append(parts, plan.Label+" ✓")
+		default:
+			parts = append(parts, plan.Label+" ✗")
+		}
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
+
+// addAnchors links each hit to the section most relevant to the query: the heading matched
+// by --heading, otherwise the first heading containing every query term. Hits without
+// headings keep linking to the top of the page.
+func addAnchors(opts *options, query string, hits []SearchItem) {
+	terms := searchdocs.QueryTerms(query)
+	for i, item := range hits {
+		headings := searchdocs.SplitHeadings(item.Headings)
+		if len(headings) == 0 {
+			continue
+		}
+
+		heading := ""
+		if matched := matchedHeadings(opts, item); len(matched) > 0 {
+			heading = matched[0]
+		} else if len(terms) > 0 {
+			for _, h := range headings {
+				if searchdocs.ContainsTerms(h, terms, false) {
+					heading = h
+					break
+				}
+			}
+		}
+		if heading != "" {
+			hits[i].Anchor = searchdocs.HeadingAnchor(headings, heading)
+		}
+		if opts.anchors {
+			hits[i].HeadingLinks = headingLinks(opts, terms, item, headings)
+		}
+	}
+}
+
+// headingLinks returns a deep link to each of a hit's headings that contains a query term or
+// was matched by --heading, in page order
+func headingLinks(opts *options, terms []string, item SearchItem, headings []string) []HeadingLink {
+	matched := matchedHeadings(opts, item)
+	anchors := searchdocs.HeadingAnchors(headings)
+	var links []HeadingLink
+	for i, h := range headings {
+		if len(terms) > 0 && searchdocs.ContainsTerms(h, terms, true) || slices.Contains(matched, h) {
+			links = append(links, HeadingLink{Heading: h, URL: searchdocs.DocsBaseURL + item.URL + "#" + anchors[i]})
+		}
+	}
+	return links
+}
+
+// headingLines returns the "§" lines listing a hit's headings: the ones matched by --heading,
+// or with --anchors each matching heading with its deep link
+func headingLines(opts *options, item SearchItem) []string {
+	var lines []string
+	if opts.anchors {
+		for _, link := range item.HeadingLinks {
+			lines = append(lines, "§ "+link.Heading+" — "+link.URL)
+		}
+		return lines
+	}
+	for _, heading := range matchedHeadings(opts, item) {
+		lines = append(lines, "§ "+heading)
+	}
+	return lines
+}
+
+// When result titles and breadcrumbs are OSC 8 hyperlinks, as accepted by --hyperlinks
+const (
+	hyperlinksAuto   = "auto"
+	hyperlinksAlways = "always"
+	hyperlinksNever  = "never"
+)
+
+// hyperlinkTerminals are the TERM_PROGRAM values of terminals known to support OSC 8 hyperlinks
+var hyperlinkTerminals = []string{"iTerm.app", "WezTerm", "vscode", "ghostty", "Hyper", "Tabby", "WarpTerminal"}
+
+// hyperlinksEnabled reports whether stdout is a terminal known to support OSC 8 hyperlinks.
+// Windows Terminal and kitty don't set TERM_PROGRAM, so they're recognized by their own
+// variables. It is a variable so tests can simulate a terminal.
+var hyperlinksEnabled = func() bool {
+	if !stdoutIsTerminal() || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return slices.Contains(hyperlinkTerminals, os.Getenv("TERM_PROGRAM")) ||
+		os.Getenv("WT_SESSION") != "" ||
+		os.Getenv("KITTY_WINDOW_ID") != "" ||
+		strings.HasPrefix(os.Getenv("TERM"), "xterm-kitty")
+}
+
+// useHyperlinks reports whether output is linked with OSC 8 hyperlinks: as --hyperlinks says,
+// or when stdout supports them by default. Plain output, which is also what piped output gets
+// without --color always, is never linked.
+func useHyperlinks(opts *options) bool {
+	if opts.plain {
+		return false
+	}
+	switch opts.hyperlinks {
+	case hyperlinksAlways:
+		return true
+	case hyperlinksNever:
+		return false
+	}
+	return hyperlinksEnabled()
+}
+
+// breadcrumbLine returns a hit's breadcrumbs for display, or "" when breadcrumbs aren't being
+// shown. When hyperlinks are enabled each segment links to its inferred landing page.
+func breadcrumbLine(opts *options, item SearchItem) string {
+	if !opts.showBreadcrumbs {
+		return ""
+	}
+
+	if opts.noBreadcrumbLinks || item.Archived || !useHyperlinks(opts) {
+		return searchdocs.FormatBreadcrumbs(item.Breadcrumbs, searchdocs.BreadcrumbSeparator)
+	}
+
+	links := searchdocs.BreadcrumbLinks(item.URL, item.Breadcrumbs)
+	parts := make([]string, len(links))
+	for i, link := range links {
+		parts[i] = searchdocs.Hyperlink(searchdocs.DocsBaseURL+link.Path, link.Title)
+	}
+	return strings.Join(parts, searchdocs.BreadcrumbSeparator)
+}
+
+// longDetails returns the --long lines for a hit: every populated field the other lines don't
+// already show. Breadcrumbs are left to breadcrumbLine when --show-breadcrumbs is given.
+func longDetails(opts *options, item SearchItem) []string {
+	var lines []string
+	if !opts.showBreadcrumbs {
+		if path := searchdocs.FormatBreadcrumbs(item.Breadcrumbs, " > "); path != "" {
+			lines = append(lines, "Path: "+path)
+		}
+	}
+	if item.Toplevel != "" {
+		lines = append(lines, "Top level: "+item.Toplevel)
+	}
+	if headings := searchdocs.SplitHeadings(item.Headings); len(headings) > 0 {
+		lines = append(lines, "Headings: "+strings.Join(headings, ", "))
+	}
+	if item.Score != 0 {
+		lines = append(lines, "Score: "+strconv.FormatFloat(item.Score, 'f', -1, 64))
+	}
+	return lines
+}
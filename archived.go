@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Ebonsignori/gh-search-docs/searchdocs"
+)
+
+// maxArchivedSitemaps bounds how many child sitemaps of an archived sitemap index are fetched
+const maxArchivedSitemaps = 10
+
+// searchArchived performs a best-effort search of the archived docs for an enterprise server
+// version that is no longer in the live search index. Archived docs have no search API, so
+// page URLs from the archived sitemap are matched against the query client-side.
+func searchArchived(stdout, stderr io.Writer, client *http.Client, opts *options, query, server string, rec *transcript) int {
+	locations, err := fetchArchivedPages(client, searchdocs.ArchivedSitemapURL(server), opts.language)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error fetching archived docs for enterprise-server@%s: %v\n", server, err)
+		return 1
+	}
+
+	var result SearchResult
+	for _, page := range searchdocs.SearchArchivedPages(locations, query, opts.size) {
+		result.Hits = append(result.Hits, SearchItem{
+			Title:       page.Title,
+			URL:         page.URL,
+			Breadcrumbs: page.Breadcrumbs,
+			Archived:    true,
+		})
+	}
+	result.Meta.Found.Value = len(result.Hits)
+	result.Meta.Found.Relation = "eq"
+	result.Meta.Page = 1
+	result.Meta.Size = opts.size
+	rec.Meta = result.Meta
+	rec.recordHits(result.Hits)
+
+	note := fmt.Sprintf("enterprise-server@%s is archived; results are matched against archived page URLs only and may be incomplete", server)
+	return outputResults(stdout, stderr, opts, query, result, nil, []string{note})
+}
+
+// fetchArchivedPages returns the page URLs in an archived sitemap for a language. Sitemap
+// indexes are followed one level deep.
+func fetchArchivedPages(client *http.Client, sitemapURL, language string) ([]string, error) {
+	sitemap, err := fetchSitemap(client, sitemapURL)
+	if err != nil {
+		return nil, err
+	}
+
+	pages := sitemap.Locations
+	if sitemap.Index {
+		// Prefer the child sitemaps for the requested language when the index is split by language
+		children := sitemap.Locations
+		var localized []string
+		for _, child := range children {
+			if strings.Contains(child, "/"+language+"/") {
+				localized = append(localized, child)
+			}
+		}
+		if len(localized) > 0 {
+			children = localized
+		}
+		if len(children) > maxArchivedSitemaps {
+			children = children[:maxArchivedSitemaps]
+		}
+
+		pages = nil
+		for _, child := range children {
+			childSitemap, err := fetchSitemap(client, child)
+			if err != nil {
+				return nil, err
+			}
+			pages = append(pages, childSitemap.Locations...)
+		}
+	}
+
+	var localized []string
+	for _, page := range pages {
+		if archivedPageLanguage(page) == language {
+			localized = append(localized, page)
+		}
+	}
+	return localized, nil
+}
+
+// archivedPageLanguage returns the language of an archived page URL, which follows the
+// archive prefix, e.g. https://github.github.com/docs-ghes-3.10/en/...
+func archivedPageLanguage(page string) string {
+	u, err := url.Parse(page)
+	if err != nil {
+		return ""
+	}
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(segments) > 1 && strings.HasPrefix(segments[0], "docs-ghes-") {
+		return segments[1]
+	}
+	return segments[0]
+}
+
+// fetchSitemap downloads and parses a sitemap
+func fetchSitemap(client *http.Client, sitemapURL string) (*searchdocs.Sitemap, error) {
+	resp, err := client.Get(sitemapURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned status %d", sitemapURL, resp.StatusCode)
+	}
+	return searchdocs.ParseSitemap(resp.Body)
+}
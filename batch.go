@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/Ebonsignori/gh-search-docs/searchdocs"
+)
+
+// runBatch runs each query from --queries-file with the same options, --parallel at a time,
+// printing the results of each under a separator in file order. A failed query is reported
+// and skipped. The queries share client, so they stay within --concurrency and back off
+// together when rate limited. Results aren't paged, since each query's would otherwise get a
+// pager of its own between the separators.
+func runBatch(stdout, stderr io.Writer, client *http.Client, opts *options, queries []string, version string) int {
+	batch := *opts
+	batch.noPager = true
+	opts = &batch
+
+	cache, err := searchCache(opts)
+	if err != nil {
+		fmt.Fprintln(stderr, "error:", err)
+		return 1
+	}
+
+	// RunBatchSearch only hands render the result, so the rest of each processed search is
+	// kept here for it
+	var mu sync.Mutex
+	processed := map[*SearchResult]processedSearch{}
+	fetch := func(query string) (*SearchResult, error) {
+		query, err := prepareQuery(stderr, opts, query)
+		if err != nil {
+			return nil, err
+		}
+		result, err := requestSearch(stderr, client, opts, cache, query, version)
+		if err != nil {
+			return nil, err
+		}
+		search := processSearch(stderr, client, opts, query, version, *result)
+		search.record(stderr, opts, query, version)
+		mu.Lock()
+		processed[result] = search
+		mu.Unlock()
+		return result, nil
+	}
+	if opts.parallel > 1 {
+		// Fetch ahead, then hand the results to the batch in query order
+		fetched, _ := searchdocs.RunParallelSearch(queries, fetch, opts.parallel)
+		next := 0
+		fetch = func(string) (*SearchResult, error) {
+			result := fetched[next]
+			next++
+			return result.Result, result.Err
+		}
+	}
+	render := func(result *SearchResult, query string, w io.Writer) {
+		mu.Lock()
+		search := processed[result]
+		mu.Unlock()
+		writeSearch(w, stderr, opts, query, search)
+	}
+	if err := searchdocs.RunBatchSearch(queries, fetch, render, stdout, stderr); err != nil {
+		return 1
+	}
+	return 0
+}
+
+// lockedWriter serializes writes to w, so --parallel workers can share stderr
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Ebonsignori/gh-search-docs/searchdocs"
+)
+
+// bookmarkResult saves the hit picked with --bookmark or --bookmark-n. The confirmation goes
+// to stderr so stdout holds only the results.
+func bookmarkResult(stderr io.Writer, opts *options, query string, hits []SearchItem) int {
+	n := max(opts.bookmarkN, 1)
+	if n > len(hits) {
+		fmt.Fprintf(stderr, "Error: can't bookmark result %d; only %d shown.\n", n, len(hits))
+		return 1
+	}
+	item := hits[n-1]
+	bookmark, err := searchdocs.AddBookmark(item, query)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error saving bookmark: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stderr, "Bookmarked %s (ID %s)\n", bookmark.Title, bookmark.ID)
+
+	err = searchdocs.AppendHistory(searchdocs.HistoryEntry{
+		Timestamp: time.Now().UTC(),
+		Action:    searchdocs.HistoryBookmark,
+		URL:       item.URL,
+	})
+	if err != nil {
+		fmt.Fprintf(stderr, "Warning: could not record search history: %v\n", err)
+	}
+	return 0
+}
+
+// listBookmarks prints every saved bookmark with the ID --remove-bookmark takes
+func listBookmarks(stdout, stderr io.Writer) int {
+	bookmarks, err := searchdocs.LoadBookmarks()
+	if err != nil {
+		fmt.Fprintf(stderr, "Error reading bookmarks: %v\n", err)
+		return 1
+	}
+	if len(bookmarks) == 0 {
+		fmt.Fprintln(stdout, "No bookmarks yet. Save one with: gh search-docs --bookmark <query>")
+		return 0
+	}
+
+	for _, b := range bookmarks {
+		fmt.Fprintf(stdout, "%s  %s\n", b.ID, b.Title)
+		fmt.Fprintf(stdout, "          %s\n", b.URL)
+		if b.Query != "" {
+			fmt.Fprintf(stdout, "          from %q, %s\n", b.Query, b.AddedAt)
+		}
+	}
+	return 0
+}
+
+// removeBookmark deletes a bookmark by ID
+func removeBookmark(stdout, stderr io.Writer, id string) int {
+	if err := searchdocs.RemoveBookmark(id); err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "Removed bookmark %s\n", id)
+	return 0
+}
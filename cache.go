@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/Ebonsignori/gh-search-docs/searchdocs"
+)
+
+// namedCacheStats labels the statistics of one of the on-disk caches
+type namedCacheStats struct {
+	Name string `json:"name"`
+	searchdocs.CacheStats
+}
+
+// runCache implements the cache subcommand for inspecting the on-disk caches
+func runCache(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] != "stats" {
+		fmt.Fprintf(stderr, "usage: gh search-docs cache stats [--format table|json]\n")
+		return 2
+	}
+
+	fs := flag.NewFlagSet("cache stats", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	format := fs.String("format", "table", "output format: table (default), json")
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: gh search-docs cache stats [flags]\n\n")
+		fmt.Fprintf(stderr, "Report the size and contents of the search and article caches.\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	searches, err := searchdocs.DefaultFileCache(searchdocs.DefaultCacheTTL)
+	if err != nil {
+		fmt.Fprintln(stderr, "error:", err)
+		return 1
+	}
+	searchStats, err := searches.Stats()
+	if err != nil {
+		fmt.Fprintf(stderr, "Error reading search cache: %v\n", err)
+		return 1
+	}
+	articles, err := searchdocs.DefaultArticleCache()
+	if err != nil {
+		fmt.Fprintln(stderr, "error:", err)
+		return 1
+	}
+	articleStats, err := articles.Stats()
+	if err != nil {
+		fmt.Fprintf(stderr, "Error reading article cache: %v\n", err)
+		return 1
+	}
+	caches := []namedCacheStats{
+		{Name: "search", CacheStats: searchStats},
+		{Name: "articles", CacheStats: articleStats},
+	}
+
+	switch *format {
+	case "json":
+		output, err := json.MarshalIndent(caches, "", "  ")
+		if err != nil {
+			fmt.Fprintln(stderr, "error:", err)
+			return 1
+		}
+		fmt.Fprintln(stdout, string(output))
+	case "table":
+		for i, c := range caches {
+			if i > 0 {
+				fmt.Fprintln(stdout)
+			}
+			printCacheStats(stdout, c)
+		}
+	default:
+		fmt.Fprintf(stderr, "Error: unknown --format %q (use table or json)\n", *format)
+		return 1
+	}
+	return 0
+}
+
+// printCacheStats describes one cache
+func printCacheStats(w io.Writer, c namedCacheStats) {
+	fmt.Fprintf(w, "%s cache: %s\n", c.Name, c.Dir)
+	fmt.Fprintf(w, "  Entries: %d (%d expired)\n", c.Entries, c.Expired)
+	if c.MaxBytes > 0 {
+		fmt.Fprintf(w, "  Size: %s of %s\n", formatBytes(c.Bytes), formatBytes(c.MaxBytes))
+	} else {
+		fmt.Fprintf(w, "  Size: %s\n", formatBytes(c.Bytes))
+	}
+	fmt.Fprintf(w, "  TTL: %s\n", c.TTL)
+}
+
+// formatBytes renders a byte count with a binary unit, e.g. 1.5 MiB
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/Ebonsignori/gh-search-docs/searchdocs"
+)
+
+// runPager pipes output through a pager; it is a variable so tests can capture what would be paged
+var runPager = searchdocs.RunWithPager
+
+// fetchContent fills in each hit's Content with its full article for --show-content. Articles
+// are fetched through the article cache, at most --concurrency at a time. Hits whose article
+// can't be fetched keep an empty Content and a warning is printed.
+func fetchContent(stderr io.Writer, client *http.Client, opts *options, hits []SearchItem) {
+	fetcher := &searchdocs.ArticleFetcher{Client: client, BaseURL: searchdocs.DocsBaseURL}
+	if cache, err := searchdocs.DefaultArticleCache(); err == nil {
+		fetcher.Cache = cache
+		if opts.debug {
+			defer func() {
+				hits, misses := cache.Lookups()
+				fmt.Fprintf(stderr, "Article cache: %d hits, %d misses\n", hits, misses)
+			}()
+		}
+	}
+
+	errs := make([]error, len(hits))
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, max(opts.concurrency, 1))
+	)
+	for i := range hits {
+		// Archived pages aren't served by the article API
+		if hits[i].Content != "" || hits[i].Archived {
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			// Each goroutine writes only its own index
+			hits[i].Content, errs[i] = fetcher.FetchArticle(context.Background(), hits[i].URL)
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			fmt.Fprintf(stderr, "Warning: couldn't fetch the content of %s: %v\n", hits[i].URL, err)
+		}
+	}
+}
+
+// printRenderedContent writes a hit's article rendered as Markdown, falling back to plain
+// text if rendering fails
+func printRenderedContent(w io.Writer, renderer markdownRenderer, item SearchItem) {
+	if item.Content == "" {
+		return
+	}
+	output, err := renderMarkdown(renderer, item.Content)
+	if err != nil {
+		printPlainContent(w, item)
+		return
+	}
+	fmt.Fprint(w, output)
+}
+
+// printPlainContent writes a hit's article with its HTML stripped, indented under the hit
+func printPlainContent(w io.Writer, item SearchItem) {
+	content := strings.TrimSpace(searchdocs.StripHTML(item.Content))
+	if content == "" {
+		return
+	}
+	fmt.Fprintln(w)
+	for _, line := range strings.Split(content, "\n") {
+		if line = strings.TrimRight(line, " \t\r"); line == "" {
+			fmt.Fprintln(w)
+			continue
+		}
+		fmt.Fprintf(w, "   %s\n", line)
+	}
+}
+
+// writePaged writes output to stdout, through the pager when it has more lines than fit in
+// the terminal. If the pager can't be started the output is written directly.
+func writePaged(stdout, stderr io.Writer, output string) {
+	pager := searchdocs.PagerCommand()
+	if pager == "" || strings.Count(output, "\n") <= terminalHeight() {
+		fmt.Fprint(stdout, output)
+		return
+	}
+	var wrote bool
+	err := runPager(pager, func(w io.Writer) {
+		wrote = true
+		fmt.Fprint(w, output)
+	})
+	if err != nil {
+		fmt.Fprintf(stderr, "Warning: couldn't run pager %q: %v\n", pager, err)
+		if !wrote {
+			fmt.Fprint(stdout, output)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// explainRequest writes a description of how the search request was built to w, one
+// "explain:" line per decision, so users can see why they got the results they did
+func explainRequest(w io.Writer, opts *options, input, query, version string, searchURL *url.URL) {
+	explain := func(format string, args ...interface{}) {
+		fmt.Fprintf(w, "explain: "+format+"\n", args...)
+	}
+
+	explain("query: %q", query)
+	if input != query {
+		explain("normalized query from %q (disable with --no-normalize)", input)
+	}
+
+	explain("version: %s", version)
+	if opts.version != version {
+		explain("requested version %q was normalized to %q", opts.version, version)
+	}
+	explain("language: %s", opts.language)
+	if slices.Contains(splitList(opts.includes), includeAll) {
+		explain("include: %s expanded to %s", includeAll, strings.Join(expandIncludes(opts.includes), ", "))
+	}
+
+	if included, excluded := toplevelFilters(opts); len(excluded) > 0 {
+		sent := "none"
+		if len(included) > 0 {
+			sent = strings.Join(included, ", ")
+		}
+		explain("toplevel: sending %s to the API; excluding %s client-side (the API only supports positive filters)", sent, strings.Join(excluded, ", "))
+	}
+
+	if filters := clientFilters(opts, query); len(filters) > 0 {
+		names := make([]string, 0, len(filters))
+		for _, filter := range filters {
+			names = append(names, filter.flag)
+		}
+		explain("client-side filters: %s", strings.Join(names, ", "))
+		if opts.all {
+			explain("filtering every page fetched by --all")
+		} else if opts.count {
+			explain("--count prints the API's total, which client-side filters don't change")
+		} else if overFetching(opts, query) {
+			explain("over-fetching %d results so filtering doesn't starve the %d displayed", maxAPISize, opts.size)
+		} else {
+			explain("filtering page %d only; over-fetching is disabled with --page", opts.page)
+		}
+	}
+
+	if opts.count {
+		explain("size: requesting 1 result, since --count only prints the total")
+	}
+	if opts.all {
+		explain("paging: fetching up to %d pages of %d results (--all, --max-pages)", opts.maxPages, maxAPISize)
+	}
+	explain("concurrency: at most %d requests in flight (--concurrency)", opts.concurrency)
+	explain("request: GET %s", searchURL.String())
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Ebonsignori/gh-search-docs/searchdocs"
+)
+
+// maxAPISize is the largest page size the GitHub Docs search API accepts
+const maxAPISize = 50
+
+// hitFilter is a client-side filter applied to hits after they are fetched
+type hitFilter struct {
+	// flag is the command line flag responsible for the filter, used when reporting
+	flag string
+	// reason optionally explains why hits were removed, e.g. "for diversity"
+	reason string
+	keep   func(item SearchItem) bool
+}
+
+// filterCount records how many hits a client-side filter suppressed
+type filterCount struct {
+	flag   string
+	reason string
+	count  int
+}
+
+func (c filterCount) String() string {
+	if c.reason != "" {
+		return fmt.Sprintf("%d by %s (%s)", c.count, c.flag, c.reason)
+	}
+	return fmt.Sprintf("%d by %s", c.count, c.flag)
+}
+
+// keepBy adapts a searchdocs filter over a list of hits to a hitFilter's keep function,
+// which applyFilters calls one hit at a time to count what each filter removed
+func keepBy(filter func(hits []SearchItem) []SearchItem) func(item SearchItem) bool {
+	return func(item SearchItem) bool {
+		return len(filter([]SearchItem{item})) == 1
+	}
+}
+
+// clientFilters returns the client-side filters requested by the options, in the order
+// they should be applied
+func clientFilters(opts *options, query string) []hitFilter {
+	var filters []hitFilter
+
+	if len(opts.breadcrumbs) > 0 {
+		prefixes := opts.breadcrumbs
+		filters = append(filters, hitFilter{
+			flag: "--breadcrumb",
+			keep: func(item SearchItem) bool {
+				for _, prefix := range prefixes {
+					if searchdocs.MatchBreadcrumbPrefix(item.Breadcrumbs, prefix) {
+						return true
+					}
+				}
+				return false
+			},
+		})
+	}
+
+	if len(opts.breadcrumbTexts) > 0 {
+		texts := opts.breadcrumbTexts
+		filters = append(filters, hitFilter{
+			flag: "--filter-breadcrumb",
+			keep: keepBy(func(hits []SearchItem) []SearchItem {
+				return searchdocs.FilterByBreadcrumb(hits, texts)
+			}),
+		})
+	}
+
+	if _, excluded := toplevelFilters(opts); len(excluded) > 0 {
+		filters = append(filters, hitFilter{
+			flag: "--exclude-toplevel",
+			keep: func(item SearchItem) bool {
+				for _, value := range excluded {
+					if searchdocs.InToplevel(item.URL, item.Toplevel, value) {
+						return false
+					}
+				}
+				return true
+			},
+		})
+	}
+
+	if opts.matchTitle != "" {
+		terms := searchdocs.QueryTerms(query)
+		matchAny := opts.matchTitle == "any"
+		filters = append(filters, hitFilter{
+			flag: "--match-title",
+			keep: func(item SearchItem) bool {
+				return searchdocs.ContainsTerms(item.Title, terms, matchAny)
+			},
+		})
+	}
+
+	if len(opts.headings) > 0 {
+		texts := opts.headings
+		filters = append(filters, hitFilter{
+			flag: "--heading",
+			keep: func(item SearchItem) bool {
+				for _, text := range texts {
+					if _, ok := searchdocs.MatchHeading(item.Headings, text); !ok {
+						return false
+					}
+				}
+				return true
+			},
+		})
+	}
+
+	if opts.minScore > 0 {
+		minScore := opts.minScore
+		filters = append(filters, hitFilter{
+			flag:   fmt.Sprintf("--min-score %.2f", minScore),
+			reason: "below threshold",
+			keep: keepBy(func(hits []SearchItem) []SearchItem {
+				return searchdocs.FilterByScore(hits, minScore)
+			}),
+		})
+	}
+
+	// Diversity capping runs last so it only counts hits that survived the other filters
+	if opts.perCategory > 0 {
+		limit := opts.perCategory
+		// Hits without a toplevel share the empty bucket
+		seen := map[string]int{}
+		filters = append(filters, hitFilter{
+			flag:   fmt.Sprintf("--per-category %d", limit),
+			reason: "skipped for diversity",
+			keep: func(item SearchItem) bool {
+				seen[item.Toplevel]++
+				return seen[item.Toplevel] <= limit
+			},
+		})
+	}
+
+	return filters
+}
+
+// toplevelFilters splits the toplevel filters into those sent to the API and those excluded
+// client-side: --exclude-toplevel values and --toplevel values prefixed with "!". The API
+// only supports positive filters.
+func toplevelFilters(opts *options) (included, excluded []string) {
+	for _, value := range splitList(opts.toplevel) {
+		if negated, ok := strings.CutPrefix(value, "!"); ok {
+			if negated != "" {
+				excluded = append(excluded, negated)
+			}
+		} else {
+			included = append(included, value)
+		}
+	}
+	return included, splitList(append(excluded, opts.excludeToplevel...))
+}
+
+// overFetching reports whether the request asks for a full API page so client-side filters
+// don't starve the displayed results. Explicit pages are fetched at --size instead: API pages
+// of 50 can't be mapped onto pages of filtered results, so with --page the filters apply to
+// that page only.
+func overFetching(opts *options, query string) bool {
+	return opts.page == 0 && len(clientFilters(opts, query)) > 0
+}
+
+// matchedHeadings returns the heading matched by each --heading value, skipping duplicates
+func matchedHeadings(opts *options, item SearchItem) []string {
+	var matched []string
+	for _, text := range opts.headings {
+		heading, ok := searchdocs.MatchHeading(item.Headings, text)
+		if ok && !slices.Contains(matched, heading) {
+			matched = append(matched, heading)
+		}
+	}
+	return matched
+}
+
+// applyFilters runs each filter over the hits and returns the hits that survived along with
+// the number of hits each filter removed. Only hits among the first window, the ones that
+// would have been shown without filtering, are counted; over-fetched hits past it are not.
+// Filters that removed nothing counted are omitted.
+func applyFilters(hits []SearchItem, filters []hitFilter, window int) ([]SearchItem, []filterCount) {
+	// positions tracks each surviving hit's rank in the unfiltered results
+	positions := make([]int, len(hits))
+	for i := range positions {
+		positions[i] = i
+	}
+
+	var counts []filterCount
+	for _, filter := range filters {
+		kept := hits[:0:0]
+		keptPositions := positions[:0:0]
+		removed := 0
+		for i, item := range hits {
+			if filter.keep(item) {
+				kept = append(kept, item)
+				keptPositions = append(keptPositions, positions[i])
+			} else if positions[i] < window {
+				removed++
+			}
+		}
+		if removed > 0 {
+			counts = append(counts, filterCount{flag: filter.flag, reason: filter.reason, count: removed})
+		}
+		hits, positions = kept, keptPositions
+	}
+	return hits, counts
+}
+
+// deduplicateHits drops later hits for a page already in the results, by URL or by ID, for
+// --deduplicate, and returns the hits kept and how many were removed
+func deduplicateHits(hits []SearchItem) ([]SearchItem, int) {
+	kept := searchdocs.DeduplicateByID(searchdocs.DeduplicateByURL(hits))
+	return kept, len(hits) - len(kept)
+}
+
+// warnAllFiltered says how to get results back when a single client-side filter removed
+// every hit, since it then likely doesn't suit the query
+func warnAllFiltered(stderr io.Writer, opts *options, hits []SearchItem, counts []filterCount) {
+	if len(hits) > 0 || len(counts) != 1 {
+		return
+	}
+	switch c := counts[0]; {
+	case strings.HasPrefix(c.flag, "--min-score"):
+		fmt.Fprintf(stderr, "All %d results were below score threshold %.2f; use --min-score 0 to see them\n", c.count, opts.minScore)
+	case c.flag == "--filter-breadcrumb":
+		quoted := make([]string, len(opts.breadcrumbTexts))
+		for i, text := range opts.breadcrumbTexts {
+			quoted[i] = strconv.Quote(text)
+		}
+		fmt.Fprintf(stderr, "All %d results were filtered out by --filter-breadcrumb %s; remove it to see them\n", c.count, strings.Join(quoted, " or "))
+	}
+}
+
+// printSuppressed writes a footer line describing hits hidden by client-side filters. When
+// --min-score hid any, a summary of how many of the returned hits are shown follows.
+func printSuppressed(w io.Writer, shown int, counts []filterCount) {
+	if len(counts) == 0 {
+		return
+	}
+
+	parts := make([]string, 0, len(counts))
+	returned, belowThreshold := shown, 0
+	for _, c := range counts {
+		parts = append(parts, c.String())
+		returned += c.count
+		if strings.HasPrefix(c.flag, "--min-score") {
+			belowThreshold = c.count
+		}
+	}
+	fmt.Fprintf(w, "Hidden by client-side filters: %s\n", strings.Join(parts, ", "))
+	if belowThreshold > 0 {
+		fmt.Fprintf(w, "Showing %d of %d returned (%d below threshold)\n", shown, returned, belowThreshold)
+	}
+}
+
+// suppressedNotes describes hits hidden by client-side filters for structured output
+func suppressedNotes(counts []filterCount) []string {
+	notes := make([]string, 0, len(counts))
+	for _, c := range counts {
+		notes = append(notes, "hits hidden by client-side filters: "+c.String())
+	}
+	return notes
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/Ebonsignori/gh-search-docs/searchdocs"
+)
+
+// isResultsOnlyFormat reports whether a --format value writes nothing but the results to
+// stdout, so messages such as notes go to stderr instead
+func isResultsOnlyFormat(format string) bool {
+	_, tabular := tabularWriters[format]
+	return tabular || isDocumentFormat(format) || format == "markdown" || format == "html" || format == "tree"
+}
+
+// isDocumentFormat reports whether a --format value writes the whole result, meta included,
+// as a single document
+func isDocumentFormat(format string) bool {
+	return format == "json" || format == "yaml"
+}
+
+// writeResults writes result to w in the --format chosen in opts. notes are remarks about
+// the results beyond the hits hidden by client-side filters: they go into the meta of the
+// structured formats, and to stderr or ahead of the results otherwise.
+func writeResults(w, stderr io.Writer, opts *options, query string, result SearchResult, suppressed []filterCount, notes []string) error {
+	// --show-timing goes into json and csv output and below pretty and plain results. Other
+	// output gets it on stderr, so stdout stays parseable.
+	if opts.showTiming && (opts.refs || opts.hitTemplate != nil || opts.jqCode != nil ||
+		isResultsOnlyFormat(opts.format) && opts.format != "json" && opts.format != "csv") {
+		defer fmt.Fprintln(stderr, apiTiming(&result))
+	}
+	if isDocumentFormat(opts.format) || tabularWriters[opts.format] != nil {
+		result.Meta.Notes = append(slices.Clone(notes), suppressedNotes(suppressed)...)
+		for _, c := range suppressed {
+			result.Meta.Suppressed += c.count
+		}
+	}
+	if opts.jqCode != nil && opts.format == "json" {
+		return writeJQ(w, opts.jqCode, &result)
+	}
+	if opts.format == "yaml" {
+		return searchdocs.ExportYAML(result, w)
+	}
+	if opts.format == "json" {
+		var document any = result
+		if opts.showTiming {
+			document = timedResult{SearchResult: result, Timing: result.Meta.Took}
+		}
+		output, err := marshalJSON(opts, document)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(output))
+		return err
+	}
+
+	// Text formats show docs text as it reads; jsonl and templates get it as the API sent it,
+	// like the document formats
+	if opts.format != "jsonl" && opts.hitTemplate == nil {
+		result.Hits = decodeEntities(result.Hits)
+	}
+
+	for _, note := range notes {
+		if opts.refs || opts.hitTemplate != nil || isResultsOnlyFormat(opts.format) {
+			fmt.Fprintf(stderr, "Note: %s.\n", note)
+		} else {
+			fmt.Fprintf(w, "Note: %s.\n", note)
+		}
+	}
+	if opts.refs {
+		printRefs(w, stderr, opts, query, result.Hits, suppressed)
+		return nil
+	}
+	if opts.hitTemplate != nil {
+		for _, note := range suppressedNotes(suppressed) {
+			fmt.Fprintf(stderr, "Note: %s\n", note)
+		}
+		return writeTemplate(w, opts.hitTemplate, opts, query, &result)
+	}
+	if !isResultsOnlyFormat(opts.format) {
+		printResults(w, stderr, opts, query, &result, suppressed)
+		return nil
+	}
+
+	for _, note := range suppressedNotes(suppressed) {
+		fmt.Fprintf(stderr, "Note: %s\n", note)
+	}
+	switch opts.format {
+	case "markdown":
+		if opts.report {
+			return exporter(opts).Markdown(result, query, opts.version, w)
+		}
+		return exporter(opts).MarkdownList(result, query, opts.version, w)
+	case "html":
+		return exporter(opts).HTML(result, query, opts.version, w)
+	case "tree":
+		return writeTree(w, stderr, opts, query, result.Hits)
+	default:
+		if opts.debug && opts.format == "jsonl" && !opts.jsonlMeta {
+			// A comment-style line ahead of the hits, which stream readers can skip
+			if meta, err := json.Marshal(result.Meta); err == nil {
+				fmt.Fprintf(w, "//meta:%s\n", meta)
+			}
+		}
+		return writeLines(w, opts, &result)
+	}
+}
+
+// timedResult is a result for --format json with --show-timing, which repeats the API's
+// timing under a top-level _timing key
+type timedResult struct {
+	SearchResult
+	Timing any `json:"_timing"`
+}
+
+// apiTiming returns the --show-timing line for a result
+func apiTiming(result *SearchResult) string {
+	return searchdocs.FormatTiming(struct{ QueryMsec, TotalMsec int }(result.Meta.Took))
+}
+
+// decodeEntities returns copies of hits with the HTML character references in their titles,
+// intros, and highlights decoded, e.g. "fork &amp; pull" to "fork & pull". Each string is
+// decoded once, so double-escaped text keeps one level of escaping. Highlights lose their
+// tags other than <mark> first, so decoded text such as "&lt;details&gt;" isn't mistaken
+// for a tag later.
+func decodeEntities(hits []SearchItem) []SearchItem {
+	decoded := make([]SearchItem, len(hits))
+	for i, item := range hits {
+		item.Title = html.UnescapeString(item.Title)
+		item.Intro = html.UnescapeString(item.Intro)
+		if item.Highlights != nil {
+			highlights := make(map[string]interface{}, len(item.Highlights))
+			for key, value := range item.Highlights {
+				switch v := value.(type) {
+				case string:
+					highlights[key] = html.UnescapeString(searchdocs.StripTags(v, "mark"))
+				case []interface{}:
+					snippets := make([]interface{}, len(v))
+					for j, snippet := range v {
+						if s, ok := snippet.(string); ok {
+							snippet = html.UnescapeString(searchdocs.StripTags(s, "mark"))
+						}
+						snippets[j] = snippet
+					}
+					highlights[key] = snippets
+				default:
+					highlights[key] = value
+				}
+			}
+			item.Highlights = highlights
+		}
+		decoded[i] = item
+	}
+	return decoded
+}
+
+// tabularWriters write hits for the line-oriented --format values, one record per hit with
+// nothing else on stdout
+var tabularWriters = map[string]func(w io.Writer, opts *options, result *SearchResult) error{
+	"csv":    writeCSV,
+	"tsv":    writeTSV,
+	"jsonl":  writeJSONL,
+	"urls":   writeURLs,
+	"titles": writeTitles,
+}
+
+// writeLines writes a result in one of the line-oriented formats, starting with a
+// {"meta": ...} line for --jsonl-meta
+func writeLines(w io.Writer, opts *options, result *SearchResult) error {
+	if opts.jsonlMeta {
+		meta := map[string]any{"meta": result.Meta}
+		if err := json.NewEncoder(w).Encode(meta); err != nil {
+			return err
+		}
+	}
+	return tabularWriters[opts.format](w, opts, result)
+}
+
+// writeCSV writes the hits for --format csv: a header row (unless --csv-no-header) followed
+// by one RFC 4180 row per hit, with the --csv-fields or --columns columns, or
+// searchdocs.CSVColumns
+func writeCSV(w io.Writer, opts *options, result *SearchResult) error {
+	columns := searchdocs.CSVColumns
+	if len(opts.csvFields) > 0 {
+		columns = splitList(opts.csvFields)
+	} else if len(opts.columns) > 0 {
+		columns = splitList(opts.columns)
+	}
+	if opts.showScore && !slices.Contains(columns, "score") {
+		columns = append(slices.Clone(columns), "score")
+	}
+	if opts.showTiming {
+		columns = append(slices.Clone(columns), timingColumns...)
+	}
+	e := exporter(opts)
+	e.Columns = columns
+	return e.CSV(*result, w)
+}
+
+// timingColumns are the columns --show-timing adds to --format csv, the same on every row
+var timingColumns = []string{"query_msec", "total_msec"}
+
+// tsvField replaces the tabs and line breaks that would split a --format tsv record
+var tsvField = strings.NewReplacer("\t", " ", "\r\n", " ", "\n", " ", "\r", " ")
+
+// tsvColumns are the columns --format tsv writes without --columns
+var tsvColumns = []string{"rank", "title", "url", "intro"}
+
+// writeTSV writes the hits for --format tsv: rank, title, URL, and intro, or the --columns
+// columns, one line per hit with no header. --show-score adds a score column. Nothing is
+// truncated.
+func writeTSV(w io.Writer, opts *options, result *SearchResult) error {
+	columns := splitList(opts.columns)
+	if len(columns) == 0 {
+		columns = tsvColumns
+	}
+	if opts.showScore && !slices.Contains(columns, "score") {
+		columns = append(slices.Clone(columns), "score")
+	}
+	for i, item := range result.Hits {
+		fields := make([]string, len(columns))
+		for j, column := range columns {
+			fields[j] = tsvField.Replace(searchdocs.ColumnValue(column, i+1, item))
+		}
+		if _, err := fmt.Fprintln(w, strings.Join(fields, "\t")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeURLs writes the hits for --format urls: the full URL of each hit (its path with
+// --relative-urls) on its own line, or ended by a NUL byte with --null
+func writeURLs(w io.Writer, opts *options, result *SearchResult) error {
+	return exporter(opts).URLs(*result, w)
+}
+
+// writeTitles writes the hits for --format titles: each title on its own line, after its rank
+// with --show-rank and its score with --show-score
+func writeTitles(w io.Writer, opts *options, result *SearchResult) error {
+	return exporter(opts).Titles(*result, w)
+}
+
+// writeJSONL writes the hits for --format jsonl with searchdocs.ExportJSONL
+func writeJSONL(w io.Writer, _ *options, result *SearchResult) error {
+	return searchdocs.ExportJSONL(*result, w)
+}
+
+// writeTree writes the hits for --format tree: grouped under the segments of their breadcrumb
+// paths in order of relevance, each leaf the title and URL of a hit. Hits without breadcrumbs
+// sit at the root, which is the query. --no-unicode draws the tree in ASCII.
+func writeTree(w, stderr io.Writer, opts *options, query string, hits []SearchItem) error {
+	if len(hits) == 0 {
+		fmt.Fprintf(stderr, "No results found for query: %s\n", query)
+		return nil
+	}
+	root := searchdocs.BuildTree(hits, func(item SearchItem) []string {
+		return searchdocs.BreadcrumbSegments(item.Breadcrumbs)
+	})
+	root.Name = query
+	leaf := func(item SearchItem) string {
+		return strings.Join(strings.Fields(searchdocs.HitTitle(item)), " ") + onelineSeparator + shownURL(opts, item)
+	}
+	return searchdocs.WriteTree(w, root, leaf, opts.noUnicode)
+}
+
+// exporter returns the searchdocs exporter for the output flags in opts
+func exporter(opts *options) searchdocs.Exporter {
+	return searchdocs.Exporter{
+		RelativeURLs:   opts.relativeURLs,
+		MatchedContent: opts.includeMatchedContent,
+		Fragment:       !opts.htmlFullPage,
+		NoHeader:       opts.csvNoHeader,
+		Null:           opts.null,
+		ShowRank:       opts.showRank,
+		ShowScore:      opts.showScore,
+	}
+}
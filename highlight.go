@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Ebonsignori/gh-search-docs/searchdocs"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Placeholders stand in for <mark> tags while Markdown is rendered, since glamour mangles ANSI
+// codes in its input. They are private-use runes, which never appear in docs text.
+const (
+	markOpen  = "\uE000"
+	markClose = "\uE001"
+)
+
+// Placeholders stand in for the start and end of a hyperlinked title while Markdown is
+// rendered, for the same reason; linkTitle then swaps them for OSC 8 escape sequences
+const (
+	linkOpen  = "\uE002"
+	linkClose = "\uE003"
+)
+
+// defaultHighlightStyle is how matched terms are shown in pretty output without
+// --highlight-style or GH_SEARCH_DOCS_HIGHLIGHT
+const defaultHighlightStyle = "yellow"
+
+// When output is colored, as accepted by --color
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+)
+
+// highlightColors are the named colors --highlight-style accepts, in ANSI color order
+var highlightColors = []string{"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+
+// highlightStyleNames lists every value --highlight-style accepts
+var highlightStyleNames = append([]string{"bold", "underline", "reverse"}, highlightColors...)
+
+// parseHighlightStyle returns the style for a --highlight-style value. Named colors are shown
+// in bold so they stand out from the rendered Markdown around them.
+func parseHighlightStyle(name string) (lipgloss.Style, bool) {
+	switch name {
+	case "bold":
+		return lipgloss.NewStyle().Bold(true), true
+	case "underline":
+		return lipgloss.NewStyle().Underline(true), true
+	case "reverse":
+		return lipgloss.NewStyle().Reverse(true), true
+	}
+	if i := slices.Index(highlightColors, name); i >= 0 {
+		return lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(strconv.Itoa(i))), true
+	}
+	return lipgloss.Style{}, false
+}
+
+// bracketMark shows a matched term as [term], for output without colors
+func bracketMark(term string) string {
+	return "[" + term + "]"
+}
+
+// prettyMark returns how pretty output shows a matched term: in the --highlight-style, or in
+// brackets with --no-color
+func prettyMark(opts *options) func(string) string {
+	if opts.noColor {
+		return bracketMark
+	}
+	style := opts.markStyle
+	return func(term string) string { return style.Render(term) }
+}
+
+// plainMarks returns a function that shows the matched terms of a highlight as plain text:
+// as *term*, or as [term] with --no-color
+func plainMarks(opts *options) func(string) string {
+	mark := func(term string) string { return "*" + term + "*" }
+	if opts.noColor {
+		mark = bracketMark
+	}
+	return func(s string) string { return searchdocs.ReplaceMarks(s, mark) }
+}
+
+// marksToPlaceholders swaps the <mark> tags in Markdown for placeholders that survive
+// rendering; styleMarks then styles the terms between them
+func marksToPlaceholders(s string) string {
+	return searchdocs.ReplaceMarks(s, func(term string) string { return markOpen + term + markClose })
+}
+
+// styleMarks passes the terms between placeholders in rendered output through mark, dropping
+// whatever styling the renderer gave them. Unpaired placeholders are removed.
+func styleMarks(output string, mark func(string) string) string {
+	var b strings.Builder
+	for {
+		start := strings.Index(output, markOpen)
+		if start < 0 {
+			break
+		}
+		end := strings.Index(output[start:], markClose)
+		if end < 0 {
+			break
+		}
+		end += start
+		b.WriteString(output[:start])
+		b.WriteString(mark(ansi.Strip(output[start+len(markOpen) : end])))
+		output = output[end+len(markClose):]
+	}
+	b.WriteString(output)
+	return strings.NewReplacer(markOpen, "", markClose, "").Replace(b.String())
+}
+
+// linkTitle swaps the link placeholders in rendered output for an OSC 8 hyperlink to url
+func linkTitle(output, url string) string {
+	return strings.NewReplacer(linkOpen, searchdocs.HyperlinkStart(url), linkClose, searchdocs.HyperlinkEnd).Replace(output)
+}
+
+// markedTitle returns a hit's title with its matched terms passed through mark when the API
+// highlighted the title, and the plain title otherwise
+func markedTitle(item SearchItem, mark func(string) string) string {
+	if title, ok := searchdocs.TitleHighlight(item); ok {
+		return mark(title)
+	}
+	return searchdocs.HitTitle(item)
+}
+
+// matchedSnippets returns a hit's content_explicit highlights, or its content highlights when
+// there are none, with their <mark> tags
+func matchedSnippets(item SearchItem) []string {
+	if snippets := searchdocs.HighlightStrings(item, "content_explicit"); len(snippets) > 0 {
+		return snippets
+	}
+	return searchdocs.HighlightStrings(item, "content")
+}
+
+// shownSnippets returns the matched snippets of a hit that --max-highlights lets pretty and
+// plain output show, and how many more were left out
+func shownSnippets(opts *options, item SearchItem) (snippets []string, more int) {
+	snippets = matchedSnippets(item)
+	if opts.maxHighlights > 0 && len(snippets) > opts.maxHighlights {
+		return snippets[:opts.maxHighlights], len(snippets) - opts.maxHighlights
+	}
+	return snippets, 0
+}
+
+// moreMatchesLine says how many snippets --max-highlights left out, e.g. "… and 2 more
+// matches". It is empty when none were.
+func moreMatchesLine(more int) string {
+	if more == 0 {
+		return ""
+	}
+	return fmt.Sprintf("… and %d more matches", more)
+}
+
+// termLine lists the distinct terms a hit matched, from its term highlights, with each passed
+// through mark, e.g. "Matched terms: secrets, tokens". Terms differing only in case are listed
+// once. It is empty unless term highlights were asked for with --highlights term.
+func termLine(opts *options, item SearchItem, mark func(string) string) string {
+	if !slices.Contains(splitList(opts.highlights), "term") {
+		return ""
+	}
+	var terms []string
+	seen := map[string]bool{}
+	for _, term := range searchdocs.HighlightStrings(item, "term") {
+		key := strings.ToLower(stripMarks(term))
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		terms = append(terms, mark(term))
+	}
+	if len(terms) == 0 {
+		return ""
+	}
+	return "Matched terms: " + strings.Join(terms, ", ")
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Ebonsignori/gh-search-docs/searchdocs"
+)
+
+// defaultHistoryLimit is how many searches --history shows unless --history-limit says otherwise
+const defaultHistoryLimit = 20
+
+// showHistory lists the most recent searches, newest first, numbered for --replay
+func showHistory(stdout, stderr io.Writer, limit int) int {
+	entries, err := searchdocs.LoadHistory()
+	if err != nil {
+		fmt.Fprintf(stderr, "Error reading history: %v\n", err)
+		return 1
+	}
+	searches := searchdocs.RecentSearches(entries, limit)
+	if len(searches) == 0 {
+		fmt.Fprintln(stdout, "No searches in history yet.")
+		return 0
+	}
+
+	for i, entry := range searches {
+		details := []string{entry.Version}
+		if entry.Language != "" && entry.Language != "en" {
+			details = append(details, entry.Language)
+		}
+		fmt.Fprintf(stdout, "%2d. %s  %s  [%s]  %d results\n", i+1, entry.Timestamp.Local().Format("2006-01-02 15:04"), entry.Query, strings.Join(details, ", "), entry.ResultCount)
+	}
+	fmt.Fprintln(stdout, "\nRun a search again with: gh search-docs --replay <number>")
+	return 0
+}
+
+// clearHistory empties the history file
+func clearHistory(stdout, stderr io.Writer) int {
+	if err := searchdocs.ClearHistory(); err != nil {
+		fmt.Fprintf(stderr, "Error clearing history: %v\n", err)
+		return 1
+	}
+	fmt.Fprintln(stdout, "Search history cleared.")
+	return 0
+}
+
+// replaySearch fills in the query, version, and language of the nth most recent search for
+// --replay. Version and language flags given on the command line still win.
+func replaySearch(fs *flag.FlagSet, opts *options) error {
+	if opts.query != "" || fs.NArg() > 0 {
+		return fmt.Errorf("--replay can't be combined with a query")
+	}
+	entries, err := searchdocs.LoadHistory()
+	if err != nil {
+		return fmt.Errorf("reading history: %w", err)
+	}
+	searches := searchdocs.RecentSearches(entries, opts.replay)
+	if len(searches) < opts.replay {
+		return fmt.Errorf("there are only %d searches in history (see --history)", len(searches))
+	}
+
+	entry := searches[opts.replay-1]
+	opts.query = entry.Query
+	// Set through the FlagSet so config file defaults don't replace the replayed values
+	if entry.Version != "" && !isFlagSet(fs, "version") {
+		_ = fs.Set("version", entry.Version)
+	}
+	if entry.Language != "" && !isFlagSet(fs, "language") {
+		_ = fs.Set("language", entry.Language)
+	}
+	return nil
+}
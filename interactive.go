@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/Ebonsignori/gh-search-docs/searchdocs"
+)
+
+// runInteractive runs the --interactive search UI, starting with query if one was given
+func runInteractive(stderr io.Writer, client *http.Client, opts *options, query, version string) int {
+	if !searchdocs.InputAllowed(opts.noInput, stdinIsTerminal()) || !stdoutIsTerminal() {
+		fmt.Fprintf(stderr, "Error: --interactive needs a terminal and can't be used with --no-input or CI=true.\n")
+		return 1
+	}
+
+	cache, err := searchCache(opts)
+	if err != nil {
+		fmt.Fprintln(stderr, "error:", err)
+		return 1
+	}
+	search := func(q string) ([]searchdocs.TUIResult, error) {
+		if !opts.noNormalize {
+			q = searchdocs.NormalizeQuery(q)
+		}
+		// Warnings would draw over the UI, so they're dropped
+		result, err := requestSearch(io.Discard, client, opts, cache, q, version)
+		if err != nil {
+			return nil, err
+		}
+		search := processSearch(io.Discard, client, opts, q, version, *result)
+		search.record(io.Discard, opts, q, version)
+		hits := search.result.Hits
+
+		results := make([]searchdocs.TUIResult, len(hits))
+		for i, item := range hits {
+			results[i] = searchdocs.TUIResult{
+				Title:       searchdocs.HitTitle(item),
+				URL:         searchdocs.HitURL(item),
+				Breadcrumbs: item.Breadcrumbs,
+				Preview:     item.Intro,
+			}
+		}
+		return results, nil
+	}
+	open := func(target string) error {
+		if err := openURL(target); err != nil {
+			return err
+		}
+		recordOpen(io.Discard, strings.TrimPrefix(target, searchdocs.DocsBaseURL))
+		return nil
+	}
+
+	theme := opts.theme
+	if theme == themeAuto {
+		theme = detectedTheme()
+	}
+	model := searchdocs.NewTUIModel(query, search, open)
+	model.Render = func(md string, width int) (string, error) {
+		if opts.styleFile != "" {
+			if renderer, err := searchdocs.NewRendererFromFile(opts.styleFile, width); err == nil {
+				return renderMarkdown(renderer, md)
+			}
+		}
+		return renderMarkdown(searchdocs.NewRenderer(theme, width), md)
+	}
+
+	if _, err := tea.NewProgram(model, tea.WithAltScreen()).Run(); err != nil {
+		fmt.Fprintln(stderr, "error:", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/itchyny/gojq"
+)
+
+// compileJQ parses and compiles a --jq expression. Syntax errors report the position of the
+// offending token, counted in bytes from 1.
+func compileJQ(expr string) (*gojq.Code, error) {
+	query, err := gojq.Parse(expr)
+	if err != nil {
+		var perr *gojq.ParseError
+		if errors.As(err, &perr) {
+			return nil, fmt.Errorf("%v at position %d", perr, max(perr.Offset-len(perr.Token)+1, 1))
+		}
+		return nil, err
+	}
+	return gojq.Compile(query)
+}
+
+// writeJQ runs code against result as --format json would print it, and writes each output
+// on its own line. Strings are written as is and everything else as compact JSON, like
+// gh's --jq.
+func writeJQ(w io.Writer, code *gojq.Code, result *SearchResult) error {
+	// gojq only works with the types encoding/json decodes into
+	raw, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	var input any
+	if err := json.Unmarshal(raw, &input); err != nil {
+		return err
+	}
+
+	iter := code.Run(input)
+	for {
+		v, ok := iter.Next()
+		if !ok {
+			return nil
+		}
+		if err, ok := v.(error); ok {
+			var halt *gojq.HaltError
+			if errors.As(err, &halt) && halt.Value() == nil {
+				return nil
+			}
+			return fmt.Errorf("--jq: %w", err)
+		}
+		if s, ok := v.(string); ok {
+			if _, err := fmt.Fprintln(w, s); err != nil {
+				return err
+			}
+			continue
+		}
+		line, err := gojq.Marshal(v)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(w, string(line)); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,374 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/Ebonsignori/gh-search-docs/searchdocs"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/mattn/go-runewidth"
+)
+
+// compactWidth is the terminal width below which --layout auto switches to the compact layout
+const compactWidth = 60
+
+// columnGap is the space between the two columns of the columns layout
+const columnGap = 4
+
+// terminalWidth reports the width results are laid out for, terminalHeight the number of
+// lines that fit before output is paged, and stdoutIsTerminal whether results are written to
+// a terminal at all. They are variables so tests can simulate different terminals.
+var (
+	terminalWidth    = searchdocs.GetTerminalWidth
+	terminalHeight   = searchdocs.GetTerminalHeight
+	stdoutIsTerminal = func() bool { return searchdocs.IsTerminal(os.Stdout.Fd()) }
+)
+
+// Result layouts accepted by --layout
+const (
+	layoutAuto    = "auto"
+	layoutFull    = "full"
+	layoutCompact = "compact"
+	layoutColumns = "columns"
+	layoutOneline = "oneline"
+)
+
+// minWrapWidth is the narrowest terminal pretty output is wrapped for. Narrower terminals
+// would leave a word or two per line, so wrapping is left to the terminal.
+const minWrapWidth = 40
+
+// wrapMargin is how many columns of the terminal pretty output leaves unused: the renderer's
+// indent plus a little room at the right edge
+const wrapMargin = 4
+
+// outputWidth returns the width results are laid out for, and whether output is fit to it at
+// all: by default the terminal's width, and only when writing to a terminal. --width wins
+// over both, wherever output goes, with 0 turning fitting off.
+func outputWidth(opts *options) (width int, fit bool) {
+	if opts.widthGiven {
+		return opts.width, opts.width > 0
+	}
+	return terminalWidth(), stdoutIsTerminal()
+}
+
+// prettyWrapWidth returns the width pretty output is wrapped to, or 0 when it isn't wrapped.
+// Detected widths below minWrapWidth aren't wrapped for, but one from --width always is.
+func prettyWrapWidth(opts *options, width int, fit bool) int {
+	if !fit || (!opts.widthGiven && width < minWrapWidth) {
+		return 0
+	}
+	return max(width-wrapMargin, 1)
+}
+
+// prettyLine returns text as a Markdown line indented under a result's title, wrapped to wrap
+// columns (0 for no wrapping) with hard line breaks. Pretty output is rendered without
+// wrapping, since the renderer would break URLs, and the terminal's own wrapping would lose
+// the indent. Only lines without URLs should be passed, so URLs stay whole and clickable.
+func prettyLine(text string, wrap int) string {
+	if wrap > 0 {
+		text = ansi.Wordwrap(text, wrap, "")
+	}
+	return "   " + strings.ReplaceAll(text, "\n", "\n   ")
+}
+
+// prettyIntro returns a hit's intro for pretty output, cut to the intro length and wrapped
+// like prettyLine
+func prettyIntro(opts *options, intro string, wrap int) string {
+	return prettyLine(searchdocs.TruncateString(intro, introLimit(opts)), wrap)
+}
+
+// resolveLayout picks the layout to use for a terminal width, resolving auto. Terminals at
+// least columnsWidth wide get the columns layout. Output that isn't going to a terminal
+// (pipes, files) always gets the full layout, since the width of whatever terminal the
+// command runs in says nothing about where the output ends up.
+func resolveLayout(layout string, width, columnsWidth int, terminal bool) string {
+	if layout != layoutAuto {
+		return layout
+	}
+	switch {
+	case !terminal:
+		return layoutFull
+	case width < compactWidth:
+		return layoutCompact
+	case columnsWidth > 0 && width >= columnsWidth:
+		return layoutColumns
+	default:
+		return layoutFull
+	}
+}
+
+// printCompactHit writes a single result for narrow terminals: the title on its own line, the
+// URL on the next (never wrapped, even if it overflows), and the intro cut to one line.
+// Annotations such as headings, badges, and breadcrumbs are kept whole.
+func printCompactHit(w io.Writer, opts *options, n int, item SearchItem, width int) {
+	const indent = "   "
+	lineWidth := width - len(indent)
+
+	fmt.Fprintf(w, "%d. %s%s\n", n, markedTitle(item, plainMarks(opts)), scoreSuffix(opts, item))
+	fmt.Fprintf(w, "%s%s\n", indent, shownURL(opts, item))
+	if crumbs := breadcrumbLine(opts, item); crumbs != "" {
+		fmt.Fprintf(w, "%sin: %s\n", indent, crumbs)
+	}
+
+	if !opts.includeMatchedContent && item.Intro != "" {
+		fmt.Fprintf(w, "%s%s\n", indent, oneLine(item.Intro, lineWidth))
+	}
+	if opts.includeMatchedContent {
+		for _, line := range matchedContent(opts, item) {
+			fmt.Fprintf(w, "%s%s\n", indent, oneLine(line, lineWidth))
+		}
+	}
+
+	if line := termLine(opts, item, plainMarks(opts)); line != "" {
+		fmt.Fprintf(w, "%s%s\n", indent, line)
+	}
+	for _, line := range headingLines(opts, item) {
+		fmt.Fprintf(w, "%s%s\n", indent, line)
+	}
+	if line := translationLine(splitList(opts.translations), item); line != "" {
+		fmt.Fprintf(w, "%s%s\n", indent, line)
+	}
+	if badges := availabilityBadges(item); badges != "" {
+		fmt.Fprintf(w, "%s%s\n", indent, badges)
+	}
+	fmt.Fprintln(w)
+}
+
+// onelineSeparator goes between the title and URL of the oneline layout
+const onelineSeparator = " — "
+
+// urlStyle dims URLs in the pretty oneline layout
+var urlStyle = lipgloss.NewStyle().Faint(true)
+
+// printOnelineHit writes a single result for --layout oneline: "N. Title — URL". The title is
+// cut so the line fits width terminal columns (0 for no limit); the URL never is. dim dims the
+// URL and any --show-score score for pretty output, where the title is also a link when
+// hyperlinks are on. With --hyperlinks always the linked title stands in for the URL.
+func printOnelineHit(w io.Writer, opts *options, n int, item SearchItem, width int, dim bool) {
+	prefix := fmt.Sprintf("%d. ", n)
+	url := shownURL(opts, item)
+	link := dim && useHyperlinks(opts)
+	separator := onelineSeparator
+	if link && opts.hyperlinks == hyperlinksAlways {
+		separator, url = "", ""
+	}
+	score := scoreSuffix(opts, item)
+	title := strings.Join(strings.Fields(searchdocs.HitTitle(item)), " ")
+	if width > 0 {
+		room := width - runewidth.StringWidth(prefix+score+separator+url)
+		title = runewidth.Truncate(title, max(room, 1), "…")
+	}
+	if link {
+		title = searchdocs.Hyperlink(searchdocs.HitURL(item), title)
+	}
+	if dim {
+		url = urlStyle.Render(url)
+		if score != "" {
+			score = " " + urlStyle.Render(strings.TrimPrefix(score, " "))
+		}
+	}
+	fmt.Fprintf(w, "%s%s%s%s%s\n", prefix, title, score, separator, url)
+}
+
+// scoreSuffix returns " [0.875]" with a hit's relevance score for --show-score, or ""
+func scoreSuffix(opts *options, item SearchItem) string {
+	if !opts.showScore {
+		return ""
+	}
+	return " [" + searchdocs.ScoreLabel(item.Score) + "]"
+}
+
+// insertAfterURL adds line to rendered output below the line holding url, or at the end if
+// the renderer changed the URL
+func insertAfterURL(output, url, line string) string {
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	at := len(lines)
+	for i, l := range lines {
+		if strings.Contains(ansi.Strip(l), url) {
+			at = i + 1
+			break
+		}
+	}
+	lines = slices.Insert(lines, at, line)
+	return strings.Join(lines, "\n") + "\n\n"
+}
+
+// appendToFirstLine adds suffix to the end of the first line of rendered output with visible
+// text, which is where the title of a rendered hit is
+func appendToFirstLine(output, suffix string) string {
+	lines := strings.Split(output, "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(ansi.Strip(line)) != "" {
+			lines[i] = strings.TrimRight(line, " ") + suffix
+			break
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// oneLine collapses whitespace in s and cuts it to fit width terminal columns
+func oneLine(s string, width int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if width < 1 {
+		return s
+	}
+	return runewidth.Truncate(s, width, "…")
+}
+
+// matchedContent returns the bullets listing a hit's matched snippets for plain text, with
+// matched terms marked by plainMarks, followed by a line counting the snippets left out by
+// --max-highlights
+func matchedContent(opts *options, item SearchItem) []string {
+	mark := plainMarks(opts)
+	snippets, more := shownSnippets(opts, item)
+	lines := make([]string, 0, len(snippets)+1)
+	for _, snippet := range snippets {
+		lines = append(lines, "• "+mark(snippet))
+	}
+	if line := moreMatchesLine(more); line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// printColumns writes results as cards in two balanced columns for wide terminals. Results
+// fill the left column and then the right, so each column reads top to bottom in rank order.
+// Cards are never split. A card that doesn't fit half the width (URLs are never wrapped) is
+// shown on its own at full width, with the results before it flushed into columns first so
+// the order is kept.
+func printColumns(w io.Writer, opts *options, hits []SearchItem, width int) {
+	colWidth := (width - columnGap) / 2
+
+	// pending holds the ranks of consecutive results that fit in a column
+	var pending []int
+	flush := func() {
+		printColumnBlock(w, opts, hits, pending, colWidth, width)
+		pending = nil
+	}
+
+	for i, item := range hits {
+		if !fitsWidth(hitCard(opts, i+1, item, colWidth), colWidth) {
+			flush()
+			printCard(w, hitCard(opts, i+1, item, width))
+			continue
+		}
+		pending = append(pending, i)
+	}
+	flush()
+}
+
+// printColumnBlock writes the given results as two columns, the first half on the left. A
+// single result has nothing to pair with, so it uses the full width.
+func printColumnBlock(w io.Writer, opts *options, hits []SearchItem, indexes []int, colWidth, width int) {
+	switch len(indexes) {
+	case 0:
+		return
+	case 1:
+		printCard(w, hitCard(opts, indexes[0]+1, hits[indexes[0]], width))
+		return
+	}
+
+	column := func(indexes []int) string {
+		var lines []string
+		for _, i := range indexes {
+			if len(lines) > 0 {
+				lines = append(lines, "")
+			}
+			lines = append(lines, hitCard(opts, i+1, hits[i], colWidth)...)
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	half := (len(indexes) + 1) / 2
+	block := lipgloss.JoinHorizontal(lipgloss.Top,
+		lipgloss.NewStyle().Width(colWidth).Render(column(indexes[:half])),
+		strings.Repeat(" ", columnGap),
+		column(indexes[half:]),
+	)
+	for _, line := range strings.Split(block, "\n") {
+		fmt.Fprintln(w, strings.TrimRight(line, " "))
+	}
+	fmt.Fprintln(w)
+}
+
+// hitCard returns the lines of a result card for the columns layout, wrapping everything but
+// the URL to width
+func hitCard(opts *options, n int, item SearchItem, width int) []string {
+	const indent = "   "
+
+	card := wrapLine(fmt.Sprintf("%d. %s%s", n, markedTitle(item, plainMarks(opts)), scoreSuffix(opts, item)), width, indent)
+	card = append(card, indent+shownURL(opts, item))
+
+	var extra []string
+	if !opts.includeMatchedContent && item.Intro != "" {
+		extra = append(extra, item.Intro)
+	}
+	if opts.includeMatchedContent {
+		extra = append(extra, matchedContent(opts, item)...)
+	}
+	if line := termLine(opts, item, plainMarks(opts)); line != "" {
+		extra = append(extra, line)
+	}
+	extra = append(extra, headingLines(opts, item)...)
+	if line := translationLine(splitList(opts.translations), item); line != "" {
+		extra = append(extra, line)
+	}
+	if badges := availabilityBadges(item); badges != "" {
+		extra = append(extra, badges)
+	}
+	for _, line := range extra {
+		card = append(card, wrapLine(indent+line, width, indent)...)
+	}
+	// Breadcrumbs may contain hyperlinks, so like the URL they are never wrapped
+	if crumbs := breadcrumbLine(opts, item); crumbs != "" {
+		card = append(card, indent+crumbs)
+	}
+	return card
+}
+
+// printCard writes a card's lines followed by a blank line
+func printCard(w io.Writer, card []string) {
+	for _, line := range card {
+		fmt.Fprintln(w, line)
+	}
+	fmt.Fprintln(w)
+}
+
+// fitsWidth reports whether every line of a card fits within width terminal columns
+func fitsWidth(card []string, width int) bool {
+	for _, line := range card {
+		if lipgloss.Width(line) > width {
+			return false
+		}
+	}
+	return true
+}
+
+// wrapLine word-wraps s to width terminal columns, indenting continuation lines. Words longer
+// than the width are left intact.
+func wrapLine(s string, width int, indent string) []string {
+	var (
+		lines   []string
+		current string
+	)
+	leading := s[:len(s)-len(strings.TrimLeft(s, " "))]
+	for _, word := range strings.Fields(s) {
+		switch {
+		case current == "":
+			current = leading + word
+		case runewidth.StringWidth(current)+1+runewidth.StringWidth(word) <= width:
+			current += " " + word
+		default:
+			lines = append(lines, current)
+			current = indent + word
+		}
+	}
+	if current != "" {
+		lines = append(lines, current)
+	}
+	return lines
+}
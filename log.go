@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Ebonsignori/gh-search-docs/searchdocs"
+)
+
+// transcript is the record appended to --log-file for each invocation
+type transcript struct {
+	Timestamp time.Time  `json:"timestamp"`
+	Args      []string   `json:"argv"`
+	Params    url.Values `json:"params,omitempty"`
+	Meta      any        `json:"meta,omitempty"`
+	URLs      []string   `json:"urls,omitempty"`
+	Errors    []string   `json:"errors,omitempty"`
+	ExitCode  int        `json:"exitCode"`
+}
+
+// recordHits records the URLs of the hits shown to the user
+func (t *transcript) recordHits(hits []SearchItem) {
+	for _, item := range hits {
+		t.URLs = append(t.URLs, searchdocs.HitURL(item))
+	}
+}
+
+// writeTranscript appends the record to the log file. Logging is best-effort: failures are
+// reported but never change the outcome of the search.
+func writeTranscript(stderr io.Writer, path string, t *transcript) {
+	if err := searchdocs.AppendJSONLine(path, t); err != nil {
+		fmt.Fprintf(stderr, "Warning: could not write to log file: %v\n", err)
+	}
+}
+
+// errorPrefixes identify the error and warning lines written to stderr
+var errorPrefixes = []string{"Error", "error:", "Warning", "API returned", "Rate limited"}
+
+// errorCollector is a writer that keeps the error and warning lines written to stderr so
+// they can be included in the transcript
+type errorCollector struct {
+	transcript *transcript
+
+	mu      sync.Mutex
+	partial []byte
+}
+
+func (c *errorCollector) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.partial = append(c.partial, p...)
+	for {
+		i := bytes.IndexByte(c.partial, '\n')
+		if i < 0 {
+			break
+		}
+		line := string(c.partial[:i])
+		c.partial = c.partial[i+1:]
+		for _, prefix := range errorPrefixes {
+			if strings.HasPrefix(line, prefix) {
+				c.transcript.Errors = append(c.transcript.Errors, line)
+				break
+			}
+		}
+	}
+	return len(p), nil
+}
+
+// runLog implements the log subcommand for reading --log-file transcripts
+func runLog(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] != "tail" {
+		fmt.Fprintf(stderr, "usage: gh search-docs log tail [-n N] <log-file>\n")
+		return 2
+	}
+
+	fs := flag.NewFlagSet("log tail", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	n := fs.Int("n", 10, "number of recent entries to show")
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: gh search-docs log tail [-n N] <log-file>\n\n")
+		fmt.Fprintf(stderr, "Show the most recent entries of a file written with --log-file.\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	entries, err := tailTranscripts(fs.Arg(0), *n)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error reading log file: %v\n", err)
+		return 1
+	}
+	for _, entry := range entries {
+		printTranscript(stdout, entry)
+	}
+	return 0
+}
+
+// tailTranscripts reads the last n transcripts from a log file, skipping malformed lines
+func tailTranscripts(path string, n int) ([]transcript, error) {
+	// #nosec G304 -- the path is chosen by the user
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []transcript
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry transcript
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+		if n > 0 && len(entries) > n {
+			entries = entries[1:]
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// printTranscript writes a human-readable summary of a transcript
+func printTranscript(w io.Writer, t transcript) {
+	args := make([]string, len(t.Args))
+	for i, arg := range t.Args {
+		args[i] = arg
+		if arg == "" || strings.ContainsAny(arg, " \t\"'") {
+			args[i] = strconv.Quote(arg)
+		}
+	}
+	fmt.Fprintf(w, "%s  exit %d  gh search-docs %s\n", t.Timestamp.Local().Format("2006-01-02 15:04:05"), t.ExitCode, strings.Join(args, " "))
+	for _, u := range t.URLs {
+		fmt.Fprintf(w, "   %s\n", u)
+	}
+	for _, e := range t.Errors {
+		fmt.Fprintf(w, "   ! %s\n", e)
+	}
+	fmt.Fprintln(w)
+}
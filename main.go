@@ -8,73 +8,115 @@
 // Usage:
 //
 //	gh search-docs [flags] <query>
+//	gh search-docs stats [--since 30d] [--format json]
+//	gh search-docs log tail [-n 10] <log-file>
+//	gh search-docs cache stats [--format json]
 //
-// Flags:
+// Flags (run with --help for the full list):
 //
 //	--size        number of results to return (max: 50, default: 5)
 //	--version     docs version (free-pro-team, enterprise-cloud,
 //	              or enterprise-server@<3.13-3.17>)
-//	--language    language code (default: en)
-//	--page        page number for pagination
+//	--language    language code (default: from the system locale, else en)
+//	--page        page number for pagination (starting at 1)
 //	--sort        sort order
 //	--highlights           highlight options: title, content, content_explicit, term
-//	--include              additional includes: intro, headings, toplevel
+//	--include              additional includes: intro, headings, toplevel, or all
 //	--include-matched-content include matched content highlights
-//	--toplevel             toplevel filter
+//	--toplevel             toplevel filter (prefix with ! to exclude)
 //	--aggregate            aggregate options
 //	--debug                show raw JSON response from the API
-//	--format               output format: pretty (default), plain, json
+//	--format               output format: pretty (default), plain, json, and more
 //	--plain                disable pretty rendering (use plain text output)
 package main
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"net/url"
 	"os"
-	"path/filepath"
+	"runtime/debug"
+	"slices"
 	"strconv"
 	"strings"
+	"text/template"
+	"time"
 
 	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/itchyny/gojq"
+	"github.com/muesli/termenv"
 
 	"github.com/Ebonsignori/gh-search-docs/searchdocs"
 )
 
+// endpoint is the search API used unless --endpoint or GH_SEARCH_DOCS_ENDPOINT names another
 const endpoint = "https://docs.github.com/api/search/v1"
 
-type SearchResult struct {
-	Meta struct {
-		Found struct {
-			Value    int    `json:"value"`
-			Relation string `json:"relation"`
-		} `json:"found"`
-		Took struct {
-			QueryMsec int `json:"query_msec"`
-			TotalMsec int `json:"total_msec"`
-		} `json:"took"`
-		Page int `json:"page"`
-		Size int `json:"size"`
-	} `json:"meta"`
-	Hits []SearchItem `json:"hits"`
-}
-
-type SearchItem struct {
-	ID          string                 `json:"id"`
-	Title       string                 `json:"title"`
-	URL         string                 `json:"url"`
-	Breadcrumbs string                 `json:"breadcrumbs,omitempty"`
-	Content     string                 `json:"content,omitempty"`
-	Intro       string                 `json:"intro,omitempty"`
-	Headings    string                 `json:"headings,omitempty"`
-	Toplevel    string                 `json:"toplevel,omitempty"`
-	Highlights  map[string]interface{} `json:"highlights,omitempty"`
-	Score       float64                `json:"score,omitempty"`
+// stdin is where piped queries and interactive prompt answers are read from, and
+// stdinIsTerminal reports whether it is a terminal. Tests swap them out to exercise prompts.
+var (
+	stdin           io.Reader = os.Stdin
+	stdinIsTerminal           = func() bool { return searchdocs.IsTerminal(os.Stdin.Fd()) }
+)
+
+// httpClient, when set, provides the transport for all API requests in place of the one
+// built from --proxy. Tests set it to talk to a local server.
+var httpClient *http.Client
+
+// printRequestError reports a failed request, explaining timeouts rather than showing the
+// transport's error
+func printRequestError(stderr io.Writer, opts *options, prefix string, err error) {
+	if searchdocs.IsTimeout(err) {
+		fmt.Fprintf(stderr, "Request timed out after %s. Check your network or increase --timeout.\n", opts.timeout)
+		return
+	}
+	fmt.Fprintf(stderr, "%s: %v\n", prefix, err)
 }
 
+// retryBaseDelay is the first wait before retrying a rate limited request. Tests shorten it.
+var retryBaseDelay = time.Second
+
+// retryingClient returns a copy of client that retries rate limited and unavailable
+// responses up to n times, saying so on stderr before each wait
+func retryingClient(stderr io.Writer, client *http.Client, n int) *http.Client {
+	transport := searchdocs.NewRetryRoundTripper(client.Transport, n)
+	transport.BaseDelay = retryBaseDelay
+	// Requests made at the same time, like --parallel queries, back off together
+	transport.Limiter = &searchdocs.RateLimiter{}
+	transport.Notify = func(status int, wait time.Duration) {
+		reason := "Rate limited"
+		if status == http.StatusServiceUnavailable {
+			reason = "API unavailable"
+		}
+		fmt.Fprintf(stderr, "%s, retrying in %ds...\n", reason, int(math.Ceil(wait.Seconds())))
+	}
+	retrying := *client
+	retrying.Transport = transport
+	return &retrying
+}
+
+// limitedClient returns a copy of client whose requests share a single limiter allowing at
+// most n in flight. One is created per invocation and passed to everything that fetches.
+func limitedClient(client *http.Client, n int) *http.Client {
+	limited := *client
+	limited.Transport = searchdocs.LimitTransport(client.Transport, n)
+	return &limited
+}
+
+// The search API's result types, which the searchdocs exporters and helpers share
+type (
+	SearchResult = searchdocs.SearchResult
+	SearchItem   = searchdocs.SearchItem
+	HeadingLink  = searchdocs.HeadingLink
+)
+
 // StringSlice allows repeated flags
 type StringSlice []string
 
@@ -87,6 +129,153 @@ func (s *StringSlice) Set(value string) error {
 	return nil
 }
 
+// matchMode is a flag that can be given bare (meaning "all") or with an explicit
+// "all" or "any" value, e.g. --match-title or --match-title any
+type matchMode string
+
+func (m *matchMode) String() string {
+	return string(*m)
+}
+
+func (m *matchMode) Set(value string) error {
+	switch value {
+	case "true", "all":
+		*m = "all"
+	case "any":
+		*m = "any"
+	case "false":
+		*m = ""
+	default:
+		return fmt.Errorf("invalid value %q: must be all or any", value)
+	}
+	return nil
+}
+
+func (m *matchMode) IsBoolFlag() bool {
+	return true
+}
+
+// options holds the parsed command line flags
+type options struct {
+	query                 string
+	size                  int
+	version               string
+	language              string
+	page                  int
+	all                   bool
+	maxPages              int
+	sort                  string
+	debug                 bool
+	verbose               bool
+	format                string
+	plain                 bool
+	listVersions          bool
+	listScopes            bool
+	completion            string
+	man                   bool
+	includeMatchedContent bool
+	maxHighlights         int
+	perCategory           int
+	minScore              float64
+	concurrency           int
+	retry                 int
+	timeout               time.Duration
+	proxy                 string
+	noInput               bool
+	explain               bool
+	noNormalize           bool
+	truncateQuery         bool
+	failOnEmpty           bool
+	checkAvailability     bool
+	deduplicate           bool
+	archived              bool
+	noAnchors             bool
+	anchors               bool
+	logFile               string
+	compact               bool
+	output                string
+	// report writes markdown as the --output report rather than the --format markdown list
+	report            bool
+	template          string
+	hitTemplate       *template.Template
+	jq                string
+	jqCode            *gojq.Code
+	jsonlMeta         bool
+	urlOnly           bool
+	null              bool
+	csvFields         StringSlice
+	columns           StringSlice
+	csvNoHeader       bool
+	htmlFullPage      bool
+	layout            string
+	oneline           bool
+	long              bool
+	showContent       bool
+	noPager           bool
+	showRank          bool
+	showScore         bool
+	showTiming        bool
+	highlightStyle    string
+	markStyle         lipgloss.Style
+	noColor           bool
+	noUnicode         bool
+	color             string
+	hyperlinks        string
+	relativeURLs      bool
+	theme             string
+	styleFile         string
+	dumpTheme         bool
+	count             bool
+	columnsWidth      int
+	width             int
+	widthGiven        bool
+	noBreadcrumbLinks bool
+	showBreadcrumbs   bool
+	noBreadcrumbs     bool
+	share             bool
+	copy              bool
+	web               bool
+	open              bool
+	openN             int
+	refs              bool
+	refsList          bool
+	cache             bool
+	cacheTTL          time.Duration
+	clearCache        bool
+	configPath        string
+	versionsFile      string
+	endpoint          string
+	queriesFile       string
+	parallel          int
+	updateVersions    bool
+	versionsURL       string
+	yes               bool
+	interactive       bool
+	watch             time.Duration
+	history           bool
+	historyLimit      int
+	clearHistory      bool
+	replay            int
+	bookmark          bool
+	bookmarkN         int
+	bookmarks         bool
+	removeBookmark    string
+	truncate          int
+	noTruncate        bool
+
+	highlights      StringSlice
+	includes        StringSlice
+	toplevel        StringSlice
+	excludeToplevel StringSlice
+	aggregate       StringSlice
+	breadcrumbs     StringSlice
+	breadcrumbTexts StringSlice
+	headings        StringSlice
+	translations    StringSlice
+	scopes          StringSlice
+	matchTitle      matchMode
+}
+
 // reorderArgs separates flags from non-flag arguments and returns them with flags first.
 // This allows flags to be specified after the query (e.g., "query" --debug).
 func reorderArgs(args []string) []string {
@@ -96,9 +285,66 @@ func reorderArgs(args []string) []string {
 	// Boolean flags that don't take values
 	boolFlags := map[string]bool{
 		"--debug":                   true,
+		"--verbose":                 true,
 		"--plain":                   true,
+		"--all":                     true,
+		"--list-scopes":             true,
+		"--dump-theme":              true,
 		"--list-versions":           true,
+		"--update-versions":         true,
+		"--yes":                     true,
+		"--man":                     true,
 		"--include-matched-content": true,
+		"--no-input":                true,
+		"--explain":                 true,
+		"--no-normalize":            true,
+		"--truncate-query":          true,
+		"--fail-on-empty":           true,
+		"--check-availability":      true,
+		"--deduplicate":             true,
+		"--archived":                true,
+		"--no-anchors":              true,
+		"--anchors":                 true,
+		"--compact":                 true,
+		"--jsonl-meta":              true,
+		"--csv-no-header":           true,
+		"--url-only":                true,
+		"--null":                    true,
+		"--oneline":                 true,
+		"--long":                    true,
+		"--no-truncate":             true,
+		"--full-intro":              true,
+		"--show-content":            true,
+		"--no-pager":                true,
+		"--no-unicode":              true,
+		"--relative-urls":           true,
+		"--show-rank":               true,
+		"--show-score":              true,
+		"--show-timing":             true,
+		"--no-color":                true,
+		"--count":                   true,
+		"--html-full-page":          true,
+		"--no-breadcrumb-links":     true,
+		"--show-breadcrumbs":        true,
+		"--no-breadcrumbs":          true,
+		"--share":                   true,
+		"--copy":                    true,
+		"--web":                     true,
+		"--open":                    true,
+		"--refs":                    true,
+		"--refs-list":               true,
+		"--cache":                   true,
+		"--clear-cache":             true,
+		"--interactive":             true,
+		"--history":                 true,
+		"--clear-history":           true,
+		"--bookmark":                true,
+		"--bookmarks":               true,
+	}
+
+	// Boolean-style flags that optionally accept one of a fixed set of values
+	optionalValueFlags := map[string][]string{
+		"--match-title": {"all", "any"},
 	}
 
 	for i := 0; i < len(args); i++ {
@@ -108,6 +354,13 @@ func reorderArgs(args []string) []string {
 			if strings.Contains(arg, "=") {
 				// Flag with embedded value, add as-is
 				flags = append(flags, arg)
+			} else if values, ok := optionalValueFlags[arg]; ok {
+				// Attach the value only when the next argument is one the flag accepts
+				if i+1 < len(args) && slices.Contains(values, args[i+1]) {
+					i++
+					arg += "=" + args[i]
+				}
+				flags = append(flags, arg)
 			} else if boolFlags[arg] {
 				// Boolean flag, no value expected
 				flags = append(flags, arg)
@@ -131,359 +384,1571 @@ func reorderArgs(args []string) []string {
 	return append(flags, nonFlags...)
 }
 
+// newFlagSet registers every command line flag onto a new FlagSet backed by opts
+func newFlagSet(opts *options, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("search-docs", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	fs.StringVar(&opts.query, "query", "", "search query (can also be provided as positional argument)")
+	fs.IntVar(&opts.size, "size", 5, "number of results to return (max: 50, default shows top 5 with links and descriptions)")
+	fs.StringVar(&opts.version, "version", "free-pro-team", "docs version")
+	fs.StringVar(&opts.language, "language", searchdocs.DetectSystemLanguage(), "language code; the default follows the system locale (LC_ALL, LANGUAGE, or LANG) when the docs are translated into it")
+	fs.IntVar(&opts.page, "page", 0, "page number for pagination (starting at 1)")
+	fs.BoolVar(&opts.all, "all", false, fmt.Sprintf("fetch every page of results, %d per page, and show them together", maxAPISize))
+	fs.IntVar(&opts.maxPages, "max-pages", searchdocs.DefaultMaxPages, "most pages --all fetches")
+	fs.StringVar(&opts.sort, "sort", "", "sort order")
+	fs.BoolVar(&opts.debug, "debug", false, "show raw JSON response")
+	fs.BoolVar(&opts.verbose, "verbose", false, "report extra details on stderr, such as how many duplicates --deduplicate removed")
+	fs.BoolVar(&opts.cache, "cache", false, "reuse search responses cached on disk in the user cache directory")
+	fs.DurationVar(&opts.cacheTTL, "cache-ttl", searchdocs.DefaultCacheTTL, "how long --cache reuses a cached response, e.g. 30m or 24h")
+	fs.BoolVar(&opts.clearCache, "clear-cache", false, "delete every cached search response and print how many were removed")
+	fs.BoolVar(&opts.explain, "explain", false, "describe how the search request was built (written to stderr)")
+	fs.BoolVar(&opts.failOnEmpty, "fail-on-empty", false, "exit with status 1 when no results are shown")
+	fs.BoolVar(&opts.truncateQuery, "truncate-query", false, fmt.Sprintf("trim queries longer than %d bytes once URL-encoded at a word boundary instead of failing", searchdocs.MaxQueryLength))
+	fs.BoolVar(&opts.noNormalize, "no-normalize", false, "send the query exactly as typed instead of normalizing smart quotes, dashes, and invisible spaces")
+	fs.StringVar(&opts.format, "format", "pretty", "output format: pretty (default), plain, markdown, html, json, jsonl, yaml, csv, tsv, urls, titles, tree, raw")
+	fs.StringVar(&opts.layout, "layout", layoutAuto, "result layout: auto (default; compact below 60 columns), full, compact, columns, oneline")
+	fs.IntVar(&opts.truncate, "truncate", defaultIntroLength, "number of characters of each intro shown in pretty and plain output (0 shows them in full; default scales with the terminal width)")
+	fs.IntVar(&opts.truncate, "intro-length", defaultIntroLength, "same as --truncate")
+	fs.BoolVar(&opts.noTruncate, "no-truncate", false, "show intros in full (same as --truncate 0)")
+	fs.BoolVar(&opts.noTruncate, "full-intro", false, "same as --no-truncate")
+	fs.BoolVar(&opts.long, "long", false, "show every field returned for each result: full intro, breadcrumb path, top level, headings, and score")
+	fs.BoolVar(&opts.showContent, "show-content", false, "show the full article of each result below it")
+	fs.BoolVar(&opts.noPager, "no-pager", false, "don't pipe output longer than the terminal through $GH_PAGER or $PAGER (default: less -FRX)")
+	fs.BoolVar(&opts.count, "count", false, "print only the total number of results (with a + when the API gives a lower bound), and exit 1 when there are none")
+	fs.BoolVar(&opts.showRank, "show-rank", false, "start each --format titles line with the result's rank")
+	fs.BoolVar(&opts.showTiming, "show-timing", false, "show how long the API took to search: below the results, as _timing in --format json, and as query_msec and total_msec columns in --format csv")
+	fs.BoolVar(&opts.showScore, "show-score", false, "show each result's relevance score, to three decimals (n/a when missing), and add a score column to csv and tsv")
+	fs.StringVar(&opts.highlightStyle, "highlight-style", "", fmt.Sprintf("how pretty output shows matched terms: %s (default: %s, or $GH_SEARCH_DOCS_HIGHLIGHT)", strings.Join(highlightStyleNames, ", "), defaultHighlightStyle))
+	fs.BoolVar(&opts.noColor, "no-color", false, "turn off colors and text styling; matched terms are shown as [term] (same as --color never)")
+	fs.BoolVar(&opts.noUnicode, "no-unicode", false, "draw --format tree with ASCII characters instead of box-drawing ones")
+	fs.StringVar(&opts.hyperlinks, "hyperlinks", hyperlinksAuto, "when pretty output links result titles to their pages: auto (terminals known to support OSC 8 links), always, never")
+	fs.BoolVar(&opts.relativeURLs, "relative-urls", false, "show result URLs and Markdown and HTML links as paths without the https://docs.github.com prefix; terminal hyperlinks, --open, and json keep the full URL")
+	fs.StringVar(&opts.theme, "theme", "", fmt.Sprintf("style of pretty output: %s (default: %s, or $GH_SEARCH_DOCS_THEME)", strings.Join(themeNames, ", "), themeAuto))
+	fs.StringVar(&opts.styleFile, "style-file", "", "style pretty output with this glamour JSON style file instead of --theme (default: $GLAMOUR_STYLE)")
+	fs.StringVar(&opts.styleFile, "theme-file", "", "same as --style-file")
+	fs.BoolVar(&opts.dumpTheme, "dump-theme", false, "print the JSON of the theme or style file pretty output uses, as a starting point for --style-file")
+	fs.StringVar(&opts.color, "color", colorAuto, "when to use colors and pretty output: auto (only when writing to a terminal), always, never")
+	fs.BoolVar(&opts.oneline, "oneline", false, "show each result on one line: number, title, and URL (same as --layout oneline)")
+	fs.IntVar(&opts.width, "width", 0, "lay out and wrap output for N columns instead of the terminal width, even when piped (0 turns wrapping off)")
+	fs.IntVar(&opts.columnsWidth, "columns-width", 200, "terminal width at or above which --layout auto shows two columns (0 disables)")
+	fs.StringVar(&opts.template, "template", "", "print each result with this Go text/template; the dot is the result, or the whole search if the template reads .Hits, .Meta, .Query, .Version, or .Language")
+	fs.StringVar(&opts.jq, "jq", "", "filter the JSON results with a jq expression (implies --format json)")
+	fs.StringVar(&opts.output, "output", "", "also write the results to this file: a Markdown report for pretty and plain output, otherwise the --format output (- for stdout only)")
+	fs.BoolVar(&opts.compact, "compact", false, "print --format json output on a single line")
+	fs.BoolVar(&opts.htmlFullPage, "html-full-page", false, "wrap --format html output in a standalone page with inline CSS")
+	fs.Var(&opts.columns, "columns", "fields shown by plain, csv, and tsv output, in order (comma-separated or repeated): "+strings.Join(searchdocs.ColumnNames, ", "))
+	fs.Var(&opts.csvFields, "csv-fields", "columns for --format csv, in order (comma-separated or repeated): "+strings.Join(searchdocs.ColumnNames, ", "))
+	fs.BoolVar(&opts.csvNoHeader, "csv-no-header", false, "leave out the --format csv header row")
+	fs.BoolVar(&opts.urlOnly, "url-only", false, "print only the full URL of each result, one per line (same as --format urls)")
+	fs.BoolVar(&opts.null, "null", false, "end each --url-only URL with a NUL byte instead of a newline, for xargs -0")
+	fs.BoolVar(&opts.jsonlMeta, "jsonl-meta", false, "start --format jsonl output with a {\"meta\": ...} line before the hits")
+	fs.BoolVar(&opts.plain, "plain", false, "disable pretty rendering (use plain text output)")
+	fs.BoolVar(&opts.listVersions, "list-versions", false, "list supported enterprise server versions")
+	fs.BoolVar(&opts.listScopes, "list-scopes", false, "list the --scope presets and the toplevel filters they expand to")
+	fs.StringVar(&opts.completion, "completion", "", "print a completion script for this shell: "+strings.Join(searchdocs.CompletionShells, ", "))
+	fs.BoolVar(&opts.man, "man", false, "print the man page, e.g. gh search-docs --man | man -l -")
+	fs.BoolVar(&opts.includeMatchedContent, "include-matched-content", false, "include matched content highlights")
+	fs.IntVar(&opts.maxHighlights, "max-highlights", 3, "show at most this many matched content snippets per result in pretty and plain output (0 for all)")
+	fs.BoolVar(&opts.share, "share", false, "print the docs.github.com search page URL for the query instead of searching")
+	fs.BoolVar(&opts.copy, "copy", false, "copy the search page URL to the clipboard (implies --share)")
+	fs.BoolVar(&opts.web, "web", false, "open the search page in the browser (implies --share)")
+	fs.BoolVar(&opts.open, "open", false, "open the first result in the default browser after showing the results")
+	fs.IntVar(&opts.openN, "open-n", 0, "open the Nth result (starting at 1) in the default browser (implies --open)")
+	fs.BoolVar(&opts.refs, "refs", false, "print Markdown reference-link definitions ([1]: url \"Title\") for the results")
+	fs.BoolVar(&opts.refsList, "refs-list", false, "precede the --refs definitions with a numbered list of linked titles (implies --refs)")
+	fs.StringVar(&opts.endpoint, "endpoint", "", "search API endpoint, e.g. of an internal docs mirror; must be https (default: $GH_SEARCH_DOCS_ENDPOINT, then "+endpoint+")")
+	fs.StringVar(&opts.versionsFile, "versions-file", "", "read the supported enterprise server versions from this JSON file (default: $GH_SEARCH_DOCS_VERSIONS_FILE, then data/supported-versions.json next to the executable)")
+	fs.BoolVar(&opts.updateVersions, "update-versions", false, "download the current supported enterprise server versions and update the versions file")
+	fs.StringVar(&opts.versionsURL, "versions-url", searchdocs.DefaultVersionsURL, "where --update-versions downloads the supported versions from")
+	fs.BoolVar(&opts.yes, "yes", false, "with --update-versions, rewrite the versions file even when nothing changed")
+	fs.StringVar(&opts.configPath, "config", "", "read flag defaults from this YAML file (default: config.yaml in the gh-search-docs config directory)")
+	fs.StringVar(&opts.queriesFile, "queries-file", "", "run each query in this file, one per line (blank lines and lines starting with # are skipped), with the same flags")
+	fs.IntVar(&opts.parallel, "parallel", 1, fmt.Sprintf("with --queries-file, run up to this many queries at once (1-%d)", searchdocs.MaxConcurrency))
+	fs.DurationVar(&opts.watch, "watch", 0, "run the search again every interval, e.g. 30s, redrawing the results until Ctrl+C")
+	fs.BoolVar(&opts.interactive, "interactive", false, "browse results in a full-screen search UI (enter opens a result, / searches again, q quits)")
+	fs.BoolVar(&opts.bookmark, "bookmark", false, "save the first result as a bookmark after showing the results")
+	fs.IntVar(&opts.bookmarkN, "bookmark-n", 0, "save the Nth result (starting at 1) as a bookmark (implies --bookmark)")
+	fs.BoolVar(&opts.bookmarks, "bookmarks", false, "list saved bookmarks")
+	fs.StringVar(&opts.removeBookmark, "remove-bookmark", "", "delete the bookmark with this ID (see --bookmarks)")
+	fs.BoolVar(&opts.history, "history", false, "list recent searches, newest first")
+	fs.IntVar(&opts.historyLimit, "history-limit", defaultHistoryLimit, "number of searches --history lists")
+	fs.BoolVar(&opts.clearHistory, "clear-history", false, "delete the local search history")
+	fs.IntVar(&opts.replay, "replay", 0, "run the Nth most recent search listed by --history again")
+	fs.BoolVar(&opts.noInput, "no-input", false, "never prompt for input (implied when stdin is not a terminal or CI=true)")
+
+	fs.Var(&opts.highlights, "highlights", "highlight options (can be used multiple times): title, content, content_explicit, term")
+	fs.Var(&opts.includes, "include", "additional includes (can be used multiple times): intro, headings, toplevel, or all")
+	fs.Var(&opts.toplevel, "toplevel", "toplevel filter (can be used multiple times; prefix with ! to exclude)")
+	fs.Var(&opts.excludeToplevel, "exclude-toplevel", "leave out results from this toplevel, e.g. rest (can be used multiple times; matched client-side)")
+	fs.Var(&opts.scopes, "scope", "named preset of toplevel filters, e.g. admin, developer, security (see --list-scopes)")
+	fs.Var(&opts.aggregate, "aggregate", "aggregate options (can be used multiple times)")
+	fs.Var(&opts.matchTitle, "match-title", "only keep results whose title contains all query terms (use --match-title any for at least one)")
+	fs.IntVar(&opts.concurrency, "concurrency", searchdocs.DefaultConcurrency, fmt.Sprintf("maximum number of requests in flight at once (1-%d)", searchdocs.MaxConcurrency))
+	fs.DurationVar(&opts.timeout, "timeout", searchdocs.DefaultTimeout, "how long each request may take, e.g. 10s or 2m (0 waits as long as needed)")
+	fs.StringVar(&opts.proxy, "proxy", "", "send requests through this proxy URL, e.g. http://proxy.example.com:8080 (default: HTTPS_PROXY, HTTP_PROXY, and NO_PROXY)")
+	fs.IntVar(&opts.retry, "retry", searchdocs.DefaultRetries, "how many times to retry a request that is rate limited (429) or finds the API unavailable (503), with backoff (0 turns retries off)")
+	fs.Float64Var(&opts.minScore, "min-score", 0, "hide results whose relevance score is below this threshold (client-side; 0 keeps all)")
+	fs.IntVar(&opts.perCategory, "per-category", 0, "keep at most N results from each toplevel category for more diverse results")
+	fs.Var(&opts.headings, "heading", "keep results with a section heading containing this text (can be used multiple times; all must match)")
+	fs.StringVar(&opts.logFile, "log-file", "", "append a JSON record of each invocation to this file")
+	fs.BoolVar(&opts.showBreadcrumbs, "show-breadcrumbs", false, "show each result's breadcrumb path below its URL (the default)")
+	fs.BoolVar(&opts.noBreadcrumbs, "no-breadcrumbs", false, "don't show each result's breadcrumb path")
+	fs.BoolVar(&opts.noBreadcrumbLinks, "no-breadcrumb-links", false, "don't link breadcrumb segments to their landing pages")
+	fs.BoolVar(&opts.noAnchors, "no-anchors", false, "link to the top of each page instead of the heading that matched the query")
+	fs.BoolVar(&opts.anchors, "anchors", false, "list a deep link to each heading that matches the query under each result (implies --include headings)")
+	fs.BoolVar(&opts.archived, "archived", false, "search the archived docs for an enterprise-server version that is no longer supported (best effort)")
+	fs.BoolVar(&opts.deduplicate, "deduplicate", false, "drop results for a page that's already listed, e.g. when it matched on both its title and its content")
+	fs.BoolVar(&opts.checkAvailability, "check-availability", false, "check whether each result exists for free-pro-team, enterprise-cloud, and the latest enterprise-server")
+	fs.Var(&opts.translations, "check-translations", "check whether each result exists in these languages, e.g. ja or ja,ko (can be used multiple times)")
+	fs.Var(&opts.breadcrumbs, "breadcrumb", "keep results whose breadcrumbs start with this path, e.g. \"Actions / Security guides\" (can be used multiple times)")
+	fs.Var(&opts.breadcrumbTexts, "filter-breadcrumb", "keep results whose breadcrumbs contain this text anywhere, e.g. \"Actions\" (can be used multiple times; any may match)")
+
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: %s [flags] <query>\n\n", searchdocs.CommandName())
+		fmt.Fprintf(stderr, "By default, output uses pretty formatting with colors on a terminal and plain text when piped.\n")
+		fmt.Fprintf(stderr, "Use --plain for simple text output with clickable URLs.\n\n")
+		fs.PrintDefaults()
+	}
+
+	return fs
+}
+
+func init() {
+	// The man page documents the same flags as --help
+	searchdocs.SetManFlags(func() *flag.FlagSet {
+		return newFlagSet(&options{}, io.Discard)
+	})
+}
+
+// buildVersion returns the module version the binary was built from, or "dev" for local builds
+func buildVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "dev"
+}
+
+// subcommands are dispatched on the first argument, e.g. gh search-docs stats
+var subcommands = map[string]func(args []string, stdout, stderr io.Writer) int{
+	"stats": runStats,
+	"log":   runLog,
+	"cache": runCache,
+}
+
 func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes the command with the given arguments and returns the process exit code.
+// All output goes through stdout and stderr so the command can be exercised from tests.
+func run(args []string, stdout, stderr io.Writer) (code int) {
+	if len(args) > 0 {
+		if subcommand, ok := subcommands[args[0]]; ok {
+			return subcommand(args[1:], stdout, stderr)
+		}
+	}
+
 	//----------------------------------------------------------------------
 	// Flags
 	//----------------------------------------------------------------------
-	fs := flag.NewFlagSet("search-docs", flag.ExitOnError)
-
-	queryFlag := fs.String("query", "", "search query (can also be provided as positional argument)")
-	sizeFlag := fs.Int("size", 5, "number of results to return (max: 50, default shows top 5 with links and descriptions)")
-	versionFlag := fs.String("version", "free-pro-team", "docs version")
-	languageFlag := fs.String("language", "en", "language code")
-	pageFlag := fs.Int("page", 0, "page number for pagination")
-	sortFlag := fs.String("sort", "", "sort order")
-	debugFlag := fs.Bool("debug", false, "show raw JSON response")
-	formatFlag := fs.String("format", "pretty", "output format: pretty (default), plain, json")
-	plainFlag := fs.Bool("plain", false, "disable pretty rendering (use plain text output)")
-	listVersions := fs.Bool("list-versions", false, "list supported enterprise server versions")
-	includeMatchedContentFlag := fs.Bool("include-matched-content", false, "include matched content highlights")
-
-	var highlights StringSlice
-	var includes StringSlice
-	var toplevel StringSlice
-	var aggregate StringSlice
-
-	fs.Var(&highlights, "highlights", "highlight options (can be used multiple times): title, content, content_explicit, term")
-	fs.Var(&includes, "include", "additional includes (can be used multiple times): intro, headings, toplevel")
-	fs.Var(&toplevel, "toplevel", "toplevel filter (can be used multiple times)")
-	fs.Var(&aggregate, "aggregate", "aggregate options (can be used multiple times)")
+	opts := &options{}
+	fs := newFlagSet(opts, stderr)
 
-	fs.Usage = func() {
-		bin := filepath.Base(os.Args[0])
-		if strings.HasPrefix(bin, "gh-") {
-			bin = "gh " + strings.TrimPrefix(bin, "gh-")
+	// Reorder arguments to allow flags after the query
+	if err := fs.Parse(reorderArgs(args)); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
 		}
-		fmt.Fprintf(os.Stderr, "usage: %s [flags] <query>\n\n", bin)
-		fmt.Fprintf(os.Stderr, "By default, output uses pretty formatting with colors.\n")
-		fmt.Fprintf(os.Stderr, "Use --plain for simple text output with clickable URLs.\n\n")
-		fs.PrintDefaults()
+		return 2
+	}
+	if opts.replay != 0 {
+		if opts.replay < 0 {
+			fmt.Fprintf(stderr, "Error: --replay must be at least 1.\n")
+			return 1
+		}
+		if err := replaySearch(fs, opts); err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+	// Defaults from the config file don't conflict with flags that pick their own
+	formatGiven := isFlagSet(fs, "format")
+	truncateGiven := isFlagSet(fs, "truncate") || isFlagSet(fs, "intro-length")
+	if err := applyConfig(fs, opts); err != nil {
+		fmt.Fprintf(stderr, "Error loading config: %v\n", err)
+		return 1
 	}
 
-	// Reorder arguments to allow flags after the query
-	reorderedArgs := reorderArgs(os.Args[1:])
+	rec := &transcript{Timestamp: time.Now().UTC(), Args: args}
+	if opts.logFile != "" && !searchdocs.HistoryDisabled() {
+		logStderr := stderr
+		stderr = io.MultiWriter(stderr, &errorCollector{transcript: rec})
+		defer func() {
+			rec.ExitCode = code
+			writeTranscript(logStderr, opts.logFile, rec)
+		}()
+	}
 
-	if err := fs.Parse(reorderedArgs); err != nil {
-		searchdocs.Fatal(err)
+	// Result URLs, share links, and article fetches all point at the endpoint's site
+	resolved, err := searchdocs.ResolveEndpoint(opts.endpoint, os.Getenv("GH_SEARCH_DOCS_ENDPOINT"), endpoint)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: invalid --endpoint: %v\n", err)
+		return 1
 	}
+	opts.endpoint = resolved
+	searchdocs.DocsBaseURL = searchdocs.EndpointBaseURL(resolved)
 
-	if *listVersions {
-		versions, err := searchdocs.LoadSupportedVersions()
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error loading supported versions: %v\n", err)
-			fmt.Fprintf(os.Stderr, "Fallback supported versions: 3.11, 3.12, 3.13, 3.14, 3.15, 3.16, 3.17\n")
-			os.Exit(1)
+	// A versions file that was asked for has to be readable; the bundled one falls back to
+	// built-in versions
+	searchdocs.VersionsFile = opts.versionsFile
+	if opts.updateVersions {
+		return updateVersions(stdout, stderr, opts)
+	}
+	if opts.versionsFile != "" || os.Getenv("GH_SEARCH_DOCS_VERSIONS_FILE") != "" {
+		if _, err := searchdocs.LoadSupportedVersions(); err != nil {
+			fmt.Fprintf(stderr, "Error loading versions file: %v\n", err)
+			return 1
 		}
+	}
 
-		fmt.Println("Supported GitHub Enterprise Server versions:")
-		for _, version := range versions.SupportedVersions {
-			if version == versions.LatestVersion {
-				fmt.Printf("  %s (latest)\n", version)
-			} else {
-				fmt.Printf("  %s\n", version)
-			}
+	if opts.listVersions {
+		return listSupportedVersions(stdout, stderr)
+	}
+	if opts.listScopes {
+		return listScopes(stdout, stderr)
+	}
+	if opts.dumpTheme {
+		if !resolveTheme(stderr, opts) {
+			return 1
+		}
+		return dumpTheme(stdout, stderr, opts)
+	}
+	if opts.completion != "" {
+		if err := searchdocs.GenerateCompletion(opts.completion, fs, stdout); err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
 		}
-		fmt.Printf("\nLast updated: %s\n", versions.LastUpdated)
-		fmt.Println("\nUsage: gh search-docs --version enterprise-server@<version> <query>")
-		os.Exit(0)
+		return 0
+	}
+	if opts.man {
+		if err := searchdocs.GenerateManPage(buildVersion(), stdout); err != nil {
+			fmt.Fprintln(stderr, "error:", err)
+			return 1
+		}
+		return 0
+	}
+	if opts.clearCache {
+		return clearCache(stdout, stderr)
+	}
+	if opts.history {
+		if opts.historyLimit < 1 {
+			fmt.Fprintf(stderr, "Error: --history-limit must be at least 1.\n")
+			return 1
+		}
+		return showHistory(stdout, stderr, opts.historyLimit)
+	}
+	if opts.clearHistory {
+		return clearHistory(stdout, stderr)
+	}
+	if opts.bookmarks {
+		return listBookmarks(stdout, stderr)
+	}
+	if opts.removeBookmark != "" {
+		return removeBookmark(stdout, stderr, opts.removeBookmark)
 	}
 
-	// Get query from flag or positional arguments
-	query := *queryFlag
+	// --queries-file runs its queries in place of one from the command line
+	var queries []string
+	if opts.queriesFile != "" {
+		if opts.query != "" || fs.NArg() > 0 {
+			fmt.Fprintf(stderr, "Error: --queries-file can't be combined with a query on the command line.\n")
+			return 1
+		}
+		var err error
+		if queries, err = readQueriesFile(opts.queriesFile); err != nil {
+			fmt.Fprintf(stderr, "Error reading --queries-file: %v\n", err)
+			return 1
+		}
+		if len(queries) == 0 {
+			fmt.Fprintf(stderr, "Error: %s has no queries.\n", opts.queriesFile)
+			return 1
+		}
+	}
+
+	// Get query from flag or positional arguments, then from piped input, asking for one in
+	// interactive sessions. A terminal's stdin is only read by the prompt, so nothing blocks.
+	query := opts.query
 	if query == "" && fs.NArg() > 0 {
 		query = strings.Join(fs.Args(), " ")
 	}
-
-	if query == "" {
+	batch := len(queries) > 0
+	if query == "" && !batch && !opts.interactive && !stdinIsTerminal() {
+		var err error
+		if query, err = searchdocs.ReadQueryFromStdin(stdin); err != nil {
+			fmt.Fprintln(stderr, "error reading query from stdin:", err)
+			return 1
+		}
+	}
+	input := query
+	query, err = prepareQuery(stderr, opts, query)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v.\n", err)
+		return 1
+	}
+	if query == "" && !batch && !opts.interactive {
 		fs.Usage()
-		os.Exit(1)
+		return 1
 	}
 
 	// Validate size flag - GitHub Docs API has a maximum limit of 50
-	if *sizeFlag > 50 {
-		fmt.Fprintf(os.Stderr, "Error: --size cannot exceed 50 (GitHub Docs API limit). Use --page to navigate through more results.\n")
-		os.Exit(1)
+	if opts.size > 50 {
+		fmt.Fprintf(stderr, "Error: --size cannot exceed 50 (GitHub Docs API limit). Use --page to navigate through more results.\n")
+		return 1
+	}
+	if opts.size < 1 {
+		fmt.Fprintf(stderr, "Error: --size must be at least 1.\n")
+		return 1
+	}
+	if opts.showBreadcrumbs && opts.noBreadcrumbs {
+		fmt.Fprintf(stderr, "Error: --show-breadcrumbs and --no-breadcrumbs can't be used together.\n")
+		return 1
+	}
+	opts.showBreadcrumbs = !opts.noBreadcrumbs
+	if opts.oneline {
+		if isFlagSet(fs, "layout") && opts.layout != layoutOneline {
+			fmt.Fprintf(stderr, "Error: --oneline can't be combined with --layout %s.\n", opts.layout)
+			return 1
+		}
+		opts.layout = layoutOneline
+	}
+	if opts.truncate < 0 {
+		fmt.Fprintf(stderr, "Error: --truncate and --intro-length must not be negative.\n")
+		return 1
+	}
+	if opts.width < 0 {
+		fmt.Fprintf(stderr, "Error: --width must not be negative.\n")
+		return 1
+	}
+	opts.widthGiven = isFlagSet(fs, "width")
+	// Without a length from the flags or the config file, intros fill about two lines
+	if width, fit := outputWidth(opts); !truncateGiven && !isFlagSet(fs, "truncate") && fit {
+		opts.truncate = scaledIntroLength(width)
+	}
+	if opts.noTruncate {
+		if truncateGiven && opts.truncate != 0 {
+			fmt.Fprintf(stderr, "Error: --no-truncate and --full-intro can't be combined with --truncate %d.\n", opts.truncate)
+			return 1
+		}
+		opts.truncate = 0
+	}
+	if opts.long && opts.layout != layoutAuto && opts.layout != layoutFull {
+		fmt.Fprintf(stderr, "Error: --long can't be combined with --layout %s.\n", opts.layout)
+		return 1
+	}
+	if opts.showContent && opts.layout != layoutAuto && opts.layout != layoutFull {
+		fmt.Fprintf(stderr, "Error: --show-content can't be combined with --layout %s.\n", opts.layout)
+		return 1
+	}
+	switch opts.layout {
+	case layoutAuto, layoutFull, layoutCompact, layoutColumns, layoutOneline:
+	default:
+		fmt.Fprintf(stderr, "Error: unknown --layout %q (use auto, full, compact, columns, or oneline).\n", opts.layout)
+		return 1
+	}
+	if opts.count {
+		if formatGiven || opts.urlOnly || opts.jq != "" || opts.refs || opts.refsList || opts.template != "" || opts.output != "" {
+			fmt.Fprintf(stderr, "Error: --count prints only the number of results, so it can't be combined with --format, --url-only, --jq, --refs, --template, or --output.\n")
+			return 1
+		}
+		opts.format = "pretty"
+	}
+	if opts.urlOnly {
+		if formatGiven && opts.format != "urls" {
+			fmt.Fprintf(stderr, "Error: --url-only can't be combined with --format %s.\n", opts.format)
+			return 1
+		}
+		opts.format = "urls"
+	}
+	if opts.showRank && opts.format != "titles" {
+		fmt.Fprintf(stderr, "Error: --show-rank can only be used with --format titles.\n")
+		return 1
+	}
+	if opts.showScore && !slices.Contains([]string{"pretty", "plain", "csv", "tsv", "titles"}, opts.format) {
+		fmt.Fprintf(stderr, "Error: --show-score can't be used with --format %s.\n", opts.format)
+		return 1
+	}
+	highlightStyle, source := opts.highlightStyle, "--highlight-style"
+	if highlightStyle == "" {
+		highlightStyle, source = os.Getenv("GH_SEARCH_DOCS_HIGHLIGHT"), "GH_SEARCH_DOCS_HIGHLIGHT style"
+	}
+	if highlightStyle == "" {
+		highlightStyle = defaultHighlightStyle
+	}
+	style, ok := parseHighlightStyle(highlightStyle)
+	if !ok {
+		fmt.Fprintf(stderr, "Error: unknown %s %q (use %s).\n", source, highlightStyle, strings.Join(highlightStyleNames, ", "))
+		return 1
+	}
+	opts.markStyle = style
+	if !resolveTheme(stderr, opts) {
+		return 1
+	}
+	switch opts.color {
+	case colorAuto:
+		// Like ls --color=auto, output that isn't going to a terminal is plain text
+		if !stdoutIsTerminal() {
+			opts.plain = true
+		}
+	case colorAlways:
+		if opts.noColor {
+			fmt.Fprintf(stderr, "Error: --no-color can't be combined with --color always.\n")
+			return 1
+		}
+		if !stdoutIsTerminal() {
+			defer lipgloss.SetColorProfile(lipgloss.ColorProfile())
+			lipgloss.SetColorProfile(termenv.ANSI256)
+		}
+	case colorNever:
+		opts.noColor = true
+	default:
+		fmt.Fprintf(stderr, "Error: unknown --color %q (use %s, %s, or %s).\n", opts.color, colorAuto, colorAlways, colorNever)
+		return 1
+	}
+	if !slices.Contains([]string{hyperlinksAuto, hyperlinksAlways, hyperlinksNever}, opts.hyperlinks) {
+		fmt.Fprintf(stderr, "Error: unknown --hyperlinks %q (use %s, %s, or %s).\n", opts.hyperlinks, hyperlinksAuto, hyperlinksAlways, hyperlinksNever)
+		return 1
+	}
+	if opts.noColor {
+		// Restored on return, since tests run many searches in one process
+		defer lipgloss.SetColorProfile(lipgloss.ColorProfile())
+		lipgloss.SetColorProfile(termenv.Ascii)
 	}
-	if *sizeFlag < 1 {
-		fmt.Fprintf(os.Stderr, "Error: --size must be at least 1.\n")
-		os.Exit(1)
+	if opts.noUnicode && opts.format != "tree" {
+		fmt.Fprintf(stderr, "Error: --no-unicode can only be used with --format tree.\n")
+		return 1
+	}
+	if opts.null && opts.format != "urls" {
+		fmt.Fprintf(stderr, "Error: --null can only be used with --url-only or --format urls.\n")
+		return 1
+	}
+	if opts.jq != "" {
+		if opts.refs || opts.refsList || opts.template != "" {
+			fmt.Fprintf(stderr, "Error: --jq can't be combined with --refs or --template.\n")
+			return 1
+		}
+		// Compiled up front so syntax errors are reported before any request is made
+		code, err := compileJQ(opts.jq)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: invalid --jq expression: %v\n", err)
+			return 1
+		}
+		opts.jqCode = code
+		// --jq filters the JSON output, whatever --format says
+		opts.format = "json"
+	}
+	if opts.compact && opts.format != "json" {
+		fmt.Fprintf(stderr, "Error: --compact can only be used with --format json.\n")
+		return 1
+	}
+	if opts.htmlFullPage && opts.format != "html" {
+		fmt.Fprintf(stderr, "Error: --html-full-page can only be used with --format html.\n")
+		return 1
+	}
+	if opts.jsonlMeta && opts.format != "jsonl" {
+		fmt.Fprintf(stderr, "Error: --jsonl-meta can only be used with --format jsonl.\n")
+		return 1
+	}
+	if (len(opts.csvFields) > 0 || opts.csvNoHeader) && opts.format != "csv" {
+		fmt.Fprintf(stderr, "Error: --csv-fields and --csv-no-header can only be used with --format csv.\n")
+		return 1
+	}
+	for _, list := range []struct {
+		flag    string
+		columns StringSlice
+	}{{"columns", opts.columns}, {"csv-fields", opts.csvFields}} {
+		if !isFlagSet(fs, list.flag) {
+			continue
+		}
+		columns := splitList(list.columns)
+		if len(columns) == 0 {
+			fmt.Fprintf(stderr, "Error: --%s needs at least one column.\n", list.flag)
+			return 1
+		}
+		for _, column := range columns {
+			if !slices.Contains(searchdocs.ColumnNames, column) {
+				fmt.Fprintf(stderr, "Error: unknown --%s column %q (use %s).\n", list.flag, column, strings.Join(searchdocs.ColumnNames, ", "))
+				return 1
+			}
+		}
+	}
+	if len(opts.columns) > 0 && !opts.plain && opts.format != "plain" && opts.format != "csv" && opts.format != "tsv" {
+		fmt.Fprintf(stderr, "Error: --columns can only be used with --plain, --format csv, or --format tsv.\n")
+		return 1
+	}
+	if opts.refsList {
+		opts.refs = true
+	}
+	if opts.showContent && (opts.format != "pretty" && opts.format != "plain" || opts.count || opts.refs || opts.template != "") {
+		fmt.Fprintf(stderr, "Error: --show-content only works with pretty and plain output.\n")
+		return 1
+	}
+	if opts.refs && opts.format != "pretty" && opts.format != "plain" {
+		fmt.Fprintf(stderr, "Error: --refs can't be used with --format %s.\n", opts.format)
+		return 1
+	}
+	if isFlagSet(fs, "open-n") {
+		if opts.openN < 1 {
+			fmt.Fprintf(stderr, "Error: --open-n must be at least 1.\n")
+			return 1
+		}
+		opts.open = true
+	}
+	if opts.anchors && opts.noAnchors {
+		fmt.Fprintf(stderr, "Error: --anchors and --no-anchors can't be used together.\n")
+		return 1
+	}
+	if opts.template != "" {
+		if opts.format != "pretty" || opts.refs {
+			fmt.Fprintf(stderr, "Error: --template can't be combined with --format %s or --refs.\n", opts.format)
+			return 1
+		}
+		// Parsed up front so mistakes are reported before any request is made
+		tmpl, err := parseHitTemplate(opts.template)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: invalid --template: %v\n", err)
+			return 1
+		}
+		opts.hitTemplate = tmpl
+	}
+	if opts.output != "" && opts.format == "raw" {
+		fmt.Fprintf(stderr, "Error: --output can't be used with --format raw; redirect stdout instead.\n")
+		return 1
+	}
+	if opts.showTiming && opts.format == "raw" {
+		fmt.Fprintf(stderr, "Error: --show-timing can't be used with --format raw.\n")
+		return 1
+	}
+	if opts.open && opts.format == "raw" {
+		fmt.Fprintf(stderr, "Error: --open can't be used with --format raw.\n")
+		return 1
+	}
+	if isFlagSet(fs, "bookmark-n") {
+		if opts.bookmarkN < 1 {
+			fmt.Fprintf(stderr, "Error: --bookmark-n must be at least 1.\n")
+			return 1
+		}
+		opts.bookmark = true
+	}
+	if opts.bookmark && opts.format == "raw" {
+		fmt.Fprintf(stderr, "Error: --bookmark can't be used with --format raw.\n")
+		return 1
+	}
+	if opts.watch < 0 {
+		fmt.Fprintf(stderr, "Error: --watch must not be negative.\n")
+		return 1
+	}
+	if opts.maxHighlights < 0 {
+		fmt.Fprintf(stderr, "Error: --max-highlights must not be negative.\n")
+		return 1
+	}
+	if opts.parallel < 1 || opts.parallel > searchdocs.MaxConcurrency {
+		fmt.Fprintf(stderr, "Error: --parallel must be between 1 and %d.\n", searchdocs.MaxConcurrency)
+		return 1
+	}
+	if isFlagSet(fs, "parallel") && !batch {
+		fmt.Fprintf(stderr, "Error: --parallel can only be used with --queries-file.\n")
+		return 1
+	}
+	if batch {
+		switch {
+		case opts.format == "raw":
+			fmt.Fprintf(stderr, "Error: --queries-file can't be used with --format raw.\n")
+			return 1
+		case opts.interactive, opts.watch > 0, opts.open, opts.bookmark, opts.output != "", opts.share, opts.copy, opts.web:
+			fmt.Fprintf(stderr, "Error: --queries-file can't be combined with --interactive, --watch, --open, --bookmark, --output, --share, --copy, or --web.\n")
+			return 1
+		}
+	}
+	if opts.watch > 0 {
+		// Each refresh redraws the screen, which only makes sense for output meant to be read
+		switch {
+		case opts.format == "raw" || isResultsOnlyFormat(opts.format):
+			fmt.Fprintf(stderr, "Error: --watch can't be used with --format %s.\n", opts.format)
+			return 1
+		case opts.interactive, opts.open, opts.bookmark, opts.showContent:
+			fmt.Fprintf(stderr, "Error: --watch can't be combined with --interactive, --open, --bookmark, or --show-content.\n")
+			return 1
+		}
+	}
+	if isFlagSet(fs, "page") && opts.page < 1 {
+		fmt.Fprintf(stderr, "Error: --page must be at least 1.\n")
+		return 1
+	}
+	if opts.maxPages < 1 {
+		fmt.Fprintf(stderr, "Error: --max-pages must be at least 1.\n")
+		return 1
+	}
+	if isFlagSet(fs, "max-pages") && !opts.all {
+		fmt.Fprintf(stderr, "Error: --max-pages can only be used with --all.\n")
+		return 1
+	}
+	if opts.all {
+		switch {
+		case isFlagSet(fs, "page"), opts.format == "raw":
+			fmt.Fprintf(stderr, "Error: --all can't be used with --page or --format raw.\n")
+			return 1
+		case opts.interactive, opts.watch > 0, batch, opts.count:
+			fmt.Fprintf(stderr, "Error: --all can't be combined with --interactive, --watch, --queries-file, or --count.\n")
+			return 1
+		}
+	}
+	for _, inc := range splitList(opts.includes) {
+		if inc != includeAll && !slices.Contains(includeFields, inc) {
+			fmt.Fprintf(stderr, "Error: unknown --include %q (use %s, or %s).\n", inc, strings.Join(includeFields, ", "), includeAll)
+			return 1
+		}
+	}
+	if opts.perCategory < 0 {
+		fmt.Fprintf(stderr, "Error: --per-category must not be negative.\n")
+		return 1
+	}
+	if opts.minScore < 0 {
+		fmt.Fprintf(stderr, "Error: --min-score must not be negative.\n")
+		return 1
+	}
+	if opts.cache && opts.cacheTTL <= 0 {
+		fmt.Fprintf(stderr, "Error: --cache-ttl must be positive.\n")
+		return 1
+	}
+	if opts.concurrency < 1 || opts.concurrency > searchdocs.MaxConcurrency {
+		fmt.Fprintf(stderr, "Error: --concurrency must be between 1 and %d.\n", searchdocs.MaxConcurrency)
+		return 1
+	}
+	if opts.retry < 0 {
+		fmt.Fprintf(stderr, "Error: --retry must not be negative.\n")
+		return 1
+	}
+	if opts.timeout < 0 {
+		fmt.Fprintf(stderr, "Error: --timeout must not be negative.\n")
+		return 1
+	}
+	base, err := searchdocs.NewHTTPClientWithProxy(opts.proxy, opts.timeout)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: invalid --proxy: %v\n", err)
+		return 1
+	}
+	if httpClient != nil {
+		base.Transport = httpClient.Transport
+	}
+
+	// --parallel workers warn and report retries on stderr at the same time
+	if batch && opts.parallel > 1 {
+		stderr = &lockedWriter{w: stderr}
+	}
+
+	// Every request from here on shares one concurrency limit, however many features fan out.
+	// Retries wait inside the limit, so a rate limited API isn't sent more requests meanwhile.
+	client := limitedClient(retryingClient(stderr, base, opts.retry), opts.concurrency)
+
+	// Unsupported enterprise server versions fall back to the latest release unless archived
+	// docs were requested
+	server, isServer := strings.CutPrefix(opts.version, "enterprise-server@")
+	archived := isServer && searchdocs.IsArchivedVersion(server)
+	if opts.archived {
+		// Archived docs have no search API, so there is no raw response and no per-hit fields
+		// (intros, headings, toplevel) for client-side filters to work with
+		if opts.format == "raw" {
+			fmt.Fprintf(stderr, "Error: --format raw can't be used with --archived; archived docs have no search API response.\n")
+			return 1
+		}
+		if filters := clientFilters(opts, query); len(filters) > 0 {
+			fmt.Fprintf(stderr, "Error: %s can't be used with --archived; archived results only have titles and URLs.\n", filters[0].flag)
+			return 1
+		}
+		if !archived {
+			fmt.Fprintf(stderr, "Error: --archived requires an enterprise-server version older than the supported versions (%s).\n", strings.Join(searchdocs.SupportedServerVersions(), ", "))
+			return 1
+		}
+		return searchArchived(stdout, stderr, client, opts, query, server, rec)
+	}
+
+	if names := splitList(opts.scopes); len(names) > 0 {
+		scopes, err := searchdocs.LoadScopes()
+		if err != nil {
+			fmt.Fprintf(stderr, "Error loading scopes: %v\n", err)
+			return 1
+		}
+		toplevel, err := searchdocs.ExpandScopes(scopes, names)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		opts.toplevel = StringSlice(splitList(append(opts.toplevel, toplevel...)))
 	}
 
-	version := searchdocs.NormalizeVersion(*versionFlag)
+	version := searchdocs.NormalizeVersion(opts.version)
+	if isServer && version != opts.version {
+		fmt.Fprintf(stderr, "Warning: %s is not supported; searching %s instead.", opts.version, version)
+		if archived {
+			fmt.Fprintf(stderr, " Use --archived to search the archived docs for %s.", server)
+		}
+		fmt.Fprintln(stderr)
+	}
+
+	if opts.share || opts.copy || opts.web {
+		return shareSearch(stdout, stderr, opts, query, version)
+	}
+	if opts.interactive {
+		return runInteractive(stderr, client, opts, query, version)
+	}
+	if opts.watch > 0 {
+		return runWatch(stdout, stderr, client, opts, query, version)
+	}
+	if batch {
+		return runBatch(stdout, stderr, client, opts, queries, version)
+	}
 
 	//----------------------------------------------------------------------
 	// Build URL with query parameters
 	//----------------------------------------------------------------------
-	searchURL, err := url.Parse(endpoint)
+	searchURL, err := url.Parse(opts.endpoint)
 	if err != nil {
-		searchdocs.Fatal(err)
+		fmt.Fprintln(stderr, "error:", err)
+		return 1
 	}
+	params := buildParams(opts, query, version)
+	searchURL.RawQuery = params.Encode()
+	rec.Params = params
 
-	params := url.Values{}
-	params.Set("query", query)
-	params.Set("size", strconv.Itoa(*sizeFlag))
-	params.Set("version", version)
-	params.Set("language", *languageFlag)
-	params.Set("client_name", "gh-search-docs")
-
-	if *pageFlag > 0 {
-		params.Set("page", strconv.Itoa(*pageFlag))
+	if opts.explain {
+		explainRequest(stderr, opts, input, query, version, searchURL)
 	}
-	if *sortFlag != "" {
-		params.Set("sort", *sortFlag)
+
+	//----------------------------------------------------------------------
+	// HTTP Request
+	//----------------------------------------------------------------------
+	cache, err := searchCache(opts)
+	if err != nil {
+		fmt.Fprintln(stderr, "error:", err)
+		return 1
 	}
-	if len(highlights) > 0 {
-		for _, h := range highlights {
-			params.Add("highlights", h)
+	var result SearchResult
+	if opts.all {
+		all, err := fetchAllPages(stderr, client, opts, searchURL)
+		if err != nil {
+			printRequestError(stderr, opts, "Error fetching results", err)
+			return 1
+		}
+		// Every fetched hit is shown, however many pages that took
+		result = *all
+		opts.size = len(result.Hits)
+	} else if cached, ok := cachedResult(stderr, opts, cache, searchURL.String()); ok {
+		result = *cached
+	} else {
+		body, status, err := fetchResponse(client, searchURL.String())
+		if err != nil {
+			printRequestError(stderr, opts, "Error making request", err)
+			return 1
+		}
+
+		if opts.format == "raw" {
+			// Raw output is the response body byte-for-byte, including error bodies
+			if _, err := stdout.Write(body); err != nil {
+				fmt.Fprintln(stderr, "error:", err)
+				return 1
+			}
 		}
+
+		if status != http.StatusOK {
+			fmt.Fprintf(stderr, "API returned status %d\n", status)
+			if status == 429 {
+				fmt.Fprintf(stderr, "Rate limited. Please try again later.\n")
+			}
+			return 1
+		}
+
+		if opts.debug {
+			fmt.Fprintf(stderr, "Raw response:\n%s\n", body)
+		}
+
+		if opts.format == "raw" {
+			return 0
+		}
+
+		//----------------------------------------------------------------------
+		// Parse Response
+		//----------------------------------------------------------------------
+		if err := json.Unmarshal(body, &result); err != nil {
+			fmt.Fprintf(stderr, "Error parsing response: %v\n", err)
+			if opts.debug {
+				fmt.Fprintf(stderr, "Response body: %s\n", body)
+			}
+			return 1
+		}
+		cacheResult(stderr, cache, searchURL.String(), &result)
 	}
-	if *includeMatchedContentFlag {
-		// Auto-add content_explicit highlights for matched content
-		params.Add("highlights", "content_explicit")
+	rec.Meta = result.Meta
+
+	search := processSearch(stderr, client, opts, query, version, result)
+	search.record(stderr, opts, query, version)
+	rec.recordHits(search.result.Hits)
+
+	//----------------------------------------------------------------------
+	// Output Results
+	//----------------------------------------------------------------------
+	return writeSearch(stdout, stderr, opts, query, search)
+}
+
+// outputResults writes the results, to --output as well when it's set, then bookmarks or
+// opens a result if asked and returns the exit code. notes are remarks about the results
+// beyond the hits hidden by client-side filters.
+func outputResults(stdout, stderr io.Writer, opts *options, query string, result SearchResult, suppressed []filterCount, notes []string) int {
+	if opts.count {
+		// Like grep -c, no matches is a failure so --count works in shell conditionals
+		fmt.Fprintln(stdout, searchdocs.FormatFoundCount(result.Meta.Found.Value, result.Meta.Found.Relation))
+		if result.Meta.Found.Value == 0 {
+			return 1
+		}
+		return 0
 	}
-	// Auto-include intro for descriptions unless user specified includes
-	if len(includes) == 0 {
-		if *includeMatchedContentFlag {
-			// For matched content, we need at least one include field for API compatibility
-			params.Add("include", "toplevel")
+	display := opts
+	if opts.output != "" {
+		// Pretty and plain output is for terminals, so files get the Markdown report instead
+		export := *opts
+		if !opts.refs && !isResultsOnlyFormat(opts.format) {
+			export.format = "markdown"
+			export.report = true
+		}
+		if opts.output == "-" {
+			display = &export
 		} else {
-			// Default behavior - include intro
-			params.Add("include", "intro")
+			if err := exportResults(opts.output, &export, query, result, suppressed, notes); err != nil {
+				fmt.Fprintf(stderr, "Error writing %s: %v\n", opts.output, err)
+				return 1
+			}
+			// The terminal still gets the regular display
+			shown := *opts
+			if isResultsOnlyFormat(opts.format) {
+				shown.format = "pretty"
+			}
+			display = &shown
 		}
-	} else {
-		for _, inc := range includes {
-			params.Add("include", inc)
+	}
+
+	// Output for people reading a terminal is collected first to see whether it needs a
+	// pager. Formats meant for other programs are never paged.
+	out := stdout
+	var paged bytes.Buffer
+	page := !display.noPager && stdoutIsTerminal() && !isResultsOnlyFormat(display.format) && display.hitTemplate == nil
+	if page {
+		out = &paged
+	}
+	if err := writeResults(out, stderr, display, query, result, suppressed, notes); err != nil {
+		fmt.Fprintln(stderr, "error:", err)
+		return 1
+	}
+	if page {
+		writePaged(stdout, stderr, paged.String())
+	}
+
+	if len(result.Hits) == 0 {
+		return emptyExitCode(opts)
+	}
+	if opts.bookmark {
+		if code := bookmarkResult(stderr, opts, query, result.Hits); code != 0 {
+			return code
 		}
 	}
-	if len(toplevel) > 0 {
-		for _, tl := range toplevel {
-			params.Add("toplevel", tl)
+	if opts.open {
+		return openResult(stderr, opts, result.Hits)
+	}
+	return 0
+}
+
+// exportResults writes the results for --output to a file, replacing it if it exists. Notes
+// aren't repeated; the display on stdout shows them.
+func exportResults(path string, opts *options, query string, result SearchResult, suppressed []filterCount, notes []string) error {
+	// #nosec G304 -- the path is chosen by the user
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := writeResults(f, io.Discard, opts, query, result, suppressed, notes); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// defaultIntroLength is how many characters of an intro pretty and plain output show
+const defaultIntroLength = 150
+
+// scaledIntroLength returns the default intro length for a terminal width: enough to fill
+// two lines below a result, and about defaultIntroLength in an 80 column terminal
+func scaledIntroLength(width int) int {
+	return max(2*(width-3), 40)
+}
+
+// introLimit returns the number of characters intros are cut to, or 0 to show them in full
+func introLimit(opts *options) int {
+	if opts.long {
+		return 0
+	}
+	return opts.truncate
+}
+
+// applyConfig fills in flags that weren't given on the command line from the config file:
+// --config, then GH_SEARCH_DOCS_CONFIG, then config.yaml in the config directory. Only an
+// explicitly chosen file has to exist.
+func applyConfig(fs *flag.FlagSet, opts *options) error {
+	path := opts.configPath
+	explicit := path != "" || os.Getenv("GH_SEARCH_DOCS_CONFIG") != ""
+	if path == "" {
+		var err error
+		if path, err = searchdocs.ConfigPath(); err != nil {
+			return nil
 		}
 	}
-	if len(aggregate) > 0 {
-		for _, agg := range aggregate {
-			params.Add("aggregate", agg)
+
+	cfg, err := searchdocs.LoadConfig(path)
+	if errors.Is(err, os.ErrNotExist) && !explicit {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	searchdocs.MergeConfigIntoFlags(cfg, fs)
+	return nil
+}
+
+// isFlagSet reports whether the named flag was given explicitly on the command line
+func isFlagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
 		}
+	})
+	return set
+}
+
+// emptyExitCode is the exit code used when a search shows no results
+func emptyExitCode(opts *options) int {
+	if opts.failOnEmpty {
+		return 1
 	}
+	return 0
+}
 
-	searchURL.RawQuery = params.Encode()
+// beyondLastPage reports whether an empty result is only empty because the requested page is
+// past the last page of results, and returns that last page
+func beyondLastPage(result *SearchResult, page, size int) (int, bool) {
+	if len(result.Hits) > 0 || result.Meta.Found.Value == 0 {
+		return 0, false
+	}
+	lastPage := searchdocs.TotalPages(result.Meta.Found.Value, size)
+	return lastPage, page > lastPage
+}
 
-	//----------------------------------------------------------------------
-	// HTTP Request
-	//----------------------------------------------------------------------
-	req, err := http.NewRequest(http.MethodGet, searchURL.String(), nil)
+// listSupportedVersions prints the supported enterprise server versions
+func listSupportedVersions(stdout, stderr io.Writer) int {
+	versions, err := searchdocs.LoadSupportedVersions()
 	if err != nil {
-		searchdocs.Fatal(err)
+		fmt.Fprintf(stderr, "Error loading supported versions: %v\n", err)
+		fmt.Fprintf(stderr, "Fallback supported versions: 3.11, 3.12, 3.13, 3.14, 3.15, 3.16, 3.17\n")
+		return 1
 	}
-	req.Header.Set("Accept", "application/json")
 
-	resp, err := http.DefaultClient.Do(req)
+	fmt.Fprintln(stdout, "Supported GitHub Enterprise Server versions:")
+	for _, version := range versions.SupportedVersions {
+		if version == versions.LatestVersion {
+			fmt.Fprintf(stdout, "  %s (latest)\n", version)
+		} else {
+			fmt.Fprintf(stdout, "  %s\n", version)
+		}
+	}
+	fmt.Fprintf(stdout, "\nLast updated: %s\n", versions.LastUpdated)
+	fmt.Fprintln(stdout, "\nUsage: gh search-docs --version enterprise-server@<version> <query>")
+	return 0
+}
+
+// prepareQuery normalizes a query unless --no-normalize is set, and trims one over the
+// length limit at a word boundary when --truncate-query allows it
+func prepareQuery(stderr io.Writer, opts *options, query string) (string, error) {
+	if !opts.noNormalize {
+		query = searchdocs.NormalizeQuery(query)
+	}
+	if length := searchdocs.EncodedQueryLength(query); length > searchdocs.MaxQueryLength {
+		if !opts.truncateQuery {
+			return "", fmt.Errorf("query is %d bytes once URL-encoded; the limit is %d. Shorten it or use --truncate-query", length, searchdocs.MaxQueryLength)
+		}
+		query = searchdocs.TruncateQuery(query, searchdocs.MaxQueryLength)
+		fmt.Fprintf(stderr, "Note: query truncated to %d characters: %q\n", len([]rune(query)), query)
+	}
+	return query, nil
+}
+
+// readQueriesFile reads the queries of a --queries-file
+func readQueriesFile(path string) ([]string, error) {
+	// #nosec G304 -- the path is the user's own queries file
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return searchdocs.ReadQueries(f)
+}
+
+// updateVersions downloads the supported enterprise server versions for --update-versions,
+// prints which versions were added and removed, and writes them to the versions file when
+// they changed or --yes was given
+func updateVersions(stdout, stderr io.Writer, opts *options) int {
+	client, err := searchdocs.NewHTTPClientWithProxy(opts.proxy, opts.timeout)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: invalid --proxy: %v\n", err)
+		return 1
+	}
+	if httpClient != nil {
+		client.Transport = httpClient.Transport
+	}
+
+	next, err := searchdocs.FetchLatestVersions(opts.versionsURL, client)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error making request: %v\n", err)
-		os.Exit(1)
+		printRequestError(stderr, opts, "Error fetching supported versions", err)
+		return 1
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		fmt.Fprintf(os.Stderr, "API returned status %d\n", resp.StatusCode)
-		if resp.StatusCode == 429 {
-			fmt.Fprintf(os.Stderr, "Rate limited. Please try again later.\n")
+	// A missing or unreadable file is replaced; every fetched version counts as added
+	path := searchdocs.VersionsPath()
+	prev, _ := searchdocs.LoadSupportedVersionsFromPath(path)
+	added, removed := searchdocs.DiffVersions(prev, next)
+	for _, version := range added {
+		fmt.Fprintf(stdout, "+ %s\n", version)
+	}
+	for _, version := range removed {
+		fmt.Fprintf(stdout, "- %s\n", version)
+	}
+	changed := len(added) > 0 || len(removed) > 0 || prev == nil || prev.LatestVersion != next.LatestVersion
+	if prev != nil && prev.LatestVersion != next.LatestVersion {
+		fmt.Fprintf(stdout, "Latest: %s → %s\n", prev.LatestVersion, next.LatestVersion)
+	}
+	if !changed && !opts.yes {
+		fmt.Fprintf(stdout, "Supported versions are up to date in %s (pass --yes to rewrite it anyway).\n", path)
+		return 0
+	}
+
+	next.LastUpdated = time.Now().UTC().Format(time.RFC3339)
+	if err := searchdocs.WriteSupportedVersions(path, next); err != nil {
+		fmt.Fprintf(stderr, "Error writing %s: %v\n", path, err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "Updated %s (latest: %s).\n", path, next.LatestVersion)
+	return 0
+}
+
+// listScopes prints each --scope preset and the toplevel filters it expands to
+func listScopes(stdout, stderr io.Writer) int {
+	scopes, err := searchdocs.LoadScopes()
+	if err != nil {
+		fmt.Fprintf(stderr, "Error loading scopes: %v\n", err)
+		return 1
+	}
+
+	fmt.Fprintln(stdout, "Scopes:")
+	for _, name := range searchdocs.ScopeNames(scopes) {
+		scope := scopes[name]
+		fmt.Fprintf(stdout, "  %s: %s\n", name, strings.Join(scope.Toplevel, ", "))
+		if scope.Description != "" {
+			fmt.Fprintf(stdout, "      %s\n", scope.Description)
 		}
-		os.Exit(1)
 	}
+	if path, err := searchdocs.ScopesPath(); err == nil {
+		fmt.Fprintf(stdout, "\nAdd or override scopes in %s\n", path)
+	}
+	fmt.Fprintln(stdout, "\nUsage: gh search-docs --scope <name> <query>")
+	return 0
+}
 
-	//----------------------------------------------------------------------
-	// Parse Response
-	//----------------------------------------------------------------------
-	body, err := io.ReadAll(resp.Body)
+// openURL opens a URL in the default browser. Tests swap it out to avoid launching one.
+var openURL = searchdocs.OpenURL
+
+// openResult opens the hit picked with --open or --open-n in the default browser
+func openResult(stderr io.Writer, opts *options, hits []SearchItem) int {
+	n := max(opts.openN, 1)
+	if n > len(hits) {
+		fmt.Fprintf(stderr, "Error: can't open result %d; only %d shown.\n", n, len(hits))
+		return 1
+	}
+	item := hits[n-1]
+	if err := openURL(searchdocs.HitURL(item)); err != nil {
+		fmt.Fprintf(stderr, "Error opening result: %v\n", err)
+		return 1
+	}
+	recordOpen(stderr, item.URL)
+	return 0
+}
+
+// clearCache deletes every cached search response and reports how many were removed
+func clearCache(stdout, stderr io.Writer) int {
+	cache, err := searchdocs.DefaultFileCache(searchdocs.DefaultCacheTTL)
+	if err != nil {
+		fmt.Fprintln(stderr, "error:", err)
+		return 1
+	}
+	deleted, err := cache.Clear()
 	if err != nil {
-		searchdocs.Fatal(err)
+		fmt.Fprintf(stderr, "Error clearing cache: %v\n", err)
+		return 1
 	}
+	fmt.Fprintf(stdout, "Deleted %d cached responses from %s\n", deleted, cache.Dir)
+	return 0
+}
 
-	if *debugFlag {
-		fmt.Fprintf(os.Stderr, "Raw response:\n%s\n", body)
+// shareSearch prints the docs.github.com search page URL for the query, optionally copying it
+// to the clipboard or opening it in the browser
+func shareSearch(stdout, stderr io.Writer, opts *options, query, version string) int {
+	shareURL := searchdocs.SearchPageURL(query, version, opts.language)
+	fmt.Fprintln(stdout, shareURL)
+
+	if opts.copy {
+		if err := searchdocs.CopyToClipboard(shareURL); err != nil {
+			fmt.Fprintf(stderr, "Error copying to clipboard: %v\n", err)
+			return 1
+		}
+		fmt.Fprintln(stderr, "Copied search URL to clipboard.")
 	}
+	if opts.web {
+		if err := openURL(shareURL); err != nil {
+			fmt.Fprintf(stderr, "Error opening browser: %v\n", err)
+			return 1
+		}
+	}
+	return 0
+}
 
-	var result SearchResult
-	if err := json.Unmarshal(body, &result); err != nil {
-		fmt.Fprintf(os.Stderr, "Error parsing response: %v\n", err)
-		if *debugFlag {
-			fmt.Fprintf(os.Stderr, "Response body: %s\n", body)
+// includeFields are the values --include accepts, besides includeAll
+var includeFields = []string{"intro", "headings", "toplevel"}
+
+// includeAll is the --include value that stands for every value in includeFields
+const includeAll = "all"
+
+// expandIncludes splits and deduplicates --include values, replacing all with every field
+func expandIncludes(values []string) []string {
+	var includes []string
+	for _, inc := range splitList(values) {
+		if inc == includeAll {
+			includes = append(includes, includeFields...)
+		} else {
+			includes = append(includes, inc)
 		}
-		os.Exit(1)
 	}
+	return splitList(includes)
+}
 
-	//----------------------------------------------------------------------
-	// Output Results
-	//----------------------------------------------------------------------
-	if *formatFlag == "json" {
-		output, err := json.MarshalIndent(result, "", "  ")
-		if err != nil {
-			searchdocs.Fatal(err)
+// buildParams builds the search API query parameters from the parsed options
+func buildParams(opts *options, query, version string) url.Values {
+	params := url.Values{}
+	params.Set("query", query)
+	size := opts.size
+	if opts.all || overFetching(opts, query) {
+		// Over-fetch so client-side filters don't starve the displayed results, and page
+		// through --all in as few requests as possible
+		size = maxAPISize
+	}
+	if opts.count {
+		// Only meta.found is printed, so one hit is all that's worth downloading
+		size = 1
+	}
+	params.Set("size", strconv.Itoa(size))
+	params.Set("version", version)
+	params.Set("language", opts.language)
+	params.Set("client_name", "gh-search-docs")
+
+	if opts.page > 0 {
+		params.Set("page", strconv.Itoa(opts.page))
+	}
+	if opts.sort != "" {
+		params.Set("sort", opts.sort)
+	}
+	for _, h := range opts.highlights {
+		params.Add("highlights", h)
+	}
+	if opts.includeMatchedContent {
+		// Auto-add content_explicit highlights for matched content
+		params.Add("highlights", "content_explicit")
+	}
+	// Auto-include intro for descriptions unless user specified includes
+	if len(opts.includes) == 0 {
+		if opts.includeMatchedContent {
+			// For matched content, we need at least one include field for API compatibility
+			params.Add("include", "toplevel")
+		} else {
+			// Default behavior - include intro
+			params.Add("include", "intro")
 		}
-		fmt.Println(string(output))
-		return
+	} else {
+		for _, inc := range expandIncludes(opts.includes) {
+			params.Add("include", inc)
+		}
+	}
+	if opts.perCategory > 0 && !slices.Contains(params["include"], "toplevel") {
+		// Diversity capping needs to know each hit's category
+		params.Add("include", "toplevel")
+	}
+	if (len(opts.headings) > 0 || opts.anchors) && !slices.Contains(params["include"], "headings") {
+		// Heading filters and --anchors match against each hit's headings
+		params.Add("include", "headings")
+	}
+	wanted := splitList(opts.columns)
+	if opts.long {
+		wanted = includeFields
+	}
+	for _, field := range wanted {
+		// Chosen columns and --long need their fields in the response
+		if slices.Contains(includeFields, field) && !slices.Contains(params["include"], field) {
+			params.Add("include", field)
+		}
+	}
+	included, _ := toplevelFilters(opts)
+	for _, tl := range included {
+		params.Add("toplevel", tl)
+	}
+	for _, agg := range opts.aggregate {
+		params.Add("aggregate", agg)
 	}
 
+	return params
+}
+
+// marshalJSON encodes v for --format json, indented unless --compact was given
+func marshalJSON(opts *options, v any) ([]byte, error) {
+	if opts.compact {
+		return json.Marshal(v)
+	}
+	return json.MarshalIndent(v, "", "  ")
+}
+
+// shownURL returns the URL of a hit as output prints or links it: its path with
+// --relative-urls, otherwise the full URL. Terminal hyperlinks and --open always use searchdocs.HitURL.
+func shownURL(opts *options, item SearchItem) string {
+	if opts.relativeURLs {
+		return searchdocs.HitPath(item)
+	}
+	return searchdocs.HitURL(item)
+}
+
+// printResults writes the human readable (pretty or plain) listing of a search result
+func printResults(w, stderr io.Writer, opts *options, query string, result *SearchResult, suppressed []filterCount) {
 	if result.Meta.Found.Value == 0 {
-		fmt.Printf("No results found for query: %s\n", query)
+		fmt.Fprintf(w, "No results found for query: %s\n", query)
 		return
 	}
 
-	fmt.Printf("Found %d results", result.Meta.Found.Value)
+	fmt.Fprintf(w, "Found %s results", searchdocs.FormatFoundCount(result.Meta.Found.Value, result.Meta.Found.Relation))
 	if result.Meta.Page > 1 {
-		fmt.Printf(" (page %d)", result.Meta.Page)
+		fmt.Fprintf(w, " (page %d)", result.Meta.Page)
+	}
+	fmt.Fprintln(w)
+
+	if len(result.Hits) == 0 && len(suppressed) > 0 {
+		fmt.Fprintln(w, "No results matched the client-side filters.")
 	}
-	fmt.Println()
 
 	// Determine how many results to show and what level of detail
 	maxResults := len(result.Hits)
 	// Always respect user-specified size, but limit to 5 by default when no special flags
-	if *sizeFlag == 5 && maxResults > 5 && !*includeMatchedContentFlag {
+	if opts.size == 5 && maxResults > 5 && !opts.includeMatchedContent {
 		maxResults = 5
-	} else if *sizeFlag < maxResults {
-		maxResults = *sizeFlag
+	} else if opts.size < maxResults {
+		maxResults = opts.size
 	}
 
 	// Check if we should use pretty rendering or plain text
 	// Pretty is now the default unless explicitly disabled
-	usePrettyRendering := !*plainFlag && *formatFlag != "plain"
+	usePrettyRendering := !opts.plain && opts.format != "plain"
 
-	var renderer *glamour.TermRenderer
-	if usePrettyRendering {
-		// Create renderer for pretty output without word wrapping
-		renderer = searchdocs.NewAutoRendererNoWrap()
-		if renderer == nil {
-			theme := "dark"
-			if searchdocs.IsLight() {
-				theme = "light"
-			}
-			renderer = searchdocs.NewRendererNoWrap(theme)
-		}
+	// Narrow terminals get a compact plain layout that keeps URLs on their own line, and very
+	// wide terminals get two columns of cards
+	width, fit := outputWidth(opts)
+	layout := resolveLayout(opts.layout, width, opts.columnsWidth, fit)
+	if len(opts.columns) > 0 || opts.long || opts.showContent {
+		// --columns picks the fields itself, and --long and --show-content show them all
+		layout = layoutFull
+	}
+	compact := layout == layoutCompact
+	if compact {
+		usePrettyRendering = false
 	}
 
-	for i := 0; i < maxResults; i++ {
-		item := result.Hits[i]
-
+	if layout == layoutOneline {
+		if !fit {
+			// Only terminals (or a --width) need titles cut to fit
+			width = 0
+		}
+		for i, item := range result.Hits[:maxResults] {
+			printOnelineHit(w, opts, i+1, item, width, usePrettyRendering)
+		}
+		fmt.Fprintln(w)
+	} else if layout == layoutColumns {
+		printColumns(w, opts, result.Hits[:maxResults], width)
+	} else {
+		// URLs are written on their own line and never wrapped, so they stay clickable. Titles
+		// are links too where the terminal supports them.
+		wrap := prettyWrapWidth(opts, width, fit)
+		links := useHyperlinks(opts)
+		var renderer markdownRenderer
 		if usePrettyRendering {
-			// Pretty rendering with markdown
-			var md strings.Builder
-			md.WriteString(fmt.Sprintf("%d. %s\n", i+1, item.Title))
-			md.WriteString(fmt.Sprintf("   %s\n", "https://docs.github.com"+item.URL))
-
-			// Show summary by default unless matched content is requested
-			if !*includeMatchedContentFlag {
-				if item.Intro != "" {
-					description := item.Intro
-					if len(description) > 150 {
-						description = description[:150] + "..."
-					}
-					md.WriteString(fmt.Sprintf("   %s\n", description))
+			renderer = newMarkdownRenderer()
+			if opts.theme != themeAuto {
+				renderer = searchdocs.NewRendererNoWrap(opts.theme)
+			}
+			if opts.styleFile != "" {
+				// The file was checked up front, but it can still change or vanish since
+				if custom, err := searchdocs.NewRendererFromFile(opts.styleFile, 0); err != nil {
+					fmt.Fprintf(stderr, "Warning: can't use style file %s: %v\n", opts.styleFile, err)
+				} else {
+					renderer = custom
 				}
 			}
+			if opts.noColor {
+				renderer = searchdocs.NewRendererNoWrap("notty")
+			}
+		}
 
-			// Show matched content if flag is set
-			if *includeMatchedContentFlag && item.Highlights != nil {
-				if contentExplicit, exists := item.Highlights["content_explicit"]; exists {
-					switch v := contentExplicit.(type) {
-					case []interface{}:
-						for _, highlight := range v {
-							if str, ok := highlight.(string); ok {
-								md.WriteString(fmt.Sprintf("   • %s\n", str))
-							}
-						}
-					case string:
-						md.WriteString(fmt.Sprintf("   • %s\n", v))
-					}
-				}
+		for i := 0; i < maxResults; i++ {
+			item := result.Hits[i]
+
+			if compact {
+				printCompactHit(w, opts, i+1, item, width)
+				continue
 			}
 
-			md.WriteString("\n")
+			if usePrettyRendering {
+				// Pretty rendering with markdown
+				var md strings.Builder
+				title := markedTitle(item, marksToPlaceholders)
+				if links {
+					title = linkOpen + title + linkClose
+				}
+				md.WriteString(fmt.Sprintf("%d. %s\n", i+1, title))
+				md.WriteString(fmt.Sprintf("   %s\n", shownURL(opts, item)))
 
-			// Render the markdown
-			if renderer != nil {
-				output, err := renderer.Render(md.String())
-				if err == nil {
-					fmt.Print(output)
-					continue
+				// Show summary by default unless matched content is requested
+				if !opts.includeMatchedContent {
+					if item.Intro != "" {
+						md.WriteString(prettyIntro(opts, item.Intro, wrap) + "\n")
+					}
+				}
+				if opts.long {
+					for _, line := range longDetails(opts, item) {
+						md.WriteString(prettyLine(line, wrap) + "\n")
+					}
 				}
-			}
 
-			// Fallback to plain text if rendering fails
-			fmt.Print(md.String())
-		} else {
-			// Plain text output - URLs will never be wrapped
-			fmt.Printf("%d. %s\n", i+1, item.Title)
-			fmt.Printf("   %s\n", "https://docs.github.com"+item.URL)
-
-			// Show summary by default unless matched content is requested
-			if !*includeMatchedContentFlag {
-				if item.Intro != "" {
-					description := item.Intro
-					if len(description) > 150 {
-						description = description[:150] + "..."
+				// Show matched content if flag is set
+				if opts.includeMatchedContent {
+					snippets, more := shownSnippets(opts, item)
+					for _, highlight := range snippets {
+						md.WriteString(prettyLine("• "+marksToPlaceholders(highlight), wrap) + "\n")
+					}
+					if line := moreMatchesLine(more); line != "" {
+						md.WriteString(prettyLine(line, wrap) + "\n")
 					}
-					fmt.Printf("   %s\n", description)
 				}
-			}
+				if line := termLine(opts, item, marksToPlaceholders); line != "" {
+					md.WriteString(prettyLine(line, wrap) + "\n")
+				}
+
+				for _, line := range headingLines(opts, item) {
+					md.WriteString(prettyLine(line, wrap) + "\n")
+				}
+				if line := translationLine(splitList(opts.translations), item); line != "" {
+					md.WriteString(fmt.Sprintf("   %s\n", line))
+				}
+				if badges := availabilityBadges(item); badges != "" {
+					md.WriteString(fmt.Sprintf("   %s\n", badges))
+				}
+
+				md.WriteString("\n")
 
-			// Show matched content if flag is set
-			if *includeMatchedContentFlag && item.Highlights != nil {
-				if contentExplicit, exists := item.Highlights["content_explicit"]; exists {
-					switch v := contentExplicit.(type) {
-					case []interface{}:
-						for _, highlight := range v {
-							if str, ok := highlight.(string); ok {
-								// Remove HTML tags for plain text output
-								cleanStr := strings.ReplaceAll(str, "<mark>", "")
-								cleanStr = strings.ReplaceAll(cleanStr, "</mark>", "")
-								fmt.Printf("   • %s\n", cleanStr)
-							}
+				// Render the markdown
+				if renderer != nil {
+					output, err := renderMarkdown(renderer, md.String())
+					if err == nil {
+						output = linkTitle(styleMarks(output, prettyMark(opts)), searchdocs.HitURL(item))
+						// Scores are added after rendering so they can be dimmed
+						if score := scoreSuffix(opts, item); score != "" {
+							output = appendToFirstLine(output, " "+urlStyle.Render(strings.TrimPrefix(score, " ")))
+						}
+						// Breadcrumbs are added after rendering so their hyperlinks survive
+						if crumbs := breadcrumbLine(opts, item); crumbs != "" {
+							output = insertAfterURL(output, shownURL(opts, item), "  "+urlStyle.Render(crumbs))
+						}
+						fmt.Fprint(w, output)
+						if opts.showContent {
+							printRenderedContent(w, renderer, item)
 						}
-					case string:
-						// Remove HTML tags for plain text output
-						cleanStr := strings.ReplaceAll(v, "<mark>", "")
-						cleanStr = strings.ReplaceAll(cleanStr, "</mark>", "")
-						fmt.Printf("   • %s\n", cleanStr)
+						continue
+					}
+					if opts.debug {
+						fmt.Fprintf(stderr, "Rendering result %d failed, using plain output: %v\n", i+1, err)
 					}
 				}
 			}
 
-			fmt.Println()
+			// Plain text output, also the fallback if rendering fails - URLs will never be wrapped
+			printPlainHit(w, opts, i+1, item)
 		}
 	}
 
+	printSuppressed(w, maxResults, suppressed)
+	printTranslationCoverage(w, splitList(opts.translations), result.Hits[:maxResults])
+
 	// Show info about remaining results if there are more than shown
-	if maxResults == 5 && result.Meta.Found.Value > 5 && !*includeMatchedContentFlag {
+	if maxResults == 5 && result.Meta.Found.Value > 5 && !opts.includeMatchedContent && !opts.all {
 		if result.Meta.Found.Value <= 50 {
-			fmt.Printf("Showing top 5 results. Use --size %d to see all %d results.\n", result.Meta.Found.Value, result.Meta.Found.Value)
+			fmt.Fprintf(w, "Showing top 5 results. Use --size %d to see all %d results.\n", result.Meta.Found.Value, result.Meta.Found.Value)
 		} else {
-			fmt.Printf("Showing top 5 results. Use --size 50 to see the maximum 50 results per page.\n")
-			fmt.Printf("Use --page to navigate through all %d results.\n", result.Meta.Found.Value)
+			fmt.Fprintf(w, "Showing top 5 results. Use --size 50 to see the maximum 50 results per page.\n")
+			fmt.Fprintf(w, "Use --page to navigate through all %d results.\n", result.Meta.Found.Value)
 		}
-		fmt.Printf("Use --include-matched-content for highlighted matches instead of descriptions.\n\n")
+		fmt.Fprintf(w, "Use --include-matched-content for highlighted matches instead of descriptions.\n\n")
 	}
 
-	// Show pagination info
-	totalPages := (result.Meta.Found.Value + result.Meta.Size - 1) / result.Meta.Size
-	if totalPages > 1 {
-		fmt.Printf("\nShowing page %d of %d (%d total results)\n",
+	// Show pagination info in units of --size, which differs from the API's page size when
+	// client-side filters over-fetch. --all already shows every page.
+	pages := searchdocs.TotalPages(result.Meta.Found.Value, opts.size)
+	if pages > 1 && !opts.all {
+		fmt.Fprintf(w, "\nShowing page %d of %d (%d total results)\n",
 			result.Meta.Page,
-			totalPages,
+			pages,
 			result.Meta.Found.Value)
 
-		if result.Meta.Page < totalPages {
-			fmt.Printf("Use --page %d to see the next page\n", result.Meta.Page+1)
+		if result.Meta.Page < pages {
+			fmt.Fprintf(w, "Use --page %d to see the next page\n", result.Meta.Page+1)
+		}
+	}
+
+	if opts.showTiming {
+		fmt.Fprintf(w, "\n%s\n", apiTiming(result))
+	}
+}
+
+// printPlainHit writes a single result as plain text
+func printPlainHit(w io.Writer, opts *options, n int, item SearchItem) {
+	if len(opts.columns) > 0 {
+		printColumnsHit(w, opts, n, item)
+		return
+	}
+	fmt.Fprintf(w, "%d. %s%s\n", n, markedTitle(item, plainMarks(opts)), scoreSuffix(opts, item))
+	fmt.Fprintf(w, "   %s\n", shownURL(opts, item))
+	if crumbs := breadcrumbLine(opts, item); crumbs != "" {
+		fmt.Fprintf(w, "   in: %s\n", crumbs)
+	}
+
+	// Show summary by default unless matched content is requested
+	if !opts.includeMatchedContent {
+		if item.Intro != "" {
+			description := searchdocs.TruncateString(item.Intro, introLimit(opts))
+			fmt.Fprintf(w, "   %s\n", description)
+		}
+	}
+	if opts.long {
+		for _, line := range longDetails(opts, item) {
+			fmt.Fprintf(w, "   %s\n", line)
+		}
+	}
+
+	// Show matched content if flag is set
+	if opts.includeMatchedContent {
+		for _, line := range matchedContent(opts, item) {
+			fmt.Fprintf(w, "   %s\n", line)
 		}
 	}
+	if line := termLine(opts, item, plainMarks(opts)); line != "" {
+		fmt.Fprintf(w, "   %s\n", line)
+	}
+
+	for _, line := range headingLines(opts, item) {
+		fmt.Fprintf(w, "   %s\n", line)
+	}
+	if line := translationLine(splitList(opts.translations), item); line != "" {
+		fmt.Fprintf(w, "   %s\n", line)
+	}
+	if badges := availabilityBadges(item); badges != "" {
+		fmt.Fprintf(w, "   %s\n", badges)
+	}
+	if opts.showContent {
+		printPlainContent(w, item)
+	}
+	fmt.Fprintln(w)
+}
+
+// printColumnsHit writes a single result as plain text with only the --columns fields: the
+// first after the number, the rest indented below it. Empty fields are left out.
+func printColumnsHit(w io.Writer, opts *options, n int, item SearchItem) {
+	prefix := fmt.Sprintf("%d. ", n)
+	for _, column := range splitList(opts.columns) {
+		value := searchdocs.ColumnValue(column, n, item)
+		if column == "intro" {
+			value = searchdocs.TruncateString(value, introLimit(opts))
+		}
+		if value == "" {
+			continue
+		}
+		fmt.Fprintf(w, "%s%s\n", prefix, value)
+		prefix = "   "
+	}
+	fmt.Fprintln(w)
+}
+
+// markdownRenderer renders markdown for pretty output. *glamour.TermRenderer satisfies it.
+type markdownRenderer interface {
+	Render(in string) (string, error)
+}
+
+// newMarkdownRenderer creates the renderer for pretty output, without word wrapping. Output
+// that isn't going to a terminal is only pretty with --color always, so it gets a colored
+// theme rather than the one auto-detection picks for pipes. It is a variable so tests can
+// substitute a renderer.
+var newMarkdownRenderer = func() markdownRenderer {
+	var renderer *glamour.TermRenderer
+	if stdoutIsTerminal() {
+		renderer = searchdocs.NewAutoRendererNoWrap()
+	}
+	if renderer == nil {
+		renderer = searchdocs.NewRendererNoWrap(detectedTheme())
+	}
+	if renderer == nil {
+		return nil
+	}
+	return renderer
+}
+
+// themeAuto is the --theme that picks a dark or light style to suit the terminal
+const themeAuto = "auto"
+
+// themeNames lists every value --theme accepts. Besides auto they're glamour's built-in styles.
+var themeNames = []string{themeAuto, "dark", "light", "dracula", "notty"}
+
+// detectedTheme returns the style --theme auto settles on when the renderer can't detect one
+// itself: light for light terminals, otherwise dark
+func detectedTheme() string {
+	if searchdocs.IsLight() {
+		return "light"
+	}
+	return "dark"
+}
+
+// renderMarkdown renders md, converting a panic inside the renderer into an error. Pathological
+// content (enormous lines, odd control characters) can panic deep inside glamour's word
+// wrapping, and one bad hit shouldn't take down the rest of the output.
+func renderMarkdown(renderer markdownRenderer, md string) (output string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("renderer panicked: %v", r)
+		}
+	}()
+	return renderer.Render(md)
 }
@@ -1,15 +1,89 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
 	"reflect"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/Ebonsignori/gh-search-docs/searchdocs"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"golang.org/x/net/html"
+	"gopkg.in/yaml.v3"
 )
 
+// TestMain points the XDG directories at a scratch directory so searches run by the tests
+// never touch the real history, cache, or configuration
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "gh-search-docs-test")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	for _, name := range []string{"XDG_DATA_HOME", "XDG_CACHE_HOME", "XDG_CONFIG_HOME"} {
+		_ = os.Setenv(name, filepath.Join(dir, strings.ToLower(name)))
+	}
+	// The default --language follows the locale, so the tests run in none
+	for _, name := range []string{"LC_ALL", "LANGUAGE", "LANG"} {
+		_ = os.Unsetenv(name)
+	}
+	_ = os.Unsetenv("GLAMOUR_STYLE")
+	// Output longer than a faked terminal would otherwise go to a real pager
+	_ = os.Setenv("GH_PAGER", "")
+	// Searches without a query must never wait on the real stdin
+	stdin = strings.NewReader("")
+	// Rate limited test servers shouldn't make the tests wait seconds
+	retryBaseDelay = time.Millisecond
+
+	code := m.Run()
+	_ = os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// update rewrites golden files in testdata instead of comparing against them
+var update = flag.Bool("update", false, "update golden files")
+
+// assertGolden compares got with testdata/name, or rewrites the file when -update is set
+func assertGolden(t *testing.T, name, got string) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.MkdirAll("testdata", 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read golden file (run go test -update to create it): %v", err)
+	}
+	if got != string(want) {
+		t.Errorf("Output does not match %s\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
 func TestSearchResultParsing(t *testing.T) {
 	// Test JSON response parsing
 	jsonResponse := `{
@@ -321,15 +395,17 @@ func TestJSONFormat(t *testing.T) {
 	result := SearchResult{
 		Meta: struct {
 			Found struct {
-				Value    int    `json:"value"`
-				Relation string `json:"relation"`
-			} `json:"found"`
+				Value    int    `json:"value" yaml:"value"`
+				Relation string `json:"relation" yaml:"relation"`
+			} `json:"found" yaml:"found"`
 			Took struct {
-				QueryMsec int `json:"query_msec"`
-				TotalMsec int `json:"total_msec"`
-			} `json:"took"`
-			Page int `json:"page"`
-			Size int `json:"size"`
+				QueryMsec int `json:"query_msec" yaml:"query_msec"`
+				TotalMsec int `json:"total_msec" yaml:"total_msec"`
+			} `json:"took" yaml:"took"`
+			Page       int      `json:"page" yaml:"page"`
+			Size       int      `json:"size" yaml:"size"`
+			Suppressed int      `json:"suppressed,omitempty" yaml:"suppressed,omitempty"`
+			Notes      []string `json:"notes,omitempty" yaml:"notes,omitempty"`
 		}{},
 		Hits: []SearchItem{
 			{
@@ -1277,6 +1353,16 @@ func TestReorderArgs(t *testing.T) {
 			input:    []string{"ssh key", "--format=json"},
 			expected: []string{"--format=json", "ssh key"},
 		},
+		{
+			name:     "optional value flag with value",
+			input:    []string{"ssh key", "--match-title", "any"},
+			expected: []string{"--match-title=any", "ssh key"},
+		},
+		{
+			name:     "optional value flag without value",
+			input:    []string{"--match-title", "ssh", "key"},
+			expected: []string{"--match-title", "ssh", "key"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -1296,3 +1382,4677 @@ func TestReorderArgs(t *testing.T) {
 		})
 	}
 }
+
+// rewriteTransport sends every request to a local test server regardless of the requested host
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (rt rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = rt.target.Scheme
+	req.URL.Host = rt.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// serveSearch points httpClient at a test server that answers every request with the given
+// status and body. The returned slice collects the query parameters of each request received.
+func serveSearch(t *testing.T, status int, body string) *[]url.Values {
+	t.Helper()
+
+	var requests []url.Values
+	serveHTTP(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Query())
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, body)
+	}))
+
+	return &requests
+}
+
+// serveHTTP points httpClient at a test server backed by handler
+func serveHTTP(t *testing.T, handler http.Handler) {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	target, _ := url.Parse(server.URL)
+	oldClient := httpClient
+	httpClient = &http.Client{Transport: rewriteTransport{target: target}}
+	t.Cleanup(func() { httpClient = oldClient })
+}
+
+func TestRunRetry(t *testing.T) {
+	var requests int
+	serveHTTP(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch requests {
+		case 1:
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			_, _ = io.WriteString(w, `{"meta": {"found": {"value": 1, "relation": "eq"}}, "hits": [{"title": "Rate limits", "url": "/en/rest/rate-limits"}]}`)
+		}
+	}))
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--format", "titles", "limits"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if stdout.String() != "Rate limits\n" {
+		t.Errorf("Expected the result after retrying, got %q", stdout.String())
+	}
+	if want := "Rate limited, retrying in 1s...\nAPI unavailable, retrying in 1s...\n"; stderr.String() != want {
+		t.Errorf("stderr = %q, want %q", stderr.String(), want)
+	}
+
+	// --retry 0 gives up on the first rate limited response
+	requests = 0
+	stderr.Reset()
+	if code := run([]string{"--retry", "0", "limits"}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1, got %d", code)
+	}
+	if requests != 1 || !strings.Contains(stderr.String(), "Rate limited. Please try again later.") {
+		t.Errorf("Expected one request and the rate limit error, got %d and %q", requests, stderr.String())
+	}
+
+	if code := run([]string{"--retry", "-1", "limits"}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1 for a negative --retry, got %d", code)
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	release := make(chan struct{})
+	serveHTTP(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	t.Cleanup(func() { close(release) })
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--timeout", "50ms", "slow"}, &stdout, &stderr); code != 1 {
+		t.Fatalf("Expected exit code 1, got %d", code)
+	}
+	if want := "Request timed out after 50ms. Check your network or increase --timeout.\n"; stderr.String() != want {
+		t.Errorf("stderr = %q, want %q", stderr.String(), want)
+	}
+
+	stderr.Reset()
+	if code := run([]string{"--timeout", "-1s", "slow"}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1 for a negative --timeout, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "--timeout must not be negative") {
+		t.Errorf("Expected the negative timeout error, got %q", stderr.String())
+	}
+}
+
+func TestRunInvalidProxy(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--proxy", "proxy.example.com:8080", "actions"}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "Error: invalid --proxy:") {
+		t.Errorf("Expected the invalid proxy error, got %q", stderr.String())
+	}
+}
+
+func TestRunShowTiming(t *testing.T) {
+	serveSearch(t, http.StatusOK, `{
+		"meta": {"found": {"value": 1, "relation": "eq"}, "took": {"query_msec": 12, "total_msec": 34}, "page": 1, "size": 5},
+		"hits": [{"id": "1", "title": "About SSH", "url": "/en/ssh"}]
+	}`)
+	timing := "API timing: query=12ms, total=34ms\n"
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--show-timing", "--plain", "ssh"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if !strings.HasSuffix(stdout.String(), "\n"+timing) {
+		t.Errorf("Expected the timing below the results, got:\n%s", stdout.String())
+	}
+
+	stdout.Reset()
+	if code := run([]string{"--show-timing", "--format", "json", "ssh"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	var document struct {
+		Timing struct {
+			QueryMsec int `json:"query_msec"`
+			TotalMsec int `json:"total_msec"`
+		} `json:"_timing"`
+		Hits []SearchItem `json:"hits"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &document); err != nil {
+		t.Fatalf("Invalid JSON: %v\n%s", err, stdout.String())
+	}
+	if document.Timing.QueryMsec != 12 || document.Timing.TotalMsec != 34 || len(document.Hits) != 1 {
+		t.Errorf("Expected _timing alongside the hits, got %+v", document)
+	}
+
+	stdout.Reset()
+	if code := run([]string{"--show-timing", "--format", "csv", "ssh"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if want := "rank,id,title,url,breadcrumbs,score,intro,query_msec,total_msec\n1,\"1\",\"About SSH\",\"https://docs.github.com/en/ssh\",\"\",0,\"\",12,34\n"; stdout.String() != want {
+		t.Errorf("csv = %q, want %q", stdout.String(), want)
+	}
+
+	// Other formats keep stdout to the results
+	stdout.Reset()
+	stderr.Reset()
+	if code := run([]string{"--show-timing", "--format", "urls", "ssh"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if stdout.String() != "https://docs.github.com/en/ssh\n" || stderr.String() != timing {
+		t.Errorf("Expected the timing on stderr, got stdout %q and stderr %q", stdout.String(), stderr.String())
+	}
+
+	if code := run([]string{"--show-timing", "--format", "raw", "ssh"}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1 for --format raw, got %d", code)
+	}
+}
+
+func TestRunRawFormat(t *testing.T) {
+	// Field order and unknown fields must survive untouched
+	body := `{"meta":{"found":{"value":1,"relation":"eq"},"page":1,"size":5},"hits":[{"url":"/en/x","title":"X","future_field":true}]}`
+
+	tests := []struct {
+		name     string
+		status   int
+		wantCode int
+	}{
+		{"success", http.StatusOK, 0},
+		{"api error", http.StatusInternalServerError, 1},
+		{"rate limited", http.StatusTooManyRequests, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serveSearch(t, tt.status, body)
+
+			var stdout, stderr bytes.Buffer
+			code := run([]string{"--format", "raw", "--debug", "actions"}, &stdout, &stderr)
+
+			if code != tt.wantCode {
+				t.Errorf("Expected exit code %d, got %d", tt.wantCode, code)
+			}
+			if stdout.String() != body {
+				t.Errorf("Expected stdout to be the verbatim body, got %q", stdout.String())
+			}
+		})
+	}
+}
+
+func TestRunIncludeAll(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected []string
+	}{
+		{"all", []string{"--include", "all"}, []string{"intro", "headings", "toplevel"}},
+		{"all with duplicates", []string{"--include", "headings", "--include", "all,intro"}, []string{"headings", "intro", "toplevel"}},
+		{"comma separated", []string{"--include", "intro,headings"}, []string{"intro", "headings"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := serveSearch(t, http.StatusOK, `{"meta": {"found": {"value": 0}}, "hits": []}`)
+
+			var stdout, stderr bytes.Buffer
+			if code := run(append(tt.args, "--explain", "webhooks"), &stdout, &stderr); code != 0 {
+				t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+			}
+			if got := (*requests)[0]["include"]; !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("include = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+
+	var stdout, stderr bytes.Buffer
+	serveSearch(t, http.StatusOK, `{"meta": {"found": {"value": 0}}, "hits": []}`)
+	run([]string{"--include", "all", "--explain", "webhooks"}, &stdout, &stderr)
+	if !strings.Contains(stderr.String(), "explain: include: all expanded to intro, headings, toplevel") {
+		t.Errorf("Expected the expansion in --explain output, got:\n%s", stderr.String())
+	}
+
+	stderr.Reset()
+	if code := run([]string{"--include", "everything", "webhooks"}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1 for an unknown include, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), `unknown --include "everything"`) {
+		t.Errorf("Unexpected stderr: %q", stderr.String())
+	}
+}
+
+func TestRunExcludeToplevel(t *testing.T) {
+	body := `{
+		"meta": {"found": {"value": 4, "relation": "eq"}, "page": 1, "size": 50},
+		"hits": [
+			{"id": "1", "title": "Repositories", "url": "/en/rest/repos/repos"},
+			{"id": "2", "title": "Managing repositories", "url": "/en/repositories/managing"},
+			{"id": "3", "title": "Repository object", "url": "/en/graphql/reference/objects"},
+			{"id": "4", "title": "Repository roles", "url": "/en/organizations/roles"}
+		]
+	}`
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"negated toplevel", []string{"--toplevel", "!rest", "--toplevel", "!graphql,repositories,organizations"}},
+		{"exclude flag", []string{"--exclude-toplevel", "rest,graphql", "--toplevel", "repositories", "--toplevel", "organizations"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := serveSearch(t, http.StatusOK, body)
+
+			var stdout, stderr bytes.Buffer
+			if code := run(append(tt.args, "--plain", "--explain", "repository"), &stdout, &stderr); code != 0 {
+				t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+			}
+
+			// Positive filters go to the API; exclusions are applied locally after over-fetching
+			request := (*requests)[0]
+			if !reflect.DeepEqual(request["toplevel"], []string{"repositories", "organizations"}) || request.Get("size") != "50" {
+				t.Errorf("Unexpected request: toplevel=%v size=%s", request["toplevel"], request.Get("size"))
+			}
+
+			output := stdout.String()
+			if strings.Contains(output, "Repository object") || strings.Contains(output, "1. Repositories\n") {
+				t.Errorf("Expected REST and GraphQL results to be excluded, got:\n%s", output)
+			}
+			if !strings.Contains(output, "Hidden by client-side filters: 2 by --exclude-toplevel") {
+				t.Errorf("Expected suppressed count in footer, got:\n%s", output)
+			}
+			if !strings.Contains(stderr.String(), "explain: toplevel: sending repositories, organizations to the API; excluding rest, graphql client-side") {
+				t.Errorf("Expected exclusions in --explain output, got:\n%s", stderr.String())
+			}
+		})
+	}
+}
+
+func TestRunBreadcrumbFilter(t *testing.T) {
+	body := `{
+		"meta": {"found": {"value": 3, "relation": "eq"}, "page": 1, "size": 50},
+		"hits": [
+			{"id": "1", "title": "Using OIDC", "url": "/en/actions/oidc", "breadcrumbs": "Actions / Security guides / OIDC"},
+			{"id": "2", "title": "About billing", "url": "/en/billing/about", "breadcrumbs": "Billing / About"},
+			{"id": "3", "title": "Hardening", "url": "/en/actions/hardening", "breadcrumbs": "Actions > Security guides > Hardening"}
+		]
+	}`
+	requests := serveSearch(t, http.StatusOK, body)
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"--plain", "--breadcrumb", "actions/security guides", "security"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+
+	if got := (*requests)[0].Get("size"); got != "50" {
+		t.Errorf("Expected client-side filter to over-fetch with size 50, got %s", got)
+	}
+
+	output := stdout.String()
+	if !strings.Contains(output, "Using OIDC") || !strings.Contains(output, "Hardening") {
+		t.Errorf("Expected matching hits in output, got:\n%s", output)
+	}
+	if strings.Contains(output, "About billing") {
+		t.Errorf("Expected non-matching hit to be filtered out, got:\n%s", output)
+	}
+	if !strings.Contains(output, "Hidden by client-side filters: 1 by --breadcrumb") {
+		t.Errorf("Expected suppressed count in footer, got:\n%s", output)
+	}
+}
+
+func TestRunFilterBreadcrumbText(t *testing.T) {
+	body := `{
+		"meta": {"found": {"value": 3, "relation": "eq"}, "page": 1, "size": 50},
+		"hits": [
+			{"id": "1", "title": "Using OIDC", "url": "/en/actions/oidc", "breadcrumbs": "Actions / Security guides / OIDC"},
+			{"id": "2", "title": "About billing", "url": "/en/billing/about", "breadcrumbs": "Billing / About"},
+			{"id": "3", "title": "Code scanning", "url": "/en/code-security/scanning", "breadcrumbs": "Code security > Code scanning"}
+		]
+	}`
+	requests := serveSearch(t, http.StatusOK, body)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--plain", "--filter-breadcrumb", "security guides", "--filter-breadcrumb", "SCANNING", "security"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if got := (*requests)[0].Get("size"); got != "50" {
+		t.Errorf("Expected client-side filter to over-fetch with size 50, got %s", got)
+	}
+	output := stdout.String()
+	if !strings.Contains(output, "Using OIDC") || !strings.Contains(output, "Code scanning") || strings.Contains(output, "About billing") {
+		t.Errorf("Expected hits matching either text, got:\n%s", output)
+	}
+	if !strings.Contains(output, "Hidden by client-side filters: 1 by --filter-breadcrumb") {
+		t.Errorf("Expected suppressed count in footer, got:\n%s", output)
+	}
+
+	stdout.Reset()
+	if code := run([]string{"--plain", "--filter-breadcrumb", "Enterprise", "security"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if want := `All 3 results were filtered out by --filter-breadcrumb "Enterprise"; remove it to see them`; !strings.Contains(stderr.String(), want) {
+		t.Errorf("Expected %q, got %q", want, stderr.String())
+	}
+}
+
+func TestRunBreadcrumbFilterWithPage(t *testing.T) {
+	body := `{
+		"meta": {"found": {"value": 12, "relation": "eq"}, "page": 2, "size": 5},
+		"hits": [
+			{"id": "6", "title": "Using OIDC", "url": "/en/actions/oidc", "breadcrumbs": "Actions / Security guides / OIDC"},
+			{"id": "7", "title": "About billing", "url": "/en/billing/about", "breadcrumbs": "Billing / About"}
+		]
+	}`
+	requests := serveSearch(t, http.StatusOK, body)
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"--plain", "--explain", "--page", "2", "--breadcrumb", "actions", "security"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+
+	// Page 2 means results 6-10, not the second over-fetched page of 50
+	request := (*requests)[0]
+	if request.Get("size") != "5" || request.Get("page") != "2" {
+		t.Errorf("Expected size=5&page=2 with --page, got size=%s&page=%s", request.Get("size"), request.Get("page"))
+	}
+	if !strings.Contains(stderr.String(), "explain: filtering page 2 only") {
+		t.Errorf("Expected --explain to say over-fetching is disabled, got:\n%s", stderr.String())
+	}
+
+	output := stdout.String()
+	if !strings.Contains(output, "Using OIDC") || strings.Contains(output, "About billing") {
+		t.Errorf("Expected only the matching hit, got:\n%s", output)
+	}
+	if !strings.Contains(output, "Showing page 2 of 3") {
+		t.Errorf("Expected pagination footer in --size units, got:\n%s", output)
+	}
+}
+
+func TestRunMatchTitle(t *testing.T) {
+	body := `{
+		"meta": {"found": {"value": 3, "relation": "eq"}, "page": 1, "size": 50},
+		"hits": [
+			{"id": "1", "title": "Creating a pull request", "url": "/en/pr/create"},
+			{"id": "2", "title": "About pull request reviews", "url": "/en/pr/reviews"},
+			{"id": "3", "title": "Merging branches", "url": "/en/branches/merge"}
+		]
+	}`
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected []string
+		hidden   string
+	}{
+		{
+			name:     "all terms",
+			args:     []string{"--plain", "--match-title", "creating a pull request"},
+			expected: []string{"Creating a pull request"},
+			hidden:   "2 by --match-title",
+		},
+		{
+			name:     "any term",
+			args:     []string{"--plain", "--match-title", "any", "creating pull request"},
+			expected: []string{"Creating a pull request", "About pull request reviews"},
+			hidden:   "1 by --match-title",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serveSearch(t, http.StatusOK, body)
+
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, &stdout, &stderr); code != 0 {
+				t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+			}
+
+			output := stdout.String()
+			for _, title := range tt.expected {
+				if !strings.Contains(output, title) {
+					t.Errorf("Expected %q in output, got:\n%s", title, output)
+				}
+			}
+			if strings.Contains(output, "Merging branches") {
+				t.Errorf("Expected title without query terms to be filtered, got:\n%s", output)
+			}
+			if !strings.Contains(output, tt.hidden) {
+				t.Errorf("Expected %q in footer, got:\n%s", tt.hidden, output)
+			}
+		})
+	}
+}
+
+func TestRunPerCategory(t *testing.T) {
+	body := `{
+		"meta": {"found": {"value": 6, "relation": "eq"}, "page": 1, "size": 50},
+		"hits": [
+			{"id": "1", "title": "Actions one", "url": "/en/a1", "toplevel": "Actions"},
+			{"id": "2", "title": "Actions two", "url": "/en/a2", "toplevel": "Actions"},
+			{"id": "3", "title": "Actions three", "url": "/en/a3", "toplevel": "Actions"},
+			{"id": "4", "title": "Admin one", "url": "/en/b1", "toplevel": "Admin"},
+			{"id": "5", "title": "Untagged one", "url": "/en/u1"},
+			{"id": "6", "title": "Untagged two", "url": "/en/u2"}
+		]
+	}`
+	requests := serveSearch(t, http.StatusOK, body)
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"--format", "json", "--size", "10", "--per-category", "1", "permissions"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+
+	if !reflect.DeepEqual((*requests)[0]["include"], []string{"intro", "toplevel"}) {
+		t.Errorf("Expected toplevel to be auto-included, got %v", (*requests)[0]["include"])
+	}
+
+	var result SearchResult
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v", err)
+	}
+
+	var ids []string
+	for _, hit := range result.Hits {
+		ids = append(ids, hit.ID)
+	}
+	if !reflect.DeepEqual(ids, []string{"1", "4", "5"}) {
+		t.Errorf("Expected one hit per category in original order, got %v", ids)
+	}
+
+	if len(result.Meta.Notes) != 1 || !strings.Contains(result.Meta.Notes[0], "3 by --per-category 1 (skipped for diversity)") {
+		t.Errorf("Expected a note about skipped hits in meta, got %v", result.Meta.Notes)
+	}
+	if result.Meta.Suppressed != 3 {
+		t.Errorf("Expected meta.suppressed = 3, got %d", result.Meta.Suppressed)
+	}
+}
+
+func TestRunMinScore(t *testing.T) {
+	body := `{
+		"meta": {"found": {"value": 5, "relation": "eq"}, "page": 1, "size": 50},
+		"hits": [
+			{"id": "1", "title": "Strong", "url": "/en/s1", "score": 0.9},
+			{"id": "2", "title": "Weak", "url": "/en/w1", "score": 0.2},
+			{"id": "3", "title": "Borderline", "url": "/en/b1", "score": 0.5},
+			{"id": "4", "title": "Unscored", "url": "/en/u1"},
+			{"id": "5", "title": "Also strong", "url": "/en/s2", "score": 0.75}
+		]
+	}`
+	requests := serveSearch(t, http.StatusOK, body)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--plain", "--no-anchors", "--min-score", "0.5", "tokens"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	for _, want := range []string{"1. Strong", "2. Borderline", "3. Also strong", "Hidden by client-side filters: 2 by --min-score 0.50 (below threshold)\nShowing 3 of 5 returned (2 below threshold)\n"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("Expected %q in:\n%s", want, stdout.String())
+		}
+	}
+	if strings.Contains(stdout.String(), "Weak") || strings.Contains(stdout.String(), "Unscored") {
+		t.Errorf("Expected hits below the threshold to be hidden, got:\n%s", stdout.String())
+	}
+	if got := (*requests)[0].Get("size"); got != "50" {
+		t.Errorf("Expected a full page to be fetched for the filter, got size %s", got)
+	}
+
+	// A threshold nothing reaches says so on stderr
+	stdout.Reset()
+	if code := run([]string{"--plain", "--min-score", "0.95", "tokens"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if want := "All 5 results were below score threshold 0.95; use --min-score 0 to see them\n"; !strings.Contains(stderr.String(), want) {
+		t.Errorf("Expected %q, got %q", want, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "No results matched the client-side filters.") {
+		t.Errorf("Unexpected stdout:\n%s", stdout.String())
+	}
+
+	stderr.Reset()
+	if code := run([]string{"--min-score", "-1", "tokens"}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1 for a negative threshold, got %d", code)
+	}
+}
+
+func TestApplyFiltersWindow(t *testing.T) {
+	var hits []SearchItem
+	for i := range 8 {
+		hits = append(hits, SearchItem{ID: strconv.Itoa(i), Toplevel: "Actions"})
+	}
+	hits[6].Toplevel = "Admin"
+
+	// Only hits 1 and 2 would have been shown with --size 3; the over-fetched hits past them
+	// were never going to be displayed, so they don't count as hidden
+	kept, counts := applyFilters(hits, clientFilters(&options{perCategory: 1}, ""), 3)
+	if len(kept) != 2 || kept[1].ID != "6" {
+		t.Errorf("Expected hits 0 and 6 to be kept, got %v", kept)
+	}
+	if len(counts) != 1 || counts[0].count != 2 {
+		t.Errorf("Expected 2 hits counted as hidden, got %v", counts)
+	}
+}
+
+func TestRunHeadingFilter(t *testing.T) {
+	body := `{
+		"meta": {"found": {"value": 3, "relation": "eq"}, "page": 1, "size": 50},
+		"hits": [
+			{"id": "1", "title": "Self-hosted runners", "url": "/en/runners", "headings": "About runners\nRequired permissions\nTroubleshooting"},
+			{"id": "2", "title": "Runner groups", "url": "/en/groups", "headings": "About groups\nTroubleshooting"},
+			{"id": "3", "title": "Runner images", "url": "/en/images"}
+		]
+	}`
+	requests := serveSearch(t, http.StatusOK, body)
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"--plain", "--heading", "troubleshoot", "--heading", "permissions", "runners"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+
+	if !slices.Contains((*requests)[0]["include"], "headings") {
+		t.Errorf("Expected headings to be requested, got %v", (*requests)[0]["include"])
+	}
+
+	output := stdout.String()
+	if !strings.Contains(output, "Self-hosted runners") {
+		t.Errorf("Expected hit with both headings, got:\n%s", output)
+	}
+	if strings.Contains(output, "Runner groups") || strings.Contains(output, "Runner images") {
+		t.Errorf("Expected hits missing a heading to be filtered, got:\n%s", output)
+	}
+	if !strings.Contains(output, "§ Troubleshooting") || !strings.Contains(output, "§ Required permissions") {
+		t.Errorf("Expected matched headings under the result, got:\n%s", output)
+	}
+	if !strings.Contains(output, "2 by --heading") {
+		t.Errorf("Expected suppressed count in footer, got:\n%s", output)
+	}
+}
+
+func TestRunShare(t *testing.T) {
+	requests := serveSearch(t, http.StatusOK, `{}`)
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"--share", "--version", "enterprise-cloud", "SAML SSO"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+
+	expected := "https://docs.github.com/en/enterprise-cloud@latest/search?query=SAML+SSO\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+	if len(*requests) != 0 {
+		t.Errorf("Expected --share not to call the search API, got %d requests", len(*requests))
+	}
+}
+
+func TestRunRefs(t *testing.T) {
+	body := `{
+		"meta": {"found": {"value": 3, "relation": "eq"}, "page": 1, "size": 5},
+		"hits": [
+			{"id": "1", "title": "About required workflows", "url": "/en/actions/required-workflows"},
+			{"id": "2", "title": "Using \"required\" [beta] workflows", "url": "/en/actions/using"},
+			{"id": "3", "title": "Troubleshooting", "url": "/en/actions/troubleshooting"}
+		]
+	}`
+
+	t.Run("definitions", func(t *testing.T) {
+		serveSearch(t, http.StatusOK, body)
+
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"--refs", "--size", "2", "--no-anchors", "required workflows"}, &stdout, &stderr); code != 0 {
+			t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+		}
+		expected := `[1]: https://docs.github.com/en/actions/required-workflows "About required workflows"
+[2]: https://docs.github.com/en/actions/using "Using \"required\" [beta] workflows"
+`
+		if stdout.String() != expected {
+			t.Errorf("Expected:\n%s\ngot:\n%s", expected, stdout.String())
+		}
+	})
+
+	t.Run("with list", func(t *testing.T) {
+		serveSearch(t, http.StatusOK, body)
+
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"--refs-list", "--no-anchors", "required workflows"}, &stdout, &stderr); code != 0 {
+			t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+		}
+		expected := `1. [About required workflows][1]
+2. [Using "required" \[beta\] workflows][2]
+3. [Troubleshooting][3]
+
+[1]: https://docs.github.com/en/actions/required-workflows "About required workflows"
+[2]: https://docs.github.com/en/actions/using "Using \"required\" [beta] workflows"
+[3]: https://docs.github.com/en/actions/troubleshooting "Troubleshooting"
+`
+		if stdout.String() != expected {
+			t.Errorf("Expected:\n%s\ngot:\n%s", expected, stdout.String())
+		}
+	})
+
+	t.Run("filtered ranks", func(t *testing.T) {
+		serveSearch(t, http.StatusOK, body)
+
+		var stdout, stderr bytes.Buffer
+		run([]string{"--refs", "--no-anchors", "--match-title", "required workflows"}, &stdout, &stderr)
+		if strings.Contains(stdout.String(), "Troubleshooting") || !strings.HasPrefix(stdout.String(), "[1]: ") {
+			t.Errorf("Expected ranks after filtering, got:\n%s", stdout.String())
+		}
+		if !strings.Contains(stderr.String(), "hidden by client-side filters") {
+			t.Errorf("Expected the filter note on stderr, got %q", stderr.String())
+		}
+	})
+
+	t.Run("no results", func(t *testing.T) {
+		serveSearch(t, http.StatusOK, `{"meta": {"found": {"value": 0}}, "hits": []}`)
+
+		var stdout, stderr bytes.Buffer
+		run([]string{"--refs", "nothing"}, &stdout, &stderr)
+		if stdout.Len() != 0 {
+			t.Errorf("Expected nothing on stdout, got %q", stdout.String())
+		}
+		if !strings.Contains(stderr.String(), "No results found") {
+			t.Errorf("Expected the empty message on stderr, got %q", stderr.String())
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"--refs", "--format", "json", "workflows"}, &stdout, &stderr); code != 1 {
+			t.Errorf("Expected exit code 1, got %d", code)
+		}
+	})
+}
+
+func TestRunCSVFormat(t *testing.T) {
+	body := `{
+		"meta": {"found": {"value": 3, "relation": "eq"}, "page": 1, "size": 5},
+		"hits": [
+			{"id": "1", "title": "Managing secrets, variables", "url": "/en/actions/secrets", "breadcrumbs": "Actions / Security", "intro": "Store \"sensitive\" values.", "toplevel": "Actions", "score": 12.5},
+			{"id": "2", "title": "Webhooks", "url": "/en/webhooks", "score": 3},
+			{"id": "3", "title": "Third", "url": "/en/third", "score": 1}
+		]
+	}`
+	requests := serveSearch(t, http.StatusOK, body)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--format", "csv", "--size", "2", "--no-anchors", "secrets"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+
+	expected := `rank,id,title,url,breadcrumbs,score,intro
+1,"1","Managing secrets, variables","https://docs.github.com/en/actions/secrets","Actions / Security",12.5,"Store ""sensitive"" values."
+2,"2","Webhooks","https://docs.github.com/en/webhooks","",3,""
+`
+	if stdout.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, stdout.String())
+	}
+	if got := (*requests)[0].Get("size"); got != "2" {
+		t.Errorf("Expected size 2 to be requested, got %q", got)
+	}
+
+	stdout.Reset()
+	serveSearch(t, http.StatusOK, `{"meta": {"found": {"value": 0}}, "hits": []}`)
+	run([]string{"--format", "csv", "nothing"}, &stdout, &stderr)
+	if stdout.String() != "rank,id,title,url,breadcrumbs,score,intro\n" {
+		t.Errorf("Expected only the header for no results, got %q", stdout.String())
+	}
+
+	stdout.Reset()
+	serveSearch(t, http.StatusOK, body)
+	if code := run([]string{"--format", "csv", "--csv-fields", "rank,id,title", "--csv-fields", "score", "--csv-no-header", "--no-anchors", "secrets"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	expected = `1,"1","Managing secrets, variables",12.5
+2,"2","Webhooks",3
+3,"3","Third",1
+`
+	if stdout.String() != expected {
+		t.Errorf("Expected the chosen columns without a header:\n%s\ngot:\n%s", expected, stdout.String())
+	}
+
+	for _, args := range [][]string{
+		{"--format", "csv", "--csv-fields", "title,stars", "secrets"},
+		{"--format", "tsv", "--csv-no-header", "secrets"},
+	} {
+		stderr.Reset()
+		if code := run(args, &stdout, &stderr); code != 1 {
+			t.Errorf("%v: expected exit code 1, got %d", args, code)
+		}
+	}
+}
+
+func TestRunColumns(t *testing.T) {
+	body := `{
+		"meta": {"found": {"value": 2, "relation": "eq"}, "page": 1, "size": 5},
+		"hits": [
+			{"id": "1", "title": "Managing secrets", "url": "/en/actions/secrets", "toplevel": "Actions", "score": 12.5},
+			{"id": "2", "title": "Webhooks", "url": "/en/webhooks", "score": 3}
+		]
+	}`
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{"plain", []string{"--plain"}, "Found 2 results\n1. Managing secrets\n   Actions\n   12.5\n\n2. Webhooks\n   3\n\n"},
+		{"csv", []string{"--format", "csv"}, "title,toplevel,score\n\"Managing secrets\",\"Actions\",12.5\n\"Webhooks\",\"\",3\n"},
+		{"tsv", []string{"--format", "tsv"}, "Managing secrets\tActions\t12.5\nWebhooks\t\t3\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := serveSearch(t, http.StatusOK, body)
+
+			var stdout, stderr bytes.Buffer
+			args := append(tt.args, "--columns", "title,toplevel,score", "--no-anchors", "secrets")
+			if code := run(args, &stdout, &stderr); code != 0 {
+				t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+			}
+			if stdout.String() != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, stdout.String())
+			}
+			if includes := (*requests)[0]["include"]; !slices.Contains(includes, "toplevel") {
+				t.Errorf("Expected toplevel to be requested, got %v", includes)
+			}
+		})
+	}
+
+	for _, args := range [][]string{
+		{"--plain", "--columns", "title,stars", "secrets"},
+		{"--format", "json", "--columns", "title", "secrets"},
+	} {
+		var stdout, stderr bytes.Buffer
+		if code := run(args, &stdout, &stderr); code != 1 {
+			t.Errorf("%v: expected exit code 1, got %d", args, code)
+		}
+	}
+}
+
+func TestRunURLOnly(t *testing.T) {
+	body := `{
+		"meta": {"found": {"value": 40, "relation": "eq"}, "page": 2, "size": 2},
+		"hits": [
+			{"id": "1", "title": "First", "url": "/en/first"},
+			{"id": "2", "title": "Second", "url": "/en/second"}
+		]
+	}`
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{"url-only", []string{"--url-only"}, "https://docs.github.com/en/first\nhttps://docs.github.com/en/second\n"},
+		{"format urls", []string{"--format", "urls"}, "https://docs.github.com/en/first\nhttps://docs.github.com/en/second\n"},
+		{"null", []string{"--url-only", "--null"}, "https://docs.github.com/en/first\x00https://docs.github.com/en/second\x00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := serveSearch(t, http.StatusOK, body)
+
+			var stdout, stderr bytes.Buffer
+			args := append(tt.args, "--size", "2", "--page", "2", "--no-anchors", "docs")
+			if code := run(args, &stdout, &stderr); code != 0 {
+				t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+			}
+			if stdout.String() != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, stdout.String())
+			}
+			if got := (*requests)[0].Get("page"); got != "2" {
+				t.Errorf("Expected page 2 to be requested, got %q", got)
+			}
+		})
+	}
+
+	t.Run("beyond the default size", func(t *testing.T) {
+		var hits []string
+		for i := 1; i <= 8; i++ {
+			hits = append(hits, fmt.Sprintf(`{"id": "%d", "title": "Page %d", "url": "/en/page-%d"}`, i, i, i))
+		}
+		serveSearch(t, http.StatusOK, `{"meta": {"found": {"value": 8, "relation": "eq"}, "page": 1, "size": 8}, "hits": [`+strings.Join(hits, ",")+`]}`)
+
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"--format", "urls", "--size", "8", "--no-anchors", "docs"}, &stdout, &stderr); code != 0 {
+			t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+		}
+		lines := strings.Split(strings.TrimSuffix(stdout.String(), "\n"), "\n")
+		if len(lines) != 8 || lines[7] != "https://docs.github.com/en/page-8" {
+			t.Errorf("Expected all 8 URLs and nothing else, got:\n%s", stdout.String())
+		}
+	})
+
+	for _, args := range [][]string{
+		{"--url-only", "--format", "json", "docs"},
+		{"--null", "docs"},
+	} {
+		var stdout, stderr bytes.Buffer
+		if code := run(args, &stdout, &stderr); code != 1 {
+			t.Errorf("%v: expected exit code 1, got %d", args, code)
+		}
+	}
+}
+
+func TestRunTitlesFormat(t *testing.T) {
+	body := `{
+		"meta": {"found": {"value": 2, "relation": "eq"}, "page": 1, "size": 5},
+		"hits": [
+			{"id": "1", "title": "Managing\nsecrets", "url": "/en/actions/secrets", "score": 0.875},
+			{"id": "2", "title": "Webhooks", "url": "/en/webhooks"}
+		]
+	}`
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{"titles", nil, "Managing secrets\nWebhooks\n"},
+		{"rank and score", []string{"--show-rank", "--show-score"}, "1. [0.875] Managing secrets\n2. [n/a] Webhooks\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serveSearch(t, http.StatusOK, body)
+
+			var stdout, stderr bytes.Buffer
+			args := append([]string{"--format", "titles"}, tt.args...)
+			if code := run(append(args, "docs"), &stdout, &stderr); code != 0 {
+				t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+			}
+			if stdout.String() != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, stdout.String())
+			}
+		})
+	}
+
+	t.Run("rank without titles", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"--show-rank", "docs"}, &stdout, &stderr); code != 1 {
+			t.Errorf("Expected exit code 1, got %d", code)
+		}
+	})
+}
+
+func TestRunShowScore(t *testing.T) {
+	body := `{
+		"meta": {"found": {"value": 2, "relation": "eq"}, "page": 1, "size": 5},
+		"hits": [
+			{"id": "1", "title": "Managing secrets", "url": "/en/actions/secrets", "score": 12.34567},
+			{"id": "2", "title": "Webhooks", "url": "/en/webhooks"}
+		]
+	}`
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected []string
+	}{
+		{"plain", []string{"--plain"}, []string{"1. Managing secrets [12.346]\n", "2. Webhooks [n/a]\n"}},
+		{"pretty", nil, []string{"Managing secrets [12.346]", "Webhooks [n/a]"}},
+		{"oneline", []string{"--oneline", "--plain"}, []string{"1. Managing secrets [12.346] — https://docs.github.com/en/actions/secrets\n"}},
+		{"tsv", []string{"--format", "tsv"}, []string{"1\tManaging secrets\thttps://docs.github.com/en/actions/secrets\t\t12.34567\n"}},
+		{"csv columns", []string{"--format", "csv", "--columns", "title"}, []string{"title,score\n\"Managing secrets\",12.34567\n"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serveSearch(t, http.StatusOK, body)
+
+			var stdout, stderr bytes.Buffer
+			if code := run(append(tt.args, "--show-score", "--no-anchors", "secrets"), &stdout, &stderr); code != 0 {
+				t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+			}
+			for _, want := range tt.expected {
+				if !strings.Contains(stdout.String(), want) {
+					t.Errorf("Expected %q in:\n%s", want, stdout.String())
+				}
+			}
+		})
+	}
+
+	t.Run("json", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"--show-score", "--format", "json", "secrets"}, &stdout, &stderr); code != 1 {
+			t.Errorf("Expected exit code 1, got %d", code)
+		}
+	})
+}
+
+func TestAppendToFirstLine(t *testing.T) {
+	output := "\n  \n\x1b[1m  1. Title\x1b[0m  \n  https://docs.github.com\n"
+	expected := "\n  \n\x1b[1m  1. Title\x1b[0m [1.000]\n  https://docs.github.com\n"
+	if got := appendToFirstLine(output, " [1.000]"); got != expected {
+		t.Errorf("appendToFirstLine() = %q, want %q", got, expected)
+	}
+}
+
+func TestReplaceMarks(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"no marks", "Managing secrets", "Managing secrets"},
+		{"one mark", "Managing <mark>secrets</mark>", "Managing *secrets*"},
+		{"several marks", "<mark>Use</mark> <mark>secrets</mark> in <mark>workflows</mark>", "*Use* *secrets* in *workflows*"},
+		{"nested", "<mark>a <mark>b</mark> c</mark> d", "*a b c* d"},
+		{"unclosed", "Use <mark>secrets in workflows", "Use *secrets in workflows*"},
+		{"stray close", "Use</mark> secrets", "Use secrets"},
+		{"empty mark", "Use <mark></mark>secrets", "Use secrets"},
+		{"other tags", "a <b>bold</b> <mark>term</mark>", "a <b>bold</b> *term*"},
+	}
+
+	mark := plainMarks(&options{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mark(tt.input); got != tt.expected {
+				t.Errorf("plainMarks()(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+
+	if got := plainMarks(&options{noColor: true})("Use <mark>secrets</mark>"); got != "Use [secrets]" {
+		t.Errorf("Expected brackets without colors, got %q", got)
+	}
+}
+
+func TestStyleMarks(t *testing.T) {
+	oldProfile := lipgloss.ColorProfile()
+	lipgloss.SetColorProfile(termenv.ANSI)
+	t.Cleanup(func() { lipgloss.SetColorProfile(oldProfile) })
+
+	style, ok := parseHighlightStyle("cyan")
+	if !ok {
+		t.Fatal("Expected cyan to be a highlight style")
+	}
+	opts := &options{markStyle: style}
+
+	// The renderer's own styling inside a mark is replaced by the highlight style
+	output := styleMarks("  Use "+markOpen+"\x1b[1msecrets\x1b[0m"+markClose+" in "+markOpen+"workflows"+markClose+markOpen+"\n", prettyMark(opts))
+	if want := "  Use " + style.Render("secrets") + " in " + style.Render("workflows") + "\n"; output != want {
+		t.Errorf("styleMarks() = %q, want %q", output, want)
+	}
+	if !strings.Contains(output, "\x1b[") {
+		t.Errorf("Expected matched terms to be styled, got %q", output)
+	}
+}
+
+func TestRunCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	body := `{"meta": {"found": {"value": 1, "relation": "eq"}, "page": 1, "size": 5}, "hits": [{"id": "1", "title": "Cached", "url": "/en/cached"}]}`
+	requests := serveSearch(t, http.StatusOK, body)
+
+	var outputs []string
+	for i := 0; i < 2; i++ {
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"--cache", "--format", "json", "ssh"}, &stdout, &stderr); code != 0 {
+			t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+		}
+		if !strings.Contains(stdout.String(), `"title": "Cached"`) {
+			t.Errorf("Run %d: expected the cached hit, got %q", i+1, stdout.String())
+		}
+		outputs = append(outputs, stdout.String())
+	}
+	if len(*requests) != 1 {
+		t.Errorf("Expected the second search to be served from the cache, got %d requests", len(*requests))
+	}
+	if outputs[0] != outputs[1] {
+		t.Errorf("Expected the cached search to print the same output, got:\n%s\nthen:\n%s", outputs[0], outputs[1])
+	}
+
+	var stdout, stderr bytes.Buffer
+	run([]string{"ssh"}, &stdout, &stderr)
+	if len(*requests) != 2 {
+		t.Errorf("Expected searches without --cache to call the API, got %d requests", len(*requests))
+	}
+
+	// Raw output is the body exactly as sent, which the cache doesn't keep
+	stdout.Reset()
+	if code := run([]string{"--cache", "--format", "raw", "ssh"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if stdout.String() != body || len(*requests) != 3 {
+		t.Errorf("Expected --format raw to bypass the cache, got %q after %d requests", stdout.String(), len(*requests))
+	}
+
+	stdout.Reset()
+	if code := run([]string{"--clear-cache"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if !strings.HasPrefix(stdout.String(), "Deleted 1 cached responses") {
+		t.Errorf("Unexpected --clear-cache output: %q", stdout.String())
+	}
+
+	stderr.Reset()
+	if code := run([]string{"--cache", "--cache-ttl", "0s", "ssh"}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1 for a zero TTL, got %d", code)
+	}
+}
+
+func TestRunCacheSkipsErrors(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	requests := serveSearch(t, http.StatusInternalServerError, `{"error": "boom"}`)
+
+	for i := 0; i < 2; i++ {
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"--cache", "ssh"}, &stdout, &stderr); code != 1 {
+			t.Errorf("Expected exit code 1, got %d", code)
+		}
+	}
+	if len(*requests) != 2 {
+		t.Errorf("Expected failed responses not to be cached, got %d requests", len(*requests))
+	}
+}
+
+func TestRunTSVFormat(t *testing.T) {
+	body := `{
+		"meta": {"found": {"value": 2, "relation": "eq"}, "page": 1, "size": 5},
+		"hits": [
+			{"id": "1", "title": "Tabs\tin title", "url": "/en/tabs", "intro": "A long intro that spans\nseveral lines and is never truncated, however long it gets, because tsv output is meant for tools."},
+			{"id": "2", "title": "No intro", "url": "/en/none"}
+		]
+	}`
+	serveSearch(t, http.StatusOK, body)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--format", "tsv", "--no-anchors", "tabs"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+
+	expected := "1\tTabs in title\thttps://docs.github.com/en/tabs\tA long intro that spans several lines and is never truncated, however long it gets, because tsv output is meant for tools.\n" +
+		"2\tNo intro\thttps://docs.github.com/en/none\t\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected:\n%q\ngot:\n%q", expected, stdout.String())
+	}
+}
+
+func TestRunVersionsFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "versions.json")
+	if err := os.WriteFile(path, []byte(`{"lastUpdated": "2026-01-01", "supportedVersions": ["3.18", "3.19"], "latestVersion": "3.19"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { searchdocs.VersionsFile = "" })
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--versions-file", path, "--list-versions"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "3.19 (latest)") {
+		t.Errorf("Expected the versions from the file, got:\n%s", stdout.String())
+	}
+
+	// The environment variable is used when the flag isn't given
+	t.Setenv("GH_SEARCH_DOCS_VERSIONS_FILE", path)
+	requests := serveSearch(t, http.StatusOK, `{"meta": {"found": {"value": 0, "relation": "eq"}}, "hits": []}`)
+	stdout.Reset()
+	if code := run([]string{"--version", "enterprise-server@3.14", "runners"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if len(*requests) != 1 || (*requests)[0].Get("version") != "enterprise-server@3.19" {
+		t.Errorf("Expected unsupported versions to fall back to the file's latest, got %v", *requests)
+	}
+
+	for _, args := range [][]string{
+		{"--versions-file", filepath.Join(dir, "missing.json"), "--list-versions"},
+		{"--versions-file", filepath.Join(dir, "missing.json"), "runners"},
+	} {
+		stderr.Reset()
+		if code := run(args, &stdout, &stderr); code != 1 {
+			t.Errorf("%v: expected exit code 1, got %d", args, code)
+		}
+		if !strings.Contains(stderr.String(), "Error loading versions file") {
+			t.Errorf("%v: unexpected stderr: %q", args, stderr.String())
+		}
+	}
+}
+
+func TestRunUpdateVersions(t *testing.T) {
+	var requested []string
+	serveHTTP(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		_, _ = io.WriteString(w, `{"lastUpdated": "old", "supportedVersions": ["3.18", "3.19"], "latestVersion": "3.19"}`)
+	}))
+	path := filepath.Join(t.TempDir(), "versions.json")
+	if err := os.WriteFile(path, []byte(`{"lastUpdated": "2025-01-01T00:00:00Z", "supportedVersions": ["3.17", "3.18"], "latestVersion": "3.18"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { searchdocs.VersionsFile = "" })
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--update-versions", "--versions-file", path, "--versions-url", "https://example.com/versions.json"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if len(requested) != 1 || requested[0] != "/versions.json" {
+		t.Errorf("Expected the --versions-url to be fetched, got %v", requested)
+	}
+	for _, want := range []string{"+ 3.19\n", "- 3.17\n", "Latest: 3.18 → 3.19\n", "Updated " + path} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("Expected %q in:\n%s", want, stdout.String())
+		}
+	}
+	written, err := searchdocs.LoadSupportedVersionsFromPath(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written.LatestVersion != "3.19" || written.LastUpdated == "old" || !strings.HasSuffix(written.LastUpdated, "Z") {
+		t.Errorf("Expected the fetched versions with a fresh lastUpdated, got %+v", written)
+	}
+
+	// Nothing changed, so the file is left alone unless --yes is given
+	stdout.Reset()
+	if code := run([]string{"--update-versions", "--versions-file", path}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "up to date") || strings.Contains(stdout.String(), "Updated") {
+		t.Errorf("Expected an up to date message, got:\n%s", stdout.String())
+	}
+	stdout.Reset()
+	if code := run([]string{"--update-versions", "--yes", "--versions-file", path}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Updated") {
+		t.Errorf("Expected --yes to rewrite the file, got:\n%s", stdout.String())
+	}
+}
+
+func TestRunQueriesFile(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		queries []string
+	)
+	serveHTTP(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("query")
+		mu.Lock()
+		queries = append(queries, query)
+		mu.Unlock()
+		if query == "broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, `{"meta": {"found": {"value": 1, "relation": "eq"}}, "hits": [{"id": "1", "title": "About %s", "url": "/en/%s"}]}`, query, query)
+	}))
+	path := filepath.Join(t.TempDir(), "queries.txt")
+	if err := os.WriteFile(path, []byte("# weekly checks\nrunners\n\nbroken\nsecrets\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--queries-file", path, "--format", "urls", "--size", "3"}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1 when a query fails, got %d", code)
+	}
+	if !slices.Equal(queries, []string{"runners", "broken", "secrets"}) {
+		t.Errorf("Expected each query in order, got %v", queries)
+	}
+	expected := "--- Query 1: \"runners\" ---\nhttps://docs.github.com/en/runners\n\n" +
+		"--- Query 2: \"broken\" ---\n\n" +
+		"--- Query 3: \"secrets\" ---\nhttps://docs.github.com/en/secrets\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "API returned status 500") || !strings.HasSuffix(stderr.String(), "Completed 3 queries, 1 errors\n") {
+		t.Errorf("Expected the failure and a summary on stderr, got %q", stderr.String())
+	}
+
+	// In parallel the results keep the order of the file
+	stdout.Reset()
+	stderr.Reset()
+	if code := run([]string{"--queries-file", path, "--format", "urls", "--size", "3", "--parallel", "3"}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1 when a query fails, got %d", code)
+	}
+	if stdout.String() != expected {
+		t.Errorf("Expected %q with --parallel, got %q", expected, stdout.String())
+	}
+
+	for _, args := range [][]string{
+		{"--queries-file", path, "ssh"},
+		{"--queries-file", path, "--watch", "1m"},
+		{"--queries-file", filepath.Join(t.TempDir(), "missing.txt")},
+		{"--parallel", "2", "ssh"},
+		{"--queries-file", path, "--parallel", "0"},
+	} {
+		stderr.Reset()
+		if code := run(args, &stdout, &stderr); code != 1 {
+			t.Errorf("%v: expected exit code 1, got %d", args, code)
+		}
+		if !strings.Contains(stderr.String(), "--queries-file") && !strings.Contains(stderr.String(), "--parallel") {
+			t.Errorf("%v: unexpected stderr: %q", args, stderr.String())
+		}
+	}
+}
+
+func TestRunQueriesFileParallelRateLimited(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		limited = map[string]bool{}
+	)
+	serveHTTP(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("query")
+		mu.Lock()
+		first := !limited[query]
+		limited[query] = true
+		mu.Unlock()
+		if first {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprintf(w, `{"meta": {"found": {"value": 1, "relation": "eq"}}, "hits": [{"id": "1", "title": "About %s", "url": "/en/%s"}]}`, query, query)
+	}))
+	dir := t.TempDir()
+	path, logPath := filepath.Join(dir, "queries.txt"), filepath.Join(dir, "log.jsonl")
+	if err := os.WriteFile(path, []byte("one\ntwo\nthree\nfour\nfive\nsix\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every worker is rate limited at once, writing to stderr and the log together
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--queries-file", path, "--format", "urls", "--parallel", "6", "--log-file", logPath}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	for _, query := range []string{"one", "two", "three", "four", "five", "six"} {
+		if !strings.Contains(stdout.String(), "https://docs.github.com/en/"+query+"\n") {
+			t.Errorf("Expected the retried result for %q, got:\n%s", query, stdout.String())
+		}
+	}
+	if n := strings.Count(stderr.String(), "Rate limited, retrying"); n != 6 {
+		t.Errorf("Expected a retry notice per query, got %d in %q", n, stderr.String())
+	}
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var logged transcript
+	if err := json.Unmarshal(data, &logged); err != nil {
+		t.Fatalf("Failed to parse the log: %v", err)
+	}
+	if len(logged.Errors) != 6 {
+		t.Errorf("Expected each retry notice in the log, got %q", logged.Errors)
+	}
+}
+
+func TestRunQueriesFileNotPaged(t *testing.T) {
+	serveSearch(t, http.StatusOK, layoutFixture)
+	withTerminalWidth(t, 80)
+	t.Setenv("GH_PAGER", "less -FRX")
+	paged := 0
+	oldHeight, oldPager := terminalHeight, runPager
+	terminalHeight = func() int { return 3 }
+	runPager = func(string, func(io.Writer)) error {
+		paged++
+		return nil
+	}
+	t.Cleanup(func() { terminalHeight, runPager = oldHeight, oldPager })
+	path := filepath.Join(t.TempDir(), "queries.txt")
+	if err := os.WriteFile(path, []byte("tokens\nssh\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--queries-file", path, "--plain"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	output := stdout.String()
+	if paged != 0 || strings.Count(output, "Quickstart for GitHub Copilot") != 2 || !strings.Contains(output, `--- Query 2: "ssh" ---`) {
+		t.Errorf("Expected every query's results between the separators without a pager, paged %d times, got:\n%s", paged, output)
+	}
+}
+
+func TestRunQueriesFileProcessing(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dir)
+	var (
+		mu     sync.Mutex
+		probes []string
+	)
+	serveHTTP(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/search/v1" {
+			query := r.URL.Query().Get("query")
+			fmt.Fprintf(w, `{"meta": {"found": {"value": 1, "relation": "eq"}}, "hits": [{"id": "1", "title": "About %s", "url": "/en/%s"}]}`, query, query)
+			return
+		}
+		mu.Lock()
+		probes = append(probes, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+	}))
+	path := filepath.Join(t.TempDir(), "queries.txt")
+	if err := os.WriteFile(path, []byte("runners\nsecrets\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each query gets the same processing as a single search, in parallel too
+	for _, parallel := range []string{"1", "2"} {
+		probes = nil
+		var stdout, stderr bytes.Buffer
+		args := []string{"--queries-file", path, "--plain", "--check-translations", "ja", "--parallel", parallel}
+		if code := run(args, &stdout, &stderr); code != 0 {
+			t.Fatalf("--parallel %s: expected exit code 0, got %d (stderr: %s)", parallel, code, stderr.String())
+		}
+		slices.Sort(probes)
+		if !slices.Equal(probes, []string{"HEAD /ja/runners", "HEAD /ja/secrets"}) {
+			t.Errorf("--parallel %s: expected a translation check for each query, got %v", parallel, probes)
+		}
+		if strings.Count(stdout.String(), "ja: ✓") != 2 {
+			t.Errorf("--parallel %s: expected the translations shown for each query, got:\n%s", parallel, stdout.String())
+		}
+	}
+
+	history, err := os.ReadFile(filepath.Join(dir, "gh-search-docs", "history.jsonl"))
+	if err != nil {
+		t.Fatalf("Expected the queries recorded in history: %v", err)
+	}
+	if n := strings.Count(string(history), `"query":"secrets"`); n != 2 {
+		t.Errorf("Expected each batch query in history, got:\n%s", history)
+	}
+}
+
+func TestRunConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	config := "size: 12\nversion: enterprise-cloud\ninclude: [intro]\ntruncate_at: 10\n"
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	body := `{"meta": {"found": {"value": 1, "relation": "eq"}, "page": 1, "size": 12}, "hits": [{"id": "1", "title": "SSO", "url": "/en/sso", "intro": "A long introduction to SSO."}]}`
+
+	t.Run("defaults from --config", func(t *testing.T) {
+		requests := serveSearch(t, http.StatusOK, body)
+
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"--config", path, "--plain", "sso"}, &stdout, &stderr); code != 0 {
+			t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+		}
+		params := (*requests)[0]
+		if params.Get("size") != "12" || params.Get("version") != "enterprise-cloud" || params.Get("include") != "intro" {
+			t.Errorf("Expected config defaults in the request, got %v", params)
+		}
+		if !strings.Contains(stdout.String(), "A long...") {
+			t.Errorf("Expected the intro cut at truncate_at, got:\n%s", stdout.String())
+		}
+	})
+
+	t.Run("--no-truncate over truncate_at", func(t *testing.T) {
+		serveSearch(t, http.StatusOK, body)
+
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"--config", path, "--plain", "--no-truncate", "sso"}, &stdout, &stderr); code != 0 {
+			t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+		}
+		if !strings.Contains(stdout.String(), "A long introduction to SSO.") {
+			t.Errorf("Expected the full intro, got:\n%s", stdout.String())
+		}
+	})
+
+	t.Run("flags win", func(t *testing.T) {
+		requests := serveSearch(t, http.StatusOK, body)
+		t.Setenv("GH_SEARCH_DOCS_CONFIG", path)
+
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"--size", "3", "sso"}, &stdout, &stderr); code != 0 {
+			t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+		}
+		params := (*requests)[0]
+		if params.Get("size") != "3" || params.Get("version") != "enterprise-cloud" {
+			t.Errorf("Expected --size to override the config, got %v", params)
+		}
+	})
+
+	t.Run("missing explicit file", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"--config", filepath.Join(t.TempDir(), "missing.yaml"), "sso"}, &stdout, &stderr); code != 1 {
+			t.Errorf("Expected exit code 1, got %d", code)
+		}
+		if !strings.Contains(stderr.String(), "Error loading config") {
+			t.Errorf("Unexpected stderr: %q", stderr.String())
+		}
+	})
+}
+
+func TestRunYAMLFormat(t *testing.T) {
+	body := `{
+		"meta": {"found": {"value": 1, "relation": "eq"}, "took": {"query_msec": 3, "total_msec": 5}, "page": 1, "size": 5},
+		"hits": [
+			{
+				"id": "1",
+				"title": "Managing secrets",
+				"url": "/en/actions/secrets",
+				"breadcrumbs": "Actions / Security",
+				"highlights": {"title": ["Managing <mark>secrets</mark>"], "content": ["one", "two"]},
+				"score": 1.5
+			}
+		]
+	}`
+	serveSearch(t, http.StatusOK, body)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--format", "yaml", "--no-anchors", "secrets"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+
+	output := stdout.String()
+	for _, expected := range []string{"query_msec: 3", "breadcrumbs: Actions / Security", "title:\n        - Managing <mark>secrets</mark>"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected %q in the YAML output:\n%s", expected, output)
+		}
+	}
+	for _, omitted := range []string{"intro:", "suppressed:", "notes:", "translations:"} {
+		if strings.Contains(output, omitted) {
+			t.Errorf("Expected empty %s to be omitted:\n%s", omitted, output)
+		}
+	}
+
+	var fromYAML, fromJSON SearchResult
+	if err := yaml.Unmarshal(stdout.Bytes(), &fromYAML); err != nil {
+		t.Fatalf("Output is not valid YAML: %v", err)
+	}
+	if err := json.Unmarshal([]byte(body), &fromJSON); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fromYAML, fromJSON) {
+		t.Errorf("YAML round trip = %+v, want %+v", fromYAML, fromJSON)
+	}
+
+	t.Run("multiline fields", func(t *testing.T) {
+		serveSearch(t, http.StatusOK, `{
+			"meta": {"found": {"value": 1, "relation": "eq"}, "page": 1, "size": 5},
+			"hits": [{"id": "1", "title": "Secrets", "url": "/en/secrets", "intro": "Line one\nline two", "content": "First paragraph.\n\nSecond paragraph.\n"}]
+		}`)
+
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"--format", "yaml", "--no-anchors", "secrets"}, &stdout, &stderr); code != 0 {
+			t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+		}
+		output := stdout.String()
+		for _, expected := range []string{"intro: |-\n      Line one\n      line two\n", "content: |\n      First paragraph.\n\n      Second paragraph.\n"} {
+			if !strings.Contains(output, expected) {
+				t.Errorf("Expected a block scalar %q in:\n%s", expected, output)
+			}
+		}
+
+		var result SearchResult
+		if err := yaml.Unmarshal(stdout.Bytes(), &result); err != nil {
+			t.Fatalf("Output is not valid YAML: %v", err)
+		}
+		if item := result.Hits[0]; item.Intro != "Line one\nline two" || item.Content != "First paragraph.\n\nSecond paragraph.\n" {
+			t.Errorf("Expected the multiline fields to round trip, got %+v", item)
+		}
+	})
+}
+
+func TestRunJSONLFormat(t *testing.T) {
+	body := `{
+		"meta": {"found": {"value": 40, "relation": "eq"}, "page": 2, "size": 2},
+		"hits": [
+			{"id": "1", "title": "First", "url": "/en/first"},
+			{"id": "2", "title": "Second", "url": "/en/second", "intro": "Line one\nline two"}
+		]
+	}`
+
+	t.Run("hits", func(t *testing.T) {
+		requests := serveSearch(t, http.StatusOK, body)
+
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"--format", "jsonl", "--size", "2", "--page", "2", "--no-anchors", "docs"}, &stdout, &stderr); code != 0 {
+			t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+		}
+		expected := `{"id":"1","title":"First","url":"/en/first"}
+{"id":"2","title":"Second","url":"/en/second","intro":"Line one\nline two"}
+`
+		if stdout.String() != expected {
+			t.Errorf("Expected:\n%s\ngot:\n%s", expected, stdout.String())
+		}
+		if got := (*requests)[0].Get("page"); got != "2" {
+			t.Errorf("Expected page 2 to be requested, got %q", got)
+		}
+	})
+
+	t.Run("meta line", func(t *testing.T) {
+		serveSearch(t, http.StatusOK, body)
+
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"--format", "jsonl", "--jsonl-meta", "--no-anchors", "docs"}, &stdout, &stderr); code != 0 {
+			t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+		}
+		lines := strings.Split(strings.TrimSuffix(stdout.String(), "\n"), "\n")
+		if len(lines) != 3 {
+			t.Fatalf("Expected a meta line and 2 hits, got %d lines:\n%s", len(lines), stdout.String())
+		}
+		var first struct {
+			Meta struct {
+				Found struct {
+					Value int `json:"value"`
+				} `json:"found"`
+			} `json:"meta"`
+		}
+		if err := json.Unmarshal([]byte(lines[0]), &first); err != nil || first.Meta.Found.Value != 40 {
+			t.Errorf("Unexpected meta line %q (%v)", lines[0], err)
+		}
+		for _, line := range lines {
+			if !json.Valid([]byte(line)) {
+				t.Errorf("Line is not valid JSON: %q", line)
+			}
+		}
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		serveSearch(t, http.StatusOK, body)
+
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"--format", "jsonl", "--debug", "--no-anchors", "docs"}, &stdout, &stderr); code != 0 {
+			t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+		}
+		if !strings.HasPrefix(stdout.String(), `//meta:{"found":{"value":40`) {
+			t.Errorf("Expected a meta comment line ahead of the hits with --debug, got %q", stdout.String())
+		}
+		var hits []SearchItem
+		scanner := bufio.NewScanner(&stdout)
+		for scanner.Scan() {
+			if strings.HasPrefix(scanner.Text(), "//meta:") {
+				continue
+			}
+			var item SearchItem
+			if err := json.Unmarshal(scanner.Bytes(), &item); err != nil {
+				t.Fatalf("Line %q doesn't decode: %v", scanner.Text(), err)
+			}
+			hits = append(hits, item)
+		}
+		if len(hits) != 2 || hits[1].Intro != "Line one\nline two" {
+			t.Errorf("Expected both hits back, got %+v", hits)
+		}
+		if strings.Contains(stderr.String(), "//meta:") {
+			t.Errorf("Expected the meta line only on stdout, got %q", stderr.String())
+		}
+	})
+
+	t.Run("meta without jsonl", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"--jsonl-meta", "docs"}, &stdout, &stderr); code != 1 {
+			t.Errorf("Expected exit code 1, got %d", code)
+		}
+	})
+}
+
+func TestRunInteractiveNeedsTerminal(t *testing.T) {
+	requests := serveSearch(t, http.StatusOK, `{}`)
+	withStdin(t, unreadable{t: t})
+	oldIsTerminal := stdoutIsTerminal
+	stdoutIsTerminal = func() bool { return false }
+	t.Cleanup(func() { stdoutIsTerminal = oldIsTerminal })
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--interactive"}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "--interactive needs a terminal") {
+		t.Errorf("Unexpected stderr: %q", stderr.String())
+	}
+	if len(*requests) != 0 {
+		t.Errorf("Expected no searches, got %d", len(*requests))
+	}
+}
+
+func TestRunInteractiveNeedsInput(t *testing.T) {
+	requests := serveSearch(t, http.StatusOK, `{}`)
+	withStdin(t, unreadable{t: t})
+	withTerminalWidth(t, 80)
+
+	for _, tc := range []struct {
+		name string
+		args []string
+		ci   string
+	}{
+		{"CI", []string{"--interactive"}, "true"},
+		{"no input", []string{"--interactive", "--no-input"}, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("CI", tc.ci)
+			var stdout, stderr bytes.Buffer
+			if code := run(tc.args, &stdout, &stderr); code != 1 {
+				t.Errorf("Expected exit code 1, got %d", code)
+			}
+			if !strings.Contains(stderr.String(), "--interactive needs a terminal") {
+				t.Errorf("Unexpected stderr: %q", stderr.String())
+			}
+		})
+	}
+	if len(*requests) != 0 {
+		t.Errorf("Expected no searches, got %d", len(*requests))
+	}
+}
+
+func TestRunEndpoint(t *testing.T) {
+	var paths []string
+	serveHTTP(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.Host+r.URL.Path)
+		_, _ = io.WriteString(w, `{"meta": {"found": {"value": 1, "relation": "eq"}}, "hits": [{"id": "1", "title": "Quickstart", "url": "/en/actions/quickstart"}]}`)
+	}))
+	t.Cleanup(func() { searchdocs.DocsBaseURL = searchdocs.EndpointBaseURL(endpoint) })
+
+	var stdout, stderr bytes.Buffer
+	t.Setenv("GH_SEARCH_DOCS_ENDPOINT", "https://docs.example.com/api/search/v1")
+	if code := run([]string{"--format", "urls", "actions"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if len(paths) != 1 || !strings.HasSuffix(paths[0], "/api/search/v1") {
+		t.Errorf("Expected the endpoint's path to be requested, got %v", paths)
+	}
+	if stdout.String() != "https://docs.example.com/en/actions/quickstart\n" {
+		t.Errorf("Expected result URLs on the mirror, got %q", stdout.String())
+	}
+
+	// The flag wins over the environment
+	stdout.Reset()
+	if code := run([]string{"--endpoint", "https://mirror.example.com/search", "--format", "urls", "actions"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if !strings.HasSuffix(paths[1], "/search") || stdout.String() != "https://mirror.example.com/en/actions/quickstart\n" {
+		t.Errorf("Expected --endpoint to be used, got %v and %q", paths, stdout.String())
+	}
+
+	for _, value := range []string{"http://docs.example.com/api/search/v1", "docs.example.com"} {
+		stderr.Reset()
+		if code := run([]string{"--endpoint", value, "actions"}, &stdout, &stderr); code != 1 || !strings.Contains(stderr.String(), "invalid --endpoint") {
+			t.Errorf("%s: expected an invalid --endpoint error, got exit code %d (stderr: %q)", value, code, stderr.String())
+		}
+	}
+}
+
+func TestProcessSearch(t *testing.T) {
+	t.Setenv("GH_SEARCH_DOCS_NO_HISTORY", "1")
+	serveSearch(t, http.StatusOK, `{
+		"meta": {"found": {"value": 3}},
+		"hits": [
+			{"id": "1", "title": "A", "url": "/en/a", "breadcrumbs": "Pages"},
+			{"id": "2", "title": "B", "url": "/en/b", "breadcrumbs": "Actions"},
+			{"id": "3", "title": "C", "url": "/en/c", "breadcrumbs": "Actions"}
+		]
+	}`)
+
+	opts := &options{endpoint: endpoint, size: 1, language: "en", breadcrumbs: StringSlice{"Actions"}}
+	result, err := requestSearch(io.Discard, httpClient, opts, nil, "docs", "free-pro-team")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	search := processSearch(io.Discard, httpClient, opts, "docs", "free-pro-team", *result)
+	if len(search.result.Hits) != 1 || search.result.Hits[0].ID != "2" {
+		t.Errorf("Expected the filtered and trimmed hits, got %+v", search.result.Hits)
+	}
+	if len(search.suppressed) != 1 || search.suppressed[0].count != 1 {
+		t.Errorf("Expected the hit hidden by --breadcrumbs counted, got %+v", search.suppressed)
+	}
+
+	serveSearch(t, http.StatusInternalServerError, `{}`)
+	if _, err := requestSearch(io.Discard, httpClient, opts, nil, "docs", "free-pro-team"); err == nil {
+		t.Error("Expected an error for a failed search")
+	}
+}
+
+func TestRunTreeFormat(t *testing.T) {
+	serveSearch(t, http.StatusOK, `{
+		"meta": {"found": {"value": 3, "relation": "eq"}, "page": 1, "size": 5},
+		"hits": [
+			{"id": "1", "title": "Using secrets", "url": "/en/actions/secrets", "breadcrumbs": "Actions / Security"},
+			{"id": "2", "title": "Quickstart", "url": "/en/quickstart"},
+			{"id": "3", "title": "Hardening", "url": "/en/actions/hardening", "breadcrumbs": "Actions / Security"}
+		]
+	}`)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--format", "tree", "--no-anchors", "--relative-urls", "secrets"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	want := `secrets
+├── Actions
+│   └── Security
+│       ├── Using secrets — /en/actions/secrets
+│       └── Hardening — /en/actions/hardening
+└── Quickstart — /en/quickstart
+`
+	if stdout.String() != want {
+		t.Errorf("Unexpected tree:\n%s", stdout.String())
+	}
+
+	stdout.Reset()
+	if code := run([]string{"--format", "tree", "--no-unicode", "--no-anchors", "secrets"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "`-- Quickstart — https://docs.github.com/en/quickstart\n") || strings.Contains(stdout.String(), "└") {
+		t.Errorf("Expected an ASCII tree, got:\n%s", stdout.String())
+	}
+
+	stderr.Reset()
+	if code := run([]string{"--no-unicode", "secrets"}, &stdout, &stderr); code != 1 || !strings.Contains(stderr.String(), "--no-unicode can only be used with --format tree") {
+		t.Errorf("Expected --no-unicode to need --format tree, got %d (stderr: %q)", code, stderr.String())
+	}
+}
+
+func TestRunMarkdownFormat(t *testing.T) {
+	body := `{
+		"meta": {"found": {"value": 2, "relation": "eq"}, "page": 1, "size": 5},
+		"hits": [
+			{
+				"id": "1",
+				"title": "Managing [beta] secrets",
+				"url": "/en/actions/secrets",
+				"breadcrumbs": "Actions / Security",
+				"intro": "Store sensitive\nvalues safely.",
+				"highlights": {"title": ["Managing [beta] <mark>secrets</mark>"]}
+			},
+			{"id": "2", "title": "Webhooks", "url": "/en/webhooks"}
+		]
+	}`
+	serveSearch(t, http.StatusOK, body)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--format", "markdown", "--no-anchors", "--highlights", "title", "secrets"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+
+	expected := `## Search results for "secrets"
+
+1. [Managing \[beta\] **secrets**](https://docs.github.com/en/actions/secrets)
+
+   > Store sensitive values safely.
+
+   *Actions / Security*
+
+2. [Webhooks](https://docs.github.com/en/webhooks)
+`
+	if stdout.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, stdout.String())
+	}
+	if strings.Contains(stdout.String(), "\x1b[") {
+		t.Error("Expected no ANSI escape codes")
+	}
+}
+
+func TestRunHistory(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	requests := serveSearch(t, http.StatusOK, `{"meta": {"found": {"value": 1}}, "hits": [{"id": "1", "title": "A", "url": "/en/a"}]}`)
+
+	var stdout, stderr bytes.Buffer
+	run([]string{"--version", "enterprise-cloud", "--language", "ja", "ssh keys"}, &stdout, &stderr)
+	run([]string{"webhooks"}, &stdout, &stderr)
+
+	stdout.Reset()
+	if code := run([]string{"--history"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	output := stdout.String()
+	first, second := strings.Index(output, " 1. "), strings.Index(output, " 2. ")
+	if first < 0 || second < 0 || !strings.Contains(output[first:second], "webhooks") || !strings.Contains(output[second:], "ssh keys  [enterprise-cloud, ja]  1 results") {
+		t.Errorf("Expected newest searches first, got:\n%s", output)
+	}
+
+	stdout.Reset()
+	run([]string{"--history", "--history-limit", "1"}, &stdout, &stderr)
+	if strings.Contains(stdout.String(), "ssh keys") {
+		t.Errorf("Expected --history-limit 1 to show only the latest search, got:\n%s", stdout.String())
+	}
+
+	// Replaying the second search reuses its query, version, and language
+	if code := run([]string{"--replay", "2"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	params := (*requests)[len(*requests)-1]
+	if params.Get("query") != "ssh keys" || params.Get("version") != "enterprise-cloud" || params.Get("language") != "ja" {
+		t.Errorf("Unexpected replayed request: %v", params)
+	}
+
+	stderr.Reset()
+	if code := run([]string{"--replay", "9"}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1 replaying beyond the history, got %d", code)
+	}
+
+	stdout.Reset()
+	if code := run([]string{"--clear-history"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	stdout.Reset()
+	run([]string{"--history"}, &stdout, &stderr)
+	if !strings.Contains(stdout.String(), "No searches in history yet.") {
+		t.Errorf("Expected an empty history after clearing, got:\n%s", stdout.String())
+	}
+}
+
+func TestRunHTMLFormat(t *testing.T) {
+	body := `{
+		"meta": {"found": {"value": 12, "relation": "eq"}, "page": 1, "size": 5},
+		"hits": [
+			{
+				"id": "1",
+				"title": "Managing <script>alert(1)</script> secrets",
+				"url": "/en/actions/secrets",
+				"breadcrumbs": "Actions / Security",
+				"intro": "Store \"sensitive\" values & tokens.",
+				"highlights": {"content": ["Use <mark>secrets</mark> for <b>tokens</b> in &lt;script&gt;"]}
+			}
+		]
+	}`
+
+	t.Run("fragment", func(t *testing.T) {
+		serveSearch(t, http.StatusOK, body)
+
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"--format", "html", "--no-anchors", "secrets <b>"}, &stdout, &stderr); code != 0 {
+			t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+		}
+		output := stdout.String()
+		for _, expected := range []string{
+			"<h2>1 of 12 results for &ldquo;secrets &lt;b&gt;&rdquo;</h2>",
+			`<a href="https://docs.github.com/en/actions/secrets">Managing &lt;script&gt;alert(1)&lt;/script&gt; secrets</a>`,
+			`<p class="breadcrumbs">Actions / Security</p>`,
+			"<p>Store &#34;sensitive&#34; values &amp; tokens.</p>",
+			// Tags in snippets are stripped, and escaped text stays escaped
+			"<blockquote>Use <mark>secrets</mark> for tokens in &lt;script&gt;</blockquote>",
+		} {
+			if !strings.Contains(output, expected) {
+				t.Errorf("Expected %q in:\n%s", expected, output)
+			}
+		}
+		if strings.Contains(output, "<html") {
+			t.Error("Expected a fragment without --html-full-page")
+		}
+	})
+
+	t.Run("full page", func(t *testing.T) {
+		serveSearch(t, http.StatusOK, body)
+
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"--format", "html", "--html-full-page", "secrets"}, &stdout, &stderr); code != 0 {
+			t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+		}
+		output := stdout.String()
+		if !strings.HasPrefix(output, "<!DOCTYPE html>") || !strings.Contains(output, "<style>") || !strings.Contains(output, `<section class="gh-search-docs">`) {
+			t.Errorf("Expected a standalone page, got:\n%s", output)
+		}
+
+		doc, err := html.Parse(strings.NewReader(output))
+		if err != nil {
+			t.Fatalf("Expected the page to parse, got %v", err)
+		}
+		meta := map[string]string{}
+		var walk func(n *html.Node)
+		walk = func(n *html.Node) {
+			if n.Type == html.ElementNode && n.Data == "meta" {
+				var name, content string
+				for _, attr := range n.Attr {
+					switch attr.Key {
+					case "name":
+						name = attr.Val
+					case "content":
+						content = attr.Val
+					}
+				}
+				meta[name] = content
+			}
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				walk(c)
+			}
+		}
+		walk(doc)
+		if meta["generator"] != "gh-search-docs" || meta["gh-search-docs:query"] != "secrets" || meta["gh-search-docs:version"] != "free-pro-team" {
+			t.Errorf("Expected the search in the head, got %v", meta)
+		}
+		if _, err := time.Parse(time.RFC3339, meta["date"]); err != nil {
+			t.Errorf("Expected an RFC 3339 date, got %q", meta["date"])
+		}
+	})
+
+	t.Run("full page without html", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"--html-full-page", "secrets"}, &stdout, &stderr); code != 1 {
+			t.Errorf("Expected exit code 1, got %d", code)
+		}
+	})
+}
+
+func TestRunBookmarks(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	serveSearch(t, http.StatusOK, `{
+		"meta": {"found": {"value": 2, "relation": "eq"}, "page": 1, "size": 5},
+		"hits": [
+			{"id": "1", "title": "First", "url": "/en/first"},
+			{"id": "2", "title": "Second", "url": "/en/second"}
+		]
+	}`)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--bookmark-n", "2", "--no-anchors", "docs"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	id := searchdocs.BookmarkID("https://docs.github.com/en/second")
+	if !strings.Contains(stderr.String(), "Bookmarked Second (ID "+id+")") {
+		t.Errorf("Expected a confirmation on stderr, got %q", stderr.String())
+	}
+	if strings.Contains(stdout.String(), "Bookmarked") {
+		t.Error("Expected stdout to hold only the results")
+	}
+
+	// Bookmarking the same result again keeps a single bookmark
+	run([]string{"--bookmark-n", "2", "--no-anchors", "docs"}, &stdout, &stderr)
+	stdout.Reset()
+	if code := run([]string{"--bookmarks"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if strings.Count(stdout.String(), id) != 1 || !strings.Contains(stdout.String(), "https://docs.github.com/en/second") {
+		t.Errorf("Expected one bookmark listed, got:\n%s", stdout.String())
+	}
+
+	entries, _ := searchdocs.LoadHistory()
+	bookmarked := 0
+	for _, entry := range entries {
+		if entry.Action == searchdocs.HistoryBookmark && entry.URL == "/en/second" {
+			bookmarked++
+		}
+	}
+	if bookmarked != 2 {
+		t.Errorf("Expected bookmarks to be recorded in the history for stats, got %d", bookmarked)
+	}
+
+	stdout.Reset()
+	if code := run([]string{"--remove-bookmark", id}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	stderr.Reset()
+	if code := run([]string{"--remove-bookmark", id}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1 removing a missing bookmark, got %d", code)
+	}
+
+	if code := run([]string{"--bookmark-n", "3", "docs"}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1 bookmarking beyond the results, got %d", code)
+	}
+}
+
+func TestRunOutput(t *testing.T) {
+	body := `{
+		"meta": {"found": {"value": 1, "relation": "eq"}, "page": 1, "size": 5},
+		"hits": [{"id": "1", "title": "Managing secrets", "url": "/en/actions/secrets", "intro": "Store values."}]
+	}`
+
+	t.Run("markdown file", func(t *testing.T) {
+		serveSearch(t, http.StatusOK, body)
+		path := filepath.Join(t.TempDir(), "results.md")
+
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"--output", path, "--plain", "--no-anchors", "secrets"}, &stdout, &stderr); code != 0 {
+			t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "# Results for \"secrets\"\n\n## 1. Managing secrets\n\n[https://docs.github.com/en/actions/secrets](https://docs.github.com/en/actions/secrets)\n\nStore values.\n"
+		if string(data) != want {
+			t.Errorf("Expected a Markdown report in the file, got:\n%s", data)
+		}
+		if !strings.Contains(stdout.String(), "1. Managing secrets\n") {
+			t.Errorf("Expected the regular display on stdout, got:\n%s", stdout.String())
+		}
+	})
+
+	t.Run("json file", func(t *testing.T) {
+		serveSearch(t, http.StatusOK, body)
+		path := filepath.Join(t.TempDir(), "results.json")
+
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"--output", path, "--format", "json", "--plain", "secrets"}, &stdout, &stderr); code != 0 {
+			t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var result SearchResult
+		if err := json.Unmarshal(data, &result); err != nil || len(result.Hits) != 1 {
+			t.Errorf("Expected JSON in the file, got %s (%v)", data, err)
+		}
+		if strings.HasPrefix(stdout.String(), "{") || !strings.Contains(stdout.String(), "Managing secrets") {
+			t.Errorf("Expected the regular display on stdout, got:\n%s", stdout.String())
+		}
+	})
+
+	t.Run("stdout", func(t *testing.T) {
+		serveSearch(t, http.StatusOK, body)
+
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"--output", "-", "--no-anchors", "secrets"}, &stdout, &stderr); code != 0 {
+			t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+		}
+		if !strings.HasPrefix(stdout.String(), "# Results for") || strings.Count(stdout.String(), "Managing secrets") != 1 {
+			t.Errorf("Expected only the Markdown report on stdout, got:\n%s", stdout.String())
+		}
+	})
+
+	t.Run("unwritable", func(t *testing.T) {
+		serveSearch(t, http.StatusOK, body)
+
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"--output", filepath.Join(t.TempDir(), "missing", "results.md"), "secrets"}, &stdout, &stderr); code != 1 {
+			t.Errorf("Expected exit code 1, got %d", code)
+		}
+	})
+}
+
+func TestRunTemplate(t *testing.T) {
+	body := `{
+		"meta": {"found": {"value": 7, "relation": "eq"}, "page": 1, "size": 5},
+		"hits": [
+			{"id": "1", "title": "Managing secrets", "url": "/en/actions/secrets", "intro": "Store sensitive values for workflows.", "highlights": {"title": ["Managing <mark>secrets</mark>"]}},
+			{"id": "2", "title": "Webhooks", "url": "/en/webhooks"}
+		]
+	}`
+
+	tests := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{"fields", `{{.Title}}\t{{.URL}}`, "Managing secrets\t/en/actions/secrets\nWebhooks\t/en/webhooks\n"},
+		{"helpers", `{{fullurl .}} {{truncate 10 .Intro}}`, "https://docs.github.com/en/actions/secrets Store sen…\nhttps://docs.github.com/en/webhooks \n"},
+		{"meta and highlights", `{{(meta).Found.Value}} {{with index .Highlights "title"}}{{stripmarks (index . 0)}}{{else}}-{{end}}`, "7 Managing secrets\n7 -\n"},
+		{"string helpers", `{{upper .Title}} {{urlEncode .Title}}`, "MANAGING SECRETS Managing+secrets\nWEBHOOKS Webhooks\n"},
+		{"whole search", `{{.Query}} ({{.Meta.Found.Value}}):\n{{range .Hits}}{{.URL}}\n{{end}}`, "secrets (7):\n/en/actions/secrets\n/en/webhooks\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serveSearch(t, http.StatusOK, body)
+
+			var stdout, stderr bytes.Buffer
+			if code := run([]string{"--template", tt.template, "--no-anchors", "secrets"}, &stdout, &stderr); code != 0 {
+				t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+			}
+			if stdout.String() != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, stdout.String())
+			}
+		})
+	}
+
+	t.Run("parse error before searching", func(t *testing.T) {
+		requests := serveSearch(t, http.StatusOK, body)
+
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"--template", "{{.Title", "secrets"}, &stdout, &stderr); code != 1 {
+			t.Errorf("Expected exit code 1, got %d", code)
+		}
+		if !strings.Contains(stderr.String(), "invalid --template") || len(*requests) != 0 {
+			t.Errorf("Expected a parse error without a request, got %q and %d requests", stderr.String(), len(*requests))
+		}
+	})
+
+	t.Run("error line number", func(t *testing.T) {
+		serveSearch(t, http.StatusOK, body)
+
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"--template", `{{range .Hits}}\n{{.Nope}}{{end}}`, "secrets"}, &stdout, &stderr); code != 1 {
+			t.Errorf("Expected exit code 1, got %d", code)
+		}
+		if !strings.Contains(stderr.String(), "template:2:") {
+			t.Errorf("Expected the error to name line 2, got %q", stderr.String())
+		}
+	})
+}
+
+func TestRunJQ(t *testing.T) {
+	body := `{
+		"meta": {"found": {"value": 2, "relation": "eq"}, "page": 1, "size": 5},
+		"hits": [
+			{"id": "1", "title": "Managing secrets", "url": "/en/actions/secrets"},
+			{"id": "2", "title": "Webhooks", "url": "/en/webhooks"}
+		]
+	}`
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{"strings are printed as is", []string{"--jq", ".hits[].title"}, "Managing secrets\nWebhooks\n"},
+		{"other values are compact JSON", []string{"--jq", "{total: .meta.found.value, urls: [.hits[].url]}"}, `{"total":2,"urls":["/en/actions/secrets","/en/webhooks"]}` + "\n"},
+		{"overrides --format", []string{"--format", "csv", "--jq", ".hits | length"}, "2\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serveSearch(t, http.StatusOK, body)
+
+			var stdout, stderr bytes.Buffer
+			args := append(tt.args, "--no-anchors", "secrets")
+			if code := run(args, &stdout, &stderr); code != 0 {
+				t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+			}
+			if stdout.String() != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, stdout.String())
+			}
+		})
+	}
+
+	t.Run("syntax error before searching", func(t *testing.T) {
+		requests := serveSearch(t, http.StatusOK, body)
+
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"--jq", ".hits[] | .title)", "secrets"}, &stdout, &stderr); code != 1 {
+			t.Errorf("Expected exit code 1, got %d", code)
+		}
+		expected := "Error: invalid --jq expression: unexpected token \")\" at position 17\n"
+		if stderr.String() != expected || len(*requests) != 0 {
+			t.Errorf("Expected %q without a request, got %q and %d requests", expected, stderr.String(), len(*requests))
+		}
+	})
+
+	t.Run("runtime error", func(t *testing.T) {
+		serveSearch(t, http.StatusOK, body)
+
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"--jq", ".hits[].title | error", "secrets"}, &stdout, &stderr); code != 1 {
+			t.Errorf("Expected exit code 1, got %d", code)
+		}
+		if !strings.Contains(stderr.String(), "--jq: ") {
+			t.Errorf("Expected a --jq error, got %q", stderr.String())
+		}
+	})
+}
+
+func TestRunCount(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		args     []string
+		expected string
+		code     int
+	}{
+		{"exact", `{"meta": {"found": {"value": 42, "relation": "eq"}, "page": 1, "size": 5}, "hits": [{"id": "1", "title": "First", "url": "/en/first"}]}`, nil, "42\n", 0},
+		{"lower bound", `{"meta": {"found": {"value": 5000, "relation": "gte"}, "page": 1, "size": 5}, "hits": [{"id": "1", "title": "First", "url": "/en/first"}]}`, nil, "5000+\n", 0},
+		{"beyond the last page", `{"meta": {"found": {"value": 3, "relation": "eq"}, "page": 9, "size": 5}, "hits": []}`, []string{"--page", "9"}, "3\n", 0},
+		{"none", `{"meta": {"found": {"value": 0, "relation": "eq"}, "page": 1, "size": 5}, "hits": []}`, nil, "0\n", 1},
+		{"none with --fail-on-empty", `{"meta": {"found": {"value": 0, "relation": "eq"}, "page": 1, "size": 5}, "hits": []}`, []string{"--fail-on-empty"}, "0\n", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := serveSearch(t, http.StatusOK, tt.body)
+
+			var stdout, stderr bytes.Buffer
+			if code := run(append(tt.args, "--count", "actions"), &stdout, &stderr); code != tt.code {
+				t.Fatalf("Expected exit code %d, got %d (stderr: %s)", tt.code, code, stderr.String())
+			}
+			if stdout.String() != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, stdout.String())
+			}
+			if size := (*requests)[0].Get("size"); size != "1" {
+				t.Errorf("Expected only one hit requested, got size=%s", size)
+			}
+		})
+	}
+
+	// Only the count is printed, so the one hit fetched isn't checked, and history records the
+	// count found
+	t.Run("skips processing", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("XDG_DATA_HOME", dir)
+		var probes int
+		serveHTTP(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodHead {
+				probes++
+				return
+			}
+			_, _ = io.WriteString(w, `{"meta": {"found": {"value": 42, "relation": "eq"}}, "hits": [{"id": "1", "title": "First", "url": "/en/first"}]}`)
+		}))
+
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"--count", "--check-translations", "ja", "--check-availability", "actions"}, &stdout, &stderr); code != 0 {
+			t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+		}
+		if probes != 0 || stdout.String() != "42\n" {
+			t.Errorf("Expected only the count and no checks, got %d probes and %q", probes, stdout.String())
+		}
+		history, err := os.ReadFile(filepath.Join(dir, "gh-search-docs", "history.jsonl"))
+		if err != nil || !strings.Contains(string(history), `"resultCount":42`) {
+			t.Errorf("Expected the count found in history, got %q (%v)", history, err)
+		}
+	})
+
+	t.Run("with format", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"--count", "--format", "json", "actions"}, &stdout, &stderr); code != 1 {
+			t.Errorf("Expected exit code 1, got %d", code)
+		}
+	})
+}
+
+func TestRunOpen(t *testing.T) {
+	body := `{
+		"meta": {"found": {"value": 2, "relation": "eq"}, "page": 1, "size": 5},
+		"hits": [
+			{"id": "1", "title": "First", "url": "/en/first"},
+			{"id": "2", "title": "Second", "url": "/en/second"}
+		]
+	}`
+	var opened []string
+	original := openURL
+	openURL = func(target string) error {
+		opened = append(opened, target)
+		return nil
+	}
+	t.Cleanup(func() { openURL = original })
+
+	tests := []struct {
+		name     string
+		args     []string
+		code     int
+		expected []string
+	}{
+		{"first", []string{"--open"}, 0, []string{"https://docs.github.com/en/first"}},
+		{"nth", []string{"--open-n", "2"}, 0, []string{"https://docs.github.com/en/second"}},
+		{"with json", []string{"--open", "--format", "json"}, 0, []string{"https://docs.github.com/en/first"}},
+		{"beyond results", []string{"--open-n", "3"}, 1, nil},
+		{"zero", []string{"--open-n", "0"}, 1, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opened = nil
+			serveSearch(t, http.StatusOK, body)
+
+			var stdout, stderr bytes.Buffer
+			if code := run(append(tt.args, "--no-anchors", "docs"), &stdout, &stderr); code != tt.code {
+				t.Fatalf("Expected exit code %d, got %d (stderr: %s)", tt.code, code, stderr.String())
+			}
+			if !reflect.DeepEqual(opened, tt.expected) {
+				t.Errorf("Opened %v, want %v", opened, tt.expected)
+			}
+			if tt.code == 0 && !strings.Contains(stdout.String(), "First") {
+				t.Errorf("Expected the results to be shown before opening, got %q", stdout.String())
+			}
+		})
+	}
+}
+
+func TestRunCheckTranslations(t *testing.T) {
+	body := `{
+		"meta": {"found": {"value": 2, "relation": "eq"}, "page": 1, "size": 5},
+		"hits": [
+			{"id": "1", "title": "Quickstart", "url": "/en/actions/quickstart"},
+			{"id": "2", "title": "New feature", "url": "/en/actions/new-feature"},
+			{"id": "3", "title": "Flaky page", "url": "/en/actions/flaky"}
+		]
+	}`
+	serveHTTP(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/search/v1":
+			_, _ = io.WriteString(w, body)
+		case r.URL.Path == "/ja/actions/quickstart":
+			w.WriteHeader(http.StatusOK)
+		case r.URL.Path == "/ja/actions/flaky":
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			http.Redirect(w, r, "/en"+r.URL.Path[3:], http.StatusFound)
+		}
+	}))
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--plain", "--check-translations", "ja", "actions"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+
+	output := stdout.String()
+	// A failed check is unknown, not a claim that the page is untranslated
+	for _, want := range []string{"ja: ✓", "ja: ✗ (English only)", "ja: ?", "Translation coverage: ja 1/2 (1 unknown)"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q in output, got:\n%s", want, output)
+		}
+	}
+
+	stdout.Reset()
+	if code := run([]string{"--format", "json", "--check-translations", "ja", "actions"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	var result SearchResult
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v", err)
+	}
+	if !result.Hits[0].Translations["ja"] || result.Hits[1].Translations["ja"] {
+		t.Errorf("Unexpected per-hit translations in JSON: %v, %v", result.Hits[0].Translations, result.Hits[1].Translations)
+	}
+	if _, known := result.Hits[2].Translations["ja"]; known {
+		t.Errorf("Expected the failed check to be left out of JSON, got %v", result.Hits[2].Translations)
+	}
+}
+
+func TestAvailabilityBadgesUnknown(t *testing.T) {
+	item := SearchItem{Availability: map[string]bool{"FPT": true, "GHEC": false}}
+	if got, want := availabilityBadges(item), "[FPT ✓ GHEC ✗ GHES ?]"; got != want {
+		t.Errorf("availabilityBadges() = %q, want %q", got, want)
+	}
+}
+
+func TestRunDeduplicate(t *testing.T) {
+	serveSearch(t, http.StatusOK, `{
+		"meta": {"found": {"value": 4, "relation": "eq"}, "page": 1, "size": 5},
+		"hits": [
+			{"id": "1", "title": "Managing secrets", "url": "/en/actions/secrets"},
+			{"id": "2", "title": "Webhooks", "url": "/en/webhooks"},
+			{"id": "3", "title": "Managing secrets", "url": "/en/Actions/Secrets"},
+			{"id": "2", "title": "Webhooks again", "url": "/en/webhooks-again"}
+		]
+	}`)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--deduplicate", "--verbose", "--format", "urls", "--no-anchors", "secrets"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	expected := "https://docs.github.com/en/actions/secrets\nhttps://docs.github.com/en/webhooks\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected the first result for each page, got %q", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "Removed 2 duplicate results") {
+		t.Errorf("Expected the number removed with --verbose, got %q", stderr.String())
+	}
+
+	// The count is only reported with --verbose
+	stderr.Reset()
+	run([]string{"--deduplicate", "--format", "urls", "--no-anchors", "secrets"}, &stdout, &stderr)
+	if strings.Contains(stderr.String(), "duplicate") {
+		t.Errorf("Expected no count without --verbose, got %q", stderr.String())
+	}
+
+	// Without --deduplicate every result is kept
+	stdout.Reset()
+	stderr.Reset()
+	if code := run([]string{"--format", "urls", "--no-anchors", "secrets"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d", code)
+	}
+	if strings.Count(stdout.String(), "\n") != 4 || strings.Contains(stderr.String(), "duplicate") {
+		t.Errorf("Expected all 4 results, got %q (stderr: %q)", stdout.String(), stderr.String())
+	}
+}
+
+func TestRunCheckAvailability(t *testing.T) {
+	body := `{
+		"meta": {"found": {"value": 1, "relation": "eq"}, "page": 1, "size": 5},
+		"hits": [{"id": "1", "title": "Copilot overview", "url": "/en/copilot/overview"}]
+	}`
+	serveHTTP(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/search/v1":
+			_, _ = io.WriteString(w, body)
+		case strings.HasPrefix(r.URL.Path, "/en/enterprise-cloud@latest/"):
+			w.WriteHeader(http.StatusOK)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--plain", "--check-availability", "copilot"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "[FPT ✓ GHEC ✓ GHES ✗]") {
+		t.Errorf("Expected availability badges in output, got:\n%s", stdout.String())
+	}
+
+	stdout.Reset()
+	if code := run([]string{"--format", "json", "--check-availability", "copilot"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	var result SearchResult
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v", err)
+	}
+	expected := map[string]bool{"FPT": true, "GHEC": true, "GHES": false}
+	if !reflect.DeepEqual(result.Hits[0].Availability, expected) {
+		t.Errorf("Availability = %v, want %v", result.Hits[0].Availability, expected)
+	}
+}
+
+// peakTransport forwards requests to next and records the most requests it has seen in
+// flight at once. A request is in flight until its response body is closed.
+type peakTransport struct {
+	next     http.RoundTripper
+	inFlight atomic.Int32
+	peak     atomic.Int32
+}
+
+func (t *peakTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	n := t.inFlight.Add(1)
+	for {
+		peak := t.peak.Load()
+		if n <= peak || t.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		t.inFlight.Add(-1)
+		return nil, err
+	}
+	resp.Body = peakBody{ReadCloser: resp.Body, done: func() { t.inFlight.Add(-1) }}
+	return resp, nil
+}
+
+type peakBody struct {
+	io.ReadCloser
+	done func()
+}
+
+func (b peakBody) Close() error {
+	b.done()
+	return b.ReadCloser.Close()
+}
+
+func TestRunConcurrency(t *testing.T) {
+	var hits []string
+	for i := range 10 {
+		hits = append(hits, fmt.Sprintf(`{"id": "%d", "title": "Page %d", "url": "/en/page-%d"}`, i, i, i))
+	}
+	body := `{"meta": {"found": {"value": 10, "relation": "eq"}, "page": 1, "size": 10}, "hits": [` + strings.Join(hits, ",") + `]}`
+	serveHTTP(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/search/v1" {
+			_, _ = io.WriteString(w, body)
+			return
+		}
+		time.Sleep(2 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	counter := &peakTransport{next: httpClient.Transport}
+	httpClient = &http.Client{Transport: counter}
+
+	// Translation and availability checks fan out at the same time but share one limit
+	for _, limit := range []int{1, 3} {
+		counter.peak.Store(0)
+		var stdout, stderr bytes.Buffer
+		args := []string{"--plain", "--size", "10", "--concurrency", strconv.Itoa(limit), "--check-translations", "ja,ko", "--check-availability", "pages"}
+		if code := run(args, &stdout, &stderr); code != 0 {
+			t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+		}
+		if peak := counter.peak.Load(); peak > int32(limit) {
+			t.Errorf("--concurrency %d: peak of %d requests in flight", limit, peak)
+		}
+	}
+
+	for _, value := range []string{"0", "17"} {
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"--concurrency", value, "pages"}, &stdout, &stderr); code != 1 {
+			t.Errorf("--concurrency %s: expected exit code 1, got %d", value, code)
+		}
+		if !strings.Contains(stderr.String(), "--concurrency must be between 1 and 16") {
+			t.Errorf("--concurrency %s: unexpected stderr: %s", value, stderr.String())
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--explain", "--concurrency", "8", "pages"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "explain: concurrency: at most 8 requests in flight") {
+		t.Errorf("Expected concurrency in --explain output, got:\n%s", stderr.String())
+	}
+}
+
+func TestRunWatch(t *testing.T) {
+	var requests int
+	serveHTTP(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = fmt.Fprintf(w, `{"meta": {"found": {"value": 1, "relation": "eq"}}, "hits": [{"title": "Search %d", "url": "/en/search"}]}`, requests)
+	}))
+
+	oldContext := searchdocs.WatchContext
+	searchdocs.WatchContext = func() (context.Context, context.CancelFunc) {
+		return context.WithTimeout(context.Background(), 50*time.Millisecond)
+	}
+	t.Cleanup(func() { searchdocs.WatchContext = oldContext })
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--watch", "10ms", "--plain", "search"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if requests < 2 {
+		t.Fatalf("Expected the search to be repeated, got %d requests", requests)
+	}
+	output := stdout.String()
+	for _, want := range []string{"1. Search 1\n", searchdocs.ClearScreen + "Found 1 results\n1. Search 2\n", "Refreshing in 10ms... (Ctrl+C to stop)\n"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q in output, got %q", want, output)
+		}
+	}
+
+	// Each refresh is checked and filtered like a single search
+	var probes int
+	serveHTTP(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			probes++
+			return
+		}
+		_, _ = io.WriteString(w, `{"meta": {"found": {"value": 2, "relation": "eq"}}, "hits": [{"title": "Kept", "url": "/en/kept", "breadcrumbs": "Actions"}, {"title": "Hidden", "url": "/en/hidden", "breadcrumbs": "Pages"}]}`)
+	}))
+	stdout.Reset()
+	if code := run([]string{"--watch", "10ms", "--plain", "--check-translations", "ja", "--filter-breadcrumb", "Actions", "search"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if probes == 0 || !strings.Contains(stdout.String(), "ja: ✓") {
+		t.Errorf("Expected the translations checked on refresh, got %d probes and %q", probes, stdout.String())
+	}
+	if !strings.Contains(stdout.String(), "Hidden by client-side filters") || strings.Contains(stdout.String(), "1. Hidden") {
+		t.Errorf("Expected the filtered hit hidden and counted, got %q", stdout.String())
+	}
+
+	for _, args := range [][]string{{"--watch", "-1s"}, {"--watch", "1s", "--format", "json"}, {"--watch", "1s", "--open"}} {
+		stderr.Reset()
+		if code := run(append(args, "search"), &stdout, &stderr); code != 1 {
+			t.Errorf("%v: expected exit code 1, got %d", args, code)
+		}
+		if !strings.Contains(stderr.String(), "--watch") {
+			t.Errorf("%v: expected a --watch error, got %q", args, stderr.String())
+		}
+	}
+}
+
+func TestRunWatchPagerAndHistory(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dir)
+	requests := serveSearch(t, http.StatusOK, layoutFixture)
+	withTerminalWidth(t, 80)
+	t.Setenv("GH_PAGER", "less -FRX")
+	paged := 0
+	oldHeight, oldPager, oldContext := terminalHeight, runPager, searchdocs.WatchContext
+	terminalHeight = func() int { return 3 }
+	runPager = func(string, func(io.Writer)) error {
+		paged++
+		return nil
+	}
+	searchdocs.WatchContext = func() (context.Context, context.CancelFunc) {
+		return context.WithTimeout(context.Background(), 50*time.Millisecond)
+	}
+	t.Cleanup(func() { terminalHeight, runPager, searchdocs.WatchContext = oldHeight, oldPager, oldContext })
+
+	// Results taller than the terminal are still drawn in place, since a pager would stop
+	// the refreshes
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--watch", "10ms", "--plain", "tokens"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if paged != 0 || !strings.Contains(stdout.String(), "Quickstart for GitHub Copilot") {
+		t.Errorf("Expected the results written without a pager, paged %d times, got %q", paged, stdout.String())
+	}
+
+	// However often it refreshes, the watched search is one search in history
+	if len(*requests) < 2 {
+		t.Fatalf("Expected the search to be repeated, got %d requests", len(*requests))
+	}
+	history, err := os.ReadFile(filepath.Join(dir, "gh-search-docs", "history.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(history), `"query":"tokens"`); n != 1 {
+		t.Errorf("Expected one history entry for the watched search, got %d:\n%s", n, history)
+	}
+}
+
+func TestRunLanguageFromLocale(t *testing.T) {
+	var language string
+	serveHTTP(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		language = r.URL.Query().Get("language")
+		_, _ = io.WriteString(w, `{"meta": {"found": {"value": 0, "relation": "eq"}}, "hits": []}`)
+	}))
+
+	for _, tt := range []struct {
+		lang string
+		args []string
+		want string
+	}{
+		{"pt_BR.UTF-8", nil, "pt"},
+		{"nl_NL.UTF-8", nil, "en"},
+		{"pt_BR.UTF-8", []string{"--language", "ja"}, "ja"},
+	} {
+		t.Setenv("LANG", tt.lang)
+		var stdout, stderr bytes.Buffer
+		run(append(tt.args, "--plain", "actions"), &stdout, &stderr)
+		if language != tt.want {
+			t.Errorf("LANG=%s %v: searched language %q, want %q", tt.lang, tt.args, language, tt.want)
+		}
+	}
+}
+
+func TestRunQueryFromStdin(t *testing.T) {
+	var query string
+	serveHTTP(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query().Get("query")
+		_, _ = io.WriteString(w, `{"meta": {"found": {"value": 0, "relation": "eq"}}, "hits": []}`)
+	}))
+
+	oldStdin, oldIsTerminal := stdin, stdinIsTerminal
+	stdin = strings.NewReader("creating pull requests\n")
+	stdinIsTerminal = func() bool { return false }
+	t.Cleanup(func() { stdin, stdinIsTerminal = oldStdin, oldIsTerminal })
+
+	var stdout, stderr bytes.Buffer
+	run([]string{"--plain"}, &stdout, &stderr)
+	if query != "creating pull requests" {
+		t.Errorf("Expected the piped query to be searched, got %q (stderr: %s)", query, stderr.String())
+	}
+
+	// Empty input still shows the usage
+	stdin = strings.NewReader("")
+	stderr.Reset()
+	if code := run(nil, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1 without a query, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "usage:") {
+		t.Errorf("Expected the usage, got %q", stderr.String())
+	}
+}
+
+// withStdin makes run read prompt answers from input, as if stdin were a terminal
+func withStdin(t *testing.T, input io.Reader) {
+	t.Helper()
+	t.Setenv("CI", "")
+
+	oldStdin, oldIsTerminal := stdin, stdinIsTerminal
+	stdin = input
+	stdinIsTerminal = func() bool { return true }
+	t.Cleanup(func() { stdin, stdinIsTerminal = oldStdin, oldIsTerminal })
+}
+
+// unreadable fails the test if anything reads from it
+type unreadable struct {
+	t *testing.T
+}
+
+func (r unreadable) Read([]byte) (int, error) {
+	r.t.Error("Unexpected read from stdin")
+	return 0, io.EOF
+}
+
+func TestRunWithoutQueryOnTerminal(t *testing.T) {
+	requests := serveSearch(t, http.StatusOK, `{"meta": {"found": {"value": 0, "relation": "eq"}, "page": 1, "size": 5}, "hits": []}`)
+
+	// Without a query, a terminal on stdin gets the usage rather than a prompt, with or
+	// without --no-input, and stdin is never read
+	for _, args := range [][]string{{"--plain"}, {"--plain", "--no-input"}} {
+		withStdin(t, unreadable{t: t})
+		var stdout, stderr bytes.Buffer
+		if code := run(args, &stdout, &stderr); code != 1 {
+			t.Errorf("%v: expected exit code 1, got %d", args, code)
+		}
+		if strings.Contains(stderr.String(), "Search GitHub Docs:") || !strings.Contains(stderr.String(), "usage:") {
+			t.Errorf("%v: expected usage without a prompt, got %q", args, stderr.String())
+		}
+	}
+	if len(*requests) != 0 {
+		t.Errorf("Expected no searches, got %v", *requests)
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value    string
+		expected time.Time
+		wantErr  bool
+	}{
+		{"7d", now.AddDate(0, 0, -7), false},
+		{"2w", now.AddDate(0, 0, -14), false},
+		{"48h", now.Add(-48 * time.Hour), false},
+		{"2026-10-01", time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), false},
+		{"yesterday", time.Time{}, true},
+		{"-3d", time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseSince(tt.value, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSince(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !got.Equal(tt.expected) {
+				t.Errorf("parseSince(%q) = %v, want %v", tt.value, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRunStats(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dir)
+	history := `{"query":"ssh key","version":"free-pro-team","language":"en","timestamp":"2026-10-05T10:00:00Z","resultCount":12}
+{"query":"missing thing","version":"free-pro-team","language":"en","timestamp":"2026-10-06T10:00:00Z","resultCount":0}
+`
+	if err := os.MkdirAll(filepath.Join(dir, "gh-search-docs"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "gh-search-docs", "history.jsonl"), []byte(history), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	requests := serveSearch(t, http.StatusOK, `{}`)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"stats"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	for _, want := range []string{"Searches: 2", "Zero-result searches: 1 (50.0%)", "Top queries", "ssh key"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("Expected %q in output, got:\n%s", want, stdout.String())
+		}
+	}
+
+	stdout.Reset()
+	if code := run([]string{"stats", "--format", "json"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	var stats searchdocs.HistoryStats
+	if err := json.Unmarshal(stdout.Bytes(), &stats); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v", err)
+	}
+	if stats.Searches != 2 {
+		t.Errorf("Expected 2 searches in JSON output, got %d", stats.Searches)
+	}
+
+	if len(*requests) != 0 {
+		t.Errorf("Expected stats to make no network requests, got %d", len(*requests))
+	}
+}
+
+func TestRunRecordsHistory(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	serveSearch(t, http.StatusOK, `{
+		"meta": {"found": {"value": 1, "relation": "eq"}, "page": 1, "size": 5},
+		"hits": [{"id": "1", "title": "About SSH", "url": "/en/authentication/about-ssh"}]
+	}`)
+
+	var stdout, stderr bytes.Buffer
+	for _, args := range [][]string{
+		{"--plain", "ssh key"},
+		{"--plain", "--version", "enterprise-cloud", "--heading", "nothing matches", "ssh key"},
+	} {
+		if code := run(args, &stdout, &stderr); code != 0 {
+			t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+		}
+	}
+	t.Setenv("GH_SEARCH_DOCS_NO_HISTORY", "1")
+	run([]string{"--plain", "private"}, &stdout, &stderr)
+
+	stdout.Reset()
+	if code := run([]string{"stats", "--format", "json"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	var stats searchdocs.HistoryStats
+	if err := json.Unmarshal(stdout.Bytes(), &stats); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v", err)
+	}
+	if stats.Searches != 2 || stats.ZeroResults != 1 {
+		t.Errorf("Expected 2 recorded searches, 1 with no results shown; got %+v", stats)
+	}
+	if !reflect.DeepEqual(stats.TopQueries, []searchdocs.Count{{Key: "ssh key", Count: 2}}) {
+		t.Errorf("TopQueries = %v", stats.TopQueries)
+	}
+	if strings.Contains(stdout.String(), `"since"`) {
+		t.Errorf("Expected no since without --since, got:\n%s", stdout.String())
+	}
+}
+
+func TestRunStatsRejectsArguments(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"stats", "api"}, &stdout, &stderr); code != 2 {
+		t.Errorf("Expected exit code 2, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), `unexpected argument "api"`) || !strings.Contains(stderr.String(), "usage: gh search-docs stats") {
+		t.Errorf("Expected a usage error, got:\n%s", stderr.String())
+	}
+}
+
+func TestRunNormalizesQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+		explain  bool
+	}{
+		{"normalized", []string{"--explain", "“pull\u00a0request”\u2014draft"}, `"pull request"-draft`, true},
+		{"no-normalize", []string{"--no-normalize", "“pull request”"}, "“pull request”", false},
+		{"already plain", []string{"--explain", "pull request"}, "pull request", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := serveSearch(t, http.StatusOK, `{"meta": {"found": {"value": 0}, "size": 5}, "hits": []}`)
+
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, &stdout, &stderr); code != 0 {
+				t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+			}
+
+			if got := (*requests)[0].Get("query"); got != tt.expected {
+				t.Errorf("Expected query %q to be sent, got %q", tt.expected, got)
+			}
+			if got := strings.Contains(stderr.String(), "normalized query from"); got != tt.explain {
+				t.Errorf("Expected normalization explained = %v, got stderr:\n%s", tt.explain, stderr.String())
+			}
+		})
+	}
+}
+
+func TestRunQueryLengthLimit(t *testing.T) {
+	atLimit := strings.Repeat("a", searchdocs.MaxQueryLength)
+	overLimit := strings.Repeat("word ", searchdocs.MaxQueryLength/5) + "overflowing"
+	// Each of these characters is 9 bytes encoded, so far fewer of them fit than ASCII letters
+	multibyteNearLimit := strings.Repeat("検", searchdocs.MaxQueryLength/9)
+
+	tests := []struct {
+		name         string
+		args         []string
+		wantCode     int
+		wantRequests int
+		wantStderr   string
+	}{
+		{"exactly at limit", []string{atLimit}, 0, 1, ""},
+		{"one over limit", []string{atLimit + "b"}, 1, 0, "the limit is 1024"},
+		{"multibyte just under limit", []string{multibyteNearLimit}, 0, 1, ""},
+		{"multibyte over limit", []string{multibyteNearLimit + "索"}, 1, 0, "query is 1026 bytes once URL-encoded"},
+		{"over limit truncated", []string{"--truncate-query", overLimit}, 0, 1, "query truncated to"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := serveSearch(t, http.StatusOK, `{"meta": {"found": {"value": 0}, "size": 5}, "hits": []}`)
+
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, &stdout, &stderr); code != tt.wantCode {
+				t.Fatalf("Expected exit code %d, got %d (stderr: %s)", tt.wantCode, code, stderr.String())
+			}
+			if len(*requests) != tt.wantRequests {
+				t.Fatalf("Expected %d requests, got %d", tt.wantRequests, len(*requests))
+			}
+			if tt.wantStderr != "" && !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("Expected %q on stderr, got %q", tt.wantStderr, stderr.String())
+			}
+			if tt.wantRequests > 0 {
+				if sent := (*requests)[0].Get("query"); searchdocs.EncodedQueryLength(sent) > searchdocs.MaxQueryLength || strings.HasSuffix(sent, " ") {
+					t.Errorf("Sent query exceeds the limit or ends mid-boundary: %q", sent)
+				}
+			}
+		})
+	}
+}
+
+func TestBeyondLastPage(t *testing.T) {
+	tests := []struct {
+		name       string
+		found      int
+		hits       int
+		page       int
+		size       int
+		wantLast   int
+		wantBeyond bool
+	}{
+		{"last page has hits", 137, 2, 28, 5, 0, false},
+		{"exactly one past the last page", 137, 0, 29, 5, 28, true},
+		{"far beyond the last page", 137, 0, 40, 5, 28, true},
+		{"exact division boundary", 140, 0, 29, 5, 28, true},
+		{"no results at all", 0, 0, 3, 5, 0, false},
+		{"empty page within range", 137, 0, 10, 5, 28, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &SearchResult{Hits: make([]SearchItem, tt.hits)}
+			result.Meta.Found.Value = tt.found
+
+			last, beyond := beyondLastPage(result, tt.page, tt.size)
+			if last != tt.wantLast || beyond != tt.wantBeyond {
+				t.Errorf("beyondLastPage() = %d, %v; want %d, %v", last, beyond, tt.wantLast, tt.wantBeyond)
+			}
+		})
+	}
+}
+
+func TestRunPageBeyondLastPage(t *testing.T) {
+	serveSearch(t, http.StatusOK, `{"meta": {"found": {"value": 137, "relation": "eq"}, "page": 40, "size": 5}, "hits": []}`)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--page", "40", "actions"}, &stdout, &stderr); code != 0 {
+		t.Errorf("Expected exit code 0, got %d", code)
+	}
+	if !strings.Contains(stdout.String(), "Page 40 is beyond the last page (28) — try --page 28") {
+		t.Errorf("Expected beyond-last-page message, got:\n%s", stdout.String())
+	}
+
+	if code := run([]string{"--page", "40", "--fail-on-empty", "actions"}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1 with --fail-on-empty, got %d", code)
+	}
+}
+
+func TestRunPageValidation(t *testing.T) {
+	requests := serveSearch(t, http.StatusOK, `{}`)
+
+	for _, page := range []string{"0", "-2"} {
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"--page", page, "actions"}, &stdout, &stderr); code != 1 {
+			t.Errorf("Expected exit code 1 for --page %s, got %d", page, code)
+		}
+		if !strings.Contains(stderr.String(), "--page must be at least 1") {
+			t.Errorf("Expected validation error for --page %s, got %q", page, stderr.String())
+		}
+	}
+	if len(*requests) != 0 {
+		t.Errorf("Expected validation to fail before any request, got %d requests", len(*requests))
+	}
+}
+
+func TestRunAll(t *testing.T) {
+	var pages []string
+	serveHTTP(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		pages = append(pages, page+"/"+r.URL.Query().Get("size"))
+		hits := map[string]string{
+			"1": `{"id": "1", "title": "A", "url": "/en/a"}, {"id": "2", "title": "B", "url": "/en/b"}`,
+			"2": `{"id": "2", "title": "B", "url": "/en/b"}, {"id": "3", "title": "C", "url": "/en/c"}`,
+			"3": `{"id": "4", "title": "D", "url": "/en/d"}`,
+		}[page]
+		fmt.Fprintf(w, `{"meta": {"found": {"value": 105, "relation": "eq"}, "page": %s, "size": 50}, "hits": [%s]}`, page, hits)
+	}))
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--all", "--format", "urls", "--no-anchors", "actions"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if !slices.Equal(pages, []string{"1/50", "2/50", "3/50"}) {
+		t.Errorf("Expected three pages of 50, got %v", pages)
+	}
+	want := "https://docs.github.com/en/a\nhttps://docs.github.com/en/b\nhttps://docs.github.com/en/c\nhttps://docs.github.com/en/d\n"
+	if stdout.String() != want {
+		t.Errorf("Expected every page merged without repeats, got %q", stdout.String())
+	}
+	if stderr.String() != "Fetching page 2 of 3...\nFetching page 3 of 3...\n" {
+		t.Errorf("Expected progress on stderr, got %q", stderr.String())
+	}
+
+	// Pretty and plain output show every hit without paging hints
+	stdout.Reset()
+	if code := run([]string{"--all", "--plain", "--no-anchors", "actions"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if output := stdout.String(); !strings.Contains(output, "4. D") || strings.Contains(output, "--page") {
+		t.Errorf("Expected all four hits and no paging hints, got:\n%s", output)
+	}
+
+	pages = nil
+	stdout.Reset()
+	stderr.Reset()
+	if code := run([]string{"--all", "--max-pages", "2", "--format", "urls", "--no-anchors", "actions"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if len(pages) != 2 || !strings.HasPrefix(stderr.String(), "Fetching page 2 of 2...\n") || !strings.Contains(stderr.String(), "Stopped after 2 of 3 pages") {
+		t.Errorf("Expected --max-pages to stop after 2 pages, got %v (stderr: %q)", pages, stderr.String())
+	}
+
+	for _, args := range [][]string{
+		{"--all", "--page", "2"},
+		{"--all", "--format", "raw"},
+		{"--all", "--max-pages", "0"},
+		{"--max-pages", "3"},
+	} {
+		stderr.Reset()
+		if code := run(append(args, "actions"), &stdout, &stderr); code != 1 || !strings.Contains(stderr.String(), "Error:") {
+			t.Errorf("%v: expected a validation error, got exit code %d (stderr: %q)", args, code, stderr.String())
+		}
+	}
+}
+
+func TestRunArchived(t *testing.T) {
+	sitemap, err := os.ReadFile(filepath.Join("searchdocs", "testdata", "archived-sitemap.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var searched bool
+	serveHTTP(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/docs-ghes-3.10/sitemap.xml":
+			_, _ = w.Write(sitemap)
+		case "/api/search/v1":
+			searched = true
+			_, _ = io.WriteString(w, `{"meta": {"found": {"value": 0}}, "hits": []}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--plain", "--archived", "--version", "enterprise-server@3.10", "saml"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	output := stdout.String()
+	for _, want := range []string{
+		"enterprise-server@3.10 is archived",
+		"Using saml for enterprise iam [archived]",
+		"https://github.github.com/docs-ghes-3.10/en/enterprise-server@3.10/admin/identity-and-access-management/using-saml-for-enterprise-iam\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q in output, got:\n%s", want, output)
+		}
+	}
+	if searched {
+		t.Error("Expected archived searches not to use the live search API")
+	}
+
+	stdout.Reset()
+	if code := run([]string{"--format", "json", "--archived", "--version", "enterprise-server@3.10", "saml"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	var result SearchResult
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v", err)
+	}
+	if len(result.Hits) != 2 || !result.Hits[0].Archived || len(result.Meta.Notes) != 1 {
+		t.Errorf("Unexpected archived JSON result: %+v", result)
+	}
+
+	// --archived only applies to versions older than the supported ones
+	stderr.Reset()
+	if code := run([]string{"--archived", "--version", "enterprise-server@3.17", "saml"}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1 for a supported version, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "--archived requires") {
+		t.Errorf("Expected --archived validation error, got %q", stderr.String())
+	}
+
+	// Flags that need a search API response are rejected rather than silently ignored
+	for _, flags := range [][]string{{"--format", "raw"}, {"--breadcrumb", "admin"}, {"--per-category", "1"}} {
+		stderr.Reset()
+		args := append(flags, "--archived", "--version", "enterprise-server@3.10", "saml")
+		if code := run(args, &stdout, &stderr); code != 1 {
+			t.Errorf("%v: expected exit code 1, got %d", flags, code)
+		}
+		if !strings.Contains(stderr.String(), flags[0]) || !strings.Contains(stderr.String(), "can't be used with --archived") {
+			t.Errorf("%v: unexpected stderr %q", flags, stderr.String())
+		}
+	}
+}
+
+func TestRunUnsupportedVersionWarning(t *testing.T) {
+	requests := serveSearch(t, http.StatusOK, `{"meta": {"found": {"value": 0}}, "hits": []}`)
+
+	var stdout, stderr bytes.Buffer
+	run([]string{"--version", "enterprise-server@3.10", "saml"}, &stdout, &stderr)
+	if !strings.Contains(stderr.String(), "Warning: enterprise-server@3.10 is not supported") ||
+		!strings.Contains(stderr.String(), "Use --archived") {
+		t.Errorf("Expected a warning mentioning --archived, got %q", stderr.String())
+	}
+	if got := (*requests)[0].Get("version"); got == "enterprise-server@3.10" {
+		t.Errorf("Expected the version to be normalized, got %q", got)
+	}
+}
+
+func TestRunAnchors(t *testing.T) {
+	serveSearch(t, http.StatusOK, `{
+		"meta": {"found": {"value": 2, "relation": "eq"}, "page": 1, "size": 5},
+		"hits": [
+			{"id": "1", "title": "Managing deploy keys", "url": "/en/authentication/managing-deploy-keys", "headings": "About deploy keys\nDeploy keys: setup\nMachine users"},
+			{"id": "2", "title": "About SSH", "url": "/en/authentication/about-ssh", "headings": "About SSH\nKey passphrases"}
+		]
+	}`)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--plain", "--include", "headings", "deploy setup"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "https://docs.github.com/en/authentication/managing-deploy-keys#deploy-keys-setup\n") {
+		t.Errorf("Expected the URL to link to the matching heading, got:\n%s", stdout.String())
+	}
+	if !strings.Contains(stdout.String(), "https://docs.github.com/en/authentication/about-ssh\n") {
+		t.Errorf("Expected a plain URL when no heading matches, got:\n%s", stdout.String())
+	}
+
+	stdout.Reset()
+	if code := run([]string{"--plain", "--no-anchors", "--include", "headings", "deploy setup"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if strings.Contains(stdout.String(), "#") {
+		t.Errorf("Expected --no-anchors to link to page tops, got:\n%s", stdout.String())
+	}
+
+	t.Run("deep links", func(t *testing.T) {
+		requests := serveSearch(t, http.StatusOK, `{
+			"meta": {"found": {"value": 2, "relation": "eq"}, "page": 1, "size": 5},
+			"hits": [
+				{"id": "1", "title": "Managing deploy keys", "url": "/en/authentication/managing-deploy-keys", "headings": "About deploy keys\nDeploy keys: setup\nMachine users"},
+				{"id": "2", "title": "About SSH", "url": "/en/authentication/about-ssh"}
+			]
+		}`)
+
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"--plain", "--anchors", "--no-breadcrumbs", "deploy setup"}, &stdout, &stderr); code != 0 {
+			t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+		}
+		if !slices.Contains((*requests)[0]["include"], "headings") {
+			t.Errorf("Expected --anchors to request headings, got %v", (*requests)[0]["include"])
+		}
+		expected := "1. Managing deploy keys\n" +
+			"   https://docs.github.com/en/authentication/managing-deploy-keys#deploy-keys-setup\n" +
+			"   § About deploy keys — https://docs.github.com/en/authentication/managing-deploy-keys#about-deploy-keys\n" +
+			"   § Deploy keys: setup — https://docs.github.com/en/authentication/managing-deploy-keys#deploy-keys-setup\n" +
+			"\n" +
+			"2. About SSH\n" +
+			"   https://docs.github.com/en/authentication/about-ssh\n" +
+			"\n"
+		if !strings.Contains(stdout.String(), expected) {
+			t.Errorf("Expected deep links under the first result only, got:\n%s", stdout.String())
+		}
+
+		stderr.Reset()
+		if code := run([]string{"--anchors", "--no-anchors", "deploy"}, &stdout, &stderr); code != 1 || !strings.Contains(stderr.String(), "--no-anchors") {
+			t.Errorf("Expected --anchors and --no-anchors to conflict, got exit code %d (stderr: %q)", code, stderr.String())
+		}
+	})
+
+	t.Run("repeated headings", func(t *testing.T) {
+		serveSearch(t, http.StatusOK, `{
+			"meta": {"found": {"value": 1, "relation": "eq"}, "page": 1, "size": 5},
+			"hits": [{"id": "1", "title": "Workflow syntax", "url": "/en/actions/syntax", "headings": "Example\nUsage\nExample"}]
+		}`)
+
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"--plain", "--anchors", "--no-breadcrumbs", "example"}, &stdout, &stderr); code != 0 {
+			t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+		}
+		for _, want := range []string{"#example\n", "#example-1\n"} {
+			if !strings.Contains(stdout.String(), want) {
+				t.Errorf("Expected a deep link ending %q, got:\n%s", want, stdout.String())
+			}
+		}
+	})
+}
+
+func TestRunLogFile(t *testing.T) {
+	serveHTTP(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("query") == "broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = io.WriteString(w, `{
+			"meta": {"found": {"value": 1, "relation": "eq"}, "page": 1, "size": 5},
+			"hits": [{"id": "1", "title": "About SSH", "url": "/en/authentication/about-ssh"}]
+		}`)
+	}))
+	path := filepath.Join(t.TempDir(), "logs", "session.jsonl")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--plain", "--log-file", path, "ssh key"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if code := run([]string{"--log-file", path, "broken"}, &stdout, &stderr); code != 1 {
+		t.Fatalf("Expected exit code 1, got %d", code)
+	}
+	t.Setenv("GH_SEARCH_DOCS_NO_HISTORY", "1")
+	run([]string{"--plain", "--log-file", path, "ignored"}, &stdout, &stderr)
+
+	entries, err := tailTranscripts(path, 0)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 log entries (the opted-out run is skipped), got %d", len(entries))
+	}
+
+	first := entries[0]
+	if first.ExitCode != 0 || first.Params.Get("query") != "ssh key" || first.Meta == nil {
+		t.Errorf("Unexpected first entry: %+v", first)
+	}
+	if !reflect.DeepEqual(first.URLs, []string{"https://docs.github.com/en/authentication/about-ssh"}) {
+		t.Errorf("URLs = %v", first.URLs)
+	}
+	if !reflect.DeepEqual(first.Args, []string{"--plain", "--log-file", path, "ssh key"}) {
+		t.Errorf("Args = %v", first.Args)
+	}
+
+	second := entries[1]
+	if second.ExitCode != 1 || len(second.Errors) == 0 || !strings.Contains(second.Errors[0], "status 500") {
+		t.Errorf("Unexpected second entry: %+v", second)
+	}
+
+	stdout.Reset()
+	if code := run([]string{"log", "tail", "-n", "1", path}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0 from log tail, got %d", code)
+	}
+	if output := stdout.String(); !strings.Contains(output, "exit 1  gh search-docs --log-file") || strings.Contains(output, "about-ssh") {
+		t.Errorf("Expected only the most recent entry, got:\n%s", output)
+	}
+}
+
+func TestRunLogFileBestEffort(t *testing.T) {
+	serveSearch(t, http.StatusOK, `{"meta": {"found": {"value": 0}}, "hits": []}`)
+
+	// A directory can't be opened for appending, but the search must still succeed
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--log-file", t.TempDir(), "ssh"}, &stdout, &stderr); code != 0 {
+		t.Errorf("Expected logging failures not to change the exit code, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "could not write to log file") {
+		t.Errorf("Expected a warning about the log file, got %q", stderr.String())
+	}
+}
+
+func TestRunCompactJSON(t *testing.T) {
+	serveSearch(t, http.StatusOK, `{
+		"meta": {"found": {"value": 1, "relation": "eq"}, "took": {"query_msec": 1, "total_msec": 2}, "page": 1, "size": 5},
+		"hits": [{"id": "1", "title": "About SSH", "url": "/en/authentication/about-ssh"}]
+	}`)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--format", "json", "--compact", "ssh"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	expected := `{"meta":{"found":{"value":1,"relation":"eq"},"took":{"query_msec":1,"total_msec":2},"page":1,"size":5},` +
+		`"hits":[{"id":"1","title":"About SSH","url":"/en/authentication/about-ssh"}]}` + "\n"
+	if stdout.String() != expected {
+		t.Errorf("Compact output mismatch\ngot:  %q\nwant: %q", stdout.String(), expected)
+	}
+
+	stderr.Reset()
+	if code := run([]string{"--compact", "ssh"}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1 for --compact without --format json, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "--compact can only be used with --format json") {
+		t.Errorf("Expected a validation error, got %q", stderr.String())
+	}
+}
+
+// panickingRenderer panics on markdown containing trigger and renders everything else verbatim
+type panickingRenderer struct {
+	trigger string
+}
+
+func (r panickingRenderer) Render(in string) (string, error) {
+	if strings.Contains(in, r.trigger) {
+		panic("index out of range in word wrap")
+	}
+	return "rendered: " + in, nil
+}
+
+func TestRunColor(t *testing.T) {
+	serveSearch(t, http.StatusOK, `{
+		"meta": {"found": {"value": 1, "relation": "eq"}, "page": 1, "size": 5},
+		"hits": [{"id": "1", "title": "About pull requests", "url": "/en/pull-requests/about-pull-requests"}]
+	}`)
+	args := []string{"--no-anchors", "--no-breadcrumbs", "pull requests"}
+	plain := "1. About pull requests\n   https://docs.github.com/en/pull-requests/about-pull-requests\n"
+
+	// Piped output is plain text, like ls --color=auto
+	var stdout, stderr bytes.Buffer
+	if code := run(args, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), plain) {
+		t.Errorf("Expected plain output when piped, got:\n%s", stdout.String())
+	}
+
+	// --color always keeps pretty output and its colors when piped
+	stdout.Reset()
+	if code := run(append([]string{"--color", "always"}, args...), &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if strings.Contains(stdout.String(), plain) || !strings.Contains(stdout.String(), "\x1b[") {
+		t.Errorf("Expected colored pretty output, got:\n%q", stdout.String())
+	}
+
+	// --color never turns colors off even on a terminal
+	withTerminalWidth(t, 80)
+	stdout.Reset()
+	if code := run(append([]string{"--color", "never"}, args...), &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if strings.Contains(stdout.String(), "\x1b[") || !strings.Contains(stdout.String(), "About pull requests") {
+		t.Errorf("Expected pretty output without escape codes, got:\n%q", stdout.String())
+	}
+
+	for _, tt := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--color", "sometimes"}, `unknown --color "sometimes"`},
+		{[]string{"--color", "always", "--no-color"}, "--no-color can't be combined with --color always"},
+	} {
+		stderr.Reset()
+		if code := run(append(tt.args, args...), &stdout, &stderr); code != 1 {
+			t.Errorf("%v: expected exit code 1, got %d", tt.args, code)
+		}
+		if !strings.Contains(stderr.String(), tt.want) {
+			t.Errorf("%v: expected %q, got %q", tt.args, tt.want, stderr.String())
+		}
+	}
+}
+
+func TestRunTheme(t *testing.T) {
+	serveSearch(t, http.StatusOK, `{
+		"meta": {"found": {"value": 1, "relation": "eq"}, "page": 1, "size": 5},
+		"hits": [{"id": "1", "title": "About pull requests", "url": "/en/pull-requests/about-pull-requests"}]
+	}`)
+	args := []string{"--color", "always", "--no-anchors", "--no-breadcrumbs", "pull requests"}
+
+	// The theme picks the renderer's style: notty has no colors, dracula does
+	for _, tt := range []struct {
+		theme   string
+		colored bool
+	}{{"notty", false}, {"dracula", true}} {
+		var stdout, stderr bytes.Buffer
+		if code := run(append([]string{"--theme", tt.theme}, args...), &stdout, &stderr); code != 0 {
+			t.Fatalf("%s: expected exit code 0, got %d (stderr: %s)", tt.theme, code, stderr.String())
+		}
+		if colored := strings.Contains(stdout.String(), "\x1b["); colored != tt.colored {
+			t.Errorf("%s: colored = %v, want %v in %q", tt.theme, colored, tt.colored, stdout.String())
+		}
+	}
+
+	// GH_SEARCH_DOCS_THEME applies without --theme, which wins over it
+	t.Setenv("GH_SEARCH_DOCS_THEME", "notty")
+	var stdout, stderr bytes.Buffer
+	if code := run(args, &stdout, &stderr); code != 0 || strings.Contains(stdout.String(), "\x1b[") {
+		t.Errorf("Expected the notty theme from the environment, got %d and %q", code, stdout.String())
+	}
+	t.Setenv("GH_SEARCH_DOCS_THEME", "solarized")
+	if code := run(append([]string{"--theme", "dark"}, args...), &stdout, &stderr); code != 0 {
+		t.Errorf("Expected a valid --theme to win over the environment, got %d (stderr: %s)", code, stderr.String())
+	}
+
+	for _, tt := range []struct {
+		args []string
+		want string
+	}{
+		{args, `unknown GH_SEARCH_DOCS_THEME theme "solarized" (use auto, dark, light, dracula, notty)`},
+		{append([]string{"--theme", "pink"}, args...), `unknown --theme "pink" (use auto, dark, light, dracula, notty)`},
+	} {
+		stderr.Reset()
+		if code := run(tt.args, &stdout, &stderr); code != 1 {
+			t.Errorf("Expected exit code 1, got %d", code)
+		}
+		if !strings.Contains(stderr.String(), tt.want) {
+			t.Errorf("Expected %q, got %q", tt.want, stderr.String())
+		}
+	}
+}
+
+func TestRunStyleFile(t *testing.T) {
+	serveSearch(t, http.StatusOK, `{
+		"meta": {"found": {"value": 1, "relation": "eq"}, "page": 1, "size": 5},
+		"hits": [{"id": "1", "title": "About pull requests", "url": "/en/pull-requests/about-pull-requests"}]
+	}`)
+	dir := t.TempDir()
+	style := filepath.Join(dir, "style.json")
+	if err := os.WriteFile(style, []byte(`{"document": {"block_prefix": "<custom>"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	broken := filepath.Join(dir, "broken.json")
+	if err := os.WriteFile(broken, []byte(`{"document": [`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	args := []string{"--color", "always", "--no-anchors", "--no-breadcrumbs", "pull requests"}
+
+	var stdout, stderr bytes.Buffer
+	if code := run(append([]string{"--style-file", style}, args...), &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "<custom>") {
+		t.Errorf("Expected the custom style, got %q", stdout.String())
+	}
+
+	stdout.Reset()
+	if code := run(append([]string{"--theme-file", style}, args...), &stdout, &stderr); code != 0 || !strings.Contains(stdout.String(), "<custom>") {
+		t.Errorf("Expected the custom style from --theme-file, got %d and %q", code, stdout.String())
+	}
+
+	// GLAMOUR_STYLE works the same way
+	t.Setenv("GLAMOUR_STYLE", style)
+	stdout.Reset()
+	if code := run(args, &stdout, &stderr); code != 0 || !strings.Contains(stdout.String(), "<custom>") {
+		t.Errorf("Expected the custom style from GLAMOUR_STYLE, got %d and %q", code, stdout.String())
+	}
+
+	// Broken and missing style files warn and fall back to the theme
+	for _, path := range []string{broken, filepath.Join(dir, "missing.json")} {
+		stdout.Reset()
+		stderr.Reset()
+		if code := run(append([]string{"--style-file", path}, args...), &stdout, &stderr); code != 0 {
+			t.Fatalf("%s: expected exit code 0, got %d (stderr: %s)", path, code, stderr.String())
+		}
+		if !strings.Contains(stderr.String(), "Warning: can't use style file "+path+", using the auto theme instead") {
+			t.Errorf("%s: expected a warning, got %q", path, stderr.String())
+		}
+		if strings.Contains(stdout.String(), "<custom>") || !strings.Contains(stdout.String(), "/en/pull-requests/about-pull-requests") {
+			t.Errorf("%s: expected the results in the default style, got %q", path, stdout.String())
+		}
+	}
+}
+
+func TestPrintResultsStyleFileError(t *testing.T) {
+	// A style file that passed the check up front but can't be read by the time results are
+	// printed is reported rather than silently ignored
+	missing := filepath.Join(t.TempDir(), "style.json")
+	opts := &options{styleFile: missing, size: 5, layout: layoutFull, theme: "dark"}
+	result := &SearchResult{Hits: []SearchItem{{Title: "About pull requests", URL: "/en/pull-requests"}}}
+	result.Meta.Found.Value = 1
+
+	var stdout, stderr bytes.Buffer
+	printResults(&stdout, &stderr, opts, "pull requests", result, nil)
+	if !strings.Contains(stderr.String(), "Warning: can't use style file "+missing) {
+		t.Errorf("Expected a warning about the style file, got %q", stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "/en/pull-requests") {
+		t.Errorf("Expected the results in the theme, got %q", stdout.String())
+	}
+}
+
+func TestRunDumpTheme(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--dump-theme", "--theme", "light"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	light, _ := searchdocs.ThemeJSON("light")
+	if !bytes.Equal(stdout.Bytes(), light) {
+		t.Errorf("Expected the light theme, got %q", stdout.String())
+	}
+
+	// A style file in use is dumped as it is
+	style := filepath.Join(t.TempDir(), "style.json")
+	if err := os.WriteFile(style, []byte(`{"document": {"block_prefix": "<custom>"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stdout.Reset()
+	if code := run([]string{"--dump-theme", "--theme-file", style}, &stdout, &stderr); code != 0 || !strings.Contains(stdout.String(), "<custom>") {
+		t.Errorf("Expected the style file, got %d and %q", code, stdout.String())
+	}
+
+	if code := run([]string{"--dump-theme", "--theme", "neon"}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1 for an unknown theme, got %d", code)
+	}
+}
+
+func TestRunRendererPanic(t *testing.T) {
+	serveSearch(t, http.StatusOK, `{
+		"meta": {"found": {"value": 3, "relation": "eq"}, "page": 1, "size": 5},
+		"hits": [
+			{"id": "1", "title": "First", "url": "/en/first"},
+			{"id": "2", "title": "Pathological", "url": "/en/pathological"},
+			{"id": "3", "title": "Third", "url": "/en/third"}
+		]
+	}`)
+
+	oldRenderer := newMarkdownRenderer
+	newMarkdownRenderer = func() markdownRenderer { return panickingRenderer{trigger: "Pathological"} }
+	t.Cleanup(func() { newMarkdownRenderer = oldRenderer })
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--debug", "--color", "always", "docs"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+
+	output := stdout.String()
+	for _, want := range []string{
+		"rendered: 1. First",
+		"2. Pathological\n   https://docs.github.com/en/pathological\n",
+		"rendered: 3. Third",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q in output, got:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "rendered: 2.") {
+		t.Errorf("Expected the panicking hit to fall back to plain output, got:\n%s", output)
+	}
+	if !strings.Contains(stderr.String(), "Rendering result 2 failed") {
+		t.Errorf("Expected the panic to be logged under --debug, got %q", stderr.String())
+	}
+}
+
+// layoutFixture has hits with long titles, URLs, and intros for exercising layouts
+const layoutFixture = `{
+	"meta": {"found": {"value": 4, "relation": "eq"}, "page": 1, "size": 5},
+	"hits": [
+		{"id": "1", "title": "Managing your personal access tokens", "url": "/en/authentication/keeping-your-account-and-data-secure/managing-your-personal-access-tokens", "intro": "You can use a personal access token in place of a password when authenticating to GitHub in the command line or with the API."},
+		{"id": "2", "title": "Quickstart for GitHub Actions", "url": "/en/actions/quickstart", "intro": "Try out the features of GitHub Actions in 5 minutes or less."},
+		{"id": "3", "title": "About SSH", "url": "/en/authentication/connecting-to-github-with-ssh/about-ssh", "intro": "Using the SSH protocol, you can connect and authenticate to remote servers and services."},
+		{"id": "4", "title": "Quickstart for GitHub Copilot", "url": "/en/copilot/quickstart", "intro": "Quickly learn how to use GitHub Copilot, your AI pair programmer, in your editor of choice."}
+	]
+}`
+
+// withTerminalWidth simulates a terminal of the given width for the duration of a test
+func withTerminalWidth(t *testing.T, width int) {
+	t.Helper()
+
+	oldWidth, oldIsTerminal := terminalWidth, stdoutIsTerminal
+	terminalWidth = func() int { return width }
+	stdoutIsTerminal = func() bool { return true }
+	t.Cleanup(func() { terminalWidth, stdoutIsTerminal = oldWidth, oldIsTerminal })
+}
+
+func TestRunCompactLayout(t *testing.T) {
+	serveSearch(t, http.StatusOK, layoutFixture)
+	withTerminalWidth(t, 40)
+
+	// Narrow terminals switch to the compact layout automatically, even for pretty output
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"tokens"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	assertGolden(t, "layout-compact-40.golden", stdout.String())
+
+	stdout.Reset()
+	if code := run([]string{"--layout", "full", "--plain", "tokens"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	// Intros are shown, cut to about two lines of the narrow terminal
+	if !strings.Contains(stdout.String(), "   You can use a personal access token in place of a password when...\n") {
+		t.Errorf("Expected --layout full to keep intros, got:\n%s", stdout.String())
+	}
+
+	// Output that isn't going to a terminal keeps the full layout whatever the width
+	stdoutIsTerminal = func() bool { return false }
+	stdout.Reset()
+	if code := run([]string{"--plain", "tokens"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "when authenticating to GitHub") {
+		t.Errorf("Expected piped output to keep full intros, got:\n%s", stdout.String())
+	}
+
+	stderr.Reset()
+	if code := run([]string{"--layout", "grid", "tokens"}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1 for an unknown layout, got %d", code)
+	}
+}
+
+func TestCompactHitKeepsAnnotations(t *testing.T) {
+	item := SearchItem{
+		Title:        "Self-hosted runners",
+		URL:          "/en/actions/self-hosted-runners",
+		Intro:        "You can host your own runners and customize the environment used to run jobs.",
+		Headings:     "Adding self-hosted runners to an enterprise account",
+		Availability: map[string]bool{"FPT": true, "GHEC": true, "GHES": false},
+	}
+	opts := &options{headings: StringSlice{"enterprise account"}}
+
+	var w bytes.Buffer
+	printCompactHit(&w, opts, 1, item, 40)
+	output := w.String()
+
+	// Only the intro is cut to fit; headings and badges are shown whole
+	for _, want := range []string{"   You can host your own runners and cu…\n", "§ Adding self-hosted runners to an enterprise account\n", "[FPT ✓ GHEC ✓ GHES ✗]\n"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q in compact output, got:\n%s", want, output)
+		}
+	}
+}
+
+func TestRunColumnsLayout(t *testing.T) {
+	serveSearch(t, http.StatusOK, layoutFixture)
+
+	tests := []struct {
+		width  int
+		args   []string
+		golden string
+	}{
+		// Wide terminals switch to columns automatically
+		{220, []string{"tokens"}, "layout-columns-220.golden"},
+		{120, []string{"--layout", "columns", "tokens"}, "layout-columns-120.golden"},
+	}
+
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.width), func(t *testing.T) {
+			withTerminalWidth(t, tt.width)
+
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, &stdout, &stderr); code != 0 {
+				t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+			}
+			output := stdout.String()
+			assertGolden(t, tt.golden, output)
+
+			for _, item := range []string{
+				"https://docs.github.com/en/authentication/keeping-your-account-and-data-secure/managing-your-personal-access-tokens",
+				"https://docs.github.com/en/authentication/connecting-to-github-with-ssh/about-ssh",
+			} {
+				if !strings.Contains(output, item+"\n") {
+					t.Errorf("Expected URL %s to be shown in full", item)
+				}
+			}
+		})
+	}
+
+	withTerminalWidth(t, 220)
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--columns-width", "0", "--plain", "tokens"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if strings.Contains(stdout.String(), "Quickstart for GitHub Actions    ") {
+		t.Errorf("Expected --columns-width 0 to disable columns, got:\n%s", stdout.String())
+	}
+}
+
+func TestRunOnelineLayout(t *testing.T) {
+	serveSearch(t, http.StatusOK, `{
+		"meta": {"found": {"value": 12, "relation": "eq"}, "page": 1, "size": 5},
+		"hits": [
+			{"id": "1", "title": "Managing your personal access tokens", "url": "/en/authentication/tokens", "intro": "Use a token in place of a password."},
+			{"id": "2", "title": "About SSH", "url": "/en/ssh"}
+		]
+	}`)
+	withTerminalWidth(t, 80)
+
+	// Titles are cut to fit the terminal; URLs never are
+	for _, args := range [][]string{{"--oneline"}, {"--oneline", "--plain"}, {"--layout", "oneline"}} {
+		var stdout, stderr bytes.Buffer
+		if code := run(append(args, "--no-anchors", "tokens"), &stdout, &stderr); code != 0 {
+			t.Fatalf("%v: expected exit code 0, got %d (stderr: %s)", args, code, stderr.String())
+		}
+		output := stdout.String()
+		for _, want := range []string{
+			"1. Managing your personal ac… — https://docs.github.com/en/authentication/tokens\n",
+			"2. About SSH — https://docs.github.com/en/ssh\n",
+			"Showing page 1 of 3",
+		} {
+			if !strings.Contains(output, want) {
+				t.Errorf("%v: expected %q in:\n%s", args, want, output)
+			}
+		}
+		if strings.Contains(output, "in place of a password") {
+			t.Errorf("%v: expected intros to be left out:\n%s", args, output)
+		}
+	}
+
+	// Output that isn't going to a terminal keeps whole titles
+	stdoutIsTerminal = func() bool { return false }
+	var stdout, stderr bytes.Buffer
+	run([]string{"--oneline", "--no-anchors", "tokens"}, &stdout, &stderr)
+	if !strings.Contains(stdout.String(), "1. Managing your personal access tokens — https://") {
+		t.Errorf("Expected whole titles when piped, got:\n%s", stdout.String())
+	}
+
+	if code := run([]string{"--oneline", "--layout", "compact", "tokens"}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1 for --oneline with another layout, got %d", code)
+	}
+}
+
+func TestRunLong(t *testing.T) {
+	intro := strings.Repeat("Use a token in place of a password. ", 6)
+	body := `{
+		"meta": {"found": {"value": 1, "relation": "eq"}, "page": 1, "size": 5},
+		"hits": [{
+			"id": "1",
+			"title": "Managing your personal access tokens",
+			"url": "/en/authentication/tokens",
+			"breadcrumbs": "Authentication / Account security",
+			"intro": "` + intro + `",
+			"headings": "About tokens\nCreating a token",
+			"toplevel": "Authentication",
+			"score": 12.5
+		}]
+	}`
+
+	for _, args := range [][]string{{"--long", "--plain"}, {"--long"}} {
+		requests := serveSearch(t, http.StatusOK, body)
+
+		var stdout, stderr bytes.Buffer
+		if code := run(append(args, "--no-anchors", "tokens"), &stdout, &stderr); code != 0 {
+			t.Fatalf("%v: expected exit code 0, got %d (stderr: %s)", args, code, stderr.String())
+		}
+		output := strings.Join(strings.Fields(stdout.String()), " ")
+		for _, want := range []string{
+			strings.TrimSpace(intro),
+			"Authentication › Account security",
+			"Top level: Authentication",
+			"Headings: About tokens, Creating a token",
+			"Score: 12.5",
+		} {
+			if !strings.Contains(output, want) {
+				t.Errorf("%v: expected %q in:\n%s", args, want, stdout.String())
+			}
+		}
+		if includes := (*requests)[0]["include"]; len(includes) != 3 {
+			t.Errorf("%v: expected intro, headings, and toplevel to be requested, got %v", args, includes)
+		}
+	}
+
+	// Without breadcrumb lines the path is one of the --long details
+	serveSearch(t, http.StatusOK, body)
+	var stdout, stderr bytes.Buffer
+	run([]string{"--long", "--no-breadcrumbs", "--plain", "tokens"}, &stdout, &stderr)
+	if !strings.Contains(stdout.String(), "Path: Authentication > Account security") {
+		t.Errorf("Expected the path with --no-breadcrumbs, got:\n%s", stdout.String())
+	}
+
+	if code := run([]string{"--long", "--oneline", "tokens"}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1 for --long with --oneline, got %d", code)
+	}
+}
+
+func TestRunHyperlinks(t *testing.T) {
+	serveSearch(t, http.StatusOK, `{
+		"meta": {"found": {"value": 1, "relation": "eq"}, "page": 1, "size": 5},
+		"hits": [{"id": "1", "title": "About pull requests", "url": "/en/pull-requests/about-pull-requests"}]
+	}`)
+	url := "https://docs.github.com/en/pull-requests/about-pull-requests"
+	args := []string{"--no-color", "--no-anchors", "--no-breadcrumbs", "pull requests"}
+	search := func(extra ...string) string {
+		t.Helper()
+		var stdout, stderr bytes.Buffer
+		if code := run(append(extra, args...), &stdout, &stderr); code != 0 {
+			t.Fatalf("%v: expected exit code 0, got %d (stderr: %s)", extra, code, stderr.String())
+		}
+		return stdout.String()
+	}
+
+	// Piped output is plain text, which is never linked
+	if output := search("--hyperlinks", "always"); strings.Contains(output, "\x1b]8;;") {
+		t.Errorf("Expected no hyperlinks in plain output, got %q", output)
+	}
+
+	withTerminalWidth(t, 100)
+	oldEnabled := hyperlinksEnabled
+	hyperlinksEnabled = func() bool { return false }
+	t.Cleanup(func() { hyperlinksEnabled = oldEnabled })
+
+	// Titles are linked with --hyperlinks always, and the URL is still shown
+	output := search("--hyperlinks", "always")
+	if !strings.Contains(output, searchdocs.HyperlinkStart(url)+"About pull requests"+searchdocs.HyperlinkEnd) {
+		t.Errorf("Expected the title linked to %s, got %q", url, output)
+	}
+	if !strings.Contains(output, "  "+url+"\n") {
+		t.Errorf("Expected the URL line to be kept, got %q", output)
+	}
+	for _, extra := range [][]string{{"--hyperlinks", "never"}, {"--hyperlinks", "auto"}} {
+		if output := search(extra...); strings.Contains(output, "\x1b]8;;") {
+			t.Errorf("%v: expected no hyperlinks, got %q", extra, output)
+		}
+	}
+	hyperlinksEnabled = func() bool { return true }
+	if output := search(); !strings.Contains(output, searchdocs.HyperlinkStart(url)) {
+		t.Errorf("Expected titles linked in a supporting terminal, got %q", output)
+	}
+
+	// The oneline layout drops the URL only with --hyperlinks always
+	if output := search("--oneline", "--hyperlinks", "always"); output != "Found 1 results\n1. "+searchdocs.Hyperlink(url, "About pull requests")+"\n\n" {
+		t.Errorf("Expected only the linked title, got %q", output)
+	}
+	if output := search("--oneline"); !strings.Contains(output, searchdocs.Hyperlink(url, "About pull requests")+" — "+url) {
+		t.Errorf("Expected the linked title and the URL, got %q", output)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run(append([]string{"--hyperlinks", "sometimes"}, args...), &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1 for an unknown --hyperlinks, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), `unknown --hyperlinks "sometimes"`) {
+		t.Errorf("Expected the unknown --hyperlinks error, got %q", stderr.String())
+	}
+}
+
+func TestRunRelativeURLs(t *testing.T) {
+	serveSearch(t, http.StatusOK, `{
+		"meta": {"found": {"value": 1, "relation": "eq"}, "page": 1, "size": 5},
+		"hits": [{"id": "1", "title": "About pull requests", "url": "/en/pull-requests/about-pull-requests"}]
+	}`)
+	path := "/en/pull-requests/about-pull-requests"
+	url := "https://docs.github.com" + path
+	search := func(args ...string) string {
+		t.Helper()
+		var stdout, stderr bytes.Buffer
+		if code := run(append(args, "--relative-urls", "--no-anchors", "pull requests"), &stdout, &stderr); code != 0 {
+			t.Fatalf("%v: expected exit code 0, got %d (stderr: %s)", args, code, stderr.String())
+		}
+		return stdout.String()
+	}
+
+	for _, args := range [][]string{{"--plain"}, {"--oneline", "--plain"}, {"--format", "urls"}, {"--refs"}} {
+		if output := search(args...); !strings.Contains(output, path) || strings.Contains(output, url) {
+			t.Errorf("%v: expected only the path, got %q", args, output)
+		}
+	}
+
+	// Markdown and HTML link to the path
+	if output := search("--format", "markdown"); !strings.Contains(output, "[About pull requests]("+path+")") || strings.Contains(output, url) {
+		t.Errorf("Expected a Markdown link to the path, got %q", output)
+	}
+	if output := search("--format", "html"); !strings.Contains(output, `<a href="`+path+`">`) || strings.Contains(output, url) {
+		t.Errorf("Expected an HTML link to the path, got %q", output)
+	}
+
+	// json and the url column keep the full URL
+	if output := search("--format", "json"); !strings.Contains(output, `"url": "`+path+`"`) {
+		t.Errorf("Expected the API path in json, got %q", output)
+	}
+	if output := search("--format", "tsv", "--columns", "title,url,path"); output != "About pull requests\t"+url+"\t"+path+"\n" {
+		t.Errorf("Expected the url and path columns, got %q", output)
+	}
+
+	withTerminalWidth(t, 100)
+	oldEnabled := hyperlinksEnabled
+	hyperlinksEnabled = func() bool { return true }
+	t.Cleanup(func() { hyperlinksEnabled = oldEnabled })
+	output := search("--no-color", "--oneline")
+	if !strings.Contains(output, searchdocs.Hyperlink(url, "About pull requests")+" — "+path) {
+		t.Errorf("Expected the title linked to the full URL and the path shown, got %q", output)
+	}
+}
+
+func TestHyperlinksEnabled(t *testing.T) {
+	withTerminalWidth(t, 80)
+	for _, name := range []string{"TERM_PROGRAM", "WT_SESSION", "KITTY_WINDOW_ID"} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("TERM", "xterm-256color")
+
+	if hyperlinksEnabled() {
+		t.Error("Expected an unknown terminal not to get hyperlinks")
+	}
+	t.Setenv("TERM_PROGRAM", "WezTerm")
+	if !hyperlinksEnabled() {
+		t.Error("Expected WezTerm to get hyperlinks")
+	}
+	t.Setenv("TERM_PROGRAM", "")
+	t.Setenv("WT_SESSION", "1")
+	if !hyperlinksEnabled() {
+		t.Error("Expected Windows Terminal to get hyperlinks")
+	}
+	t.Setenv("TERM", "dumb")
+	if hyperlinksEnabled() {
+		t.Error("Expected TERM=dumb not to get hyperlinks")
+	}
+}
+
+func TestBreadcrumbLine(t *testing.T) {
+	item := SearchItem{
+		Title:       "Security hardening with OpenID Connect",
+		URL:         "/en/actions/security-guides/security-hardening-with-openid-connect",
+		Breadcrumbs: "Actions / Security guides",
+	}
+
+	oldEnabled := hyperlinksEnabled
+	hyperlinksEnabled = func() bool { return true }
+	t.Cleanup(func() { hyperlinksEnabled = oldEnabled })
+
+	linked := searchdocs.Hyperlink("https://docs.github.com/en/actions", "Actions") + " › " +
+		searchdocs.Hyperlink("https://docs.github.com/en/actions/security-guides", "Security guides")
+	tests := []struct {
+		name     string
+		opts     options
+		expected string
+	}{
+		{"hidden by default", options{}, ""},
+		{"linked when shown", options{showBreadcrumbs: true}, linked},
+		{"plain with --no-breadcrumb-links", options{showBreadcrumbs: true, noBreadcrumbLinks: true}, "Actions › Security guides"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := breadcrumbLine(&tt.opts, item); got != tt.expected {
+				t.Errorf("breadcrumbLine() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRunTitleHighlights(t *testing.T) {
+	serveSearch(t, http.StatusOK, `{
+		"meta": {"found": {"value": 4, "relation": "eq"}, "page": 1, "size": 5},
+		"hits": [
+			{"id": "1", "title": "Using secrets", "url": "/en/1", "highlights": {"title": ["Using <mark>secrets</mark>", "Using secrets"]}},
+			{"id": "2", "title": "Managing secrets", "url": "/en/2", "highlights": {"title": "Managing <mark>secrets</mark>"}},
+			{"id": "3", "title": "About secrets", "url": "/en/3", "highlights": {"title": [""]}},
+			{"id": "4", "title": "Secret scanning", "url": "/en/4", "highlights": {}}
+		]
+	}`)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--plain", "--highlights", "title", "--no-anchors", "--no-breadcrumbs", "secrets"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	for _, want := range []string{
+		"1. Using *secrets*\n",
+		"2. Managing *secrets*\n",
+		"3. About secrets\n",
+		"4. Secret scanning\n",
+	} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("Expected %q in:\n%s", want, stdout.String())
+		}
+	}
+}
+
+func TestRunMaxHighlights(t *testing.T) {
+	serveSearch(t, http.StatusOK, `{
+		"meta": {"found": {"value": 1, "relation": "eq"}, "page": 1, "size": 5},
+		"hits": [{
+			"id": "1",
+			"title": "Using secrets",
+			"url": "/en/actions/secrets",
+			"highlights": {"content_explicit": ["one <mark>secret</mark>", "two", "three", "four", "five"]}
+		}]
+	}`)
+	withTerminalWidth(t, 100)
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		notWant []string
+	}{
+		{"default", []string{"--plain"}, []string{"• three\n", "… and 2 more matches\n"}, []string{"four"}},
+		{"limit", []string{"--plain", "--max-highlights", "1"}, []string{"• one *secret*\n", "… and 4 more matches\n"}, []string{"two"}},
+		{"unlimited", []string{"--plain", "--max-highlights", "0"}, []string{"• five\n"}, []string{"more matches"}},
+		{"compact", []string{"--plain", "--layout", "compact"}, []string{"• three\n", "… and 2 more matches\n"}, []string{"four"}},
+		{"pretty", []string{"--no-color"}, []string{"three", "… and 2 more matches"}, []string{"four"}},
+		{"json", []string{"--format", "json", "--max-highlights", "1"}, []string{`"five"`}, []string{"more matches"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(append(tt.args, "--include-matched-content", "--no-anchors", "secrets"), &stdout, &stderr); code != 0 {
+				t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(stdout.String(), want) {
+					t.Errorf("Expected %q in:\n%s", want, stdout.String())
+				}
+			}
+			for _, unwanted := range tt.notWant {
+				if strings.Contains(stdout.String(), unwanted) {
+					t.Errorf("Unexpected %q in:\n%s", unwanted, stdout.String())
+				}
+			}
+		})
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--max-highlights", "-1", "secrets"}, &stdout, &stderr); code != 1 || !strings.Contains(stderr.String(), "--max-highlights") {
+		t.Errorf("Expected a negative --max-highlights to be rejected, got exit code %d (stderr: %q)", code, stderr.String())
+	}
+}
+
+func TestRunMarkedHighlights(t *testing.T) {
+	body := `{
+		"meta": {"found": {"value": 1, "relation": "eq"}, "page": 1, "size": 5},
+		"hits": [{
+			"id": "1",
+			"title": "Using secrets in GitHub Actions",
+			"url": "/en/actions/secrets",
+			"highlights": {
+				"title": ["Using <mark>secrets</mark> in GitHub Actions"],
+				"content_explicit": ["Store <mark>secrets</mark> for <mark>workflows</mark>", "Unbalanced <mark>secrets"],
+				"term": ["<mark>secrets</mark>", "<mark>Secrets</mark>", "<mark>workflows</mark>"]
+			}
+		}]
+	}`
+
+	serveSearch(t, http.StatusOK, body)
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--plain", "--include-matched-content", "--highlights", "title,term", "--no-anchors", "--no-breadcrumbs", "secrets"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	for _, want := range []string{
+		"1. Using *secrets* in GitHub Actions\n",
+		"   • Store *secrets* for *workflows*\n",
+		"   • Unbalanced *secrets*\n",
+		"   Matched terms: *secrets*, *workflows*\n",
+	} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("Expected %q in:\n%s", want, stdout.String())
+		}
+	}
+
+	// Term highlights the API sends unasked aren't listed, in any layout
+	for _, args := range [][]string{{"--plain"}, {"--plain", "--layout", "compact"}, {"--plain", "--layout", "columns"}} {
+		stdout.Reset()
+		if code := run(append(args, "--no-anchors", "secrets"), &stdout, &stderr); code != 0 {
+			t.Fatalf("%v: expected exit code 0, got %d (stderr: %s)", args, code, stderr.String())
+		}
+		if strings.Contains(stdout.String(), "Matched terms") {
+			t.Errorf("%v: expected no terms line without --highlights term, got:\n%s", args, stdout.String())
+		}
+	}
+
+	// A single term highlight is sent as a string
+	serveSearch(t, http.StatusOK, `{
+		"meta": {"found": {"value": 1, "relation": "eq"}, "page": 1, "size": 5},
+		"hits": [{"id": "1", "title": "Secrets", "url": "/en/secrets", "highlights": {"term": "<mark>secrets</mark>"}}]
+	}`)
+	for _, args := range [][]string{{"--plain"}, {"--plain", "--layout", "compact"}} {
+		stdout.Reset()
+		if code := run(append(args, "--highlights", "term", "--no-anchors", "secrets"), &stdout, &stderr); code != 0 {
+			t.Fatalf("%v: expected exit code 0, got %d (stderr: %s)", args, code, stderr.String())
+		}
+		if !strings.Contains(stdout.String(), "Matched terms: *secrets*\n") {
+			t.Errorf("%v: expected the single term, got:\n%s", args, stdout.String())
+		}
+	}
+	serveSearch(t, http.StatusOK, body)
+
+	// Pretty output never shows the tags or the placeholders standing in for them
+	withTerminalWidth(t, 120)
+	stdout.Reset()
+	if code := run([]string{"--include-matched-content", "--no-anchors", "secrets"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	for _, unwanted := range []string{"<mark>", "</mark>", markOpen, markClose} {
+		if strings.Contains(stdout.String(), unwanted) {
+			t.Errorf("Unexpected %q in:\n%s", unwanted, stdout.String())
+		}
+	}
+	if !strings.Contains(stdout.String(), "Store secrets for workflows") {
+		t.Errorf("Expected the matched content, got:\n%s", stdout.String())
+	}
+
+	// Without colors matched terms fall back to brackets, in plain and pretty output
+	for _, args := range [][]string{{"--plain", "--no-color"}, {"--no-color"}} {
+		stdout.Reset()
+		if code := run(append(args, "--include-matched-content", "--no-anchors", "secrets"), &stdout, &stderr); code != 0 {
+			t.Fatalf("%v: expected exit code 0, got %d (stderr: %s)", args, code, stderr.String())
+		}
+		if !strings.Contains(stdout.String(), "Store [secrets] for [workflows]") || strings.Contains(stdout.String(), "\x1b[") {
+			t.Errorf("%v: expected bracketed terms and no escape codes, got:\n%s", args, stdout.String())
+		}
+	}
+
+	t.Setenv("GH_SEARCH_DOCS_HIGHLIGHT", "magenta")
+	if code := run([]string{"--highlight-style", "underline", "secrets"}, &stdout, &stderr); code != 0 {
+		t.Errorf("Expected a valid --highlight-style to win over the environment, got %d (stderr: %s)", code, stderr.String())
+	}
+	for _, tt := range []struct {
+		args []string
+		env  string
+		want string
+	}{
+		{[]string{"--highlight-style", "purple", "secrets"}, "", `unknown --highlight-style "purple" (use bold, underline, reverse, black, red, green, yellow, blue, magenta, cyan, white)`},
+		{[]string{"secrets"}, "blink", `unknown GH_SEARCH_DOCS_HIGHLIGHT style "blink"`},
+	} {
+		t.Setenv("GH_SEARCH_DOCS_HIGHLIGHT", tt.env)
+		stderr.Reset()
+		if code := run(tt.args, &stdout, &stderr); code != 1 {
+			t.Errorf("%v: expected exit code 1, got %d", tt.args, code)
+		}
+		if !strings.Contains(stderr.String(), tt.want) {
+			t.Errorf("%v: expected %q, got %q", tt.args, tt.want, stderr.String())
+		}
+	}
+}
+
+func TestRunWrapsPrettyOutput(t *testing.T) {
+	url := "/en/pull-requests/collaborating-with-pull-requests/proposing-changes-to-your-work-with-pull-requests/about-pull-requests"
+	intro := "Pull requests let you tell others about changes you've pushed to a branch in a repository on GitHub."
+	serveSearch(t, http.StatusOK, `{
+		"meta": {"found": {"value": 1, "relation": "eq"}, "page": 1, "size": 5},
+		"hits": [{"id": "1", "title": "About pull requests", "url": "`+url+`", "intro": "`+intro+`"}]
+	}`)
+	args := []string{"--full-intro", "--no-color", "--layout", "full", "--no-anchors", "--no-breadcrumbs", "pull requests"}
+
+	// Intros longer than the terminal are wrapped, while the URL stays whole on its own line
+	withTerminalWidth(t, 50)
+	var stdout, stderr bytes.Buffer
+	if code := run(args, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "\n  https://docs.github.com"+url+"\n") {
+		t.Errorf("Expected the URL unwrapped on its own line, got:\n%s", stdout.String())
+	}
+	if !strings.Contains(stdout.String(), "  Pull requests let you tell others about\n  changes you've pushed to a branch in a\n") {
+		t.Errorf("Expected the intro wrapped to 46 columns, got:\n%s", stdout.String())
+	}
+
+	// Narrow terminals aren't wrapped at all
+	withTerminalWidth(t, 30)
+	stdout.Reset()
+	if code := run(args, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "  "+intro+"\n") {
+		t.Errorf("Expected the intro unwrapped, got:\n%s", stdout.String())
+	}
+}
+
+func TestRunWidth(t *testing.T) {
+	intro := "Pull requests let you tell others about changes you've pushed to a branch in a repository on GitHub."
+	serveSearch(t, http.StatusOK, `{
+		"meta": {"found": {"value": 1, "relation": "eq"}, "page": 1, "size": 5},
+		"hits": [{"id": "1", "title": "About pull requests", "url": "/en/pull-requests/about-pull-requests", "intro": "`+intro+`"}]
+	}`)
+	args := []string{"--full-intro", "--no-color", "--layout", "full", "--no-anchors", "--no-breadcrumbs", "pull requests"}
+
+	// --width wins over the detected width
+	withTerminalWidth(t, 60)
+	var stdout, stderr bytes.Buffer
+	if code := run(append([]string{"--width", "50"}, args...), &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "  Pull requests let you tell others about\n  changes you've pushed to a branch in a\n") {
+		t.Errorf("Expected the intro wrapped to 46 columns, got:\n%s", stdout.String())
+	}
+
+	// --width 0 turns wrapping off, however wide the terminal is
+	stdout.Reset()
+	if code := run(append([]string{"--width", "0"}, args...), &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "  "+intro+"\n") {
+		t.Errorf("Expected the intro unwrapped, got:\n%s", stdout.String())
+	}
+
+	stderr.Reset()
+	if code := run([]string{"--width", "-1", "pull requests"}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1 for a negative --width, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "--width must not be negative") {
+		t.Errorf("Expected the negative width error, got %q", stderr.String())
+	}
+}
+
+func TestRunTruncate(t *testing.T) {
+	intro := "Personal access tokens are an alternative to using passwords — ログイン for authentication."
+	body := `{
+		"meta": {"found": {"value": 1, "relation": "eq"}, "page": 1, "size": 5},
+		"hits": [{"id": "1", "title": "Tokens", "url": "/en/tokens", "intro": "` + intro + `"}]
+	}`
+	serveSearch(t, http.StatusOK, body)
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"default", nil, intro},
+		{"limit", []string{"--truncate", "8"}, "Personal..."},
+		{"intro-length", []string{"--intro-length", "8"}, "Personal..."},
+		{"word boundary", []string{"--truncate", "65"}, "Personal access tokens are an alternative to using passwords..."},
+		{"multi-byte", []string{"--truncate", "67"}, "Personal access tokens are an alternative to using passwords — ログイン..."},
+		{"zero", []string{"--truncate", "0"}, intro},
+		{"no-truncate", []string{"--no-truncate"}, intro},
+		{"full-intro", []string{"tokens", "--full-intro"}, intro},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(append(tt.args, "--plain", "--no-anchors", "tokens"), &stdout, &stderr); code != 0 {
+				t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+			}
+			if !strings.Contains(stdout.String(), "   "+tt.want+"\n") {
+				t.Errorf("Expected the intro %q, got:\n%s", tt.want, stdout.String())
+			}
+		})
+	}
+
+	// In a terminal the default follows the width, unless a length is given
+	withTerminalWidth(t, 30)
+	for _, tt := range []struct {
+		args []string
+		want string
+	}{
+		{nil, "Personal access tokens are an alternative to using..."},
+		{[]string{"--intro-length", "150"}, intro},
+	} {
+		var stdout, stderr bytes.Buffer
+		if code := run(append(tt.args, "--plain", "--layout", "full", "--no-anchors", "tokens"), &stdout, &stderr); code != 0 {
+			t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+		}
+		if !strings.Contains(stdout.String(), "   "+tt.want+"\n") {
+			t.Errorf("%v: expected the intro %q, got:\n%s", tt.args, tt.want, stdout.String())
+		}
+	}
+
+	// Full intros in pretty output are wrapped to the terminal, indented under their title
+	withTerminalWidth(t, 44)
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--full-intro", "--no-color", "--layout", "full", "--no-anchors", "tokens"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "  Personal access tokens are an\n  alternative to using passwords —\n  ログイン for authentication.\n") {
+		t.Errorf("Expected the full intro wrapped to 40 columns, got:\n%s", stdout.String())
+	}
+
+	for _, args := range [][]string{{"--truncate", "-1"}, {"--intro-length", "-1"}, {"--no-truncate", "--truncate", "20"}, {"--no-truncate", "--intro-length", "20"}, {"--full-intro", "--truncate", "20"}} {
+		var stdout, stderr bytes.Buffer
+		if code := run(append(args, "tokens"), &stdout, &stderr); code != 1 {
+			t.Errorf("%v: expected exit code 1, got %d", args, code)
+		}
+	}
+}
+
+func TestRunDecodeEntities(t *testing.T) {
+	body := `{
+		"meta": {"found": {"value": 1, "relation": "eq"}, "page": 1, "size": 5},
+		"hits": [{
+			"id": "1",
+			"title": "Fork &amp; pull",
+			"url": "/en/pull-requests/fork",
+			"intro": "Use &quot;gh&quot; &#39;now&#39; &#x3E; later, and escape &amp;lt;b&amp;gt; once.",
+			"highlights": {"content_explicit": ["<mark>fork</mark> &amp; pull &#8212; done"]}
+		}]
+	}`
+	serveSearch(t, http.StatusOK, body)
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"plain", []string{"--plain", "--include-matched-content"}, []string{"1. Fork & pull", "• *fork* & pull — done"}},
+		{"plain intro", []string{"--plain", "--no-truncate"}, []string{`Use "gh" 'now' > later, and escape &lt;b&gt; once.`}},
+		{"csv", []string{"--format", "csv"}, []string{"Fork & pull"}},
+		{"markdown", []string{"--format", "markdown"}, []string{"[Fork & pull]"}},
+		{"html", []string{"--format", "html"}, []string{">Fork &amp; pull</a>"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(append(tt.args, "--no-anchors", "fork"), &stdout, &stderr); code != 0 {
+				t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(stdout.String(), want) {
+					t.Errorf("Expected %q in:\n%s", want, stdout.String())
+				}
+			}
+		})
+	}
+
+	// JSON keeps the text exactly as the API sent it
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--format", "json", "--no-anchors", "fork"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	var result SearchResult
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	hit := result.Hits[0]
+	if hit.Title != "Fork &amp; pull" || !strings.HasPrefix(hit.Intro, "Use &quot;gh&quot;") {
+		t.Errorf("Expected JSON output to keep the entities, got %q and %q", hit.Title, hit.Intro)
+	}
+	if got := searchdocs.HighlightStrings(hit, "content_explicit"); len(got) != 1 || got[0] != "<mark>fork</mark> &amp; pull &#8212; done" {
+		t.Errorf("Expected JSON highlights to keep the entities, got %q", got)
+	}
+}
+
+func TestRunStripSnippetTags(t *testing.T) {
+	body := `{
+		"meta": {"found": {"value": 1, "relation": "eq"}, "page": 1, "size": 5},
+		"hits": [{
+			"id": "1",
+			"title": "Using secrets",
+			"url": "/en/actions/secrets",
+			"highlights": {"content_explicit": [
+				"Store <em>API</em> keys as <mark>secrets</mark> with <code>gh secret set</code>",
+				"See <a href=\"/en/actions?a=1>2\">the docs</a> for &lt;details&gt; blocks <mark"
+			]}
+		}]
+	}`
+	serveSearch(t, http.StatusOK, body)
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"plain", []string{"--plain"}, []string{
+			"• Store API keys as *secrets* with gh secret set",
+			"• See the docs for <details> blocks",
+		}},
+		{"markdown", []string{"--format", "markdown"}, []string{
+			"Store API keys as **secrets** with gh secret set",
+			"See the docs for <details> blocks",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			args := append(tt.args, "--include-matched-content", "--no-anchors", "secrets")
+			if code := run(args, &stdout, &stderr); code != 0 {
+				t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(stdout.String(), want) {
+					t.Errorf("Expected %q in:\n%s", want, stdout.String())
+				}
+			}
+			for _, tag := range []string{"<em>", "<code>", "<a ", "</a>", "<mark"} {
+				if strings.Contains(stdout.String(), tag) {
+					t.Errorf("Expected %q to be stripped from:\n%s", tag, stdout.String())
+				}
+			}
+		})
+	}
+}
+
+func TestRunShowContent(t *testing.T) {
+	body := `{
+		"meta": {"found": {"value": 2, "relation": "eq"}, "page": 1, "size": 5},
+		"hits": [
+			{"id": "1", "title": "Quickstart for GitHub Actions", "url": "/en/actions/quickstart"},
+			{"id": "2", "title": "Removed page", "url": "/en/actions/removed"}
+		]
+	}`
+	article := "# Quickstart\n\nCreate a <code>.github/workflows</code> directory &amp; add a workflow.\n"
+	articles := map[string]int{}
+	serveHTTP(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/search/v1":
+			_, _ = w.Write([]byte(body))
+		case "/api/article/body":
+			pathname := r.URL.Query().Get("pathname")
+			articles[pathname]++
+			if pathname != "/en/actions/quickstart" {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write([]byte(article))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--show-content", "--plain", "--no-anchors", "actions"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "   Create a .github/workflows directory & add a workflow.\n") {
+		t.Errorf("Expected the article without HTML below the hit, got:\n%s", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "Warning: couldn't fetch the content of /en/actions/removed") {
+		t.Errorf("Expected a warning for the missing article, got: %q", stderr.String())
+	}
+
+	// Pretty output renders the article, and fetched articles come from the cache
+	stdout.Reset()
+	stderr.Reset()
+	if code := run([]string{"--show-content", "--no-anchors", "actions"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "  Create a .github/workflows directory & add a workflow.") {
+		t.Errorf("Expected the article rendered as Markdown, got:\n%s", stdout.String())
+	}
+	if articles["/en/actions/quickstart"] != 1 {
+		t.Errorf("Expected the article to be fetched once, got %d requests", articles["/en/actions/quickstart"])
+	}
+
+	// --debug shows the cache hits
+	stderr.Reset()
+	if code := run([]string{"--show-content", "--plain", "--no-anchors", "--debug", "actions"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "Article cache: 1 hits, 1 misses") {
+		t.Errorf("Expected the article cache lookups with --debug, got: %q", stderr.String())
+	}
+
+	t.Run("pager", func(t *testing.T) {
+		withTerminalWidth(t, 80)
+		t.Setenv("GH_PAGER", "less -R")
+		var paged []string
+		oldHeight, oldPager := terminalHeight, runPager
+		runPager = func(pager string, fn func(io.Writer)) error {
+			var buf bytes.Buffer
+			fn(&buf)
+			paged = append(paged, buf.String())
+			return nil
+		}
+		t.Cleanup(func() { terminalHeight, runPager = oldHeight, oldPager })
+
+		for _, tt := range []struct {
+			height    int
+			wantPaged bool
+		}{
+			{height: 5, wantPaged: true},
+			{height: 200, wantPaged: false},
+		} {
+			terminalHeight = func() int { return tt.height }
+			paged = nil
+			stdout.Reset()
+			if code := run([]string{"--show-content", "--plain", "--no-anchors", "actions"}, &stdout, &stderr); code != 0 {
+				t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+			}
+			if tt.wantPaged {
+				if len(paged) != 1 || !strings.Contains(paged[0], "add a workflow") || stdout.Len() != 0 {
+					t.Errorf("height %d: expected the output to go through the pager, paged %q, stdout %q", tt.height, paged, stdout.String())
+				}
+			} else if len(paged) != 0 || !strings.Contains(stdout.String(), "add a workflow") {
+				t.Errorf("height %d: expected output that fits to skip the pager, paged %q", tt.height, paged)
+			}
+		}
+	})
+
+	for _, args := range [][]string{
+		{"--show-content", "--format", "json", "actions"},
+		{"--show-content", "--refs", "actions"},
+		{"--show-content", "--layout", "compact", "actions"},
+	} {
+		stderr.Reset()
+		if code := run(args, &stdout, &stderr); code != 1 {
+			t.Errorf("%v: expected exit code 1, got %d", args, code)
+		}
+		if !strings.Contains(stderr.String(), "--show-content") {
+			t.Errorf("%v: unexpected stderr: %q", args, stderr.String())
+		}
+	}
+}
+
+func TestRunPager(t *testing.T) {
+	serveSearch(t, http.StatusOK, layoutFixture)
+	withTerminalWidth(t, 80)
+	t.Setenv("GH_PAGER", "less -FRX")
+	var paged []string
+	oldHeight, oldPager := terminalHeight, runPager
+	terminalHeight = func() int { return 3 }
+	runPager = func(pager string, fn func(io.Writer)) error {
+		var buf bytes.Buffer
+		fn(&buf)
+		paged = append(paged, buf.String())
+		return nil
+	}
+	t.Cleanup(func() { terminalHeight, runPager = oldHeight, oldPager })
+
+	tests := []struct {
+		name      string
+		args      []string
+		wantPaged bool
+	}{
+		{"pretty", []string{"tokens"}, true},
+		{"plain", []string{"--plain", "tokens"}, true},
+		{"no pager", []string{"--no-pager", "tokens"}, false},
+		{"json", []string{"--format", "json", "tokens"}, false},
+		{"csv", []string{"--format", "csv", "tokens"}, false},
+		{"template", []string{"--template", "{{.Title}}", "tokens"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paged = nil
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, &stdout, &stderr); code != 0 {
+				t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+			}
+			if tt.wantPaged && (len(paged) != 1 || stdout.Len() != 0) {
+				t.Errorf("Expected the output to go through the pager, paged %q, stdout %q", paged, stdout.String())
+			}
+			if !tt.wantPaged && (len(paged) != 0 || stdout.Len() == 0) {
+				t.Errorf("Expected the output on stdout, paged %q", paged)
+			}
+		})
+	}
+
+	// Without a terminal nothing is paged
+	stdoutIsTerminal = func() bool { return false }
+	paged = nil
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"tokens"}, &stdout, &stderr); code != 0 || len(paged) != 0 {
+		t.Errorf("Expected piped output to skip the pager, got exit code %d and paged %q", code, paged)
+	}
+}
+
+func TestRunShowBreadcrumbs(t *testing.T) {
+	body := `{
+		"meta": {"found": {"value": 2, "relation": "eq"}, "page": 1, "size": 5},
+		"hits": [
+			{"id": "1", "title": "About billing", "url": "/en/billing/about-billing", "breadcrumbs": "Billing / Get started", "intro": "Learn about billing."},
+			{"id": "2", "title": "Webhooks", "url": "/en/webhooks"}
+		]
+	}`
+
+	for _, args := range [][]string{{"--plain"}, {}} {
+		serveSearch(t, http.StatusOK, body)
+
+		var stdout, stderr bytes.Buffer
+		if code := run(append(args, "--show-breadcrumbs", "--no-anchors", "billing"), &stdout, &stderr); code != 0 {
+			t.Fatalf("%v: expected exit code 0, got %d (stderr: %s)", args, code, stderr.String())
+		}
+		lines := strings.Split(stdout.String(), "\n")
+		for i, line := range lines {
+			if strings.Contains(line, "https://docs.github.com/en/billing/about-billing") {
+				if i+1 >= len(lines) || strings.TrimPrefix(strings.TrimSpace(lines[i+1]), "in: ") != "Billing › Get started" {
+					t.Errorf("%v: expected the breadcrumbs below the URL, got:\n%s", args, stdout.String())
+				}
+			}
+		}
+		if strings.Count(stdout.String(), "›") != 1 {
+			t.Errorf("%v: expected results without breadcrumbs to have no breadcrumb line:\n%s", args, stdout.String())
+		}
+	}
+
+	// Breadcrumbs are shown by default in plain output with an "in:" prefix
+	serveSearch(t, http.StatusOK, body)
+	var stdout, stderr bytes.Buffer
+	run([]string{"--plain", "--no-anchors", "billing"}, &stdout, &stderr)
+	if !strings.Contains(stdout.String(), "   https://docs.github.com/en/billing/about-billing\n   in: Billing › Get started\n") {
+		t.Errorf("Expected breadcrumbs by default, got:\n%s", stdout.String())
+	}
+
+	stdout.Reset()
+	run([]string{"--plain", "--no-breadcrumbs", "billing"}, &stdout, &stderr)
+	if strings.Contains(stdout.String(), "›") {
+		t.Errorf("Expected --no-breadcrumbs to hide breadcrumbs, got:\n%s", stdout.String())
+	}
+
+	if code := run([]string{"--show-breadcrumbs", "--no-breadcrumbs", "billing"}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1 for both flags, got %d", code)
+	}
+}
+
+func TestRunScope(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	requests := serveSearch(t, http.StatusOK, `{"meta": {"found": {"value": 0}}, "hits": []}`)
+
+	var stdout, stderr bytes.Buffer
+	run([]string{"--scope", "admin", "--toplevel", "billing", "--toplevel", "pages", "saml"}, &stdout, &stderr)
+	if len(*requests) != 1 {
+		t.Fatalf("Expected 1 request, got %d (stderr: %s)", len(*requests), stderr.String())
+	}
+	expected := []string{"billing", "pages", "admin", "organizations", "enterprise-onboarding", "authentication"}
+	if got := (*requests)[0]["toplevel"]; !reflect.DeepEqual(got, expected) {
+		t.Errorf("toplevel = %v, want %v", got, expected)
+	}
+
+	stderr.Reset()
+	if code := run([]string{"--scope", "ops", "saml"}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1 for an unknown scope, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), `unknown scope "ops" (available: admin, developer, security)`) {
+		t.Errorf("Expected the available scopes in the error, got %q", stderr.String())
+	}
+
+	stdout.Reset()
+	if code := run([]string{"--list-scopes"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0 from --list-scopes, got %d", code)
+	}
+	if !strings.Contains(stdout.String(), "  developer: actions, rest, graphql") {
+		t.Errorf("Expected scope expansions to be listed, got:\n%s", stdout.String())
+	}
+}
+
+func TestRunMan(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--man"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	for _, want := range []string{".TH GH-SEARCH-DOCS 1", ".SH OPTIONS", ".B \\-\\-show\\-content\n", ".B \\-\\-size \\fIint\\fR\n"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("Expected %q in the man page", want)
+		}
+	}
+}
+
+func TestRunCompletion(t *testing.T) {
+	requests := serveSearch(t, http.StatusOK, `{"meta": {}, "hits": []}`)
+
+	for _, shell := range searchdocs.CompletionShells {
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"--completion", shell}, &stdout, &stderr); code != 0 {
+			t.Fatalf("Expected exit code 0 for %s, got %d (stderr: %s)", shell, code, stderr.String())
+		}
+		for _, want := range []string{"gh-search-docs", "show-content", "no-truncate", "enterprise-server@"} {
+			if !strings.Contains(stdout.String(), want) {
+				t.Errorf("Expected %q in the %s completion script", want, shell)
+			}
+		}
+	}
+	if len(*requests) != 0 {
+		t.Errorf("Expected no search requests, got %d", len(*requests))
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--completion", "tcsh"}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1 for an unknown shell, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), `Error: unknown shell "tcsh" (use bash, zsh, fish, powershell)`) {
+		t.Errorf("Expected an unknown shell error, got %q", stderr.String())
+	}
+}
+
+func TestRunCacheStats(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", dir)
+
+	searches := searchdocs.NewFileCache(filepath.Join(dir, "gh-search-docs"), searchdocs.DefaultCacheTTL)
+	if err := searches.Set("https://docs.github.com/api/search/v1?query=ssh", &SearchResult{}); err != nil {
+		t.Fatal(err)
+	}
+	articles := searchdocs.NewArticleCache(filepath.Join(dir, "gh-search-docs", "articles"))
+	for _, path := range []string{"/en/actions/quickstart", "/en/actions/about"} {
+		if err := articles.Put(path, strings.Repeat("x", 2048)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"cache", "stats"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	for _, want := range []string{"search cache: ", "Entries: 1 (0 expired)", "TTL: 1h0m0s", "articles cache: ", "Entries: 2 (0 expired)", "of 50.0 MiB", "TTL: 24h0m0s"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("Expected %q in output, got:\n%s", want, stdout.String())
+		}
+	}
+
+	stdout.Reset()
+	if code := run([]string{"cache", "stats", "--format", "json"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	var caches []namedCacheStats
+	if err := json.Unmarshal(stdout.Bytes(), &caches); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v", err)
+	}
+	if len(caches) != 2 || caches[0].Name != "search" || caches[0].Entries != 1 || caches[1].Name != "articles" || caches[1].Entries != 2 {
+		t.Errorf("Unexpected cache stats: %+v", caches)
+	}
+
+	if code := run([]string{"cache", "clear"}, &stdout, &stderr); code != 2 {
+		t.Errorf("Expected usage exit code 2 for an unknown cache command, got %d", code)
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/Ebonsignori/gh-search-docs/searchdocs"
+)
+
+// fetchAllPages fetches every page of the search in searchURL for --all, up to --max-pages,
+// and merges their hits into one result that keeps the meta of the last page. Progress and a
+// note when --max-pages cut the search short go to stderr.
+func fetchAllPages(stderr io.Writer, client *http.Client, opts *options, searchURL *url.URL) (*SearchResult, error) {
+	params := searchURL.Query()
+	endpoint := *searchURL
+	endpoint.RawQuery = ""
+
+	fetcher := searchdocs.PageFetcher{Client: client, Endpoint: endpoint.String(), MaxPages: opts.maxPages, Progress: stderr}
+	result, err := fetcher.FetchAll(params)
+	if err != nil {
+		return nil, err
+	}
+	size, _ := strconv.Atoi(params.Get("size"))
+	if pages := searchdocs.TotalPages(result.Meta.Found.Value, size); pages > opts.maxPages {
+		fmt.Fprintf(stderr, "Stopped after %d of %d pages; use --max-pages to fetch more\n", opts.maxPages, pages)
+	}
+	return result, nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Ebonsignori/gh-search-docs/searchdocs"
+)
+
+// printRefs writes the hits as Markdown reference-link definitions, e.g.
+// [1]: https://docs.github.com/... "Title", numbered by rank so replies can cite [1], [2]
+// inline. With --refs-list a numbered list of the linked titles comes first. Only the
+// Markdown goes to stdout; notes and empty-result messages go to stderr.
+func printRefs(stdout, stderr io.Writer, opts *options, query string, hits []SearchItem, suppressed []filterCount) {
+	for _, note := range suppressedNotes(suppressed) {
+		fmt.Fprintf(stderr, "Note: %s\n", note)
+	}
+	if len(hits) == 0 {
+		fmt.Fprintf(stderr, "No results found for query: %s\n", query)
+		return
+	}
+
+	if opts.refsList {
+		for i, item := range hits {
+			fmt.Fprintf(stdout, "%d. [%s][%d]\n", i+1, searchdocs.EscapeLinkText(searchdocs.HitTitle(item)), i+1)
+		}
+		fmt.Fprintln(stdout)
+	}
+	for i, item := range hits {
+		fmt.Fprintf(stdout, "[%d]: %s %s\n", i+1, shownURL(opts, item), refTitle(searchdocs.HitTitle(item)))
+	}
+}
+
+// refTitle quotes a title for a Markdown reference-link definition
+func refTitle(title string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(title) + `"`
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/Ebonsignori/gh-search-docs/searchdocs"
+)
+
+// requestSearch fetches the first page of results for query, for the ways of searching that
+// run more than one: --queries-file, --watch, and --interactive. cache may be nil.
+func requestSearch(stderr io.Writer, client *http.Client, opts *options, cache *searchdocs.FileCache, query, version string) (*SearchResult, error) {
+	searchURL, err := url.Parse(opts.endpoint)
+	if err != nil {
+		return nil, err
+	}
+	searchURL.RawQuery = buildParams(opts, query, version).Encode()
+	return fetchPage(stderr, client, opts, cache, searchURL.String())
+}
+
+// searchCache returns the result cache for --cache, or nil without it. --format raw prints
+// the response exactly as the API sent it, which the cache doesn't keep, so it never uses it.
+func searchCache(opts *options) (*searchdocs.FileCache, error) {
+	if !opts.cache || opts.format == "raw" {
+		return nil, nil
+	}
+	return searchdocs.DefaultFileCache(opts.cacheTTL)
+}
+
+// cachedResult returns the result cached for searchURL, if cache holds a fresh one
+func cachedResult(stderr io.Writer, opts *options, cache *searchdocs.FileCache, searchURL string) (*SearchResult, bool) {
+	if cache == nil {
+		return nil, false
+	}
+	result, ok := cache.Get(searchURL)
+	if ok && opts.debug {
+		fmt.Fprintln(stderr, "Using cached response")
+	}
+	return result, ok
+}
+
+// cacheResult stores a successful result for searchURL. A failed write just means the next
+// run fetches.
+func cacheResult(stderr io.Writer, cache *searchdocs.FileCache, searchURL string, result *SearchResult) {
+	if cache == nil {
+		return
+	}
+	if err := cache.Set(searchURL, result); err != nil {
+		fmt.Fprintf(stderr, "Warning: could not cache the response: %v\n", err)
+	}
+}
+
+// fetchResponse requests searchURL and returns the response body and status
+func fetchResponse(client *http.Client, searchURL string) ([]byte, int, error) {
+	req, err := http.NewRequest(http.MethodGet, searchURL, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// The body is closed as soon as it's read so the request stops counting against
+	// --concurrency before any follow-up requests fan out
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, 0, err
+	}
+	return body, resp.StatusCode, nil
+}
+
+// fetchPage requests one page of results from searchURL and parses it, without any of the
+// client-side processing. Results come from and go to cache, which may be nil.
+func fetchPage(stderr io.Writer, client *http.Client, opts *options, cache *searchdocs.FileCache, searchURL string) (*SearchResult, error) {
+	if result, ok := cachedResult(stderr, opts, cache, searchURL); ok {
+		return result, nil
+	}
+	body, status, err := fetchResponse(client, searchURL)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("API returned status %d", status)
+	}
+
+	var result SearchResult
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("parsing response: %w", err)
+	}
+	cacheResult(stderr, cache, searchURL, &result)
+	return &result, nil
+}
+
+// processedSearch is a fetched search after the client-side processing
+type processedSearch struct {
+	result SearchResult
+	// suppressed counts the hits each client-side filter hid
+	suppressed []filterCount
+	// lastPage is the last page of results when --page is past it, and 0 otherwise
+	lastPage int
+}
+
+// processSearch applies everything done to a search between fetching and printing it, for
+// every way of running one: the check that --page isn't past the last page, --deduplicate,
+// the client-side filters and trimming to --size, the translation, availability, and content
+// checks, and heading anchors. Warnings go to stderr. --count only prints the found count, so
+// it skips all of it.
+func processSearch(stderr io.Writer, client *http.Client, opts *options, query, version string, result SearchResult) processedSearch {
+	var search processedSearch
+	if opts.count {
+		search.result = result
+		return search
+	}
+
+	// An empty page with results elsewhere means the requested page is past the end
+	pageSize, _ := strconv.Atoi(buildParams(opts, query, version).Get("size"))
+	if lastPage, beyond := beyondLastPage(&result, opts.page, pageSize); beyond {
+		search.lastPage = lastPage
+	}
+
+	if opts.deduplicate {
+		var removed int
+		if result.Hits, removed = deduplicateHits(result.Hits); removed > 0 && opts.verbose {
+			fmt.Fprintf(stderr, "Removed %d duplicate results\n", removed)
+		}
+	}
+
+	// Apply client-side filters, then trim back down to the requested size
+	result.Hits, search.suppressed = applyFilters(result.Hits, clientFilters(opts, query), opts.size)
+	if len(result.Hits) > opts.size {
+		result.Hits = result.Hits[:opts.size]
+	}
+	warnAllFiltered(stderr, opts, result.Hits, search.suppressed)
+
+	if languages := splitList(opts.translations); len(languages) > 0 {
+		checkTranslations(stderr, client, opts, languages, result.Hits)
+	}
+	if opts.checkAvailability {
+		checkAvailability(stderr, client, opts, result.Hits)
+	}
+	if opts.showContent {
+		fetchContent(stderr, client, opts, result.Hits)
+	}
+	if !opts.noAnchors {
+		addAnchors(opts, query, result.Hits)
+	}
+
+	search.result = result
+	return search
+}
+
+// record adds the search to the local history, with the number of hits shown, or with --count
+// the number found
+func (s processedSearch) record(stderr io.Writer, opts *options, query, version string) {
+	results := len(s.result.Hits)
+	if opts.count {
+		results = s.result.Meta.Found.Value
+	}
+	recordSearch(stderr, opts, query, version, results)
+}
+
+// writeSearch prints a processed search with outputResults and returns the exit code. A
+// --page past the last page is reported instead of the results, except in formats meant for
+// other programs, which still get their empty document.
+func writeSearch(stdout, stderr io.Writer, opts *options, query string, search processedSearch) int {
+	if search.lastPage > 0 {
+		message := fmt.Sprintf("Page %d is beyond the last page (%d) — try --page %d", opts.page, search.lastPage, search.lastPage)
+		switch {
+		case isResultsOnlyFormat(opts.format):
+			fmt.Fprintln(stderr, message)
+		case opts.refs || opts.hitTemplate != nil:
+			fmt.Fprintln(stderr, message)
+			return emptyExitCode(opts)
+		default:
+			meta := search.result.Meta
+			fmt.Fprintf(stdout, "Found %s results\n%s\n", searchdocs.FormatFoundCount(meta.Found.Value, meta.Found.Relation), message)
+			return emptyExitCode(opts)
+		}
+	}
+	return outputResults(stdout, stderr, opts, query, search.result, search.suppressed, nil)
+}
@@ -0,0 +1,55 @@
+package searchdocs
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Slugify converts a heading into the anchor docs.github.com generates for it. Like
+// github-slugger, it lowercases the text, drops punctuation and symbols (keeping letters,
+// numbers, marks, hyphens, and underscores), and turns each space into a hyphen, e.g.
+// "Step 2: Add the key" becomes "step-2-add-the-key".
+func Slugify(heading string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(heading)) {
+		switch {
+		case r == ' ':
+			b.WriteRune('-')
+		case r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.IsMark(r):
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// HeadingAnchor returns the anchor for the first heading among a page's headings that is
+// exactly heading, numbered like HeadingAnchors numbers repeats
+func HeadingAnchor(headings []string, heading string) string {
+	for i, anchor := range HeadingAnchors(headings) {
+		if headings[i] == heading {
+			return anchor
+		}
+	}
+	return Slugify(heading)
+}
+
+// HeadingAnchors returns the anchor of each of a page's headings, in page order. Like
+// github-slugger, a slug already used on the page gets the lowest free "-N" suffix, so the
+// second "Example" heading is "example-1" even if it's the third "example" slug in a row.
+func HeadingAnchors(headings []string) []string {
+	used := map[string]bool{}
+	counts := map[string]int{}
+	anchors := make([]string, len(headings))
+	for i, h := range headings {
+		slug := Slugify(h)
+		anchor := slug
+		for used[anchor] {
+			counts[slug]++
+			anchor = slug + "-" + strconv.Itoa(counts[slug])
+		}
+		used[anchor] = true
+		anchors[i] = anchor
+	}
+	return anchors
+}
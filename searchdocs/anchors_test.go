@@ -0,0 +1,64 @@
+package searchdocs
+
+import "testing"
+
+func TestSlugify(t *testing.T) {
+	// Expected anchors are taken from the heading links on docs.github.com
+	tests := []struct {
+		heading  string
+		expected string
+	}{
+		{"About SSH key passphrases", "about-ssh-key-passphrases"},
+		{"Step 2: Add the key to your account", "step-2-add-the-key-to-your-account"},
+		{"Creating a personal access token (classic)", "creating-a-personal-access-token-classic"},
+		{"Using GITHUB_TOKEN in a workflow", "using-github_token-in-a-workflow"},
+		{"What's new?", "whats-new"},
+		{"Re-running jobs", "re-running-jobs"},
+		{"Prerequisites for C++", "prerequisites-for-c"},
+		{"Permissions for the GITHUB_TOKEN / GitHub App", "permissions-for-the-github_token--github-app"},
+		{"Configuración de SAML", "configuración-de-saml"},
+		{"  Trailing spaces  ", "trailing-spaces"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.heading, func(t *testing.T) {
+			if got := Slugify(tt.heading); got != tt.expected {
+				t.Errorf("Slugify(%q) = %q, want %q", tt.heading, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHeadingAnchor(t *testing.T) {
+	headings := []string{"Example", "Usage", "Example", "Example!"}
+
+	tests := []struct {
+		heading  string
+		expected string
+	}{
+		{"Example", "example"},
+		{"Usage", "usage"},
+		{"Example!", "example-2"},
+		{"Not on the page", "not-on-the-page"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.heading, func(t *testing.T) {
+			if got := HeadingAnchor(headings, tt.heading); got != tt.expected {
+				t.Errorf("HeadingAnchor(%q) = %q, want %q", tt.heading, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHeadingAnchors(t *testing.T) {
+	// Repeats are numbered in page order, skipping suffixes a real heading already took
+	headings := []string{"Example", "Usage", "Example", "Example 1", "Example", "Example-1"}
+	expected := []string{"example", "usage", "example-1", "example-1-1", "example-2", "example-1-2"}
+	got := HeadingAnchors(headings)
+	for i := range headings {
+		if got[i] != expected[i] {
+			t.Errorf("HeadingAnchors()[%d] (%q) = %q, want %q", i, headings[i], got[i], expected[i])
+		}
+	}
+}
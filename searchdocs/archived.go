@@ -0,0 +1,189 @@
+package searchdocs
+
+import (
+	"encoding/xml"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ArchivedBaseURL hosts the frozen copies of enterprise server docs that are no longer supported
+const ArchivedBaseURL = "https://github.github.com"
+
+// ArchivedSitemapURL returns the sitemap of the archived docs for an enterprise server version,
+// e.g. "3.10"
+func ArchivedSitemapURL(version string) string {
+	return ArchivedBaseURL + "/docs-ghes-" + version + "/sitemap.xml"
+}
+
+// IsArchivedVersion reports whether version, e.g. "3.10", is an enterprise server release
+// older than every supported version
+func IsArchivedVersion(version string) bool {
+	if _, ok := parseServerVersion(version); !ok {
+		return false
+	}
+
+	supported := SupportedServerVersions()
+	if len(supported) == 0 {
+		return false
+	}
+	for _, v := range supported {
+		if compareServerVersions(version, v) >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// parseServerVersion parses a "major.minor" version
+func parseServerVersion(version string) ([2]int, bool) {
+	major, minor, ok := strings.Cut(version, ".")
+	if !ok {
+		return [2]int{}, false
+	}
+	maj, err := strconv.Atoi(major)
+	if err != nil || maj < 0 {
+		return [2]int{}, false
+	}
+	mnr, err := strconv.Atoi(minor)
+	if err != nil || mnr < 0 {
+		return [2]int{}, false
+	}
+	return [2]int{maj, mnr}, true
+}
+
+// compareServerVersions compares "major.minor" versions numerically, so 3.9 sorts before 3.10.
+// Unparseable versions sort first.
+func compareServerVersions(a, b string) int {
+	va, _ := parseServerVersion(a)
+	vb, _ := parseServerVersion(b)
+	for i := range va {
+		if va[i] != vb[i] {
+			if va[i] < vb[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// Sitemap is a parsed sitemap. Index sitemaps list other sitemaps rather than pages.
+type Sitemap struct {
+	// Index is true for a sitemap index, whose Locations are child sitemaps
+	Index     bool
+	Locations []string
+}
+
+// ParseSitemap parses a sitemap or sitemap index
+func ParseSitemap(r io.Reader) (*Sitemap, error) {
+	var doc struct {
+		XMLName xml.Name
+		URLs    []struct {
+			Loc string `xml:"loc"`
+		} `xml:"url"`
+		Sitemaps []struct {
+			Loc string `xml:"loc"`
+		} `xml:"sitemap"`
+	}
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	sitemap := &Sitemap{Index: doc.XMLName.Local == "sitemapindex"}
+	for _, u := range doc.URLs {
+		sitemap.Locations = append(sitemap.Locations, strings.TrimSpace(u.Loc))
+	}
+	for _, s := range doc.Sitemaps {
+		sitemap.Locations = append(sitemap.Locations, strings.TrimSpace(s.Loc))
+	}
+	return sitemap, nil
+}
+
+// ArchivedPage is a page from archived docs matched by SearchArchivedPages
+type ArchivedPage struct {
+	Title       string
+	URL         string
+	Breadcrumbs string
+	// Score is the number of query terms found in the page path
+	Score int
+}
+
+// SearchArchivedPages ranks sitemap page URLs by how many query terms appear in their paths.
+// Archived docs have no search index, so this is a best-effort match on URL slugs only. Pages
+// matching no terms are dropped; ties prefer shorter, more general paths.
+func SearchArchivedPages(locations []string, query string, limit int) []ArchivedPage {
+	terms := QueryTerms(query)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	var pages []ArchivedPage
+	for _, location := range locations {
+		u, err := url.Parse(location)
+		if err != nil {
+			continue
+		}
+		_, _, rest := SplitDocsPath(archivedDocsPath(u.Path))
+		words := FoldCase(strings.ReplaceAll(rest, "/", " "))
+
+		score := 0
+		for _, term := range terms {
+			if strings.Contains(words, term) {
+				score++
+			}
+		}
+		if score == 0 {
+			continue
+		}
+
+		segments := strings.Split(strings.Trim(rest, "/"), "/")
+		crumbs := make([]string, len(segments))
+		for i, segment := range segments {
+			crumbs[i] = slugTitle(segment)
+		}
+		pages = append(pages, ArchivedPage{
+			Title:       crumbs[len(crumbs)-1],
+			URL:         location,
+			Breadcrumbs: strings.Join(crumbs[:len(crumbs)-1], " / "),
+			Score:       score,
+		})
+	}
+
+	sort.SliceStable(pages, func(i, j int) bool {
+		if pages[i].Score != pages[j].Score {
+			return pages[i].Score > pages[j].Score
+		}
+		return len(pages[i].URL) < len(pages[j].URL)
+	})
+	if limit > 0 && len(pages) > limit {
+		pages = pages[:limit]
+	}
+	return pages
+}
+
+// archivedDocsPath strips the archive prefix, e.g. /docs-ghes-3.10, from an archived page path
+func archivedDocsPath(path string) string {
+	trimmed := strings.TrimPrefix(path, "/")
+	if strings.HasPrefix(trimmed, "docs-ghes-") {
+		if i := strings.Index(trimmed, "/"); i >= 0 {
+			return trimmed[i:]
+		}
+		return "/"
+	}
+	return path
+}
+
+// slugTitle turns a URL slug such as "configuring-saml" into "Configuring saml"
+func slugTitle(slug string) string {
+	title := strings.ReplaceAll(slug, "-", " ")
+	r, size := utf8.DecodeRuneInString(title)
+	if r == utf8.RuneError {
+		return title
+	}
+	return string(unicode.ToUpper(r)) + title[size:]
+}
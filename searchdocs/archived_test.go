@@ -0,0 +1,104 @@
+package searchdocs
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestIsArchivedVersion(t *testing.T) {
+	// Tests run from the package directory, so the hardcoded 3.14-3.17 fallback applies
+	tests := []struct {
+		version  string
+		archived bool
+	}{
+		{"3.13", true},
+		{"3.9", true},
+		{"2.22", true},
+		{"3.14", false},
+		{"3.17", false},
+		{"3.20", false},
+		{"latest", false},
+		{"3", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			if got := IsArchivedVersion(tt.version); got != tt.archived {
+				t.Errorf("IsArchivedVersion(%q) = %v, want %v", tt.version, got, tt.archived)
+			}
+		})
+	}
+}
+
+func TestParseSitemap(t *testing.T) {
+	tests := []struct {
+		file      string
+		index     bool
+		locations int
+	}{
+		{"archived-sitemap.xml", false, 5},
+		{"archived-sitemap-index.xml", true, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			f, err := os.Open(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			sitemap, err := ParseSitemap(f)
+			if err != nil {
+				t.Fatalf("ParseSitemap() error: %v", err)
+			}
+			if sitemap.Index != tt.index {
+				t.Errorf("Index = %v, want %v", sitemap.Index, tt.index)
+			}
+			if len(sitemap.Locations) != tt.locations {
+				t.Errorf("Got %d locations, want %d", len(sitemap.Locations), tt.locations)
+			}
+		})
+	}
+}
+
+func TestSearchArchivedPages(t *testing.T) {
+	f, err := os.Open(filepath.Join("testdata", "archived-sitemap.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	sitemap, err := ParseSitemap(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pages := SearchArchivedPages(sitemap.Locations, "configuring SAML", 5)
+	var titles []string
+	for _, page := range pages {
+		titles = append(titles, page.Title)
+	}
+	expected := []string{
+		"Configuring saml single sign on for your enterprise",
+		"Using saml for enterprise iam",
+	}
+	if !reflect.DeepEqual(titles, expected) {
+		t.Errorf("Titles = %q, want %q", titles, expected)
+	}
+	if got := pages[0].Breadcrumbs; got != "Admin / Identity and access management / Using saml for enterprise iam" {
+		t.Errorf("Breadcrumbs = %q", got)
+	}
+	if got := pages[0].URL; got != sitemap.Locations[1] {
+		t.Errorf("URL = %q, want the archived URL %q", got, sitemap.Locations[1])
+	}
+
+	if pages := SearchArchivedPages(sitemap.Locations, "saml", 1); len(pages) != 1 {
+		t.Errorf("Expected limit to cap results at 1, got %d", len(pages))
+	}
+	if pages := SearchArchivedPages(sitemap.Locations, "the", 5); pages != nil {
+		t.Errorf("Expected no results for a stopword-only query, got %v", pages)
+	}
+}
@@ -0,0 +1,281 @@
+package searchdocs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultArticleTTL is how long fetched article bodies are reused
+	DefaultArticleTTL = 24 * time.Hour
+	// DefaultArticleCacheBytes bounds the size of the article cache on disk
+	DefaultArticleCacheBytes = 50 << 20
+)
+
+// NormalizeArticlePath reduces a docs URL or path to the page path used to fetch and cache it,
+// dropping the host, query, fragment, trailing slash, and the redundant free-pro-team@latest
+// version. The language and version stay part of the path.
+func NormalizeArticlePath(rawURL string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return "", err
+	}
+	if u.Path == "" || u.Path == "/" {
+		return "", fmt.Errorf("%q is not a docs page", rawURL)
+	}
+
+	language, version, rest := SplitDocsPath(u.Path)
+	path := "/" + language
+	if segment := versionSegment(version); segment != "" {
+		path += "/" + segment
+	}
+	return strings.TrimSuffix(path+rest, "/"), nil
+}
+
+// articleEntry is the on-disk format of a cached article
+type articleEntry struct {
+	Path      string    `json:"path"`
+	FetchedAt time.Time `json:"fetchedAt"`
+	Body      string    `json:"body"`
+}
+
+// ArticleCache stores fetched article bodies on disk. Entries expire after TTL, and the least
+// recently used entries are evicted once the cache grows beyond MaxBytes.
+type ArticleCache struct {
+	Dir      string
+	TTL      time.Duration
+	MaxBytes int64
+
+	hits   atomic.Int64
+	misses atomic.Int64
+	now    func() time.Time
+}
+
+// NewArticleCache returns a cache in dir using the default TTL and size limit
+func NewArticleCache(dir string) *ArticleCache {
+	return &ArticleCache{
+		Dir:      dir,
+		TTL:      DefaultArticleTTL,
+		MaxBytes: DefaultArticleCacheBytes,
+		now:      time.Now,
+	}
+}
+
+// DefaultArticleCache returns the article cache in the user's cache directory
+func DefaultArticleCache() (*ArticleCache, error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return NewArticleCache(filepath.Join(dir, "articles")), nil
+}
+
+func (c *ArticleCache) file(path string) string {
+	sum := sha256.Sum256([]byte(path))
+	return filepath.Join(c.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Get returns the cached body for a normalized article path if it hasn't expired
+func (c *ArticleCache) Get(path string) (string, bool) {
+	file := c.file(path)
+	// #nosec G304 -- the file name is a hash inside the cache directory
+	data, err := os.ReadFile(file)
+	if err != nil {
+		c.misses.Add(1)
+		return "", false
+	}
+
+	var entry articleEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Path != path || c.now().Sub(entry.FetchedAt) > c.TTL {
+		c.misses.Add(1)
+		return "", false
+	}
+
+	// The modification time tracks the last use for LRU eviction
+	now := c.now()
+	_ = os.Chtimes(file, now, now)
+	c.hits.Add(1)
+	return entry.Body, true
+}
+
+// Lookups returns how many calls to Get found a fresh entry and how many didn't
+func (c *ArticleCache) Lookups() (hits, misses int64) {
+	return c.hits.Load(), c.misses.Load()
+}
+
+// Stats counts the cached articles and their size. An article is expired once it was
+// fetched more than TTL ago.
+func (c *ArticleCache) Stats() (CacheStats, error) {
+	stats := CacheStats{Dir: c.Dir, TTL: c.TTL, MaxBytes: c.MaxBytes}
+	files, err := c.files()
+	if err != nil {
+		return stats, err
+	}
+	for _, f := range files {
+		stats.Entries++
+		stats.Bytes += f.size
+		// #nosec G304 -- the file name is a hash inside the cache directory
+		data, err := os.ReadFile(f.path)
+		if err != nil {
+			continue
+		}
+		var entry articleEntry
+		if json.Unmarshal(data, &entry) != nil || c.now().Sub(entry.FetchedAt) > c.TTL {
+			stats.Expired++
+		}
+	}
+	return stats, nil
+}
+
+// Put stores the body for a normalized article path, then evicts least recently used entries
+// if the cache is over its size limit
+func (c *ArticleCache) Put(path, body string) error {
+	data, err := json.Marshal(articleEntry{Path: path, FetchedAt: c.now(), Body: body})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.Dir, 0o750); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so concurrent readers never see a partial entry
+	tmp, err := os.CreateTemp(c.Dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	file := c.file(path)
+	if err := os.Rename(tmp.Name(), file); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	now := c.now()
+	_ = os.Chtimes(file, now, now)
+
+	return c.evict()
+}
+
+// cacheFile is an entry file found on disk
+type cacheFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+func (c *ArticleCache) files() ([]cacheFile, error) {
+	entries, err := os.ReadDir(c.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var files []cacheFile
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, cacheFile{path: filepath.Join(c.Dir, entry.Name()), size: info.Size(), modTime: info.ModTime()})
+	}
+	return files, nil
+}
+
+// evict removes least recently used entries until the cache fits within MaxBytes
+func (c *ArticleCache) evict() error {
+	if c.MaxBytes <= 0 {
+		return nil
+	}
+	files, err := c.files()
+	if err != nil {
+		return err
+	}
+
+	var total int64
+	for _, f := range files {
+		total += f.size
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+	for _, f := range files {
+		if total <= c.MaxBytes {
+			break
+		}
+		if err := os.Remove(f.path); err == nil || errors.Is(err, os.ErrNotExist) {
+			total -= f.size
+		}
+	}
+	return nil
+}
+
+// ArticleFetcher fetches article bodies from the docs article API through a cache. Every
+// feature that needs article content goes through FetchArticle so they share the cache.
+type ArticleFetcher struct {
+	Client  *http.Client
+	BaseURL string
+	// Cache is optional; without it every call fetches from the network
+	Cache *ArticleCache
+}
+
+// FetchArticle returns the markdown body of the docs page at rawURL, which may be a full URL
+// or a path such as /en/actions/quickstart
+func (f *ArticleFetcher) FetchArticle(ctx context.Context, rawURL string) (string, error) {
+	path, err := NormalizeArticlePath(rawURL)
+	if err != nil {
+		return "", err
+	}
+	if f.Cache != nil {
+		if body, ok := f.Cache.Get(path); ok {
+			return body, nil
+		}
+	}
+
+	endpoint := f.BaseURL + "/api/article/body?pathname=" + url.QueryEscape(path)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := f.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fetching %s: status %d", path, resp.StatusCode)
+	}
+
+	if f.Cache != nil {
+		// Caching is best-effort; a failed write only costs a refetch next time
+		_ = f.Cache.Put(path, string(body))
+	}
+	return string(body), nil
+}
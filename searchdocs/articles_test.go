@@ -0,0 +1,169 @@
+package searchdocs
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNormalizeArticlePath(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"https://docs.github.com/en/actions/quickstart", "/en/actions/quickstart"},
+		{"/en/actions/quickstart/", "/en/actions/quickstart"},
+		{"https://docs.github.com/en/actions/quickstart?tool=cli#next-steps", "/en/actions/quickstart"},
+		{"/en/free-pro-team@latest/actions/quickstart", "/en/actions/quickstart"},
+		{"/ja/enterprise-server@3.17/admin", "/ja/enterprise-server@3.17/admin"},
+		{"/en/enterprise-cloud@latest/admin", "/en/enterprise-cloud@latest/admin"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := NormalizeArticlePath(tt.input)
+			if err != nil {
+				t.Fatalf("NormalizeArticlePath(%q) error: %v", tt.input, err)
+			}
+			if got != tt.expected {
+				t.Errorf("NormalizeArticlePath(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+
+	if _, err := NormalizeArticlePath("https://docs.github.com/"); err == nil {
+		t.Error("Expected an error for a URL without a page path")
+	}
+}
+
+func TestArticleCacheExpiry(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	cache := NewArticleCache(t.TempDir())
+	cache.now = func() time.Time { return now }
+
+	if err := cache.Put("/en/actions", "# Actions"); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	if body, ok := cache.Get("/en/actions"); !ok || body != "# Actions" {
+		t.Errorf("Get() = %q, %v; want a cache hit", body, ok)
+	}
+	if _, ok := cache.Get("/ja/actions"); ok {
+		t.Error("Expected other languages to be cached separately")
+	}
+
+	now = now.Add(DefaultArticleTTL + time.Minute)
+	if _, ok := cache.Get("/en/actions"); ok {
+		t.Error("Expected the entry to expire after the TTL")
+	}
+	if entries, _ := os.ReadDir(cache.Dir); len(entries) != 1 {
+		t.Errorf("Expected expired entries to stay on disk until evicted, got %d entries", len(entries))
+	}
+}
+
+func TestArticleCacheEviction(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	cache := NewArticleCache(t.TempDir())
+	cache.now = func() time.Time { return now }
+	body := strings.Repeat("x", 1000)
+
+	for _, path := range []string{"/en/a", "/en/b", "/en/c"} {
+		if err := cache.Put(path, body); err != nil {
+			t.Fatal(err)
+		}
+		now = now.Add(time.Minute)
+	}
+	// Using /en/a makes /en/b the least recently used entry
+	if _, ok := cache.Get("/en/a"); !ok {
+		t.Fatal("Expected /en/a to be cached")
+	}
+	now = now.Add(time.Minute)
+
+	files, _ := cache.files()
+	cache.MaxBytes = 0
+	for _, f := range files {
+		cache.MaxBytes += f.size
+	}
+	if err := cache.Put("/en/d", body); err != nil {
+		t.Fatal(err)
+	}
+
+	for path, want := range map[string]bool{"/en/a": true, "/en/b": false, "/en/c": true, "/en/d": true} {
+		if _, ok := cache.Get(path); ok != want {
+			t.Errorf("Get(%q) hit = %v, want %v", path, ok, want)
+		}
+	}
+}
+
+func TestFetchArticle(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path != "/api/article/body" {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.URL.Query().Get("pathname") {
+		case "/en/actions/quickstart":
+			_, _ = w.Write([]byte("# Quickstart"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	fetcher := &ArticleFetcher{Client: server.Client(), BaseURL: server.URL, Cache: NewArticleCache(t.TempDir())}
+
+	for _, u := range []string{"https://docs.github.com/en/actions/quickstart#next", "/en/actions/quickstart/"} {
+		body, err := fetcher.FetchArticle(context.Background(), u)
+		if err != nil {
+			t.Fatalf("FetchArticle(%q) error: %v", u, err)
+		}
+		if body != "# Quickstart" {
+			t.Errorf("FetchArticle(%q) = %q", u, body)
+		}
+	}
+	if requests.Load() != 1 {
+		t.Errorf("Expected the second fetch to be served from the cache, got %d requests", requests.Load())
+	}
+
+	if _, err := fetcher.FetchArticle(context.Background(), "/en/missing"); err == nil {
+		t.Error("Expected an error for a missing article")
+	}
+	if entries, _ := os.ReadDir(fetcher.Cache.Dir); len(entries) != 1 {
+		t.Errorf("Expected failed fetches not to be cached, got %d entries", len(entries))
+	}
+}
+
+func TestArticleCacheStats(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	cache := NewArticleCache(t.TempDir())
+	cache.now = func() time.Time { return now }
+
+	for _, path := range []string{"/en/a", "/en/b"} {
+		if err := cache.Put(path, "# Body"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cache.Get("/en/a")
+	cache.Get("/en/missing")
+	if hits, misses := cache.Lookups(); hits != 1 || misses != 1 {
+		t.Errorf("Lookups() = %d, %d; want 1, 1", hits, misses)
+	}
+
+	now = now.Add(DefaultArticleTTL + time.Minute)
+	if err := cache.Put("/en/c", "# Body"); err != nil {
+		t.Fatal(err)
+	}
+	stats, err := cache.Stats()
+	if err != nil {
+		t.Fatalf("Stats() error: %v", err)
+	}
+	if stats.Entries != 3 || stats.Expired != 2 || stats.Bytes == 0 || stats.MaxBytes != DefaultArticleCacheBytes || stats.TTL != DefaultArticleTTL {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
@@ -0,0 +1,64 @@
+package searchdocs
+
+import "net/http"
+
+// Plan is a GitHub plan whose docs version can be checked for availability
+type Plan struct {
+	// Label is the short name shown in badges, e.g. GHES
+	Label string
+	// Version is the docs version for the plan
+	Version string
+}
+
+// AvailabilityPlans returns the plans checked for availability: free-pro-team,
+// enterprise-cloud, and the latest enterprise server
+func AvailabilityPlans() []Plan {
+	return []Plan{
+		{Label: "FPT", Version: "free-pro-team"},
+		{Label: "GHEC", Version: "enterprise-cloud"},
+		{Label: "GHES", Version: LatestServerVersion()},
+	}
+}
+
+// AvailabilityChecker probes docs.github.com for the equivalent of pages in other plans
+type AvailabilityChecker struct {
+	prober *PageProber
+}
+
+// NewAvailabilityChecker returns a checker issuing requests with client against baseURL
+func NewAvailabilityChecker(client *http.Client, baseURL string, concurrency int) *AvailabilityChecker {
+	return &AvailabilityChecker{prober: NewPageProber(client, baseURL, concurrency)}
+}
+
+// Check reports, for each path, whether the page exists in each plan. The result has one
+// map per path keyed by plan label. A path is known to exist in its own version, so only
+// the other plans are probed. If any probe fails its plan is left out of the map, since it
+// is unknown rather than missing, and the first error is returned alongside the results.
+func (c *AvailabilityChecker) Check(paths []string, plans []Plan) ([]map[string]bool, error) {
+	results := make([]map[string]bool, len(paths))
+
+	var (
+		probes  []string
+		targets []struct{ path, label int }
+	)
+	for i, path := range paths {
+		results[i] = make(map[string]bool, len(plans))
+		_, version, _ := SplitDocsPath(path)
+		for j, plan := range plans {
+			if versionSegment(plan.Version) == version {
+				results[i][plan.Label] = true
+				continue
+			}
+			probes = append(probes, RewriteVersion(path, plan.Version))
+			targets = append(targets, struct{ path, label int }{i, j})
+		}
+	}
+
+	exists, errs := c.prober.CheckAll(probes)
+	for k, target := range targets {
+		if errs[k] == nil {
+			results[target.path][plans[target.label].Label] = exists[k]
+		}
+	}
+	return results, firstError(errs)
+}
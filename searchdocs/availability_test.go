@@ -0,0 +1,66 @@
+package searchdocs
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestAvailabilityCheckerCheck(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		requested []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested = append(requested, r.URL.Path)
+		mu.Unlock()
+
+		switch r.URL.Path {
+		case "/en/enterprise-cloud@latest/actions/quickstart",
+			"/en/enterprise-server@3.17/actions/quickstart",
+			"/en/enterprise-cloud@latest/copilot/overview",
+			"/en/admin/overview":
+			w.WriteHeader(http.StatusOK)
+		case "/en/enterprise-server@3.17/copilot/overview":
+			// Pages missing from a version redirect to another version
+			http.Redirect(w, r, "/en/enterprise-cloud@latest/copilot/overview", http.StatusFound)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	plans := []Plan{
+		{Label: "FPT", Version: "free-pro-team"},
+		{Label: "GHEC", Version: "enterprise-cloud"},
+		{Label: "GHES", Version: "enterprise-server@3.17"},
+	}
+	checker := NewAvailabilityChecker(server.Client(), server.URL, 2)
+	results, err := checker.Check([]string{
+		"/en/actions/quickstart",
+		"/en/copilot/overview",
+		"/en/enterprise-server@3.17/admin/overview",
+	}, plans)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []map[string]bool{
+		{"FPT": true, "GHEC": true, "GHES": true},
+		{"FPT": true, "GHEC": true, "GHES": false},
+		{"FPT": true, "GHEC": false, "GHES": true},
+	}
+	if !reflect.DeepEqual(results, expected) {
+		t.Errorf("Check() = %v, want %v", results, expected)
+	}
+
+	// Each hit's own version is never probed
+	for _, path := range requested {
+		if path == "/en/actions/quickstart" || path == "/en/enterprise-server@3.17/admin/overview" {
+			t.Errorf("Expected %s not to be probed", path)
+		}
+	}
+}
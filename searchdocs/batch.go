@@ -0,0 +1,93 @@
+package searchdocs
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// BatchResult is the outcome of one query of a batch
+type BatchResult struct {
+	Query  string
+	Result *SearchResult
+	Err    error
+}
+
+// ReadQueries reads one query per line for --queries-file, skipping blank lines and lines
+// starting with #
+func ReadQueries(r io.Reader) ([]string, error) {
+	var queries []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		queries = append(queries, line)
+	}
+	return queries, scanner.Err()
+}
+
+// RunParallelSearch fetches every query with up to concurrency fetches running at once, and
+// returns the outcomes in the order of queries once all of them are done. The error joins
+// those of the queries that failed, which are also in their BatchResult.
+func RunParallelSearch(queries []string, fetch func(string) (*SearchResult, error), concurrency int) ([]BatchResult, error) {
+	results := make([]BatchResult, len(queries))
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, max(concurrency, 1))
+	)
+	for i, query := range queries {
+		wg.Add(1)
+		go func(i int, query string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			// Each goroutine writes only its own index
+			result, err := fetch(query)
+			results[i] = BatchResult{Query: query, Result: result, Err: err}
+		}(i, query)
+	}
+	wg.Wait()
+
+	var errs []error
+	for _, result := range results {
+		if result.Err != nil {
+			errs = append(errs, fmt.Errorf("%q: %w", result.Query, result.Err))
+		}
+	}
+	return results, errors.Join(errs...)
+}
+
+// RunBatchSearch fetches and renders the results of each query in turn to w, under a
+// `--- Query N: "query" ---` separator. fetch is called once per query, in order. A query
+// that fails is reported on errw and the batch moves on to the next one. A summary of how
+// many queries ran and failed goes to errw at the end, and an error is returned if any
+// failed. To fetch several queries at once, fetch can hand out the results of
+// RunParallelSearch.
+func RunBatchSearch(queries []string, fetch func(query string) (*SearchResult, error), render func(*SearchResult, string, io.Writer), w, errw io.Writer) error {
+	failed := 0
+	for i, query := range queries {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "--- Query %d: %q ---\n", i+1, query)
+		result, err := fetch(query)
+		if err != nil {
+			fmt.Fprintf(errw, "Error: query %d (%q): %v\n", i+1, query, err)
+			failed++
+			continue
+		}
+		render(result, query, w)
+	}
+
+	fmt.Fprintf(errw, "Completed %d queries, %d errors\n", len(queries), failed)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d queries failed", failed, len(queries))
+	}
+	return nil
+}
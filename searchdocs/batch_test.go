@@ -0,0 +1,116 @@
+package searchdocs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReadQueries(t *testing.T) {
+	input := "# runners\nself-hosted runners\n\n  ssh keys  \r\n   # indented comment\ncode scanning"
+	queries, err := ReadQueries(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadQueries() error: %v", err)
+	}
+	want := []string{"self-hosted runners", "ssh keys", "code scanning"}
+	if !slices.Equal(queries, want) {
+		t.Errorf("ReadQueries() = %q, want %q", queries, want)
+	}
+}
+
+// batchResult returns a result with one hit titled title
+func batchResult(title string) *SearchResult {
+	return &SearchResult{Hits: []SearchItem{{Title: title}}}
+}
+
+func TestRunBatchSearch(t *testing.T) {
+	var fetched []string
+	fetch := func(query string) (*SearchResult, error) {
+		fetched = append(fetched, query)
+		if query == "broken" {
+			return nil, errors.New("API returned status 500")
+		}
+		return batchResult(strings.ToUpper(query)), nil
+	}
+	render := func(result *SearchResult, query string, w io.Writer) {
+		fmt.Fprintf(w, "%s: %s\n", query, result.Hits[0].Title)
+	}
+
+	var out, errOut strings.Builder
+	err := RunBatchSearch([]string{"ssh", "broken", "runners"}, fetch, render, &out, &errOut)
+	if err == nil {
+		t.Error("Expected an error when a query fails")
+	}
+	wantOut := "--- Query 1: \"ssh\" ---\nssh: SSH\n\n" +
+		"--- Query 2: \"broken\" ---\n\n" +
+		"--- Query 3: \"runners\" ---\nrunners: RUNNERS\n"
+	if out.String() != wantOut {
+		t.Errorf("output = %q, want %q", out.String(), wantOut)
+	}
+	wantErr := "Error: query 2 (\"broken\"): API returned status 500\nCompleted 3 queries, 1 errors\n"
+	if errOut.String() != wantErr {
+		t.Errorf("errors = %q, want %q", errOut.String(), wantErr)
+	}
+	if !slices.Equal(fetched, []string{"ssh", "broken", "runners"}) {
+		t.Errorf("Expected each query fetched once in order, got %q", fetched)
+	}
+
+	errOut.Reset()
+	if err := RunBatchSearch([]string{"ssh"}, fetch, render, io.Discard, &errOut); err != nil {
+		t.Errorf("RunBatchSearch() error: %v", err)
+	}
+	if errOut.String() != "Completed 1 queries, 0 errors\n" {
+		t.Errorf("Errors = %q", errOut.String())
+	}
+}
+
+func TestRunParallelSearch(t *testing.T) {
+	var (
+		mu               sync.Mutex
+		running, maximum int
+	)
+	fetch := func(query string) (*SearchResult, error) {
+		mu.Lock()
+		running++
+		maximum = max(maximum, running)
+		mu.Unlock()
+		// Later queries finish first, so the results must be put back in order
+		time.Sleep(time.Duration(10-len(query)) * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		if query == "bad" {
+			return nil, errors.New("failed")
+		}
+		return batchResult(strings.ToUpper(query)), nil
+	}
+
+	queries := []string{"a", "bb", "bad", "dddd", "eeeee"}
+	results, err := RunParallelSearch(queries, fetch, 2)
+	if err == nil || !strings.Contains(err.Error(), `"bad": failed`) {
+		t.Errorf("Expected the failed query in the error, got %v", err)
+	}
+	if len(results) != len(queries) {
+		t.Fatalf("Expected %d results, got %d", len(queries), len(results))
+	}
+	for i, result := range results {
+		if result.Query != queries[i] {
+			t.Errorf("Result %d is for %q, want %q", i, result.Query, queries[i])
+		}
+		if (result.Err != nil) != (queries[i] == "bad") || result.Err == nil && result.Result.Hits[0].Title != strings.ToUpper(queries[i]) {
+			t.Errorf("Unexpected result %d: %+v", i, result)
+		}
+	}
+	if maximum > 2 {
+		t.Errorf("Expected at most 2 fetches at once, saw %d", maximum)
+	}
+
+	if _, err := RunParallelSearch([]string{"a", "bb"}, fetch, 2); err != nil {
+		t.Errorf("Expected no error when every query succeeds, got %v", err)
+	}
+}
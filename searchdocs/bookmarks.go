@@ -0,0 +1,129 @@
+package searchdocs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Bookmark is a saved docs page
+type Bookmark struct {
+	// ID is derived from the URL, so bookmarking the same page again keeps one bookmark
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	URL   string `json:"url"`
+	// Query is the search the page was bookmarked from
+	Query   string `json:"query,omitempty"`
+	AddedAt string `json:"addedAt"`
+}
+
+// BookmarkID returns the ID of the bookmark for a URL: a short hash of the URL
+func BookmarkID(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:4])
+}
+
+// BookmarksPath returns the path of the bookmarks file
+func BookmarksPath() (string, error) {
+	dir, err := DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "bookmarks.json"), nil
+}
+
+// LoadBookmarks returns the saved bookmarks, oldest first. A missing file means no bookmarks.
+func LoadBookmarks() ([]Bookmark, error) {
+	path, err := BookmarksPath()
+	if err != nil {
+		return nil, err
+	}
+	// #nosec G304 -- the path is in the user's data directory
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var bookmarks []Bookmark
+	if err := json.Unmarshal(data, &bookmarks); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return bookmarks, nil
+}
+
+// AddBookmark saves the page of a search hit, found by searching for query, and returns its
+// bookmark. A page that is already bookmarked keeps its original bookmark.
+func AddBookmark(item SearchItem, query string) (Bookmark, error) {
+	bookmarks, err := LoadBookmarks()
+	if err != nil {
+		return Bookmark{}, err
+	}
+
+	url := HitURL(item)
+	id := BookmarkID(url)
+	for _, b := range bookmarks {
+		if b.ID == id {
+			return b, nil
+		}
+	}
+	bookmark := Bookmark{
+		ID:      id,
+		Title:   HitTitle(item),
+		URL:     url,
+		Query:   query,
+		AddedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	return bookmark, saveBookmarks(append(bookmarks, bookmark))
+}
+
+// RemoveBookmark deletes the bookmark with the given ID
+func RemoveBookmark(id string) error {
+	bookmarks, err := LoadBookmarks()
+	if err != nil {
+		return err
+	}
+	for i, b := range bookmarks {
+		if b.ID == id {
+			return saveBookmarks(append(bookmarks[:i], bookmarks[i+1:]...))
+		}
+	}
+	return fmt.Errorf("no bookmark with ID %q", id)
+}
+
+// saveBookmarks replaces the bookmarks file, writing a temporary file first so a failed
+// write never leaves it truncated
+func saveBookmarks(bookmarks []Bookmark) error {
+	path, err := BookmarksPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(bookmarks, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "bookmarks-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,46 @@
+package searchdocs
+
+import (
+	"testing"
+)
+
+func TestBookmarks(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	if bookmarks, err := LoadBookmarks(); err != nil || len(bookmarks) != 0 {
+		t.Fatalf("LoadBookmarks() = %v, %v; want none", bookmarks, err)
+	}
+
+	first, err := AddBookmark(SearchItem{Title: "About SSH", URL: "/en/ssh"}, "ssh")
+	if err != nil {
+		t.Fatalf("AddBookmark failed: %v", err)
+	}
+	if first.URL != "https://docs.github.com/en/ssh" || first.ID != BookmarkID(first.URL) || first.Title != "About SSH" || first.AddedAt == "" {
+		t.Errorf("Unexpected bookmark: %+v", first)
+	}
+
+	// Bookmarking the same URL again is a no-op
+	again, err := AddBookmark(SearchItem{Title: "About SSH (renamed)", URL: "/en/ssh"}, "keys")
+	if err != nil || again != first {
+		t.Errorf("AddBookmark again = %+v, %v; want the original %+v", again, err, first)
+	}
+	if _, err := AddBookmark(SearchItem{Title: "Webhooks", URL: "/en/webhooks"}, "webhooks"); err != nil {
+		t.Fatal(err)
+	}
+
+	bookmarks, err := LoadBookmarks()
+	if err != nil || len(bookmarks) != 2 || bookmarks[0] != first {
+		t.Fatalf("LoadBookmarks() = %+v, %v; want 2 bookmarks, oldest first", bookmarks, err)
+	}
+
+	if err := RemoveBookmark(first.ID); err != nil {
+		t.Fatalf("RemoveBookmark failed: %v", err)
+	}
+	if err := RemoveBookmark(first.ID); err == nil {
+		t.Error("Expected an error removing a missing bookmark")
+	}
+	bookmarks, _ = LoadBookmarks()
+	if len(bookmarks) != 1 || bookmarks[0].Title != "Webhooks" {
+		t.Errorf("Expected only the webhooks bookmark left, got %+v", bookmarks)
+	}
+}
@@ -0,0 +1,82 @@
+package searchdocs
+
+import "strings"
+
+// Breadcrumb is one segment of a hit's breadcrumb path along with its landing page
+type Breadcrumb struct {
+	Title string
+	// Path is the inferred docs path of the segment's landing page, e.g. /en/actions
+	Path string
+}
+
+// BreadcrumbLinks infers the landing page of each breadcrumb segment of the page at pagePath.
+// Breadcrumbs mirror the docs URL hierarchy, so each segment links to the page path cut to
+// the same depth. When the page path is shallower than the breadcrumbs, segments are instead
+// slugified and joined progressively under the page's language and version. The inferred
+// pages are not verified.
+func BreadcrumbLinks(pagePath, breadcrumbs string) []Breadcrumb {
+	var titles []string
+	for _, segment := range strings.FieldsFunc(breadcrumbs, func(r rune) bool { return r == '/' || r == '>' }) {
+		if segment = strings.Join(strings.Fields(segment), " "); segment != "" {
+			titles = append(titles, segment)
+		}
+	}
+	if len(titles) == 0 {
+		return nil
+	}
+
+	language, version, rest := SplitDocsPath(pagePath)
+	prefix := "/" + language
+	if version != "" {
+		prefix += "/" + version
+	}
+
+	slugs := strings.FieldsFunc(rest, func(r rune) bool { return r == '/' })
+	if len(slugs) < len(titles) {
+		slugs = make([]string, len(titles))
+		for i, title := range titles {
+			slugs[i] = Slugify(title)
+		}
+	}
+
+	links := make([]Breadcrumb, len(titles))
+	for i, title := range titles {
+		links[i] = Breadcrumb{Title: title, Path: prefix + "/" + strings.Join(slugs[:i+1], "/")}
+	}
+	return links
+}
+
+// BreadcrumbSeparator separates breadcrumb segments in result output
+const BreadcrumbSeparator = " › "
+
+// FormatBreadcrumbs splits a breadcrumbs string from the API on "/", trims each segment, and
+// joins the non-empty segments with separator
+func FormatBreadcrumbs(breadcrumbs, separator string) string {
+	return strings.Join(BreadcrumbSegments(breadcrumbs), separator)
+}
+
+// BreadcrumbSegments splits a breadcrumbs string from the API on "/" and returns the
+// non-empty segments, trimmed
+func BreadcrumbSegments(breadcrumbs string) []string {
+	var segments []string
+	for _, segment := range strings.Split(breadcrumbs, "/") {
+		if segment = strings.TrimSpace(segment); segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+// Hyperlink wraps text in an OSC 8 escape sequence so supporting terminals make it a link to url
+func Hyperlink(url, text string) string {
+	return HyperlinkStart(url) + text + HyperlinkEnd
+}
+
+// HyperlinkStart starts an OSC 8 hyperlink to url, which HyperlinkEnd ends. Text styled in
+// between, e.g. by a Markdown renderer, is linked as a whole.
+func HyperlinkStart(url string) string {
+	return "\x1b]8;;" + url + "\x1b\\"
+}
+
+// HyperlinkEnd ends a hyperlink started by HyperlinkStart
+const HyperlinkEnd = "\x1b]8;;\x1b\\"
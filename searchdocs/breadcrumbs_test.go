@@ -0,0 +1,93 @@
+package searchdocs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBreadcrumbLinks(t *testing.T) {
+	tests := []struct {
+		name        string
+		pagePath    string
+		breadcrumbs string
+		expected    []Breadcrumb
+	}{
+		{
+			name:        "parents only",
+			pagePath:    "/en/actions/security-guides/security-hardening-with-openid-connect",
+			breadcrumbs: "Actions / Security guides",
+			expected: []Breadcrumb{
+				{"Actions", "/en/actions"},
+				{"Security guides", "/en/actions/security-guides"},
+			},
+		},
+		{
+			name:        "including the page itself",
+			pagePath:    "/en/actions/security-guides/security-hardening-with-openid-connect",
+			breadcrumbs: "Actions / Security guides / OIDC hardening",
+			expected: []Breadcrumb{
+				{"Actions", "/en/actions"},
+				{"Security guides", "/en/actions/security-guides"},
+				{"OIDC hardening", "/en/actions/security-guides/security-hardening-with-openid-connect"},
+			},
+		},
+		{
+			name:        "versioned page",
+			pagePath:    "/en/enterprise-server@3.17/admin/configuring-settings/configuring-network-settings",
+			breadcrumbs: "Enterprise administrators > Configure",
+			expected: []Breadcrumb{
+				{"Enterprise administrators", "/en/enterprise-server@3.17/admin"},
+				{"Configure", "/en/enterprise-server@3.17/admin/configuring-settings"},
+			},
+		},
+		{
+			name:        "page shallower than breadcrumbs",
+			pagePath:    "/ja/get-started",
+			breadcrumbs: "Get started / Quickstart",
+			expected: []Breadcrumb{
+				{"Get started", "/ja/get-started"},
+				{"Quickstart", "/ja/get-started/quickstart"},
+			},
+		},
+		{
+			name:        "no breadcrumbs",
+			pagePath:    "/en/actions",
+			breadcrumbs: " / ",
+			expected:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BreadcrumbLinks(tt.pagePath, tt.breadcrumbs); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("BreadcrumbLinks(%q, %q) = %v, want %v", tt.pagePath, tt.breadcrumbs, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFormatBreadcrumbs(t *testing.T) {
+	tests := []struct {
+		breadcrumbs string
+		separator   string
+		expected    string
+	}{
+		{"Actions / Security guides / Security hardening", BreadcrumbSeparator, "Actions › Security guides › Security hardening"},
+		{" Actions/Security guides ", " > ", "Actions > Security guides"},
+		{"Actions //  / Security", BreadcrumbSeparator, "Actions › Security"},
+		{"", BreadcrumbSeparator, ""},
+	}
+
+	for _, tt := range tests {
+		if got := FormatBreadcrumbs(tt.breadcrumbs, tt.separator); got != tt.expected {
+			t.Errorf("FormatBreadcrumbs(%q, %q) = %q, want %q", tt.breadcrumbs, tt.separator, got, tt.expected)
+		}
+	}
+}
+
+func TestHyperlink(t *testing.T) {
+	expected := "\x1b]8;;https://docs.github.com/en/actions\x1b\\Actions\x1b]8;;\x1b\\"
+	if got := Hyperlink("https://docs.github.com/en/actions", "Actions"); got != expected {
+		t.Errorf("Hyperlink() = %q, want %q", got, expected)
+	}
+}
@@ -0,0 +1,168 @@
+package searchdocs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultCacheTTL is how long cached search responses are reused by default
+const DefaultCacheTTL = time.Hour
+
+// CacheDir returns the directory used for cached data, honoring XDG_CACHE_HOME
+func CacheDir() (string, error) {
+	if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" {
+		return filepath.Join(dir, "gh-search-docs"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".cache", "gh-search-docs"), nil
+}
+
+// FileCache stores search results on disk as JSON, one file per request URL named by its
+// SHA-256 hash. Entries older than TTL, judged by their modification time, are misses
+// and are deleted when read.
+type FileCache struct {
+	Dir string
+	TTL time.Duration
+}
+
+// NewFileCache returns a cache storing entries in dir for ttl
+func NewFileCache(dir string, ttl time.Duration) *FileCache {
+	return &FileCache{Dir: dir, TTL: ttl}
+}
+
+// DefaultFileCache returns the search cache in the user's cache directory
+func DefaultFileCache(ttl time.Duration) (*FileCache, error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return NewFileCache(dir, ttl), nil
+}
+
+func (c *FileCache) file(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Get returns the cached result for key, usually the full request URL, if it hasn't expired.
+// An entry that can't be read or parsed is a miss.
+func (c *FileCache) Get(key string) (*SearchResult, bool) {
+	file := c.file(key)
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, false
+	}
+	if info.ModTime().Before(time.Now().Add(-c.TTL)) {
+		_ = os.Remove(file)
+		return nil, false
+	}
+	// #nosec G304 -- the file name is a hash inside the cache directory
+	body, err := os.ReadFile(file)
+	if err != nil {
+		return nil, false
+	}
+	var result SearchResult
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, false
+	}
+	return &result, true
+}
+
+// Set stores r for key. The entry is written to a temporary file first so a concurrent Get
+// never sees a partial write.
+func (c *FileCache) Set(key string, r *SearchResult) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.Dir, 0o750); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(c.Dir, "entry-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(body); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.file(key))
+}
+
+// Clear removes every entry in the cache and returns how many were deleted. A cache that
+// was never written to is empty.
+func (c *FileCache) Clear() (int, error) {
+	entries, err := os.ReadDir(c.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		if err := os.Remove(filepath.Join(c.Dir, entry.Name())); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// CacheStats describes the contents of one of the on-disk caches
+type CacheStats struct {
+	Dir     string `json:"dir"`
+	Entries int    `json:"entries"`
+	// Expired counts entries past the TTL that haven't been removed yet
+	Expired int   `json:"expired"`
+	Bytes   int64 `json:"bytes"`
+	// MaxBytes is the size limit, or 0 for a cache without one
+	MaxBytes int64         `json:"max_bytes"`
+	TTL      time.Duration `json:"ttl"`
+}
+
+// Stats counts the entries in the cache and their size. A cache that was never written to
+// is empty.
+func (c *FileCache) Stats() (CacheStats, error) {
+	stats := CacheStats{Dir: c.Dir, TTL: c.TTL}
+	entries, err := os.ReadDir(c.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return stats, nil
+	}
+	if err != nil {
+		return stats, err
+	}
+	cutoff := time.Now().Add(-c.TTL)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		stats.Entries++
+		stats.Bytes += info.Size()
+		if info.ModTime().Before(cutoff) {
+			stats.Expired++
+		}
+	}
+	return stats, nil
+}
@@ -0,0 +1,112 @@
+package searchdocs
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFileCache(t *testing.T) {
+	cache := NewFileCache(filepath.Join(t.TempDir(), "cache"), time.Hour)
+	key := "https://docs.github.com/api/search/v1?query=ssh&version=free-pro-team"
+
+	if _, ok := cache.Get(key); ok {
+		t.Fatal("Expected a miss before anything was stored")
+	}
+	stored := &SearchResult{Hits: []SearchItem{{ID: "1", URL: "/en/ssh", Title: "About SSH"}}}
+	stored.Meta.Found.Value = 1
+	if err := cache.Set(key, stored); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	result, ok := cache.Get(key)
+	if !ok || !reflect.DeepEqual(result, stored) {
+		t.Errorf("Get = %+v, %v; want the stored result", result, ok)
+	}
+	if _, ok := cache.Get(key + "&size=10"); ok {
+		t.Error("Expected a different URL to miss")
+	}
+}
+
+func TestFileCacheUnreadable(t *testing.T) {
+	cache := NewFileCache(t.TempDir(), time.Hour)
+	key := "https://docs.github.com/api/search/v1?query=ssh"
+	if err := os.WriteFile(cache.file(key), []byte("not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.Get(key); ok {
+		t.Error("Expected an entry that isn't a result to miss")
+	}
+}
+
+func TestFileCacheExpiry(t *testing.T) {
+	cache := NewFileCache(t.TempDir(), time.Hour)
+	key := "https://docs.github.com/api/search/v1?query=ssh"
+	if err := cache.Set(key, &SearchResult{}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(cache.file(key), old, old); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.Get(key); ok {
+		t.Error("Expected an expired entry to miss")
+	}
+	if _, err := os.Stat(cache.file(key)); !os.IsNotExist(err) {
+		t.Errorf("Expected the expired entry to be deleted, got %v", err)
+	}
+}
+
+func TestFileCacheClear(t *testing.T) {
+	cache := NewFileCache(filepath.Join(t.TempDir(), "cache"), time.Hour)
+	if n, err := cache.Clear(); n != 0 || err != nil {
+		t.Errorf("Clear on a missing directory = %d, %v; want 0, nil", n, err)
+	}
+
+	for _, key := range []string{"a", "b", "c"} {
+		if err := cache.Set(key, &SearchResult{}); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+	}
+	if n, err := cache.Clear(); n != 3 || err != nil {
+		t.Errorf("Clear = %d, %v; want 3, nil", n, err)
+	}
+	if _, ok := cache.Get("a"); ok {
+		t.Error("Expected a miss after clearing")
+	}
+}
+
+func TestFileCacheStats(t *testing.T) {
+	cache := NewFileCache(filepath.Join(t.TempDir(), "cache"), time.Hour)
+	if stats, err := cache.Stats(); stats.Entries != 0 || err != nil {
+		t.Errorf("Stats on a missing directory = %+v, %v; want an empty cache", stats, err)
+	}
+
+	for _, key := range []string{"a", "b"} {
+		if err := cache.Set(key, &SearchResult{}); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+	}
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(cache.file("a"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	// The article cache's subdirectory isn't counted
+	if err := os.MkdirAll(filepath.Join(cache.Dir, "articles"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	stats, err := cache.Stats()
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	entry, err := json.Marshal(&SearchResult{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Entries != 2 || stats.Expired != 1 || stats.Bytes != int64(2*len(entry)) || stats.TTL != time.Hour {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
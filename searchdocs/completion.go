@@ -0,0 +1,233 @@
+package searchdocs
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// CompletionShells lists the shells GenerateCompletion writes scripts for
+var CompletionShells = []string{"bash", "zsh", "fish", "powershell"}
+
+// completionCommand is the executable the completion scripts register for. Completions can't
+// be attached to "gh search-docs", since gh doesn't delegate completion to extensions.
+const completionCommand = "gh-search-docs"
+
+// completionFormats are the values --format accepts
+var completionFormats = []string{"pretty", "plain", "markdown", "html", "json", "jsonl", "yaml", "csv", "tsv", "urls", "titles", "tree", "raw"}
+
+// completionFileFlags are the flags whose value is a file path
+var completionFileFlags = []string{"config", "output", "log-file", "style-file", "theme-file", "versions-file", "queries-file"}
+
+// completionFlag is one flag as the completion scripts describe it
+type completionFlag struct {
+	name  string
+	usage string
+	// takesValue is false for boolean flags
+	takesValue bool
+	// values are the accepted values, or nil when any value goes
+	values []string
+	// files is true when the value is a file path
+	files bool
+}
+
+// GenerateCompletion writes a completion script for shell (bash, zsh, fish, or powershell)
+// covering every flag in fs. --version completes the supported docs versions, --language,
+// --format, and --completion their fixed values, and --config, --output, and --log-file
+// file names.
+func GenerateCompletion(shell string, fs *flag.FlagSet, w io.Writer) error {
+	flags := completionFlags(fs)
+	switch shell {
+	case "bash":
+		return writeBashCompletion(w, flags)
+	case "zsh":
+		return writeZshCompletion(w, flags)
+	case "fish":
+		return writeFishCompletion(w, flags)
+	case "powershell":
+		return writePowerShellCompletion(w, flags)
+	}
+	return fmt.Errorf("unknown shell %q (use %s)", shell, strings.Join(CompletionShells, ", "))
+}
+
+// completionFlags describes the flags in fs, in name order
+func completionFlags(fs *flag.FlagSet) []completionFlag {
+	versions := []string{"free-pro-team", "enterprise-cloud"}
+	for _, version := range SupportedServerVersions() {
+		versions = append(versions, "enterprise-server@"+version)
+	}
+	values := map[string][]string{
+		"version":    versions,
+		"language":   SupportedLanguages,
+		"format":     completionFormats,
+		"completion": CompletionShells,
+	}
+
+	var flags []completionFlag
+	fs.VisitAll(func(f *flag.Flag) {
+		boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool })
+		flags = append(flags, completionFlag{
+			name:       f.Name,
+			usage:      f.Usage,
+			takesValue: !ok || !boolFlag.IsBoolFlag(),
+			values:     values[f.Name],
+			files:      slices.Contains(completionFileFlags, f.Name),
+		})
+	})
+	return flags
+}
+
+// writeBashCompletion writes a bash completion function, for sourcing from ~/.bashrc
+func writeBashCompletion(w io.Writer, flags []completionFlag) error {
+	var names, fileFlags, valueFlags []string
+	var b strings.Builder
+	fmt.Fprintf(&b, "# bash completion for %s\n", completionCommand)
+	fmt.Fprintf(&b, "_gh_search_docs() {\n")
+	fmt.Fprintf(&b, "    local cur=\"${COMP_WORDS[COMP_CWORD]}\" prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+	fmt.Fprintf(&b, "    case \"$prev\" in\n")
+	for _, f := range flags {
+		names = append(names, "--"+f.name)
+		switch {
+		case len(f.values) > 0:
+			fmt.Fprintf(&b, "    --%s)\n", f.name)
+			fmt.Fprintf(&b, "        COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(f.values, " "))
+			fmt.Fprintf(&b, "        return\n        ;;\n")
+		case f.files:
+			fileFlags = append(fileFlags, "--"+f.name)
+		case f.takesValue:
+			valueFlags = append(valueFlags, "--"+f.name)
+		}
+	}
+	if len(fileFlags) > 0 {
+		fmt.Fprintf(&b, "    %s)\n", strings.Join(fileFlags, "|"))
+		fmt.Fprintf(&b, "        compopt -o filenames\n")
+		fmt.Fprintf(&b, "        COMPREPLY=($(compgen -f -- \"$cur\"))\n")
+		fmt.Fprintf(&b, "        return\n        ;;\n")
+	}
+	if len(valueFlags) > 0 {
+		fmt.Fprintf(&b, "    %s)\n", strings.Join(valueFlags, "|"))
+		fmt.Fprintf(&b, "        COMPREPLY=()\n")
+		fmt.Fprintf(&b, "        return\n        ;;\n")
+	}
+	fmt.Fprintf(&b, "    esac\n")
+	fmt.Fprintf(&b, "    if [[ \"$cur\" == -* ]]; then\n")
+	fmt.Fprintf(&b, "        COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(names, " "))
+	fmt.Fprintf(&b, "    fi\n")
+	fmt.Fprintf(&b, "}\n")
+	fmt.Fprintf(&b, "complete -F _gh_search_docs %s\n", completionCommand)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeZshCompletion writes a zsh completion function, which works both sourced from
+// ~/.zshrc and saved as _gh-search-docs on $fpath
+func writeZshCompletion(w io.Writer, flags []completionFlag) error {
+	escape := strings.NewReplacer(`\`, `\\`, `[`, `\[`, `]`, `\]`, `'`, `'\''`, ":", `\:`)
+	var b strings.Builder
+	fmt.Fprintf(&b, "#compdef %s\n\n", completionCommand)
+	fmt.Fprintf(&b, "_gh_search_docs() {\n")
+	fmt.Fprintf(&b, "    _arguments \\\n")
+	for _, f := range flags {
+		// Every flag may be repeated; the last value wins, or adds to the list
+		spec := fmt.Sprintf("*--%s[%s]", f.name, escape.Replace(f.usage))
+		switch {
+		case len(f.values) > 0:
+			spec += fmt.Sprintf(":%s:(%s)", f.name, strings.Join(f.values, " "))
+		case f.files:
+			spec += fmt.Sprintf(":%s:_files", f.name)
+		case f.takesValue:
+			spec += fmt.Sprintf(":%s: ", f.name)
+		}
+		fmt.Fprintf(&b, "        '%s' \\\n", spec)
+	}
+	fmt.Fprintf(&b, "        '*:query: '\n")
+	fmt.Fprintf(&b, "}\n\n")
+	fmt.Fprintf(&b, "if [ \"$funcstack[1]\" = \"_gh_search_docs\" ]; then\n")
+	fmt.Fprintf(&b, "    _gh_search_docs \"$@\"\n")
+	fmt.Fprintf(&b, "else\n")
+	fmt.Fprintf(&b, "    compdef _gh_search_docs %s\n", completionCommand)
+	fmt.Fprintf(&b, "fi\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeFishCompletion writes fish completions, for saving as
+// ~/.config/fish/completions/gh-search-docs.fish
+func writeFishCompletion(w io.Writer, flags []completionFlag) error {
+	quote := func(s string) string {
+		return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "# fish completion for %s\n", completionCommand)
+	// The query is free text, so don't offer file names for it
+	fmt.Fprintf(&b, "complete -c %s -f\n", completionCommand)
+	for _, f := range flags {
+		fmt.Fprintf(&b, "complete -c %s -l %s", completionCommand, f.name)
+		switch {
+		case len(f.values) > 0:
+			fmt.Fprintf(&b, " -x -a %s", quote(strings.Join(f.values, " ")))
+		case f.files:
+			fmt.Fprintf(&b, " -r -F")
+		case f.takesValue:
+			fmt.Fprintf(&b, " -x")
+		}
+		fmt.Fprintf(&b, " -d %s\n", quote(f.usage))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writePowerShellCompletion writes a PowerShell argument completer, for dot-sourcing from
+// $PROFILE
+func writePowerShellCompletion(w io.Writer, flags []completionFlag) error {
+	quote := func(s string) string {
+		return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "# PowerShell completion for %s\n", completionCommand)
+	fmt.Fprintf(&b, "Register-ArgumentCompleter -Native -CommandName %s -ScriptBlock {\n", quote(completionCommand))
+	fmt.Fprintf(&b, "    param($wordToComplete, $commandAst, $cursorPosition)\n\n")
+	fmt.Fprintf(&b, "    $flags = [ordered]@{\n")
+	for _, f := range flags {
+		// Completion results need a non-empty tooltip
+		usage := f.usage
+		if usage == "" {
+			usage = "--" + f.name
+		}
+		fmt.Fprintf(&b, "        %s = %s\n", quote("--"+f.name), quote(usage))
+	}
+	fmt.Fprintf(&b, "    }\n")
+	fmt.Fprintf(&b, "    $values = @{\n")
+	for _, f := range flags {
+		if len(f.values) == 0 {
+			continue
+		}
+		quoted := make([]string, len(f.values))
+		for i, value := range f.values {
+			quoted[i] = quote(value)
+		}
+		fmt.Fprintf(&b, "        %s = @(%s)\n", quote("--"+f.name), strings.Join(quoted, ", "))
+	}
+	fmt.Fprintf(&b, "    }\n\n")
+	fmt.Fprintf(&b, "    # The word being completed is the last element unless it is still empty\n")
+	fmt.Fprintf(&b, "    $words = @($commandAst.CommandElements | ForEach-Object { $_.ToString() })\n")
+	fmt.Fprintf(&b, "    $index = $words.Count - 1\n")
+	fmt.Fprintf(&b, "    if ($wordToComplete -ne '') { $index-- }\n")
+	fmt.Fprintf(&b, "    $prev = if ($index -ge 1) { $words[$index] } else { '' }\n\n")
+	fmt.Fprintf(&b, "    if ($values.Contains($prev)) {\n")
+	fmt.Fprintf(&b, "        $values[$prev] | Where-Object { $_ -like \"$wordToComplete*\" } | ForEach-Object {\n")
+	fmt.Fprintf(&b, "            [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)\n")
+	fmt.Fprintf(&b, "        }\n")
+	fmt.Fprintf(&b, "        return\n")
+	fmt.Fprintf(&b, "    }\n")
+	fmt.Fprintf(&b, "    if ($wordToComplete -like '-*') {\n")
+	fmt.Fprintf(&b, "        $flags.Keys | Where-Object { $_ -like \"$wordToComplete*\" } | ForEach-Object {\n")
+	fmt.Fprintf(&b, "            [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterName', $flags[$_])\n")
+	fmt.Fprintf(&b, "        }\n")
+	fmt.Fprintf(&b, "    }\n")
+	fmt.Fprintf(&b, "}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,117 @@
+package searchdocs
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// completionFlagSet returns a small flag set covering each kind of flag the scripts handle
+func completionFlagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("search-docs", flag.ContinueOnError)
+	fs.Int("size", 5, "number of results")
+	fs.String("format", "pretty", "output format")
+	fs.String("version", "free-pro-team", "docs version")
+	fs.String("config", "", "read flag defaults from this file")
+	fs.Bool("plain", false, "don't use [pretty] rendering: it's plain")
+	return fs
+}
+
+func TestGenerateCompletion(t *testing.T) {
+	tests := []struct {
+		shell string
+		want  []string
+	}{
+		{"bash", []string{
+			"complete -F _gh_search_docs gh-search-docs",
+			`compgen -W "--config --format --plain --size --version"`,
+			"    --format)\n        COMPREPLY=($(compgen -W \"pretty plain markdown",
+			"enterprise-server@3.17",
+			"    --config)\n        compopt -o filenames",
+			"    --size)\n        COMPREPLY=()",
+		}},
+		{"zsh", []string{
+			"#compdef gh-search-docs",
+			`'*--plain[don'\''t use \[pretty\] rendering\: it'\''s plain]' \`,
+			"'*--format[output format]:format:(pretty plain markdown",
+			"'*--config[read flag defaults from this file]:config:_files'",
+			"'*--size[number of results]:size: '",
+			"compdef _gh_search_docs gh-search-docs",
+		}},
+		{"fish", []string{
+			"complete -c gh-search-docs -f\n",
+			`complete -c gh-search-docs -l plain -d 'don\'t use [pretty] rendering: it\'s plain'`,
+			"complete -c gh-search-docs -l format -x -a 'pretty plain markdown",
+			"complete -c gh-search-docs -l config -r -F -d",
+			"complete -c gh-search-docs -l size -x -d 'number of results'",
+		}},
+		{"powershell", []string{
+			"Register-ArgumentCompleter -Native -CommandName 'gh-search-docs'",
+			`'--plain' = 'don''t use [pretty] rendering: it''s plain'`,
+			"'--format' = @('pretty', 'plain', 'markdown'",
+			"'enterprise-server@3.17'",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := GenerateCompletion(tt.shell, completionFlagSet(), &buf); err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("Expected %q in:\n%s", want, buf.String())
+				}
+			}
+		})
+	}
+
+	err := GenerateCompletion("tcsh", completionFlagSet(), &bytes.Buffer{})
+	if err == nil || err.Error() != `unknown shell "tcsh" (use bash, zsh, fish, powershell)` {
+		t.Errorf("Expected an unknown shell error, got %v", err)
+	}
+}
+
+func TestBashCompletionCompletes(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not available")
+	}
+	var script bytes.Buffer
+	if err := GenerateCompletion("bash", completionFlagSet(), &script); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "completion.bash")
+	if err := os.WriteFile(path, script.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		words []string
+		want  string
+	}{
+		{[]string{"gh-search-docs", "--fo"}, "--format"},
+		{[]string{"gh-search-docs", "--format", "j"}, "json jsonl"},
+		{[]string{"gh-search-docs", "--format", "cs"}, "csv"},
+		{[]string{"gh-search-docs", "--version", "enterprise-c"}, "enterprise-cloud"},
+		{[]string{"gh-search-docs", "--size", ""}, ""},
+		{[]string{"gh-search-docs", "secrets"}, ""},
+	}
+	for _, tt := range tests {
+		words := make([]string, len(tt.words))
+		for i, word := range tt.words {
+			words[i] = "'" + word + "'"
+		}
+		cmd := exec.Command("bash", "-c", `source "$1"; COMP_WORDS=(`+strings.Join(words, " ")+`); COMP_CWORD=$((${#COMP_WORDS[@]} - 1)); _gh_search_docs; echo "${COMPREPLY[*]}"`, "bash", path)
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("%v: %v", tt.words, err)
+		}
+		if got := strings.TrimSpace(string(out)); got != tt.want {
+			t.Errorf("completing %v = %q, want %q", tt.words, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package searchdocs
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds per-user defaults for command line flags, read from config.yaml. Zero values
+// leave the built-in default in place.
+type Config struct {
+	DefaultSize       int      `yaml:"size"`
+	DefaultVersion    string   `yaml:"version"`
+	DefaultLanguage   string   `yaml:"language"`
+	DefaultFormat     string   `yaml:"format"`
+	DefaultHighlights []string `yaml:"highlights"`
+	DefaultIncludes   []string `yaml:"include"`
+	// TruncateAt is the number of characters intros are cut to in pretty and plain output
+	TruncateAt int `yaml:"truncate_at"`
+}
+
+// ConfigPath returns the path of the user's config file: GH_SEARCH_DOCS_CONFIG if set,
+// otherwise config.yaml in the config directory
+func ConfigPath() (string, error) {
+	if path := os.Getenv("GH_SEARCH_DOCS_CONFIG"); path != "" {
+		return path, nil
+	}
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.yaml"), nil
+}
+
+// LoadConfig reads a config file. Unknown keys are an error so typos don't go unnoticed.
+func LoadConfig(path string) (*Config, error) {
+	// #nosec G304 -- the path is the user's own config file
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if cfg.DefaultSize < 0 || cfg.TruncateAt < 0 {
+		return nil, fmt.Errorf("%s: size and truncate_at must not be negative", path)
+	}
+	return cfg, nil
+}
+
+// MergeConfigIntoFlags applies config defaults to the flags in fs that weren't set on the
+// command line, so explicit flags always win. It must be called after fs.Parse.
+func MergeConfigIntoFlags(cfg *Config, fs *flag.FlagSet) {
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	apply := func(name string, values ...string) {
+		if set[name] || fs.Lookup(name) == nil {
+			return
+		}
+		for _, value := range values {
+			// Config values are strings or ints already, so the flags accept them
+			_ = fs.Set(name, value)
+		}
+	}
+
+	if cfg.DefaultSize > 0 {
+		apply("size", strconv.Itoa(cfg.DefaultSize))
+	}
+	if cfg.DefaultVersion != "" {
+		apply("version", cfg.DefaultVersion)
+	}
+	if cfg.DefaultLanguage != "" {
+		apply("language", cfg.DefaultLanguage)
+	}
+	if cfg.DefaultFormat != "" {
+		apply("format", cfg.DefaultFormat)
+	}
+	if cfg.TruncateAt > 0 {
+		apply("truncate", strconv.Itoa(cfg.TruncateAt))
+	}
+	apply("highlights", cfg.DefaultHighlights...)
+	apply("include", cfg.DefaultIncludes...)
+}
@@ -0,0 +1,106 @@
+package searchdocs
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// stringsFlag is a repeatable flag for exercising MergeConfigIntoFlags
+type stringsFlag []string
+
+func (s *stringsFlag) String() string     { return strings.Join(*s, ",") }
+func (s *stringsFlag) Set(v string) error { *s = append(*s, v); return nil }
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := writeConfig(t, `
+size: 10
+version: enterprise-cloud
+language: ja
+format: plain
+highlights: [title, content]
+include: [intro]
+truncate_at: 80
+`)
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := &Config{
+		DefaultSize:       10,
+		DefaultVersion:    "enterprise-cloud",
+		DefaultLanguage:   "ja",
+		DefaultFormat:     "plain",
+		DefaultHighlights: []string{"title", "content"},
+		DefaultIncludes:   []string{"intro"},
+		TruncateAt:        80,
+	}
+	if !reflect.DeepEqual(cfg, expected) {
+		t.Errorf("LoadConfig() = %+v, want %+v", cfg, expected)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	if _, err := LoadConfig(filepath.Join(t.TempDir(), "missing.yaml")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected a not-exist error for a missing file, got %v", err)
+	}
+	if _, err := LoadConfig(writeConfig(t, "sise: 10\n")); err == nil || !strings.Contains(err.Error(), "sise") {
+		t.Errorf("Expected an error naming the unknown key, got %v", err)
+	}
+	if _, err := LoadConfig(writeConfig(t, "size: -1\n")); err == nil {
+		t.Error("Expected an error for a negative size")
+	}
+	if cfg, err := LoadConfig(writeConfig(t, "")); err != nil || !reflect.DeepEqual(cfg, &Config{}) {
+		t.Errorf("Expected an empty config for an empty file, got %+v, %v", cfg, err)
+	}
+}
+
+func TestMergeConfigIntoFlags(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	size := fs.Int("size", 5, "")
+	version := fs.String("version", "free-pro-team", "")
+	language := fs.String("language", "en", "")
+	truncate := fs.Int("truncate", 150, "")
+	var highlights stringsFlag
+	fs.Var(&highlights, "highlights", "")
+
+	if err := fs.Parse([]string{"--version", "enterprise-server@3.17"}); err != nil {
+		t.Fatal(err)
+	}
+	MergeConfigIntoFlags(&Config{
+		DefaultSize:       20,
+		DefaultVersion:    "enterprise-cloud",
+		DefaultHighlights: []string{"title", "term"},
+		DefaultIncludes:   []string{"intro"},
+		TruncateAt:        80,
+	}, fs)
+
+	if *size != 20 {
+		t.Errorf("size = %d, want the config default 20", *size)
+	}
+	if *version != "enterprise-server@3.17" {
+		t.Errorf("version = %q, want the explicit flag to win", *version)
+	}
+	if *language != "en" {
+		t.Errorf("language = %q, want the built-in default", *language)
+	}
+	if !reflect.DeepEqual([]string(highlights), []string{"title", "term"}) {
+		t.Errorf("highlights = %v, want the config defaults", highlights)
+	}
+	if *truncate != 80 {
+		t.Errorf("truncate = %d, want truncate_at from the config", *truncate)
+	}
+}
@@ -0,0 +1,85 @@
+package searchdocs
+
+import (
+	"html"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// htmlComment matches HTML comments, which StripTags would otherwise cut at their first ">"
+var htmlComment = regexp.MustCompile(`<!--[\s\S]*?-->`)
+
+// StripHTML removes HTML tags and comments from s and decodes its character references,
+// leaving the text for plain output
+func StripHTML(s string) string {
+	s = htmlComment.ReplaceAllString(s, "")
+	return html.UnescapeString(StripTags(s))
+}
+
+// StripTags removes the HTML tags from s, keeping their inner text and any tags named in
+// keep, e.g. StripTags(s, "mark"). Only a "<" followed by a tag name starts a tag, so
+// comparisons such as "a < b" survive, and a ">" in a quoted attribute value doesn't end one. A tag that is never closed with ">", such as a
+// trailing "<mark" in a cut-off snippet, runs to the end of s and is removed with it.
+func StripTags(s string, keep ...string) string {
+	var b strings.Builder
+	for {
+		i := strings.IndexByte(s, '<')
+		if i < 0 {
+			b.WriteString(s)
+			return b.String()
+		}
+		b.WriteString(s[:i])
+		s = s[i:]
+
+		name := tagName(s)
+		if name == "" {
+			b.WriteByte('<')
+			s = s[1:]
+			continue
+		}
+		end := tagEnd(s)
+		if end < 0 {
+			return b.String()
+		}
+		if slices.Contains(keep, name) {
+			b.WriteString(s[:end+1])
+		}
+		s = s[end+1:]
+	}
+}
+
+// tagEnd returns the index of the ">" closing the tag s starts with, skipping any inside
+// quoted attribute values, or -1 if the tag is never closed
+func tagEnd(s string) int {
+	var quote byte
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '>':
+			return i
+		}
+	}
+	return -1
+}
+
+// tagName returns the lowercased name of the tag s starts with, e.g. "a" for `<a href=...>`
+// or "mark" for "</mark>", or "" if s doesn't start with a tag
+func tagName(s string) string {
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "<"), "/")
+	n := 0
+	for n < len(s) {
+		c := s[n]
+		isLetter := c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+		if !isLetter && (n == 0 || (c < '0' || c > '9') && c != '-') {
+			break
+		}
+		n++
+	}
+	return strings.ToLower(s[:n])
+}
@@ -0,0 +1,53 @@
+package searchdocs
+
+import "testing"
+
+func TestStripHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"no markup", "Plain text", "Plain text"},
+		{"tags", `<p>Use <a href="/en/actions">Actions</a>.</p>`, "Use Actions."},
+		{"self-closing", "one<br/>two<br />three", "onetwothree"},
+		{"comments", "before<!-- a\ncomment -->after", "beforeafter"},
+		{"entities", "Fish &amp; chips &lt;3", "Fish & chips <3"},
+		{"comparisons", "if a < b and c > d", "if a < b and c > d"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StripHTML(tt.input); got != tt.expected {
+				t.Errorf("StripHTML(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestStripTags(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		keep     []string
+		expected string
+	}{
+		{"inner text kept", "Use <em>secrets</em> and <code>GITHUB_TOKEN</code>", nil, "Use secrets and GITHUB_TOKEN"},
+		{"attributes", `See <a href="/en/actions" title="a > b">Actions</a>`, nil, "See Actions"},
+		{"kept tags", "<p>Use <mark>secrets</mark></p>", []string{"mark"}, "Use <mark>secrets</mark>"},
+		{"kept tags ignore case", "<MARK>secrets</Mark>", []string{"mark"}, "<MARK>secrets</Mark>"},
+		{"unclosed tag", "Use <mark>secrets</mark> in <mark", []string{"mark"}, "Use <mark>secrets</mark> in "},
+		{"unclosed other tag", "Use secrets <code class=", nil, "Use secrets "},
+		{"unclosed quote", `Use <a href="/en>secrets`, nil, "Use "},
+		{"not tags", "a < b, c <= d, <3 and </ done", nil, "a < b, c <= d, <3 and </ done"},
+		{"self-closing", "one<br/>two", nil, "onetwo"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StripTags(tt.input, tt.keep...); got != tt.expected {
+				t.Errorf("StripTags(%q, %q) = %q, want %q", tt.input, tt.keep, got, tt.expected)
+			}
+		})
+	}
+}
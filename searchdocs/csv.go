@@ -0,0 +1,91 @@
+package searchdocs
+
+import (
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CSVColumns are the columns CSV exports write unless Exporter.Columns picks others. Every
+// column is always present so the column count is stable; fields the API didn't send are
+// left empty.
+var CSVColumns = []string{"rank", "id", "title", "url", "breadcrumbs", "score", "intro"}
+
+// ColumnNames are the columns ColumnValue knows, which --columns and --csv-fields can pick
+var ColumnNames = []string{"rank", "id", "title", "url", "path", "breadcrumbs", "intro", "headings", "score", "toplevel"}
+
+// ColumnValue returns one column of the hit at rank (from 1), or "" for an unknown column
+func ColumnValue(column string, rank int, item SearchItem) string {
+	switch column {
+	case "rank":
+		return strconv.Itoa(rank)
+	case "id":
+		return item.ID
+	case "title":
+		return HitTitle(item)
+	case "url":
+		return HitURL(item)
+	case "path":
+		return HitPath(item)
+	case "breadcrumbs":
+		return item.Breadcrumbs
+	case "intro":
+		return item.Intro
+	case "headings":
+		return item.Headings
+	case "score":
+		return strconv.FormatFloat(item.Score, 'f', -1, 64)
+	case "toplevel":
+		return item.Toplevel
+	}
+	return ""
+}
+
+// ExportCSV writes result as CSV, as Exporter.CSV does by default
+func ExportCSV(result SearchResult, w io.Writer) error {
+	return Exporter{}.CSV(result, w)
+}
+
+// numericColumns are the CSV columns that hold numbers, which are never quoted. Every other
+// column is text and always is.
+var numericColumns = map[string]bool{"rank": true, "score": true, "query_msec": true, "total_msec": true}
+
+// CSV writes result as RFC 4180 CSV: a header row, unless NoHeader is set, then one row per
+// hit with the Columns columns, or CSVColumns. Text fields are always double-quoted, with
+// quotes inside them doubled, and numbers never are. Besides ColumnNames, the query_msec and total_msec columns repeat the API's timing on
+// every row.
+func (e Exporter) CSV(result SearchResult, w io.Writer) error {
+	columns := e.Columns
+	if len(columns) == 0 {
+		columns = CSVColumns
+	}
+
+	var b strings.Builder
+	if !e.NoHeader {
+		b.WriteString(strings.Join(columns, ",") + "\n")
+	}
+	for i, item := range result.Hits {
+		for j, column := range columns {
+			if j > 0 {
+				b.WriteString(",")
+			}
+			var value string
+			switch column {
+			case "query_msec":
+				value = strconv.Itoa(result.Meta.Took.QueryMsec)
+			case "total_msec":
+				value = strconv.Itoa(result.Meta.Took.TotalMsec)
+			default:
+				value = ColumnValue(column, i+1, item)
+			}
+			if numericColumns[column] {
+				b.WriteString(value)
+			} else {
+				b.WriteString(`"` + strings.ReplaceAll(value, `"`, `""`) + `"`)
+			}
+		}
+		b.WriteString("\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
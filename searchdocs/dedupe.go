@@ -0,0 +1,30 @@
+package searchdocs
+
+import "strings"
+
+// DeduplicateByURL returns items without the later occurrences of a URL already seen,
+// comparing URLs case-insensitively. The first occurrence of each keeps its position.
+func DeduplicateByURL(items []SearchItem) []SearchItem {
+	return deduplicate(items, func(item SearchItem) string { return strings.ToLower(item.URL) })
+}
+
+// DeduplicateByID returns items without the later occurrences of an ID already seen. The
+// first occurrence of each keeps its position.
+func DeduplicateByID(items []SearchItem) []SearchItem {
+	return deduplicate(items, func(item SearchItem) string { return item.ID })
+}
+
+// deduplicate keeps the first item for each key. Items with an empty key are always kept.
+func deduplicate(items []SearchItem, key func(SearchItem) string) []SearchItem {
+	seen := make(map[string]bool, len(items))
+	kept := items[:0:0]
+	for _, item := range items {
+		k := key(item)
+		if k != "" && seen[k] {
+			continue
+		}
+		seen[k] = true
+		kept = append(kept, item)
+	}
+	return kept
+}
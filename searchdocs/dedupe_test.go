@@ -0,0 +1,41 @@
+package searchdocs
+
+import (
+	"slices"
+	"testing"
+)
+
+// dedupeIDs returns the IDs of items, in order
+func dedupeIDs(items []SearchItem) []string {
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+	return ids
+}
+
+func TestDeduplicateByURL(t *testing.T) {
+	items := []SearchItem{
+		{ID: "1", URL: "/en/actions"},
+		{ID: "2", URL: "/en/webhooks"},
+		{ID: "3", URL: "/EN/Actions"},
+		{ID: "4"},
+		{ID: "5"},
+		{ID: "6", URL: "/en/webhooks"},
+	}
+	got := dedupeIDs(DeduplicateByURL(items))
+	if want := []string{"1", "2", "4", "5"}; !slices.Equal(got, want) {
+		t.Errorf("DeduplicateByURL() = %v, want %v", got, want)
+	}
+	if len(items) != 6 || items[2].ID != "3" {
+		t.Errorf("DeduplicateByURL() modified its input: %v", items)
+	}
+}
+
+func TestDeduplicateByID(t *testing.T) {
+	items := []SearchItem{{ID: "a", URL: "/1"}, {ID: "A", URL: "/2"}, {ID: "a", URL: "/3"}, {ID: "b", URL: "/4"}}
+	got := dedupeIDs(DeduplicateByID(items))
+	if want := []string{"a", "A", "b"}; !slices.Equal(got, want) {
+		t.Errorf("DeduplicateByID() = %v, want %v", got, want)
+	}
+}
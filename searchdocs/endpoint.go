@@ -0,0 +1,46 @@
+package searchdocs
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// DocsBaseURL is the base URL of the GitHub Docs site, which result URLs are relative to. It
+// is the site of the search API endpoint, so a mirror's results link to the mirror.
+var DocsBaseURL = "https://docs.github.com"
+
+// ResolveEndpoint returns the search API endpoint to use: flagVal, then envVal, then
+// defaultVal. The endpoint must be an absolute https URL, e.g.
+// "https://docs.example.com/api/search/v1"; a trailing slash is dropped.
+func ResolveEndpoint(flagVal, envVal, defaultVal string) (string, error) {
+	endpoint := defaultVal
+	for _, value := range []string{envVal, flagVal} {
+		if value = strings.TrimSpace(value); value != "" {
+			endpoint = value
+		}
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	if !u.IsAbs() || u.Host == "" {
+		return "", fmt.Errorf("%q is not an absolute URL", endpoint)
+	}
+	if u.Scheme != "https" {
+		return "", fmt.Errorf("%q must use https", endpoint)
+	}
+	return strings.TrimSuffix(endpoint, "/"), nil
+}
+
+// EndpointBaseURL returns the site a search API endpoint belongs to, e.g.
+// "https://docs.example.com" for "https://docs.example.com/api/search/v1". An endpoint that
+// isn't a URL leaves DocsBaseURL as it is.
+func EndpointBaseURL(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return DocsBaseURL
+	}
+	return u.Scheme + "://" + u.Host
+}
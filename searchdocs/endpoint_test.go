@@ -0,0 +1,46 @@
+package searchdocs
+
+import "testing"
+
+func TestResolveEndpoint(t *testing.T) {
+	const def = "https://docs.github.com/api/search/v1"
+	tests := []struct {
+		name     string
+		flag     string
+		env      string
+		expected string
+		wantErr  bool
+	}{
+		{"default", "", "", def, false},
+		{"environment", "", "https://docs.example.com/api/search/v1", "https://docs.example.com/api/search/v1", false},
+		{"flag over environment", "https://mirror.example.com/search/", "https://docs.example.com/api/search/v1", "https://mirror.example.com/search", false},
+		{"http", "http://docs.example.com/api/search/v1", "", "", true},
+		{"relative", "/api/search/v1", "", "", true},
+		{"no host", "https:///api/search/v1", "", "", true},
+		{"invalid", "https://docs example.com/%zz", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveEndpoint(tt.flag, tt.env, def)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ResolveEndpoint() = %q, want an error", got)
+				}
+				return
+			}
+			if err != nil || got != tt.expected {
+				t.Errorf("ResolveEndpoint() = %q, %v, want %q", got, err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEndpointBaseURL(t *testing.T) {
+	if got := EndpointBaseURL("https://docs.example.com:8443/api/search/v1"); got != "https://docs.example.com:8443" {
+		t.Errorf("EndpointBaseURL() = %q", got)
+	}
+	if got := EndpointBaseURL("https://docs.github.com/api/search/v1"); got != "https://docs.github.com" {
+		t.Errorf("EndpointBaseURL() = %q", got)
+	}
+}
@@ -0,0 +1,215 @@
+package searchdocs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Exporter writes search results in the formats that --format and --output produce. Its
+// fields adjust the output for command-line flags; the zero value writes what the Export
+// functions do, with every link the full URL on DocsBaseURL.
+type Exporter struct {
+	// BaseURL is the site hit URLs are on; empty means DocsBaseURL
+	BaseURL string
+	// RelativeURLs links to each hit's site-relative path instead of its full URL
+	RelativeURLs bool
+	// MatchedContent lists the content snippets that matched the query instead of intros
+	MatchedContent bool
+	// Fragment writes HTML as the section listing the hits, without the page around it
+	Fragment bool
+	// Columns are the CSV columns, in order; empty means CSVColumns
+	Columns []string
+	// NoHeader leaves out the CSV header row
+	NoHeader bool
+	// Null ends each URL with a NUL byte instead of a newline
+	Null bool
+	// ShowRank and ShowScore put each hit's rank and score before its title
+	ShowRank, ShowScore bool
+}
+
+// url returns the link to a hit
+func (e Exporter) url(item SearchItem) string {
+	switch {
+	case e.RelativeURLs || item.Archived:
+		return HitPath(item)
+	case e.BaseURL != "":
+		return strings.TrimSuffix(e.BaseURL, "/") + HitPath(item)
+	}
+	return HitURL(item)
+}
+
+// ExportMarkdown writes result as a Markdown report, as Exporter.Markdown does by default
+func ExportMarkdown(result SearchResult, query, version string, w io.Writer) error {
+	return Exporter{}.Markdown(result, query, version, w)
+}
+
+// Markdown writes result as the Markdown report --output saves: a "# Results for" heading
+// with the query, then a "## N. Title" section for each hit with its URL as a link, its intro
+// as a paragraph, and the snippets that matched the query in a blockquote, with highlighted
+// terms in bold. The version is part of each URL, so the report doesn't repeat it.
+func (e Exporter) Markdown(result SearchResult, query, version string, w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Results for %q\n", query)
+	if len(result.Hits) == 0 {
+		b.WriteString("\n_No results found._\n")
+	}
+
+	for i, item := range result.Hits {
+		fmt.Fprintf(&b, "\n## %d. %s\n\n", i+1, strings.Join(strings.Fields(HitTitle(item)), " "))
+		link := e.url(item)
+		fmt.Fprintf(&b, "[%s](%s)\n", EscapeLinkText(link), link)
+		if intro := strings.Join(strings.Fields(item.Intro), " "); intro != "" {
+			fmt.Fprintf(&b, "\n%s\n", markToBold(intro))
+		}
+
+		snippets := HighlightStrings(item, "content_explicit")
+		if len(snippets) == 0 {
+			snippets = HighlightStrings(item, "content")
+		}
+		for j, snippet := range snippets {
+			if j == 0 {
+				b.WriteString("\n")
+			} else {
+				b.WriteString(">\n")
+			}
+			fmt.Fprintf(&b, "> %s\n", markToBold(strings.Join(strings.Fields(snippet), " ")))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// MarkdownList writes result as the --format markdown list: a heading with the query, and
+// the version when it isn't free-pro-team, then a numbered list of linked titles, each with
+// its intro quoted and breadcrumbs in italics. Highlighted terms are bold.
+func (e Exporter) MarkdownList(result SearchResult, query, version string, w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## Search results for %q", query)
+	if segment := versionSegment(version); segment != "" {
+		fmt.Fprintf(&b, " in %s", segment)
+	}
+	b.WriteString("\n\n")
+	if len(result.Hits) == 0 {
+		b.WriteString("_No results found._\n")
+	}
+
+	for i, item := range result.Hits {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		title := EscapeLinkText(HitTitle(item))
+		if highlighted, ok := TitleHighlight(item); ok {
+			title = markToBold(EscapeLinkText(highlighted))
+		}
+		fmt.Fprintf(&b, "%d. [%s](%s)\n", i+1, title, e.url(item))
+
+		if item.Intro != "" && !e.MatchedContent {
+			fmt.Fprintf(&b, "\n   > %s\n", markToBold(strings.Join(strings.Fields(item.Intro), " ")))
+		}
+		if e.MatchedContent {
+			for j, highlight := range HighlightStrings(item, "content_explicit") {
+				if j == 0 {
+					b.WriteString("\n")
+				}
+				fmt.Fprintf(&b, "   - %s\n", markToBold(strings.Join(strings.Fields(highlight), " ")))
+			}
+		}
+		if item.Breadcrumbs != "" {
+			fmt.Fprintf(&b, "\n   *%s*\n", item.Breadcrumbs)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// markToBold turns the <mark> spans around highlighted terms into Markdown bold
+func markToBold(s string) string {
+	return ReplaceMarks(s, func(term string) string { return "**" + term + "**" })
+}
+
+// EscapeLinkText escapes the brackets that would end a Markdown link's text early
+func EscapeLinkText(text string) string {
+	return strings.NewReplacer(`\`, `\\`, `[`, `\[`, `]`, `\]`).Replace(text)
+}
+
+// ExportJSONL writes the hits of result as JSON lines: one compact JSON object per hit,
+// each written as soon as it's encoded, without the meta
+func ExportJSONL(result SearchResult, w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	for _, item := range result.Hits {
+		if err := encoder.Encode(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExportYAML writes result as a YAML document with the same keys as JSON, meta and hits.
+// Multiline strings such as intros are written as block scalars.
+func ExportYAML(result SearchResult, w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(result); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// ExportURLs writes the full URL of each hit of result on its own line. baseURL is the site
+// the URLs are on; empty means DocsBaseURL, which is https://docs.github.com unless the
+// search goes to a mirror.
+func ExportURLs(result SearchResult, baseURL string, w io.Writer) error {
+	return Exporter{BaseURL: baseURL}.URLs(result, w)
+}
+
+// URLs writes the URL of each hit of result on its own line, or ended by a NUL byte with Null
+func (e Exporter) URLs(result SearchResult, w io.Writer) error {
+	end := "\n"
+	if e.Null {
+		end = "\x00"
+	}
+	for _, item := range result.Hits {
+		if _, err := io.WriteString(w, e.url(item)+end); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExportTitles writes the title of each hit of result on its own line
+func ExportTitles(result SearchResult, w io.Writer) error {
+	return Exporter{}.Titles(result, w)
+}
+
+// Titles writes the title of each hit of result on its own line, with line breaks in titles
+// turned into spaces. ShowRank puts "N. " before each and ShowScore "[0.875] ".
+func (e Exporter) Titles(result SearchResult, w io.Writer) error {
+	for i, item := range result.Hits {
+		var line strings.Builder
+		if e.ShowRank {
+			fmt.Fprintf(&line, "%d. ", i+1)
+		}
+		if e.ShowScore {
+			fmt.Fprintf(&line, "[%s] ", ScoreLabel(item.Score))
+		}
+		line.WriteString(strings.Join(strings.Fields(HitTitle(item)), " "))
+		line.WriteString("\n")
+		if _, err := io.WriteString(w, line.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ScoreLabel formats a relevance score to three decimals, or n/a when the API sent none
+func ScoreLabel(score float64) string {
+	if score == 0 {
+		return "n/a"
+	}
+	return strconv.FormatFloat(score, 'f', 3, 64)
+}
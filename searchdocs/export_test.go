@@ -0,0 +1,234 @@
+package searchdocs
+
+import (
+	"bufio"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// exportFixture is a result with a highlighted title, an intro, and breadcrumbs
+func exportFixture() SearchResult {
+	var result SearchResult
+	result.Meta.Found.Value = 2
+	result.Hits = []SearchItem{
+		{
+			ID:          "1",
+			Title:       "Managing [beta] secrets",
+			URL:         "/en/actions/secrets",
+			Breadcrumbs: "Actions / Security",
+			Intro:       "Store sensitive\nvalues safely.",
+			Score:       0.875,
+			Highlights:  map[string]interface{}{"title": []interface{}{"Managing [beta] <mark>secrets</mark>"}},
+		},
+		{ID: "2", Title: "Webhooks", URL: "/en/webhooks"},
+	}
+	return result
+}
+
+func TestExportMarkdown(t *testing.T) {
+	result := exportFixture()
+	result.Hits[0].Highlights["content"] = []interface{}{"Use <mark>secrets</mark> in\nworkflows.", "Rotate <mark>secrets</mark>."}
+
+	var b strings.Builder
+	if err := ExportMarkdown(result, "secrets", "free-pro-team", &b); err != nil {
+		t.Fatal(err)
+	}
+	want := `# Results for "secrets"
+
+## 1. Managing [beta] secrets
+
+[https://docs.github.com/en/actions/secrets](https://docs.github.com/en/actions/secrets)
+
+Store sensitive values safely.
+
+> Use **secrets** in workflows.
+>
+> Rotate **secrets**.
+
+## 2. Webhooks
+
+[https://docs.github.com/en/webhooks](https://docs.github.com/en/webhooks)
+`
+	if b.String() != want {
+		t.Errorf("ExportMarkdown() =\n%s\nwant:\n%s", b.String(), want)
+	}
+
+	b.Reset()
+	if err := ExportMarkdown(SearchResult{}, "nothing", "enterprise-cloud", &b); err != nil {
+		t.Fatal(err)
+	}
+	if want := "# Results for \"nothing\"\n\n_No results found._\n"; b.String() != want {
+		t.Errorf("ExportMarkdown() = %q, want %q", b.String(), want)
+	}
+
+	b.Reset()
+	exporter := Exporter{RelativeURLs: true}
+	if err := exporter.Markdown(exportFixture(), "secrets", "", &b); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), "[/en/actions/secrets](/en/actions/secrets)") {
+		t.Errorf("Expected relative links, got:\n%s", b.String())
+	}
+}
+
+func TestExporterMarkdownList(t *testing.T) {
+	var b strings.Builder
+	if err := (Exporter{}).MarkdownList(exportFixture(), "secrets", "free-pro-team", &b); err != nil {
+		t.Fatal(err)
+	}
+	want := `## Search results for "secrets"
+
+1. [Managing \[beta\] **secrets**](https://docs.github.com/en/actions/secrets)
+
+   > Store sensitive values safely.
+
+   *Actions / Security*
+
+2. [Webhooks](https://docs.github.com/en/webhooks)
+`
+	if b.String() != want {
+		t.Errorf("MarkdownList() =\n%s\nwant:\n%s", b.String(), want)
+	}
+
+	b.Reset()
+	if err := (Exporter{}).MarkdownList(SearchResult{}, "nothing", "enterprise-cloud", &b); err != nil {
+		t.Fatal(err)
+	}
+	if want := "## Search results for \"nothing\" in enterprise-cloud@latest\n\n_No results found._\n"; b.String() != want {
+		t.Errorf("MarkdownList() = %q, want %q", b.String(), want)
+	}
+}
+
+func TestExportCSV(t *testing.T) {
+	result := exportFixture()
+	result.Hits[0].Title = `Managing "secrets", variables`
+
+	var b strings.Builder
+	if err := ExportCSV(result, &b); err != nil {
+		t.Fatal(err)
+	}
+	want := `rank,id,title,url,breadcrumbs,score,intro
+1,"1","Managing ""secrets"", variables","https://docs.github.com/en/actions/secrets","Actions / Security",0.875,"Store sensitive
+values safely."
+2,"2","Webhooks","https://docs.github.com/en/webhooks","",0,""
+`
+	if b.String() != want {
+		t.Errorf("ExportCSV() =\n%s\nwant:\n%s", b.String(), want)
+	}
+
+	// Plain text is quoted too, and numbers never are
+	b.Reset()
+	plain := SearchResult{Hits: []SearchItem{{ID: "7", Title: "Hello", URL: "/en/hello", Score: 2}}}
+	if err := (Exporter{Columns: []string{"rank", "title", "score"}, NoHeader: true}).CSV(plain, &b); err != nil {
+		t.Fatal(err)
+	}
+	if want := "1,\"Hello\",2\n"; b.String() != want {
+		t.Errorf("CSV() = %q, want %q", b.String(), want)
+	}
+
+	b.Reset()
+	result.Meta.Took.QueryMsec = 12
+	exporter := Exporter{Columns: []string{"rank", "path", "query_msec"}, NoHeader: true}
+	if err := exporter.CSV(result, &b); err != nil {
+		t.Fatal(err)
+	}
+	if want := "1,\"/en/actions/secrets\",12\n2,\"/en/webhooks\",12\n"; b.String() != want {
+		t.Errorf("CSV() = %q, want %q", b.String(), want)
+	}
+}
+
+func TestExportJSONL(t *testing.T) {
+	var b strings.Builder
+	if err := ExportJSONL(exportFixture(), &b); err != nil {
+		t.Fatal(err)
+	}
+
+	var items []SearchItem
+	scanner := bufio.NewScanner(strings.NewReader(b.String()))
+	for scanner.Scan() {
+		var item SearchItem
+		if err := json.Unmarshal(scanner.Bytes(), &item); err != nil {
+			t.Fatalf("Invalid JSON line %q: %v", scanner.Text(), err)
+		}
+		items = append(items, item)
+	}
+	if len(items) != 2 || items[0].Title != "Managing [beta] secrets" || items[0].Intro != "Store sensitive\nvalues safely." || items[1].URL != "/en/webhooks" {
+		t.Errorf("Expected the hits to round-trip, got %+v", items)
+	}
+	if strings.Contains(b.String(), `"meta"`) {
+		t.Errorf("Expected no meta, got:\n%s", b.String())
+	}
+}
+
+func TestExportYAML(t *testing.T) {
+	var b strings.Builder
+	if err := ExportYAML(exportFixture(), &b); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(b.String(), "meta:\n  found:\n    value: 2\n") || !strings.Contains(b.String(), "    intro: |-\n      Store sensitive\n      values safely.\n") {
+		t.Errorf("Unexpected YAML:\n%s", b.String())
+	}
+
+	var result SearchResult
+	if err := yaml.Unmarshal([]byte(b.String()), &result); err != nil {
+		t.Fatalf("Invalid YAML: %v", err)
+	}
+	if result.Meta.Found.Value != 2 || len(result.Hits) != 2 || result.Hits[0].Intro != "Store sensitive\nvalues safely." || result.Hits[0].Score != 0.875 {
+		t.Errorf("Expected the result to round-trip, got %+v", result)
+	}
+}
+
+func TestExportURLs(t *testing.T) {
+	result := exportFixture()
+	result.Hits[1].Anchor = "events"
+	result.Hits = append(result.Hits, SearchItem{Title: "Old", URL: "https://example.com/old", Archived: true})
+
+	var b strings.Builder
+	if err := ExportURLs(result, "", &b); err != nil {
+		t.Fatal(err)
+	}
+	want := "https://docs.github.com/en/actions/secrets\nhttps://docs.github.com/en/webhooks#events\nhttps://example.com/old\n"
+	if b.String() != want {
+		t.Errorf("ExportURLs() = %q, want %q", b.String(), want)
+	}
+
+	b.Reset()
+	if err := ExportURLs(result, "http://localhost:4000/", &b); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(b.String(), "http://localhost:4000/en/actions/secrets\n") {
+		t.Errorf("Expected URLs on the base URL, got %q", b.String())
+	}
+
+	b.Reset()
+	if err := (Exporter{RelativeURLs: true, Null: true}).URLs(result, &b); err != nil {
+		t.Fatal(err)
+	}
+	if want := "/en/actions/secrets\x00/en/webhooks#events\x00https://example.com/old\x00"; b.String() != want {
+		t.Errorf("URLs() = %q, want %q", b.String(), want)
+	}
+}
+
+func TestExportTitles(t *testing.T) {
+	result := exportFixture()
+	result.Hits[1].Title = "Webhooks\nand events"
+
+	var b strings.Builder
+	if err := ExportTitles(result, &b); err != nil {
+		t.Fatal(err)
+	}
+	if want := "Managing [beta] secrets\nWebhooks and events\n"; b.String() != want {
+		t.Errorf("ExportTitles() = %q, want %q", b.String(), want)
+	}
+
+	b.Reset()
+	if err := (Exporter{ShowRank: true, ShowScore: true}).Titles(result, &b); err != nil {
+		t.Fatal(err)
+	}
+	if want := "1. [0.875] Managing [beta] secrets\n2. [n/a] Webhooks and events\n"; b.String() != want {
+		t.Errorf("Titles() = %q, want %q", b.String(), want)
+	}
+}
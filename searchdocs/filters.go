@@ -0,0 +1,108 @@
+package searchdocs
+
+import (
+	"strings"
+)
+
+// NormalizeBreadcrumbs lowercases a breadcrumb path and rewrites its separators so that
+// "Actions > Security guides" and "actions/security guides" compare equal
+func NormalizeBreadcrumbs(breadcrumbs string) string {
+	segments := strings.FieldsFunc(breadcrumbs, func(r rune) bool {
+		return r == '/' || r == '>'
+	})
+
+	normalized := make([]string, 0, len(segments))
+	for _, segment := range segments {
+		segment = strings.Join(strings.Fields(segment), " ")
+		if segment != "" {
+			normalized = append(normalized, strings.ToLower(segment))
+		}
+	}
+	return strings.Join(normalized, " / ")
+}
+
+// MatchBreadcrumbPrefix reports whether breadcrumbs start with prefix, ignoring case,
+// whitespace around separators, and whether "/" or ">" is used as the separator
+func MatchBreadcrumbPrefix(breadcrumbs, prefix string) bool {
+	normalizedPrefix := NormalizeBreadcrumbs(prefix)
+	if normalizedPrefix == "" {
+		return true
+	}
+	return strings.HasPrefix(NormalizeBreadcrumbs(breadcrumbs), normalizedPrefix)
+}
+
+// ContainsBreadcrumb reports whether text appears anywhere in breadcrumbs, ignoring case and
+// whether "/" or ">" is used as the separator
+func ContainsBreadcrumb(breadcrumbs, text string) bool {
+	normalizedText := NormalizeBreadcrumbs(text)
+	if normalizedText == "" {
+		return true
+	}
+	return strings.Contains(NormalizeBreadcrumbs(breadcrumbs), normalizedText)
+}
+
+// FilterByBreadcrumb returns the items whose breadcrumbs contain any of patterns, in order.
+// Matching is as ContainsBreadcrumb does it, ignoring case and the separator used. No
+// patterns keeps every item.
+func FilterByBreadcrumb(items []SearchItem, patterns []string) []SearchItem {
+	if len(patterns) == 0 {
+		return items
+	}
+	kept := make([]SearchItem, 0, len(items))
+	for _, item := range items {
+		for _, pattern := range patterns {
+			if ContainsBreadcrumb(item.Breadcrumbs, pattern) {
+				kept = append(kept, item)
+				break
+			}
+		}
+	}
+	return kept
+}
+
+// SplitHeadings splits the headings field returned by the search API into individual headings
+func SplitHeadings(headings string) []string {
+	lines := strings.Split(headings, "\n")
+	split := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" {
+			split = append(split, line)
+		}
+	}
+	return split
+}
+
+// MatchHeading returns the first heading containing text, ignoring case
+func MatchHeading(headings, text string) (string, bool) {
+	folded := FoldCase(strings.TrimSpace(text))
+	for _, heading := range SplitHeadings(headings) {
+		if strings.Contains(FoldCase(heading), folded) {
+			return heading, true
+		}
+	}
+	return "", false
+}
+
+// InToplevel reports whether a page belongs to a toplevel docs product, given either as the
+// product's URL segment (e.g. "rest") or its name as returned in the toplevel field (e.g.
+// "REST API"), ignoring case
+func InToplevel(path, toplevel, value string) bool {
+	if strings.EqualFold(toplevel, value) {
+		return true
+	}
+	_, _, rest := SplitDocsPath(path)
+	segment, _, _ := strings.Cut(strings.TrimPrefix(rest, "/"), "/")
+	return segment != "" && strings.EqualFold(segment, value)
+}
+
+// FilterByScore returns the items scored at least minScore, in order. Items the API sent
+// without a score count as 0; a minScore of 0 or less keeps every item.
+func FilterByScore(items []SearchItem, minScore float64) []SearchItem {
+	kept := make([]SearchItem, 0, len(items))
+	for _, item := range items {
+		if item.Score >= minScore {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
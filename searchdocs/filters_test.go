@@ -0,0 +1,213 @@
+package searchdocs
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNormalizeBreadcrumbs(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"slash separated", "Actions / Security guides", "actions / security guides"},
+		{"angle separated", "Actions > Security guides", "actions / security guides"},
+		{"no spaces", "Actions/Security guides", "actions / security guides"},
+		{"extra whitespace", "  Actions   /  Security   guides  ", "actions / security guides"},
+		{"trailing separator", "Actions /", "actions"},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeBreadcrumbs(tt.input); got != tt.expected {
+				t.Errorf("NormalizeBreadcrumbs(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMatchBreadcrumbPrefix(t *testing.T) {
+	breadcrumbs := "Actions / Security guides / OIDC hardening"
+
+	tests := []struct {
+		name     string
+		prefix   string
+		expected bool
+	}{
+		{"exact path", "Actions / Security guides / OIDC hardening", true},
+		{"leading section", "Actions / Security guides", true},
+		{"top section only", "Actions", true},
+		{"lowercase", "actions / security guides", true},
+		{"uppercase", "ACTIONS / SECURITY GUIDES", true},
+		{"angle separator", "Actions > Security guides", true},
+		{"no spaces around separator", "Actions/Security guides", true},
+		{"mixed separators and spacing", "actions >security guides/  oidc", true},
+		{"partial segment", "Actions / Sec", true},
+		{"different section", "Admin / Security guides", false},
+		{"not a prefix", "Security guides", false},
+		{"longer than breadcrumbs", "Actions / Security guides / OIDC hardening / More", false},
+		{"empty prefix matches everything", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MatchBreadcrumbPrefix(breadcrumbs, tt.prefix); got != tt.expected {
+				t.Errorf("MatchBreadcrumbPrefix(%q, %q) = %v, want %v", breadcrumbs, tt.prefix, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestContainsBreadcrumb(t *testing.T) {
+	breadcrumbs := "Actions / Security guides / OIDC hardening"
+
+	tests := []struct {
+		name     string
+		text     string
+		expected bool
+	}{
+		{"first section", "Actions", true},
+		{"middle section", "security guides", true},
+		{"last section", "OIDC", true},
+		{"spans a separator", "guides > oidc", true},
+		{"inside a word", "hard", true},
+		{"other section", "Billing", false},
+		{"empty text matches everything", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ContainsBreadcrumb(breadcrumbs, tt.text); got != tt.expected {
+				t.Errorf("ContainsBreadcrumb(%q, %q) = %v, want %v", breadcrumbs, tt.text, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSplitHeadings(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{"newline separated", "About OIDC\nTroubleshooting\nFurther reading", []string{"About OIDC", "Troubleshooting", "Further reading"}},
+		{"blank lines and padding", "\n  About OIDC \n\nTroubleshooting\n", []string{"About OIDC", "Troubleshooting"}},
+		{"empty", "", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SplitHeadings(tt.input)
+			if len(got) != len(tt.expected) {
+				t.Fatalf("SplitHeadings(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Errorf("SplitHeadings(%q)[%d] = %q, want %q", tt.input, i, got[i], tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMatchHeading(t *testing.T) {
+	headings := "About self-hosted runners\nRequired permissions\nTroubleshooting runner connectivity"
+
+	tests := []struct {
+		name      string
+		text      string
+		expected  string
+		wantFound bool
+	}{
+		{"exact heading", "Required permissions", "Required permissions", true},
+		{"substring", "troubleshooting", "Troubleshooting runner connectivity", true},
+		{"case insensitive", "REQUIRED PERMISSIONS", "Required permissions", true},
+		{"first match wins", "runner", "About self-hosted runners", true},
+		{"no match", "billing", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := MatchHeading(headings, tt.text)
+			if got != tt.expected || found != tt.wantFound {
+				t.Errorf("MatchHeading(%q) = %q, %v; want %q, %v", tt.text, got, found, tt.expected, tt.wantFound)
+			}
+		})
+	}
+}
+
+func TestInToplevel(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		toplevel string
+		value    string
+		expected bool
+	}{
+		{"url segment", "/en/rest/repos/repos", "", "rest", true},
+		{"url segment after version", "/en/enterprise-cloud@latest/graphql/reference", "", "graphql", true},
+		{"toplevel name", "/en/rest/repos/repos", "REST API", "rest api", true},
+		{"different product", "/en/actions/quickstart", "GitHub Actions", "rest", false},
+		{"prefix is not a match", "/en/restricted/page", "", "rest", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := InToplevel(tt.path, tt.toplevel, tt.value); got != tt.expected {
+				t.Errorf("InToplevel(%q, %q, %q) = %v, want %v", tt.path, tt.toplevel, tt.value, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFilterByScore(t *testing.T) {
+	items := []SearchItem{{ID: "1", Score: 0.9}, {ID: "2", Score: 0.5}, {ID: "3"}, {ID: "4", Score: 0.75}}
+	ids := func(items []SearchItem) []string {
+		var ids []string
+		for _, item := range items {
+			ids = append(ids, item.ID)
+		}
+		return ids
+	}
+
+	if got := ids(FilterByScore(items, 0.75)); !slices.Equal(got, []string{"1", "4"}) {
+		t.Errorf("FilterByScore(0.75) = %v, want [1 4]", got)
+	}
+	if got := ids(FilterByScore(items, 0)); !slices.Equal(got, []string{"1", "2", "3", "4"}) {
+		t.Errorf("FilterByScore(0) = %v, want every item", got)
+	}
+	if got := FilterByScore(items, 1); len(got) != 0 {
+		t.Errorf("FilterByScore(1) = %v, want none", ids(got))
+	}
+}
+
+func TestFilterByBreadcrumb(t *testing.T) {
+	items := []SearchItem{
+		{ID: "1", Breadcrumbs: "Actions / Security guides"},
+		{ID: "2", Breadcrumbs: "Code security / Secret scanning"},
+		{ID: "3", Breadcrumbs: "Webhooks"},
+		{ID: "4"},
+	}
+	ids := func(items []SearchItem) []string {
+		var ids []string
+		for _, item := range items {
+			ids = append(ids, item.ID)
+		}
+		return ids
+	}
+
+	if got := ids(FilterByBreadcrumb(items, []string{"SECURITY"})); !slices.Equal(got, []string{"1", "2"}) {
+		t.Errorf("Expected a case-insensitive substring match, got %v", got)
+	}
+	if got := ids(FilterByBreadcrumb(items, []string{"webhooks", "actions > security"})); !slices.Equal(got, []string{"1", "3"}) {
+		t.Errorf("Expected any pattern to match, got %v", got)
+	}
+	if got := ids(FilterByBreadcrumb(items, nil)); !slices.Equal(got, []string{"1", "2", "3", "4"}) {
+		t.Errorf("Expected no patterns to keep every item, got %v", got)
+	}
+	if got := FilterByBreadcrumb(items, []string{"billing"}); len(got) != 0 {
+		t.Errorf("Expected no matches, got %v", ids(got))
+	}
+}
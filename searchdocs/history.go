@@ -0,0 +1,279 @@
+package searchdocs
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// History actions recorded in HistoryEntry.Action
+const (
+	HistorySearch   = "search"
+	HistoryOpen     = "open"
+	HistoryBookmark = "bookmark"
+)
+
+// HistoryEntry is a single line of the local search history file
+type HistoryEntry struct {
+	Query       string    `json:"query,omitempty"`
+	Version     string    `json:"version,omitempty"`
+	Language    string    `json:"language,omitempty"`
+	Timestamp   time.Time `json:"timestamp"`
+	ResultCount int       `json:"resultCount"`
+	// Action is HistorySearch (the default when empty), HistoryOpen, or HistoryBookmark
+	Action string `json:"action,omitempty"`
+	// URL is the doc that was opened or bookmarked
+	URL string `json:"url,omitempty"`
+}
+
+// IsSearch reports whether the entry records a search rather than an open or bookmark
+func (e HistoryEntry) IsSearch() bool {
+	return e.Action == "" || e.Action == HistorySearch
+}
+
+// DataDir returns the directory used for persistent data such as history, honoring XDG_DATA_HOME
+func DataDir() (string, error) {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "gh-search-docs"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share", "gh-search-docs"), nil
+}
+
+// ConfigDir returns the directory used for user configuration, honoring XDG_CONFIG_HOME
+func ConfigDir() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "gh-search-docs"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "gh-search-docs"), nil
+}
+
+// HistoryPath returns the path of the history file. It's history.jsonl rather than
+// history.json because each entry is one JSON line, appended with a single write so concurrent
+// searches don't need to rewrite the whole file.
+func HistoryPath() (string, error) {
+	dir, err := DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "history.jsonl"), nil
+}
+
+// LoadHistoryFile reads history entries from a JSON lines file. A missing file is an empty
+// history, and malformed lines are skipped so one bad write can't break the whole file.
+func LoadHistoryFile(path string) ([]HistoryEntry, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []HistoryEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var entry HistoryEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// Count is a key and the number of times it occurred
+type Count struct {
+	Key   string `json:"key"`
+	Count int    `json:"count"`
+}
+
+// HistoryStats summarizes local search history
+type HistoryStats struct {
+	Since           time.Time `json:"since,omitzero"`
+	Searches        int       `json:"searches"`
+	ZeroResults     int       `json:"zeroResults"`
+	ZeroResultRate  float64   `json:"zeroResultRate"`
+	TopQueries      []Count   `json:"topQueries"`
+	TopDocs         []Count   `json:"topDocs"`
+	SearchesPerWeek []Count   `json:"searchesPerWeek"`
+	Versions        []Count   `json:"versions"`
+	Languages       []Count   `json:"languages"`
+}
+
+// ComputeHistoryStats aggregates history entries recorded at or after since (a zero since
+// includes everything). Top lists are cut to limit entries; a limit below 1 keeps them all.
+func ComputeHistoryStats(entries []HistoryEntry, since time.Time, limit int) HistoryStats {
+	stats := HistoryStats{Since: since}
+
+	queries := map[string]int{}
+	docs := map[string]int{}
+	weeks := map[string]int{}
+	versions := map[string]int{}
+	languages := map[string]int{}
+
+	for _, entry := range entries {
+		if !since.IsZero() && entry.Timestamp.Before(since) {
+			continue
+		}
+
+		if !entry.IsSearch() {
+			if entry.URL != "" {
+				docs[entry.URL]++
+			}
+			continue
+		}
+
+		stats.Searches++
+		if entry.ResultCount == 0 {
+			stats.ZeroResults++
+		}
+		if query := strings.ToLower(strings.Join(strings.Fields(entry.Query), " ")); query != "" {
+			queries[query]++
+		}
+		year, week := entry.Timestamp.ISOWeek()
+		weeks[fmt.Sprintf("%d-W%02d", year, week)]++
+		if entry.Version != "" {
+			versions[entry.Version]++
+		}
+		if entry.Language != "" {
+			languages[entry.Language]++
+		}
+	}
+
+	if stats.Searches > 0 {
+		stats.ZeroResultRate = float64(stats.ZeroResults) / float64(stats.Searches)
+	}
+	stats.TopQueries = topCounts(queries, limit)
+	stats.TopDocs = topCounts(docs, limit)
+	stats.Versions = topCounts(versions, limit)
+	stats.Languages = topCounts(languages, limit)
+
+	// Weeks read best in chronological order rather than by count
+	stats.SearchesPerWeek = topCounts(weeks, 0)
+	sort.Slice(stats.SearchesPerWeek, func(i, j int) bool {
+		return stats.SearchesPerWeek[i].Key < stats.SearchesPerWeek[j].Key
+	})
+
+	return stats
+}
+
+// topCounts sorts counts from most to least frequent, breaking ties alphabetically
+func topCounts(counts map[string]int, limit int) []Count {
+	sorted := make([]Count, 0, len(counts))
+	for key, count := range counts {
+		sorted = append(sorted, Count{Key: key, Count: count})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Count != sorted[j].Count {
+			return sorted[i].Count > sorted[j].Count
+		}
+		return sorted[i].Key < sorted[j].Key
+	})
+	if limit > 0 && len(sorted) > limit {
+		sorted = sorted[:limit]
+	}
+	return sorted
+}
+
+// LoadHistory reads the entries in the user's history file
+func LoadHistory() ([]HistoryEntry, error) {
+	path, err := HistoryPath()
+	if err != nil {
+		return nil, err
+	}
+	return LoadHistoryFile(path)
+}
+
+// ClearHistory empties the user's history file. A missing file is already clear.
+func ClearHistory() error {
+	path, err := HistoryPath()
+	if err != nil {
+		return err
+	}
+	if err := os.Truncate(path, 0); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// RecentSearches returns up to limit search entries, most recent first. Opens and
+// bookmarks are left out.
+func RecentSearches(entries []HistoryEntry, limit int) []HistoryEntry {
+	var searches []HistoryEntry
+	for _, entry := range entries {
+		if entry.IsSearch() && entry.Query != "" {
+			searches = append(searches, entry)
+		}
+	}
+	sort.SliceStable(searches, func(i, j int) bool {
+		return searches[i].Timestamp.After(searches[j].Timestamp)
+	})
+	if limit > 0 && len(searches) > limit {
+		searches = searches[:limit]
+	}
+	return searches
+}
+
+// HistoryDisabled reports whether the user opted out of recording searches locally by setting
+// GH_SEARCH_DOCS_NO_HISTORY=1
+func HistoryDisabled() bool {
+	disabled, err := strconv.ParseBool(os.Getenv("GH_SEARCH_DOCS_NO_HISTORY"))
+	return err == nil && disabled
+}
+
+// AppendHistory appends an entry to the history file after a search or open, unless history
+// is disabled
+func AppendHistory(entry HistoryEntry) error {
+	if HistoryDisabled() {
+		return nil
+	}
+	path, err := HistoryPath()
+	if err != nil {
+		return err
+	}
+	return AppendJSONLine(path, entry)
+}
+
+// AppendJSONLine appends v to a JSON lines file, creating the file and its directory if needed.
+// The line is written with a single O_APPEND write so concurrent invocations don't interleave.
+func AppendJSONLine(path string, v any) error {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	// #nosec G304 -- the path is chosen by the user
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,195 @@
+package searchdocs
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// writeHistoryFile writes a synthetic JSON lines history file and returns its path
+func writeHistoryFile(t *testing.T, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	content := ""
+	for _, line := range lines {
+		content += line + "\n"
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write history file: %v", err)
+	}
+	return path
+}
+
+func TestLoadHistoryFile(t *testing.T) {
+	path := writeHistoryFile(t,
+		`{"query":"ssh key","version":"free-pro-team","language":"en","timestamp":"2026-10-01T10:00:00Z","resultCount":12}`,
+		``,
+		`not json`,
+		`{"action":"open","url":"/en/authentication/connecting-to-github-with-ssh","timestamp":"2026-10-01T10:01:00Z","resultCount":0}`,
+	)
+
+	entries, err := LoadHistoryFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries (blank and malformed lines skipped), got %d", len(entries))
+	}
+	if entries[0].Query != "ssh key" || !entries[0].IsSearch() {
+		t.Errorf("Unexpected first entry: %+v", entries[0])
+	}
+	if entries[1].IsSearch() || entries[1].URL == "" {
+		t.Errorf("Unexpected second entry: %+v", entries[1])
+	}
+}
+
+func TestLoadHistoryFileMissing(t *testing.T) {
+	entries, err := LoadHistoryFile(filepath.Join(t.TempDir(), "missing.jsonl"))
+	if err != nil || entries != nil {
+		t.Errorf("Expected empty history for a missing file, got %v, %v", entries, err)
+	}
+}
+
+func TestHistoryPath(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dir)
+
+	path, err := HistoryPath()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := filepath.Join(dir, "gh-search-docs", "history.jsonl"); path != expected {
+		t.Errorf("HistoryPath() = %q, want %q", path, expected)
+	}
+}
+
+func TestComputeHistoryStats(t *testing.T) {
+	path := writeHistoryFile(t,
+		`{"query":"old search","version":"free-pro-team","language":"en","timestamp":"2026-08-01T10:00:00Z","resultCount":3}`,
+		`{"query":"SSH key","version":"free-pro-team","language":"en","timestamp":"2026-10-05T10:00:00Z","resultCount":12}`,
+		`{"query":"ssh  key","version":"enterprise-cloud","language":"en","timestamp":"2026-10-06T10:00:00Z","resultCount":8}`,
+		`{"query":"nonexistent thing","version":"free-pro-team","language":"ja","timestamp":"2026-10-13T10:00:00Z","resultCount":0}`,
+		`{"query":"actions","version":"free-pro-team","language":"en","timestamp":"2026-10-14T10:00:00Z","resultCount":40}`,
+		`{"action":"open","url":"/en/ssh","timestamp":"2026-10-05T10:01:00Z","resultCount":0}`,
+		`{"action":"bookmark","url":"/en/ssh","timestamp":"2026-10-05T10:02:00Z","resultCount":0}`,
+		`{"action":"open","url":"/en/actions","timestamp":"2026-10-14T10:01:00Z","resultCount":0}`,
+	)
+	entries, err := LoadHistoryFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	since := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	stats := ComputeHistoryStats(entries, since, 2)
+
+	if stats.Searches != 4 {
+		t.Errorf("Expected 4 searches since cutoff, got %d", stats.Searches)
+	}
+	if stats.ZeroResults != 1 || stats.ZeroResultRate != 0.25 {
+		t.Errorf("Expected zero-result rate 0.25, got %d (%v)", stats.ZeroResults, stats.ZeroResultRate)
+	}
+
+	expectedQueries := []Count{{"ssh key", 2}, {"actions", 1}}
+	if !reflect.DeepEqual(stats.TopQueries, expectedQueries) {
+		t.Errorf("TopQueries = %v, want %v", stats.TopQueries, expectedQueries)
+	}
+
+	expectedDocs := []Count{{"/en/ssh", 2}, {"/en/actions", 1}}
+	if !reflect.DeepEqual(stats.TopDocs, expectedDocs) {
+		t.Errorf("TopDocs = %v, want %v", stats.TopDocs, expectedDocs)
+	}
+
+	expectedWeeks := []Count{{"2026-W41", 2}, {"2026-W42", 2}}
+	if !reflect.DeepEqual(stats.SearchesPerWeek, expectedWeeks) {
+		t.Errorf("SearchesPerWeek = %v, want %v", stats.SearchesPerWeek, expectedWeeks)
+	}
+
+	expectedVersions := []Count{{"free-pro-team", 3}, {"enterprise-cloud", 1}}
+	if !reflect.DeepEqual(stats.Versions, expectedVersions) {
+		t.Errorf("Versions = %v, want %v", stats.Versions, expectedVersions)
+	}
+
+	expectedLanguages := []Count{{"en", 3}, {"ja", 1}}
+	if !reflect.DeepEqual(stats.Languages, expectedLanguages) {
+		t.Errorf("Languages = %v, want %v", stats.Languages, expectedLanguages)
+	}
+}
+
+func TestComputeHistoryStatsEmpty(t *testing.T) {
+	stats := ComputeHistoryStats(nil, time.Time{}, 10)
+	if stats.Searches != 0 || stats.ZeroResultRate != 0 {
+		t.Errorf("Expected empty stats, got %+v", stats)
+	}
+}
+
+func TestRecentSearches(t *testing.T) {
+	path := writeHistoryFile(t,
+		`{"query":"first","timestamp":"2026-10-01T10:00:00Z","resultCount":1}`,
+		`{"query":"third","timestamp":"2026-10-03T10:00:00Z","resultCount":3}`,
+		`{"action":"open","url":"/en/ssh","timestamp":"2026-10-04T10:00:00Z","resultCount":0}`,
+		`{"query":"second","timestamp":"2026-10-02T10:00:00Z","resultCount":2}`,
+	)
+	entries, err := LoadHistoryFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var queries []string
+	for _, entry := range RecentSearches(entries, 2) {
+		queries = append(queries, entry.Query)
+	}
+	if !reflect.DeepEqual(queries, []string{"third", "second"}) {
+		t.Errorf("RecentSearches() = %v, want newest searches first", queries)
+	}
+}
+
+func TestClearHistory(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	if err := ClearHistory(); err != nil {
+		t.Fatalf("Clearing a missing history failed: %v", err)
+	}
+
+	if err := AppendHistory(HistoryEntry{Query: "ssh", Timestamp: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if entries, err := LoadHistory(); err != nil || len(entries) != 1 {
+		t.Fatalf("LoadHistory() = %v, %v; want one entry", entries, err)
+	}
+	if err := ClearHistory(); err != nil {
+		t.Fatalf("ClearHistory failed: %v", err)
+	}
+	if entries, err := LoadHistory(); err != nil || len(entries) != 0 {
+		t.Errorf("LoadHistory() after clearing = %v, %v; want none", entries, err)
+	}
+}
+
+func TestAppendHistory(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("GH_SEARCH_DOCS_NO_HISTORY", "")
+
+	entry := HistoryEntry{Query: "runner groups", Version: "free-pro-team@latest", Language: "en", Timestamp: time.Now().UTC(), ResultCount: 3, Action: HistorySearch}
+	if err := AppendHistory(entry); err != nil {
+		t.Fatal(err)
+	}
+	if err := AppendHistory(HistoryEntry{Query: "ssh", Timestamp: time.Now().UTC()}); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := LoadHistory()
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("LoadHistory() = %v, %v; want two entries", entries, err)
+	}
+	if got := entries[0]; got.Query != entry.Query || got.Version != entry.Version || got.ResultCount != 3 || !got.Timestamp.Equal(entry.Timestamp) {
+		t.Errorf("Expected the first entry to round-trip, got %+v", got)
+	}
+
+	// Nothing is written when history is disabled
+	t.Setenv("GH_SEARCH_DOCS_NO_HISTORY", "1")
+	if err := AppendHistory(entry); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := LoadHistory(); len(entries) != 2 {
+		t.Errorf("Expected no entry while history is disabled, got %d entries", len(entries))
+	}
+}
@@ -0,0 +1,132 @@
+package searchdocs
+
+import (
+	"html"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+)
+
+// htmlFragment is the HTML export's section listing the hits: a heading with the query and
+// result count and an ordered list of linked results. Titles, intros, and snippets are
+// escaped; only the <mark> tags the API puts around matched terms are kept as HTML.
+var htmlFragment = template.Must(template.New("fragment").Funcs(template.FuncMap{
+	"marked": markedHTML,
+	"title":  HitTitle,
+}).Parse(`<section class="gh-search-docs">
+<h2>{{len .Hits}} of {{.Found}} results for &ldquo;{{.Query}}&rdquo;</h2>
+<ol>
+{{- range .Hits}}
+<li>
+<a href="{{.URL}}">{{if .TitleHighlight}}{{marked .TitleHighlight}}{{else}}{{title .Item}}{{end}}</a>
+{{- with .Item.Breadcrumbs}}
+<p class="breadcrumbs">{{.}}</p>
+{{- end}}
+{{- with .Item.Intro}}
+<p>{{.}}</p>
+{{- end}}
+{{- range .Snippets}}
+<blockquote>{{marked .}}</blockquote>
+{{- end}}
+</li>
+{{- end}}
+</ol>
+</section>
+`))
+
+// htmlPage wraps the fragment in a standalone document. The head records the query, docs
+// version, and time of the search so a saved page says where it came from, and the colors
+// follow the reader's light or dark preference.
+var htmlPage = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="generator" content="gh-search-docs">
+<meta name="gh-search-docs:query" content="{{.Query}}">
+<meta name="gh-search-docs:version" content="{{.Version}}">
+<meta name="date" content="{{.Generated}}">
+<title>GitHub Docs: {{.Query}}</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; max-width: 48rem; margin: 2rem auto; padding: 0 1rem; line-height: 1.5; color: #1f2328; }
+a { color: #0969da; }
+li { margin-bottom: 1rem; }
+.breadcrumbs { color: #59636e; font-size: 0.875rem; margin: 0; }
+blockquote { margin: 0.5rem 0; padding-left: 0.75rem; border-left: 3px solid #d1d9e0; color: #59636e; }
+mark { background: #fff8c5; }
+@media (prefers-color-scheme: dark) {
+body { background: #0d1117; color: #f0f6fc; }
+a { color: #4493f8; }
+.breadcrumbs, blockquote { color: #9198a1; }
+blockquote { border-left-color: #3d444d; }
+mark { background: #bb800926; color: inherit; }
+}
+</style>
+</head>
+<body>
+{{.Fragment}}</body>
+</html>
+`))
+
+// htmlHit is one result as the HTML templates see it
+type htmlHit struct {
+	Item           SearchItem
+	URL            string
+	TitleHighlight string
+	Snippets       []string
+}
+
+// markedHTML escapes s for HTML, then restores the <mark> tags around matched terms
+func markedHTML(s string) template.HTML {
+	escaped := html.EscapeString(s)
+	escaped = strings.NewReplacer("&lt;mark&gt;", "<mark>", "&lt;/mark&gt;", "</mark>").Replace(escaped)
+	// #nosec G203 -- everything but the <mark> tags has been escaped
+	return template.HTML(escaped)
+}
+
+// ExportHTML writes result as a standalone HTML document, as Exporter.HTML does by default
+func ExportHTML(result SearchResult, query, version string, w io.Writer) error {
+	return Exporter{}.HTML(result, query, version, w)
+}
+
+// HTML writes result as a standalone HTML document whose head records the query, version,
+// and time of the search, or with Fragment set as just the section listing the hits
+func (e Exporter) HTML(result SearchResult, query, version string, w io.Writer) error {
+	data := struct {
+		Query string
+		Found int
+		Hits  []htmlHit
+	}{Query: query, Found: max(result.Meta.Found.Value, len(result.Hits))}
+
+	for _, item := range result.Hits {
+		hit := htmlHit{Item: item, URL: e.url(item)}
+		if title, ok := TitleHighlight(item); ok {
+			hit.TitleHighlight = title
+		}
+		hit.Snippets = HighlightStrings(item, "content_explicit")
+		if len(hit.Snippets) == 0 {
+			hit.Snippets = HighlightStrings(item, "content")
+		}
+		data.Hits = append(data.Hits, hit)
+	}
+
+	if e.Fragment {
+		return htmlFragment.Execute(w, data)
+	}
+	var fragment strings.Builder
+	if err := htmlFragment.Execute(&fragment, data); err != nil {
+		return err
+	}
+	return htmlPage.Execute(w, struct {
+		Query     string
+		Version   string
+		Generated string
+		Fragment  template.HTML
+	}{
+		Query:     query,
+		Version:   version,
+		Generated: time.Now().UTC().Format(time.RFC3339),
+		// #nosec G203 -- the fragment was generated by the escaping template above
+		Fragment: template.HTML(fragment.String()),
+	})
+}
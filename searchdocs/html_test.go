@@ -0,0 +1,59 @@
+package searchdocs
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestExportHTML(t *testing.T) {
+	result := exportFixture()
+	result.Hits[0].Intro = `Use <script> & "quotes"`
+
+	var b strings.Builder
+	if err := ExportHTML(result, "secrets", "enterprise-cloud", &b); err != nil {
+		t.Fatal(err)
+	}
+	output := b.String()
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		`<meta name="generator" content="gh-search-docs">`,
+		`<meta name="gh-search-docs:version" content="enterprise-cloud">`,
+		"<h2>2 of 2 results for &ldquo;secrets&rdquo;</h2>",
+		`<a href="https://docs.github.com/en/actions/secrets">Managing [beta] <mark>secrets</mark></a>`,
+		"<p>Use &lt;script&gt; &amp; &#34;quotes&#34;</p>",
+		`<a href="https://docs.github.com/en/webhooks">Webhooks</a>`,
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q in:\n%s", want, output)
+		}
+	}
+
+	doc, err := html.Parse(strings.NewReader(output))
+	if err != nil {
+		t.Fatalf("Expected the page to parse, got %v", err)
+	}
+	var links int
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "a" {
+			links++
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	if links != 2 {
+		t.Errorf("Expected a link per hit, got %d", links)
+	}
+
+	b.Reset()
+	if err := (Exporter{Fragment: true, RelativeURLs: true}).HTML(result, "secrets", "", &b); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(b.String(), `<section class="gh-search-docs">`) || !strings.Contains(b.String(), `<a href="/en/webhooks">`) {
+		t.Errorf("Expected a fragment with relative links, got:\n%s", b.String())
+	}
+}
@@ -0,0 +1,51 @@
+package searchdocs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// DefaultTimeout is how long a request may take, including reading its response, by default
+const DefaultTimeout = 30 * time.Second
+
+// NewHTTPClient returns a client whose requests give up after timeout. A timeout of 0 means
+// requests wait as long as they need.
+func NewHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout}
+}
+
+// NewHTTPClientWithProxy returns a client like NewHTTPClient that sends its requests through
+// proxyURL. An empty proxyURL uses the HTTPS_PROXY, HTTP_PROXY, and NO_PROXY environment
+// variables instead.
+func NewHTTPClientWithProxy(proxyURL string, timeout time.Duration) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	if proxyURL != "" {
+		u, err := url.Parse(proxyURL)
+		if err != nil {
+			return nil, err
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("%q is not a URL like http://proxy.example.com:8080", proxyURL)
+		}
+		transport.Proxy = http.ProxyURL(u)
+	}
+
+	client := NewHTTPClient(timeout)
+	client.Transport = transport
+	return client, nil
+}
+
+// IsTimeout reports whether err comes from a request that ran out of time
+func IsTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
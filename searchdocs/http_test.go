@@ -0,0 +1,99 @@
+package searchdocs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNewHTTPClientTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+
+	_, err := NewHTTPClient(20 * time.Millisecond).Get(server.URL)
+	if err == nil {
+		t.Fatal("Expected the request to time out")
+	}
+	if !IsTimeout(err) {
+		t.Errorf("IsTimeout(%v) = false, want true", err)
+	}
+}
+
+func TestNewHTTPClientNoTimeout(t *testing.T) {
+	if client := NewHTTPClient(0); client.Timeout != 0 {
+		t.Errorf("Timeout = %s, want no limit", client.Timeout)
+	}
+}
+
+func TestIsTimeout(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{context.DeadlineExceeded, true},
+		{errors.Join(errors.New("get"), context.DeadlineExceeded), true},
+		{context.Canceled, false},
+		{errors.New("connection refused"), false},
+	}
+	for _, tt := range tests {
+		if got := IsTimeout(tt.err); got != tt.want {
+			t.Errorf("IsTimeout(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestNewHTTPClientWithProxy(t *testing.T) {
+	var forwarded []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded = append(forwarded, r.URL.String())
+		_, _ = io.WriteString(w, "via proxy")
+	}))
+	t.Cleanup(proxy.Close)
+
+	client, err := NewHTTPClientWithProxy(proxy.URL, time.Second)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp, err := client.Get("http://docs.example.test/api/search/v1?query=actions")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if string(body) != "via proxy" {
+		t.Errorf("body = %q, want the proxy's answer", body)
+	}
+	if len(forwarded) != 1 || forwarded[0] != "http://docs.example.test/api/search/v1?query=actions" {
+		t.Errorf("Expected the proxy to forward the request, got %q", forwarded)
+	}
+	if client.Timeout != time.Second {
+		t.Errorf("Timeout = %s, want 1s", client.Timeout)
+	}
+}
+
+func TestNewHTTPClientWithProxyEnvironment(t *testing.T) {
+	client, err := NewHTTPClientWithProxy("", 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if transport, ok := client.Transport.(*http.Transport); !ok || transport.Proxy == nil {
+		t.Errorf("Expected a transport using the environment's proxy, got %#v", client.Transport)
+	}
+}
+
+func TestNewHTTPClientWithProxyInvalid(t *testing.T) {
+	for _, proxyURL := range []string{"proxy.example.com:8080", "://bad", "http://"} {
+		if _, err := NewHTTPClientWithProxy(proxyURL, 0); err == nil {
+			t.Errorf("NewHTTPClientWithProxy(%q) succeeded, want an error", proxyURL)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package searchdocs
+
+import (
+	"io"
+	"net/http"
+	"sync"
+)
+
+// DefaultConcurrency and MaxConcurrency bound the number of requests in flight at once
+const (
+	DefaultConcurrency = 4
+	MaxConcurrency     = 16
+)
+
+// limitedTransport is a RoundTripper that allows at most cap(slots) requests in flight.
+// A slot is held until the response body is closed, so reading a body counts toward the limit.
+type limitedTransport struct {
+	base  http.RoundTripper
+	slots chan struct{}
+}
+
+// LimitTransport wraps base so that at most n requests made through it are in flight at
+// once. Sharing the returned transport between clients shares the limit. A nil base uses
+// http.DefaultTransport.
+func LimitTransport(base http.RoundTripper, n int) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if n < 1 {
+		n = 1
+	}
+	return &limitedTransport{base: base, slots: make(chan struct{}, n)}
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	select {
+	case t.slots <- struct{}{}:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		<-t.slots
+		return nil, err
+	}
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: sync.OnceFunc(func() { <-t.slots })}
+	return resp, nil
+}
+
+// releasingBody frees a transport slot the first time it is closed
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
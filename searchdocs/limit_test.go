@@ -0,0 +1,98 @@
+package searchdocs
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingTransport answers every request itself and records the most requests it has seen
+// in flight at once. A request is in flight until its response body is closed.
+type countingTransport struct {
+	inFlight atomic.Int32
+	peak     atomic.Int32
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	n := t.inFlight.Add(1)
+	for {
+		peak := t.peak.Load()
+		if n <= peak || t.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       countedBody{Reader: strings.NewReader("ok"), done: func() { t.inFlight.Add(-1) }},
+		Request:    req,
+	}, nil
+}
+
+type countedBody struct {
+	io.Reader
+	done func()
+}
+
+func (b countedBody) Close() error {
+	b.done()
+	return nil
+}
+
+func TestLimitTransport(t *testing.T) {
+	for _, limit := range []int{1, 3} {
+		counter := &countingTransport{}
+		transport := LimitTransport(counter, limit)
+
+		// Two clients sharing the transport share the limit
+		clients := []*http.Client{{Transport: transport}, {Transport: transport}}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(client *http.Client) {
+				defer wg.Done()
+				resp, err := client.Get("http://docs.test/page")
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+					return
+				}
+				_, _ = io.ReadAll(resp.Body)
+				resp.Body.Close()
+			}(clients[i%2])
+		}
+		wg.Wait()
+
+		if peak := counter.peak.Load(); peak > int32(limit) {
+			t.Errorf("limit %d: peak of %d requests in flight", limit, peak)
+		}
+		if counter.inFlight.Load() != 0 {
+			t.Errorf("limit %d: %d requests still in flight", limit, counter.inFlight.Load())
+		}
+	}
+}
+
+func TestLimitTransportCanceled(t *testing.T) {
+	transport := LimitTransport(&countingTransport{}, 1)
+	client := &http.Client{Transport: transport}
+
+	// Hold the only slot by leaving the body open
+	resp, err := client.Get("http://docs.test/held")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, "http://docs.test/waiting", nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := client.Do(req.WithContext(ctx)); err == nil {
+		t.Error("Expected a canceled request waiting for a slot to fail")
+	}
+}
@@ -0,0 +1,33 @@
+package searchdocs
+
+import (
+	"os"
+	"slices"
+	"strings"
+)
+
+// SupportedLanguages are the languages docs.github.com is translated into
+var SupportedLanguages = []string{"en", "es", "ja", "pt", "zh", "ru", "fr", "ko", "de"}
+
+// DetectSystemLanguage returns the docs language for the system locale, from the first of
+// LC_ALL, LANGUAGE, and LANG that is set, e.g. pt for pt_BR.UTF-8. Locales the docs aren't
+// translated into, and the C and POSIX locales, give en.
+func DetectSystemLanguage() string {
+	for _, name := range []string{"LC_ALL", "LANGUAGE", "LANG"} {
+		locale := os.Getenv(name)
+		if locale == "" {
+			continue
+		}
+		// LANGUAGE is a list of preferences, e.g. fr:en
+		locale, _, _ = strings.Cut(locale, ":")
+		// The language comes before the territory, encoding, and modifier: ll_TT.encoding@modifier
+		if i := strings.IndexAny(locale, "_-.@"); i >= 0 {
+			locale = locale[:i]
+		}
+		if language := strings.ToLower(locale); slices.Contains(SupportedLanguages, language) {
+			return language
+		}
+		return "en"
+	}
+	return "en"
+}
@@ -0,0 +1,34 @@
+package searchdocs
+
+import "testing"
+
+func TestDetectSystemLanguage(t *testing.T) {
+	tests := []struct {
+		name     string
+		lcAll    string
+		language string
+		lang     string
+		want     string
+	}{
+		{"unset", "", "", "", "en"},
+		{"LANG", "", "", "pt_BR.UTF-8", "pt"},
+		{"LANG without territory", "", "", "ja", "ja"},
+		{"LANGUAGE list", "", "fr:en", "en_US.UTF-8", "fr"},
+		{"LC_ALL wins", "ko_KR.UTF-8", "fr", "de_DE.UTF-8", "ko"},
+		{"modifier", "", "", "de_DE@euro", "de"},
+		{"unsupported", "", "", "nl_NL.UTF-8", "en"},
+		{"C locale", "C", "", "es_ES.UTF-8", "en"},
+		{"POSIX locale", "", "", "POSIX", "en"},
+		{"empty preference", "", ":", "", "en"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("LC_ALL", tt.lcAll)
+			t.Setenv("LANGUAGE", tt.language)
+			t.Setenv("LANG", tt.lang)
+			if got := DetectSystemLanguage(); got != tt.want {
+				t.Errorf("DetectSystemLanguage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
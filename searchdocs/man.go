@@ -0,0 +1,113 @@
+package searchdocs
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// manEnvironment documents the environment variables the command reads, for the man page
+var manEnvironment = []struct{ name, description string }{
+	{"GH_SEARCH_DOCS_CONFIG", "Path of the config file holding flag defaults, used when --config isn't given."},
+	{"GH_SEARCH_DOCS_HIGHLIGHT", "How pretty output shows matched terms when --highlight-style isn't given."},
+	{"GH_SEARCH_DOCS_ENDPOINT", "Search API endpoint, used when --endpoint isn't given."},
+	{"GH_SEARCH_DOCS_VERSIONS_FILE", "Path of the supported enterprise server versions file, used when --versions-file isn't given."},
+	{"GH_SEARCH_DOCS_NO_HISTORY", "Set to 1 to stop recording searches in the local history."},
+	{"GH_PAGER, PAGER", "Pager for output longer than the terminal (default: " + DefaultPager + "). Set GH_PAGER to an empty value, or pass --no-pager, to turn paging off."},
+	{"XDG_CONFIG_HOME, XDG_CACHE_HOME, XDG_DATA_HOME", "Base directories of the config file, the response cache, and the search history and bookmarks."},
+}
+
+// manFlags builds the command's flag set for the OPTIONS section, set with SetManFlags
+var manFlags func() *flag.FlagSet
+
+// SetManFlags sets how GenerateManPage builds the flag set it documents. The flags are
+// defined by the command, so it registers them before running.
+func SetManFlags(flags func() *flag.FlagSet) {
+	manFlags = flags
+}
+
+// CommandName returns the command as users type it: "gh search-docs" when run as the
+// gh-search-docs extension binary
+func CommandName() string {
+	bin := filepath.Base(os.Args[0])
+	if strings.HasPrefix(bin, "gh-") {
+		bin = "gh " + strings.TrimPrefix(bin, "gh-")
+	}
+	return bin
+}
+
+// GenerateManPage writes a troff man page for the command to w, with one OPTIONS entry per
+// flag registered with SetManFlags. version is shown in the page footer.
+func GenerateManPage(version string, w io.Writer) error {
+	if manFlags == nil {
+		return errors.New("no flags registered for the man page")
+	}
+	return generateManPage(CommandName(), version, manFlags(), w)
+}
+
+// generateManPage writes the man page for the flags in fs. name is the command as users type
+// it, e.g. "gh search-docs".
+func generateManPage(name, version string, fs *flag.FlagSet, w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, ".TH GH-SEARCH-DOCS 1 \"\" %s \"GitHub CLI extension\"\n", manQuote("gh-search-docs "+version))
+
+	b.WriteString(".SH NAME\n")
+	b.WriteString("gh\\-search\\-docs \\- search the GitHub documentation from the command line\n")
+
+	b.WriteString(".SH SYNOPSIS\n")
+	fmt.Fprintf(&b, ".B %s\n[\\fIflags\\fR] \\fIquery\\fR\n", manQuote(name))
+	fmt.Fprintf(&b, ".br\n.B %s\nstats [\\fB\\-\\-since\\fR \\fIduration\\fR] [\\fB\\-\\-format\\fR \\fIformat\\fR]\n", manQuote(name))
+	fmt.Fprintf(&b, ".br\n.B %s\nlog tail [\\fB\\-n\\fR \\fIcount\\fR] \\fIlog\\-file\\fR\n", manQuote(name))
+
+	b.WriteString(".SH DESCRIPTION\n")
+	b.WriteString(manEscape("Searches docs.github.com with the GitHub Docs search API and prints the matching pages with their titles, URLs, breadcrumbs, and intros. Flags may come before or after the query."))
+	b.WriteString("\n.PP\n")
+	b.WriteString(manEscape("Output is rendered with colors in a terminal; --plain and --format select plain text and machine-readable formats instead. Some filters run on the results after they are returned, and are marked client-side below."))
+	b.WriteString("\n")
+
+	b.WriteString(".SH OPTIONS\n")
+	fs.VisitAll(func(f *flag.Flag) {
+		valueName, usage := flag.UnquoteUsage(f)
+		b.WriteString(".TP\n")
+		fmt.Fprintf(&b, ".B \\-\\-%s", manEscape(f.Name))
+		if valueName != "" {
+			fmt.Fprintf(&b, " \\fI%s\\fR", manEscape(valueName))
+		}
+		b.WriteString("\n")
+		if f.DefValue != "" && f.DefValue != "0" && f.DefValue != "false" && f.DefValue != "[]" {
+			usage += fmt.Sprintf(" (default %s)", f.DefValue)
+		}
+		b.WriteString(manEscape(usage))
+		b.WriteString("\n")
+	})
+
+	b.WriteString(".SH ENVIRONMENT\n")
+	for _, env := range manEnvironment {
+		fmt.Fprintf(&b, ".TP\n.B %s\n%s\n", manEscape(env.name), manEscape(env.description))
+	}
+
+	b.WriteString(".SH SEE ALSO\n")
+	b.WriteString(".BR gh (1)\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// manEscape escapes text for a troff text line: backslashes are doubled, hyphens kept from
+// turning into typographic dashes, and lines kept from starting with a control character
+func manEscape(s string) string {
+	s = strings.NewReplacer(`\`, `\e`, "-", `\-`).Replace(s)
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = `\&` + s
+	}
+	return s
+}
+
+// manQuote escapes s and wraps it in double quotes, for a macro argument containing spaces
+func manQuote(s string) string {
+	return `"` + strings.ReplaceAll(manEscape(s), `"`, `\(dq`) + `"`
+}
@@ -0,0 +1,108 @@
+package searchdocs
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestGenerateManPage(t *testing.T) {
+	fs := flag.NewFlagSet("search-docs", flag.ContinueOnError)
+	fs.Int("size", 5, "number of `results` to return")
+	fs.String("format", "pretty", "output format")
+	fs.Bool("plain", false, "disable pretty rendering")
+	fs.String("template", "", `print each result with a template, e.g. '{{.Title}}' or \t`)
+
+	var buf bytes.Buffer
+	if err := generateManPage("gh search-docs", "v1.2.0", fs, &buf); err != nil {
+		t.Fatal(err)
+	}
+	page := buf.String()
+
+	for _, want := range []string{
+		".TH GH-SEARCH-DOCS 1 \"\" \"gh\\-search\\-docs v1.2.0\" \"GitHub CLI extension\"\n",
+		".SH NAME\ngh\\-search\\-docs \\- search",
+		".SH SYNOPSIS\n.B \"gh search\\-docs\"\n[\\fIflags\\fR] \\fIquery\\fR\n",
+		".SH DESCRIPTION\n",
+		".TP\n.B \\-\\-size \\fIresults\\fR\nnumber of results to return (default 5)\n",
+		".TP\n.B \\-\\-format \\fIstring\\fR\noutput format (default pretty)\n",
+		".TP\n.B \\-\\-plain\ndisable pretty rendering\n",
+		`print each result with a template, e.g. '{{.Title}}' or \et` + "\n",
+		".SH ENVIRONMENT\n",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("Expected %q in:\n%s", want, page)
+		}
+	}
+
+	// Each flag gets one entry, and every control line is a macro troff knows
+	if got := strings.Count(page, ".TP\n.B \\-\\-"); got != 4 {
+		t.Errorf("Expected 4 option entries, got %d", got)
+	}
+	macros := map[string]bool{".TH": true, ".SH": true, ".B": true, ".BR": true, ".br": true, ".PP": true, ".TP": true}
+	for _, line := range strings.Split(strings.TrimSuffix(page, "\n"), "\n") {
+		if strings.HasPrefix(line, "'") {
+			t.Errorf("Line starts with a control character: %q", line)
+		}
+		if macro, _, _ := strings.Cut(line, " "); strings.HasPrefix(line, ".") && !macros[macro] {
+			t.Errorf("Unknown macro in line %q", line)
+		}
+	}
+}
+
+func TestGenerateManPageFlags(t *testing.T) {
+	saved := manFlags
+	t.Cleanup(func() { manFlags = saved })
+
+	var buf bytes.Buffer
+	manFlags = nil
+	if err := GenerateManPage("v1.2.0", &buf); err == nil {
+		t.Error("Expected an error without registered flags")
+	}
+
+	SetManFlags(func() *flag.FlagSet {
+		fs := flag.NewFlagSet("search-docs", flag.ContinueOnError)
+		fs.Bool("plain", false, "disable pretty rendering")
+		return fs
+	})
+	if err := GenerateManPage("v1.2.0", &buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), ".TP\n.B \\-\\-plain\n") {
+		t.Errorf("Expected the registered flags in:\n%s", buf.String())
+	}
+}
+
+func TestCommandName(t *testing.T) {
+	saved := os.Args
+	t.Cleanup(func() { os.Args = saved })
+
+	tests := map[string]string{
+		"/usr/local/bin/gh-search-docs": "gh search-docs",
+		"search-docs":                   "search-docs",
+	}
+	for arg0, want := range tests {
+		os.Args = []string{arg0}
+		if got := CommandName(); got != want {
+			t.Errorf("CommandName() with %q = %q, want %q", arg0, got, want)
+		}
+	}
+}
+
+func TestManEscape(t *testing.T) {
+	tests := []struct {
+		input, want string
+	}{
+		{"use --plain", `use \-\-plain`},
+		{`a\b`, `a\eb`},
+		{".hidden", `\&.hidden`},
+		{"'quoted'", `\&'quoted'`},
+	}
+	for _, tt := range tests {
+		if got := manEscape(tt.input); got != tt.want {
+			t.Errorf("manEscape(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
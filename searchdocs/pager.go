@@ -0,0 +1,60 @@
+package searchdocs
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// DefaultPager is the pager used when neither GH_PAGER nor PAGER is set
+const DefaultPager = "less -FRX"
+
+// PagerCommand returns the pager to use: $GH_PAGER, then $PAGER, then DefaultPager. An
+// empty result means paging is turned off, e.g. with GH_PAGER=.
+func PagerCommand() string {
+	for _, name := range []string{"GH_PAGER", "PAGER"} {
+		if value, ok := os.LookupEnv(name); ok {
+			return strings.TrimSpace(value)
+		}
+	}
+	return DefaultPager
+}
+
+// RunWithPager runs fn with its output piped through pagerCmd, which is split on spaces.
+// An empty pagerCmd or "cat" writes straight to stdout instead. Like gh, less is told to
+// keep colors and to quit if the output fits on one screen unless LESS is already set.
+// Only a pager that can't be started is an error; how it exits is up to the user.
+func RunWithPager(pagerCmd string, fn func(w io.Writer)) error {
+	args := strings.Fields(pagerCmd)
+	if len(args) == 0 || args[0] == "cat" {
+		fn(os.Stdout)
+		return nil
+	}
+
+	// #nosec G204 -- the pager is chosen by the user
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+	if _, ok := os.LookupEnv("LESS"); !ok {
+		cmd.Env = append(cmd.Env, "LESS=FRX")
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	// Writes fail once the pager quits, which only means the rest wasn't wanted
+	fn(stdin)
+	stdin.Close()
+	var exitErr *exec.ExitError
+	if err := cmd.Wait(); err != nil && !errors.As(err, &exitErr) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package searchdocs
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestPagerCommand(t *testing.T) {
+	t.Setenv("GH_PAGER", "")
+	os.Unsetenv("GH_PAGER")
+	t.Setenv("PAGER", "")
+	os.Unsetenv("PAGER")
+	if got := PagerCommand(); got != DefaultPager {
+		t.Errorf("PagerCommand() = %q, want %q", got, DefaultPager)
+	}
+
+	t.Setenv("PAGER", "more")
+	if got := PagerCommand(); got != "more" {
+		t.Errorf("PagerCommand() = %q, want PAGER", got)
+	}
+
+	t.Setenv("GH_PAGER", "bat --plain")
+	if got := PagerCommand(); got != "bat --plain" {
+		t.Errorf("PagerCommand() = %q, want GH_PAGER over PAGER", got)
+	}
+
+	// An empty GH_PAGER turns paging off rather than falling back
+	t.Setenv("GH_PAGER", "")
+	if got := PagerCommand(); got != "" {
+		t.Errorf("PagerCommand() = %q, want paging off", got)
+	}
+}
+
+func TestRunWithPager(t *testing.T) {
+	if _, err := exec.LookPath("dd"); err != nil {
+		t.Skip("dd is not available")
+	}
+
+	out := filepath.Join(t.TempDir(), "paged.txt")
+	err := RunWithPager("dd status=none of="+out, func(w io.Writer) {
+		for i := 1; i <= 3; i++ {
+			fmt.Fprintf(w, "line %d\n", i)
+		}
+	})
+	if err != nil {
+		t.Fatalf("RunWithPager() error: %v", err)
+	}
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "line 1\nline 2\nline 3\n" {
+		t.Errorf("Pager received %q", got)
+	}
+
+	if err := RunWithPager("gh-search-docs-missing-pager", func(io.Writer) {}); err == nil {
+		t.Error("Expected an error for a pager that doesn't exist")
+	}
+
+	// A pager quitting early, or with a failing status, isn't an error
+	if _, err := exec.LookPath("false"); err == nil {
+		err := RunWithPager("false", func(w io.Writer) {
+			for range 10000 {
+				fmt.Fprintln(w, "more output than the pager reads")
+			}
+		})
+		if err != nil {
+			t.Errorf("RunWithPager() with a failing pager error: %v", err)
+		}
+	}
+}
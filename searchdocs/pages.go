@@ -0,0 +1,107 @@
+package searchdocs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+)
+
+// DefaultMaxPages is how many pages --all fetches unless --max-pages says otherwise
+const DefaultMaxPages = 20
+
+// PageFetcher fetches every page of a search and merges them into one result
+type PageFetcher struct {
+	Client *http.Client
+	// Endpoint is the search API URL
+	Endpoint string
+	// MaxPages caps how many pages are fetched
+	MaxPages int
+	// Progress, if set, is where each page after the first is announced
+	Progress io.Writer
+}
+
+// FetchAllPages fetches every page of the search in baseParams from endpoint, up to maxPages
+// of them, announcing progress on stderr. See PageFetcher.FetchAll.
+func FetchAllPages(baseParams url.Values, client *http.Client, endpoint string, maxPages int) (*SearchResult, error) {
+	return PageFetcher{Client: client, Endpoint: endpoint, MaxPages: maxPages, Progress: os.Stderr}.FetchAll(baseParams)
+}
+
+// FetchAll fetches page 1, 2, and so on of the search in baseParams until it has fetched the
+// last page the API reports, MaxPages of them, or an empty page. The result has the hits of
+// every page in order and the meta of the last response. Results can shift between requests,
+// so later repeats of a hit's ID are dropped. Each page after the first is announced on
+// Progress as "Fetching page N of M...", where M is the last page that will be fetched.
+func (f PageFetcher) FetchAll(baseParams url.Values) (*SearchResult, error) {
+	endpoint, err := url.Parse(f.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	params := maps.Clone(baseParams)
+	if params == nil {
+		params = url.Values{}
+	}
+	size, _ := strconv.Atoi(params.Get("size"))
+
+	var result SearchResult
+	for page, last := 1, 1; page <= last && page <= f.MaxPages; page++ {
+		if page > 1 && f.Progress != nil {
+			fmt.Fprintf(f.Progress, "Fetching page %d of %d...\n", page, min(last, f.MaxPages))
+		}
+		params.Set("page", strconv.Itoa(page))
+		endpoint.RawQuery = params.Encode()
+		fetched, err := f.fetch(endpoint.String())
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", page, err)
+		}
+		result.Meta = fetched.Meta
+		if len(fetched.Hits) == 0 {
+			break
+		}
+		result.Hits = append(result.Hits, fetched.Hits...)
+		last = TotalPages(fetched.Meta.Found.Value, size)
+	}
+	result.Hits = DeduplicateByID(result.Hits)
+	return &result, nil
+}
+
+// fetch requests one page of results and parses it
+func (f PageFetcher) fetch(pageURL string) (*SearchResult, error) {
+	req, err := http.NewRequest(http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := f.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+
+	var result SearchResult
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("parsing response: %w", err)
+	}
+	return &result, nil
+}
+
+// TotalPages returns the number of pages needed to show found results at the given page size
+func TotalPages(found, size int) int {
+	if size < 1 {
+		return 0
+	}
+	return (found + size - 1) / size
+}
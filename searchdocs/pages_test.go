@@ -0,0 +1,90 @@
+package searchdocs
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// pagesServer serves hits with the given IDs on each page, reporting found results in all, and
+// fails requests for pages it has none for. The returned slice collects the page of each
+// request received.
+func pagesServer(t *testing.T, pages map[string][]string, found int) (*httptest.Server, *[]string) {
+	t.Helper()
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		requested = append(requested, page)
+		if _, ok := pages[page]; !ok {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		hits := make([]string, len(pages[page]))
+		for i, id := range pages[page] {
+			hits[i] = fmt.Sprintf(`{"id": %q, "title": "Hit %s", "url": "/en/%s"}`, id, id, id)
+		}
+		fmt.Fprintf(w, `{"meta": {"found": {"value": %d, "relation": "eq"}, "page": %s, "size": 2}, "hits": [%s]}`, found, page, strings.Join(hits, ","))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requested
+}
+
+func TestFetchAllPages(t *testing.T) {
+	pages := map[string][]string{"1": {"a", "b"}, "2": {"b", "c"}, "3": {"d"}}
+	server, requested := pagesServer(t, pages, 5)
+	params := url.Values{"query": {"actions"}, "size": {"2"}}
+
+	var progress bytes.Buffer
+	fetcher := PageFetcher{Client: server.Client(), Endpoint: server.URL, MaxPages: 20, Progress: &progress}
+	result, err := fetcher.FetchAll(params)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var ids []string
+	for _, item := range result.Hits {
+		ids = append(ids, item.ID)
+	}
+	if !slices.Equal(ids, []string{"a", "b", "c", "d"}) {
+		t.Errorf("Expected the pages merged without repeats, got %v", ids)
+	}
+	if result.Meta.Page != 3 {
+		t.Errorf("Expected the meta of the last page, got page %d", result.Meta.Page)
+	}
+	if progress.String() != "Fetching page 2 of 3...\nFetching page 3 of 3...\n" {
+		t.Errorf("Unexpected progress: %q", progress.String())
+	}
+	if params.Get("page") != "" {
+		t.Errorf("Expected baseParams left alone, got page %q", params.Get("page"))
+	}
+
+	// MaxPages caps the requests, and the progress counts only the pages that will be fetched
+	*requested = nil
+	progress.Reset()
+	fetcher.MaxPages = 2
+	if result, _ := fetcher.FetchAll(params); !slices.Equal(*requested, []string{"1", "2"}) || len(result.Hits) != 3 {
+		t.Errorf("Expected two pages fetched, got %v and %d hits", *requested, len(result.Hits))
+	}
+	if progress.String() != "Fetching page 2 of 2...\n" {
+		t.Errorf("Expected the progress capped at MaxPages, got %q", progress.String())
+	}
+
+	// An empty page ends the loop even if more pages were reported
+	empty, requested := pagesServer(t, map[string][]string{"1": {}}, 10)
+	fetcher = PageFetcher{Client: empty.Client(), Endpoint: empty.URL, MaxPages: 20}
+	if result, _ := fetcher.FetchAll(params); len(result.Hits) != 0 || len(*requested) != 1 {
+		t.Errorf("Expected one empty page, got %v after %v", result.Hits, *requested)
+	}
+}
+
+func TestFetchAllPagesError(t *testing.T) {
+	server, _ := pagesServer(t, map[string][]string{"1": {"a", "b"}}, 5)
+	_, err := FetchAllPages(url.Values{"size": {"2"}}, server.Client(), server.URL, 20)
+	if err == nil || err.Error() != "page 2: API returned status 500" {
+		t.Errorf("Expected the failing page in the error, got %v", err)
+	}
+}
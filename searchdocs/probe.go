@@ -0,0 +1,124 @@
+package searchdocs
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// PageProber checks whether docs pages exist using HEAD requests. Requests are bounded by
+// Concurrency and results are cached for the lifetime of the prober.
+type PageProber struct {
+	Client      *http.Client
+	BaseURL     string
+	Concurrency int
+
+	mu    sync.Mutex
+	cache map[string]bool
+}
+
+// NewPageProber returns a prober issuing requests with client against baseURL
+func NewPageProber(client *http.Client, baseURL string, concurrency int) *PageProber {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	// Redirects are inspected rather than followed so they can be checked against the probed path
+	noRedirect := *client
+	noRedirect.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	return &PageProber{
+		Client:      &noRedirect,
+		BaseURL:     baseURL,
+		Concurrency: concurrency,
+		cache:       map[string]bool{},
+	}
+}
+
+// CheckAll reports whether each page exists. Failed probes are reported in errs at the same
+// index, and their result is meaningless; a failure doesn't mean the page is missing.
+func (p *PageProber) CheckAll(paths []string) (results []bool, errs []error) {
+	results = make([]bool, len(paths))
+	errs = make([]error, len(paths))
+
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, p.Concurrency)
+	)
+	for i, path := range paths {
+		wg.Add(1)
+		go func(i int, path string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			// Each goroutine writes only its own index
+			results[i], errs[i] = p.Exists(path)
+		}(i, path)
+	}
+	wg.Wait()
+
+	return results, errs
+}
+
+// firstError returns the first non-nil error in errs
+func firstError(errs []error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Exists reports whether a single page exists, consulting the cache first. docs.github.com
+// redirects pages that don't exist in a language or version to another one (e.g. back to
+// English, or from free-pro-team to enterprise-cloud), so a redirect only counts as the page
+// existing when it keeps the same language and version.
+func (p *PageProber) Exists(path string) (bool, error) {
+	p.mu.Lock()
+	cached, ok := p.cache[path]
+	p.mu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	resp, err := p.Client.Head(p.BaseURL + path)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+
+	var exists bool
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		exists = true
+	case resp.StatusCode >= 300 && resp.StatusCode < 400:
+		exists = redirectKeepsScope(path, resp.Header.Get("Location"))
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		exists = false
+	default:
+		return false, errors.New("unexpected status " + resp.Status + " checking " + path)
+	}
+
+	p.mu.Lock()
+	p.cache[path] = exists
+	p.mu.Unlock()
+	return exists, nil
+}
+
+// redirectKeepsScope reports whether a redirect location has the same language and version
+// as the requested path
+func redirectKeepsScope(path, location string) bool {
+	u, err := url.Parse(location)
+	if err != nil {
+		return false
+	}
+
+	language, version, _ := SplitDocsPath(path)
+	toLanguage, toVersion, _ := SplitDocsPath(u.Path)
+	return language == toLanguage && version == toVersion
+}
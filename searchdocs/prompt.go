@@ -0,0 +1,18 @@
+package searchdocs
+
+import (
+	"os"
+	"strconv"
+)
+
+// InputAllowed reports whether interactive prompts may be shown. Prompts are disabled by
+// --no-input, when stdin is not a terminal, and when running in CI (CI=true).
+func InputAllowed(noInput, stdinIsTerminal bool) bool {
+	if noInput || !stdinIsTerminal {
+		return false
+	}
+	if ci, err := strconv.ParseBool(os.Getenv("CI")); err == nil && ci {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,31 @@
+package searchdocs
+
+import (
+	"testing"
+)
+
+func TestInputAllowed(t *testing.T) {
+	tests := []struct {
+		name     string
+		noInput  bool
+		terminal bool
+		ci       string
+		expected bool
+	}{
+		{"interactive terminal", false, true, "", true},
+		{"no-input flag", true, true, "", false},
+		{"stdin not a terminal", false, false, "", false},
+		{"CI=true", false, true, "true", false},
+		{"CI=1", false, true, "1", false},
+		{"CI=false", false, true, "false", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CI", tt.ci)
+			if got := InputAllowed(tt.noInput, tt.terminal); got != tt.expected {
+				t.Errorf("InputAllowed(%v, %v) with CI=%q = %v, want %v", tt.noInput, tt.terminal, tt.ci, got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package searchdocs
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// queryReplacer maps typographic punctuation commonly pasted from chat tools and rendered
+// docs to the plain ASCII the search index expects. Zero-width joiners and non-joiners are
+// deliberately left alone because they are meaningful in emoji sequences and some scripts.
+var queryReplacer = strings.NewReplacer(
+	// Single quotes and primes
+	"‘", "'", "’", "'", "‚", "'", "‛", "'", "′", "'",
+	// Double quotes
+	"“", `"`, "”", `"`, "„", `"`, "‟", `"`, "″", `"`,
+	// Dashes and the minus sign
+	"‐", "-", "‑", "-", "‒", "-", "–", "-", "—", "-", "―", "-", "−", "-",
+	// Zero-width spaces and the byte order mark
+	"\u200b", " ", "\u2060", " ", "\ufeff", " ",
+)
+
+// NormalizeQuery rewrites typographic quotes, dashes, non-breaking and zero-width spaces to
+// their ASCII equivalents and collapses runs of whitespace. Other characters, including CJK
+// text, are left untouched.
+func NormalizeQuery(query string) string {
+	// strings.Fields splits on every Unicode space, including non-breaking spaces
+	return strings.Join(strings.Fields(queryReplacer.Replace(query)), " ")
+}
+
+// ReadQueryFromStdin reads a query piped to the command, e.g. echo "pull requests" |
+// gh search-docs. Only the first line is read, without its line ending; empty input gives an
+// empty query.
+func ReadQueryFromStdin(r io.Reader) (string, error) {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// MaxQueryLength is the longest query, in bytes once percent-encoded for the request URL,
+// that is sent to the search API. The API doesn't document a query limit; what breaks is the
+// URL, so the limit is on its encoded length rather than on characters (one CJK character is
+// nine bytes encoded). 1024 bytes keeps the whole request URL, including the endpoint and the
+// other parameters, under 2 KB, the most conservative URL length proxies and CDNs are
+// generally assumed to accept. Pasted logs and stack traces are usually several kilobytes.
+const MaxQueryLength = 1024
+
+// EncodedQueryLength returns the length of query once percent-encoded as a URL query value
+func EncodedQueryLength(query string) int {
+	return len(url.QueryEscape(query))
+}
+
+// TruncateQuery shortens query so its encoded length is at most limit bytes, cutting at the
+// last word boundary that fits. A single word longer than limit is cut mid-word, never in
+// the middle of a character.
+func TruncateQuery(query string, limit int) string {
+	if EncodedQueryLength(query) <= limit {
+		return query
+	}
+
+	// query[:cut] is the longest prefix that fits; query[cut] is the first rune that doesn't
+	cut, size := 0, 0
+	for i, r := range query {
+		n := EncodedQueryLength(string(r))
+		if size+n > limit {
+			cut = i
+			break
+		}
+		size += n
+	}
+
+	// Keep the whole of the last word when the cut lands exactly on a boundary
+	if query[cut] != ' ' {
+		if space := strings.LastIndexByte(query[:cut], ' '); space > 0 {
+			cut = space
+		}
+	}
+	return strings.TrimSpace(query[:cut])
+}
@@ -0,0 +1,94 @@
+package searchdocs
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"plain ascii untouched", `create a "pull request"`, `create a "pull request"`},
+		{"curly double quotes", "“pull request”", `"pull request"`},
+		{"curly single quotes", "don’t ‘fork’", "don't 'fork'"},
+		{"low quotes", "„upstream“", `"upstream"`},
+		{"non-breaking space", "pull\u00a0request", "pull request"},
+		{"narrow no-break space", "pull\u202frequest", "pull request"},
+		{"zero-width space", "pull\u200brequest", "pull request"},
+		{"byte order mark", "\ufeffssh key", "ssh key"},
+		{"en dash", "pre–receive hooks", "pre-receive hooks"},
+		{"em dash", "runners—self hosted", "runners-self hosted"},
+		{"minus sign", "−−force", "--force"},
+		{"collapse whitespace", "  ssh \u00a0 \u200b key\t", "ssh key"},
+		{"cjk untouched", "プルリクエストの作成", "プルリクエストの作成"},
+		{"chinese untouched", "拉取请求 创建", "拉取请求 创建"},
+		{"emoji zwj sequence untouched", "\U0001F469\u200d\U0001F4BB", "\U0001F469\u200d\U0001F4BB"},
+		{"accents untouched", "café résumé", "café résumé"},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeQuery(tt.input); got != tt.expected {
+				t.Errorf("NormalizeQuery(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTruncateQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		limit    int
+		expected string
+	}{
+		{"under limit", "ssh key", 10, "ssh key"},
+		{"exactly at limit", "ssh key", 7, "ssh key"},
+		{"one over limit cuts last word", "ssh keys", 7, "ssh"},
+		{"cut lands on a space", "ssh key setup", 7, "ssh key"},
+		{"cut mid word backs up", "ssh key setup", 10, "ssh key"},
+		{"single long word is hard cut", "supercalifragilistic", 5, "super"},
+		{"multibyte characters counted encoded", "プル リクエスト", 20, "プル"},
+		{"multibyte word is cut between characters", "リクエスト", 20, "リク"},
+		{"punctuation counted encoded", "a/b c", 5, "a/b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := TruncateQuery(tt.query, tt.limit)
+			if got != tt.expected {
+				t.Errorf("TruncateQuery(%q, %d) = %q, want %q", tt.query, tt.limit, got, tt.expected)
+			}
+			if n := EncodedQueryLength(got); n > tt.limit {
+				t.Errorf("TruncateQuery(%q, %d) returned %d encoded bytes", tt.query, tt.limit, n)
+			}
+		})
+	}
+}
+
+func TestReadQueryFromStdin(t *testing.T) {
+	query, err := ReadQueryFromStdin(bytes.NewBufferString("test query\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if query != "test query" {
+		t.Errorf("query = %q, want %q", query, "test query")
+	}
+
+	tests := map[string]string{
+		"":                          "",
+		"no newline":                "no newline",
+		"windows line ending\r\n":   "windows line ending",
+		"first line\nsecond line\n": "first line",
+	}
+	for input, want := range tests {
+		if got, err := ReadQueryFromStdin(strings.NewReader(input)); err != nil || got != want {
+			t.Errorf("ReadQueryFromStdin(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+}
@@ -1,7 +1,12 @@
 package searchdocs
 
 import (
+	"encoding/json"
+	"fmt"
+	"os"
+
 	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/glamour/styles"
 )
 
 // NewRenderer returns a Glamour renderer with the provided theme and wrap width.
@@ -43,3 +48,35 @@ func NewRendererNoWrap(theme string) *glamour.TermRenderer {
 	r, _ := glamour.NewTermRenderer(opts...)
 	return r
 }
+
+// NewRendererFromFile returns a Glamour renderer using the style in a JSON style file, such
+// as one written by --dump-theme, with the provided wrap width. Missing and malformed files
+// are an error.
+func NewRendererFromFile(path string, wrap int) (*glamour.TermRenderer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var style any
+	if err := json.Unmarshal(data, &style); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return glamour.NewTermRenderer(
+		glamour.WithStylesFromJSONBytes(data),
+		glamour.WithWordWrap(wrap),
+	)
+}
+
+// ThemeJSON returns the JSON of one of glamour's built-in styles, such as dark or light, as
+// a starting point for a style file
+func ThemeJSON(theme string) ([]byte, error) {
+	style, ok := styles.DefaultStyles[theme]
+	if !ok {
+		return nil, fmt.Errorf("unknown theme %q", theme)
+	}
+	data, err := json.MarshalIndent(style, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
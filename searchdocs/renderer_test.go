@@ -1,6 +1,8 @@
 package searchdocs
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -349,3 +351,51 @@ func TestRendererConsistency(t *testing.T) {
 		t.Error("Renderer should produce consistent output for the same input")
 	}
 }
+
+func TestNewRendererFromFile(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "style.json")
+	if err := os.WriteFile(valid, []byte(`{"strong": {"block_prefix": "<<", "block_suffix": ">>"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	renderer, err := NewRendererFromFile(valid, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	output, err := renderer.Render("Some **bold** text.")
+	if err != nil {
+		t.Fatalf("Renderer failed to render markdown: %v", err)
+	}
+	if !strings.Contains(output, "<<bold>>") {
+		t.Errorf("Expected the custom style, got %q", output)
+	}
+
+	broken := filepath.Join(dir, "broken.json")
+	if err := os.WriteFile(broken, []byte(`{"strong": `), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{broken, filepath.Join(dir, "missing.json")} {
+		if _, err := NewRendererFromFile(path, 0); err == nil || !strings.Contains(err.Error(), path) {
+			t.Errorf("NewRendererFromFile(%q) = %v, want an error naming the file", path, err)
+		}
+	}
+}
+
+func TestThemeJSON(t *testing.T) {
+	data, err := ThemeJSON("dark")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "dark.json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// The dump is a usable style file
+	if _, err := NewRendererFromFile(path, 0); err != nil {
+		t.Errorf("Expected the dumped theme to load, got %v", err)
+	}
+
+	if _, err := ThemeJSON("neon"); err == nil {
+		t.Error("Expected an error for an unknown theme")
+	}
+}
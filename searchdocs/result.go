@@ -0,0 +1,163 @@
+package searchdocs
+
+import "strings"
+
+// SearchResult is a response from the search API, plus the client-side remarks recorded in
+// its meta
+type SearchResult struct {
+	Meta SearchMeta   `json:"meta" yaml:"meta"`
+	Hits []SearchItem `json:"hits" yaml:"hits"`
+}
+
+// SearchMeta describes a search: how many hits it found, how long it took, and which page
+// of hits the result holds
+type SearchMeta struct {
+	Found struct {
+		Value    int    `json:"value" yaml:"value"`
+		Relation string `json:"relation" yaml:"relation"`
+	} `json:"found" yaml:"found"`
+	Took struct {
+		QueryMsec int `json:"query_msec" yaml:"query_msec"`
+		TotalMsec int `json:"total_msec" yaml:"total_msec"`
+	} `json:"took" yaml:"took"`
+	Page int `json:"page" yaml:"page"`
+	Size int `json:"size" yaml:"size"`
+	// Suppressed counts hits hidden by client-side filters
+	Suppressed int `json:"suppressed,omitempty" yaml:"suppressed,omitempty"`
+	// Notes describes client-side processing applied to the hits, such as filtering
+	Notes []string `json:"notes,omitempty" yaml:"notes,omitempty"`
+}
+
+// SearchItem is one hit of a search
+type SearchItem struct {
+	ID          string                 `json:"id" yaml:"id"`
+	Title       string                 `json:"title" yaml:"title"`
+	URL         string                 `json:"url" yaml:"url"`
+	Breadcrumbs string                 `json:"breadcrumbs,omitempty" yaml:"breadcrumbs,omitempty"`
+	Content     string                 `json:"content,omitempty" yaml:"content,omitempty"`
+	Intro       string                 `json:"intro,omitempty" yaml:"intro,omitempty"`
+	Headings    string                 `json:"headings,omitempty" yaml:"headings,omitempty"`
+	Toplevel    string                 `json:"toplevel,omitempty" yaml:"toplevel,omitempty"`
+	Highlights  map[string]interface{} `json:"highlights,omitempty" yaml:"highlights,omitempty"`
+	Score       float64                `json:"score,omitempty" yaml:"score,omitempty"`
+	// Translations records whether the page exists in each language checked with
+	// --check-translations. Languages whose check failed are left out.
+	Translations map[string]bool `json:"translations,omitempty" yaml:"translations,omitempty"`
+	// Availability records whether the page exists in each plan checked with
+	// --check-availability. Plans whose check failed are left out.
+	Availability map[string]bool `json:"availability,omitempty" yaml:"availability,omitempty"`
+	// Anchor is the heading anchor the hit's URL links to, if a heading matched the query
+	Anchor string `json:"anchor,omitempty" yaml:"anchor,omitempty"`
+	// HeadingLinks are deep links to every heading that matched the query, with --anchors
+	HeadingLinks []HeadingLink `json:"heading_links,omitempty" yaml:"heading_links,omitempty"`
+	// Archived marks hits found with --archived; their URL is the absolute archive URL
+	Archived bool `json:"archived,omitempty" yaml:"archived,omitempty"`
+}
+
+// HeadingLink is a deep link to a section of a result's page
+type HeadingLink struct {
+	Heading string `json:"heading" yaml:"heading"`
+	URL     string `json:"url" yaml:"url"`
+}
+
+// HitURL returns the absolute URL of a hit on DocsBaseURL, including its heading anchor
+func HitURL(item SearchItem) string {
+	if item.Archived {
+		return item.URL
+	}
+	return DocsBaseURL + HitPath(item)
+}
+
+// HitPath returns the site-relative path of a hit, including its heading anchor. Archived
+// hits live on another site, so they keep their full URL.
+func HitPath(item SearchItem) string {
+	if item.Archived {
+		return item.URL
+	}
+	if item.Anchor != "" {
+		return item.URL + "#" + item.Anchor
+	}
+	return item.URL
+}
+
+// HitTitle returns the title of a hit, labelled when it comes from archived docs
+func HitTitle(item SearchItem) string {
+	if item.Archived {
+		return item.Title + " [archived]"
+	}
+	return item.Title
+}
+
+// HighlightStrings returns a hit's highlights for key, which the API sends as a string or a
+// list of strings, with their <mark> tags
+func HighlightStrings(item SearchItem, key string) []string {
+	var highlights []string
+	switch v := item.Highlights[key].(type) {
+	case []interface{}:
+		for _, highlight := range v {
+			if str, ok := highlight.(string); ok {
+				highlights = append(highlights, str)
+			}
+		}
+	case string:
+		highlights = append(highlights, v)
+	}
+	return highlights
+}
+
+// TitleHighlight returns the first title highlight of a hit, with its <mark> tags. ok is
+// false when the API sent none, or an empty one, so the plain title should be shown.
+func TitleHighlight(item SearchItem) (title string, ok bool) {
+	titles := HighlightStrings(item, "title")
+	if len(titles) == 0 || item.Archived || strings.TrimSpace(StripTags(titles[0])) == "" {
+		return "", false
+	}
+	return titles[0], true
+}
+
+// ReplaceMarks replaces each <mark> span in s with fn applied to its text. The API's markup
+// isn't trusted to be well formed: nested marks count as one span, a stray </mark> is
+// dropped, and a <mark> that is never closed marks the rest of s.
+func ReplaceMarks(s string, fn func(term string) string) string {
+	const open, closing = "<mark>", "</mark>"
+
+	var b, span strings.Builder
+	depth := 0
+	flush := func() {
+		if span.Len() > 0 {
+			b.WriteString(fn(span.String()))
+			span.Reset()
+		}
+	}
+	for s != "" {
+		switch {
+		case strings.HasPrefix(s, open):
+			depth++
+			s = s[len(open):]
+			continue
+		case strings.HasPrefix(s, closing):
+			if depth > 0 {
+				depth--
+				if depth == 0 {
+					flush()
+				}
+			}
+			s = s[len(closing):]
+			continue
+		}
+
+		// Copy everything up to the next tag that could be a mark
+		n := strings.IndexByte(s[1:], '<') + 1
+		if n == 0 {
+			n = len(s)
+		}
+		if depth > 0 {
+			span.WriteString(s[:n])
+		} else {
+			b.WriteString(s[:n])
+		}
+		s = s[n:]
+	}
+	flush()
+	return b.String()
+}
@@ -0,0 +1,65 @@
+package searchdocs
+
+import "testing"
+
+func TestHitURL(t *testing.T) {
+	tests := []struct {
+		name  string
+		item  SearchItem
+		url   string
+		path  string
+		title string
+	}{
+		{"page", SearchItem{Title: "About SSH", URL: "/en/authentication/about-ssh"}, "https://docs.github.com/en/authentication/about-ssh", "/en/authentication/about-ssh", "About SSH"},
+		{"anchor", SearchItem{Title: "About SSH", URL: "/en/authentication/about-ssh", Anchor: "keys"}, "https://docs.github.com/en/authentication/about-ssh#keys", "/en/authentication/about-ssh#keys", "About SSH"},
+		{"archived", SearchItem{Title: "Old page", URL: "https://example.com/old", Anchor: "keys", Archived: true}, "https://example.com/old", "https://example.com/old", "Old page [archived]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HitURL(tt.item); got != tt.url {
+				t.Errorf("HitURL() = %q, want %q", got, tt.url)
+			}
+			if got := HitPath(tt.item); got != tt.path {
+				t.Errorf("HitPath() = %q, want %q", got, tt.path)
+			}
+			if got := HitTitle(tt.item); got != tt.title {
+				t.Errorf("HitTitle() = %q, want %q", got, tt.title)
+			}
+		})
+	}
+}
+
+func TestTitleHighlight(t *testing.T) {
+	item := SearchItem{Title: "Managing secrets", Highlights: map[string]interface{}{"title": []interface{}{"Managing <mark>secrets</mark>"}}}
+	if got, ok := TitleHighlight(item); !ok || got != "Managing <mark>secrets</mark>" {
+		t.Errorf("TitleHighlight() = %q, %v", got, ok)
+	}
+
+	item.Highlights["title"] = "<mark></mark> "
+	if _, ok := TitleHighlight(item); ok {
+		t.Error("Expected an empty highlight to be ignored")
+	}
+	item.Highlights["title"] = "Managing <mark>secrets</mark>"
+	item.Archived = true
+	if _, ok := TitleHighlight(item); ok {
+		t.Error("Expected archived hits to keep their labelled title")
+	}
+}
+
+func TestReplaceMarks(t *testing.T) {
+	bracket := func(term string) string { return "[" + term + "]" }
+	tests := map[string]string{
+		"Managing secrets":                  "Managing secrets",
+		"Managing <mark>secrets</mark>":     "Managing [secrets]",
+		"<mark>a <mark>b</mark> c</mark> d": "[a b c] d",
+		"Use <mark>secrets in workflows":    "Use [secrets in workflows]",
+		"Use</mark> secrets":                "Use secrets",
+		"a <b>bold</b> <mark>term</mark>":   "a <b>bold</b> [term]",
+		"Use <mark></mark>secrets":          "Use secrets",
+	}
+	for input, want := range tests {
+		if got := ReplaceMarks(input, bracket); got != want {
+			t.Errorf("ReplaceMarks(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package searchdocs
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultRetries is how many times a rate limited request is retried by default
+const DefaultRetries = 3
+
+// RetryRoundTripper is a RoundTripper that retries requests answered with 429 Too Many
+// Requests or 503 Service Unavailable, waiting between attempts as the response's
+// Retry-After header asks, or with exponential backoff when it has none. Waits that would
+// outlast the request's deadline aren't started.
+type RetryRoundTripper struct {
+	Base       http.RoundTripper
+	MaxRetries int
+	// BaseDelay is the first backoff wait, doubled for each retry up to MaxDelay. A
+	// Retry-After longer than MaxDelay isn't waited for; the response is returned instead.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Notify, if set, is called before each wait with the status that caused the retry
+	Notify func(status int, wait time.Duration)
+	// Limiter, if set, makes every request through the transport wait out the backoff of any
+	// one of them, instead of each finding out it's rate limited for itself
+	Limiter *RateLimiter
+}
+
+// NewRetryRoundTripper wraps base so that rate limited requests are retried up to
+// maxRetries times, with backoff starting at 1s and capped at 60s. A nil base uses
+// http.DefaultTransport.
+func NewRetryRoundTripper(base http.RoundTripper, maxRetries int) *RetryRoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &RetryRoundTripper{Base: base, MaxRetries: maxRetries, BaseDelay: time.Second, MaxDelay: time.Minute}
+}
+
+func (t *RetryRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if t.Limiter != nil {
+			if err := t.Limiter.Wait(req.Context()); err != nil {
+				return nil, err
+			}
+		}
+		resp, err := t.Base.RoundTrip(req)
+		if err != nil || attempt >= t.MaxRetries || !retryableStatus(resp.StatusCode) {
+			return resp, err
+		}
+		// Requests with a body can only be sent again if it can be rewound
+		if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+			return resp, nil
+		}
+
+		wait, ok := RetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		if !ok {
+			wait = t.backoff(attempt)
+		} else if wait > t.MaxDelay {
+			return resp, nil
+		}
+		// There's no point waiting past the request's deadline, e.g. the client's timeout
+		if deadline, ok := req.Context().Deadline(); ok && time.Now().Add(wait).After(deadline) {
+			return resp, nil
+		}
+
+		// The body is drained so the connection can be reused
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		if t.Notify != nil {
+			t.Notify(resp.StatusCode, wait)
+		}
+		if t.Limiter != nil {
+			// The wait happens at the top of the loop, along with every other request's
+			t.Limiter.Backoff(wait)
+		} else if err := sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// RateLimiter holds back the requests that share it while the API is rate limiting any of
+// them. The zero value holds nothing back.
+type RateLimiter struct {
+	mu    sync.Mutex
+	until time.Time
+}
+
+// Backoff holds requests back for d from now, unless they're already held back for longer
+func (l *RateLimiter) Backoff(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := time.Now().Add(d); until.After(l.until) {
+		l.until = until
+	}
+}
+
+// Wait blocks until the current backoff is over, or returns ctx's error if it ends first
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	wait := time.Until(l.until)
+	l.mu.Unlock()
+	if wait <= 0 {
+		return nil
+	}
+	return sleep(ctx, wait)
+}
+
+// sleep waits for d, or returns ctx's error if it ends first
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// backoff returns the wait before retry attempt+1 when the server doesn't give one
+func (t *RetryRoundTripper) backoff(attempt int) time.Duration {
+	wait := t.BaseDelay
+	for ; attempt > 0 && wait < t.MaxDelay; attempt-- {
+		wait *= 2
+	}
+	return min(wait, t.MaxDelay)
+}
+
+// retryableStatus reports whether a response with this status is worth retrying
+func retryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable
+}
+
+// RetryAfter parses a Retry-After header, given either as a number of seconds or as an HTTP
+// date, into how long to wait from now. A date in the past means no wait.
+func RetryAfter(header string, now time.Time) (time.Duration, bool) {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		// Clamped so absurd values can't overflow; they are far too long to wait for anyway
+		return time.Duration(min(seconds, 1<<31)) * time.Second, true
+	}
+	date, err := http.ParseTime(header)
+	if err != nil {
+		return 0, false
+	}
+	return max(date.Sub(now), 0), true
+}
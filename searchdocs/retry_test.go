@@ -0,0 +1,199 @@
+package searchdocs
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyServer answers the first failures requests with status and the rest with 200,
+// echoing the request body
+func flakyServer(t *testing.T, failures int32, status int, retryAfter string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= failures {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(status)
+			_, _ = io.WriteString(w, "slow down")
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(append([]byte("ok "), body...))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+// fastRetries returns a RetryRoundTripper with millisecond waits that records each wait
+func fastRetries(maxRetries int, waits *[]time.Duration) *RetryRoundTripper {
+	transport := NewRetryRoundTripper(nil, maxRetries)
+	transport.BaseDelay = time.Millisecond
+	transport.MaxDelay = 4 * time.Millisecond
+	transport.Notify = func(status int, wait time.Duration) { *waits = append(*waits, wait) }
+	return transport
+}
+
+func TestRetryRoundTripper(t *testing.T) {
+	tests := []struct {
+		name       string
+		failures   int32
+		status     int
+		retryAfter string
+		wantStatus int
+		wantWaits  []time.Duration
+	}{
+		{"success", 0, http.StatusTooManyRequests, "", http.StatusOK, nil},
+		{"rate limited", 2, http.StatusTooManyRequests, "", http.StatusOK, []time.Duration{time.Millisecond, 2 * time.Millisecond}},
+		{"unavailable", 1, http.StatusServiceUnavailable, "", http.StatusOK, []time.Duration{time.Millisecond}},
+		{"backoff is capped", 4, http.StatusTooManyRequests, "", http.StatusOK, []time.Duration{time.Millisecond, 2 * time.Millisecond, 4 * time.Millisecond, 4 * time.Millisecond}},
+		{"gives up", 9, http.StatusTooManyRequests, "", http.StatusTooManyRequests, []time.Duration{time.Millisecond, 2 * time.Millisecond, 4 * time.Millisecond, 4 * time.Millisecond}},
+		{"retry after", 1, http.StatusTooManyRequests, "0", http.StatusOK, []time.Duration{0}},
+		{"retry after too long", 1, http.StatusTooManyRequests, "120", http.StatusTooManyRequests, nil},
+		{"other errors", 1, http.StatusInternalServerError, "", http.StatusInternalServerError, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := flakyServer(t, tt.failures, tt.status, tt.retryAfter)
+			var waits []time.Duration
+			client := &http.Client{Transport: fastRetries(4, &waits)}
+
+			resp, err := client.Get(server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if len(waits) != len(tt.wantWaits) {
+				t.Fatalf("waits = %v, want %v", waits, tt.wantWaits)
+			}
+			for i := range waits {
+				if waits[i] != tt.wantWaits[i] {
+					t.Errorf("waits = %v, want %v", waits, tt.wantWaits)
+					break
+				}
+			}
+			if got, want := requests.Load(), int32(len(tt.wantWaits)+1); got != want {
+				t.Errorf("requests = %d, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestRetryRoundTripperResendsBody(t *testing.T) {
+	server, _ := flakyServer(t, 1, http.StatusTooManyRequests, "")
+	var waits []time.Duration
+	client := &http.Client{Transport: fastRetries(1, &waits)}
+
+	resp, err := client.Post(server.URL, "text/plain", strings.NewReader("query"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "ok query" {
+		t.Errorf("body = %q, want the request body sent again", body)
+	}
+}
+
+func TestRetryRoundTripperCanceled(t *testing.T) {
+	server, requests := flakyServer(t, 9, http.StatusTooManyRequests, "30")
+	transport := NewRetryRoundTripper(nil, 3)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	transport.Notify = func(int, time.Duration) { cancel() }
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+
+	start := time.Now()
+	if _, err := (&http.Client{Transport: transport}).Do(req); err == nil {
+		t.Fatal("Expected an error once the request is canceled")
+	}
+	if time.Since(start) > 5*time.Second || requests.Load() != 1 {
+		t.Errorf("Expected the wait to stop at cancellation, took %v and %d requests", time.Since(start), requests.Load())
+	}
+}
+
+func TestRetryRoundTripperSharedLimiter(t *testing.T) {
+	server, requests := flakyServer(t, 1, http.StatusTooManyRequests, "")
+	var waits []time.Duration
+	transport := fastRetries(1, &waits)
+	transport.BaseDelay = 50 * time.Millisecond
+	transport.MaxDelay = time.Second
+	transport.Limiter = &RateLimiter{}
+	client := &http.Client{Transport: transport}
+
+	// The first request is rate limited, so the next one waits out its backoff too
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("status = %d, want the retry to succeed", resp.StatusCode)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Expected the backoff waited out, took %s", elapsed)
+	}
+	if len(waits) != 1 || requests.Load() != 3 {
+		t.Errorf("Expected one retry, got waits %v and %d requests", waits, requests.Load())
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	var limiter RateLimiter
+	start := time.Now()
+	if err := limiter.Wait(context.Background()); err != nil || time.Since(start) > time.Second {
+		t.Errorf("Expected the zero value not to wait, got %v after %s", err, time.Since(start))
+	}
+
+	// A shorter backoff doesn't cut a longer one short
+	limiter.Backoff(30 * time.Millisecond)
+	limiter.Backoff(time.Millisecond)
+	start = time.Now()
+	if err := limiter.Wait(context.Background()); err != nil || time.Since(start) < 20*time.Millisecond {
+		t.Errorf("Expected the longer backoff waited out, got %v after %s", err, time.Since(start))
+	}
+
+	limiter.Backoff(time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := limiter.Wait(ctx); err != context.Canceled {
+		t.Errorf("Expected the wait canceled, got %v", err)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		header string
+		want   time.Duration
+		ok     bool
+	}{
+		{"", 0, false},
+		{"5", 5 * time.Second, true},
+		{" 0 ", 0, true},
+		{"-1", 0, false},
+		{"soon", 0, false},
+		{"Sun, 01 Jun 2025 12:00:30 GMT", 30 * time.Second, true},
+		{"Sun, 01 Jun 2025 11:59:00 GMT", 0, true},
+		{"99999999999999999999", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := RetryAfter(tt.header, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("RetryAfter(%q) = %v, %v; want %v, %v", tt.header, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package searchdocs
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed scopes.yml
+var defaultScopes []byte
+
+// Scope is a named preset expanding to several toplevel filters
+type Scope struct {
+	Description string   `yaml:"description"`
+	Toplevel    []string `yaml:"toplevel"`
+}
+
+// ParseScopes parses scope definitions keyed by scope name
+func ParseScopes(data []byte) (map[string]Scope, error) {
+	scopes := map[string]Scope{}
+	if err := yaml.Unmarshal(data, &scopes); err != nil {
+		return nil, err
+	}
+	for name, scope := range scopes {
+		if len(scope.Toplevel) == 0 {
+			return nil, fmt.Errorf("scope %q has no toplevel values", name)
+		}
+	}
+	return scopes, nil
+}
+
+// ScopesPath returns the path of the user's scope definitions file
+func ScopesPath() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "scopes.yml"), nil
+}
+
+// LoadScopes returns the built-in scopes merged with any defined in the user's scopes file.
+// User scopes replace built-in scopes with the same name.
+func LoadScopes() (map[string]Scope, error) {
+	scopes, err := ParseScopes(defaultScopes)
+	if err != nil {
+		return nil, fmt.Errorf("built-in scopes: %w", err)
+	}
+
+	path, err := ScopesPath()
+	if err != nil {
+		return scopes, nil
+	}
+	// #nosec G304 -- the path is in the user's config directory
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return scopes, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	custom, err := ParseScopes(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for name, scope := range custom {
+		scopes[name] = scope
+	}
+	return scopes, nil
+}
+
+// ScopeNames returns the scope names in alphabetical order
+func ScopeNames(scopes map[string]Scope) []string {
+	names := make([]string, 0, len(scopes))
+	for name := range scopes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ExpandScopes returns the toplevel filters for the named scopes, without duplicates. Unknown
+// scope names are an error listing the available scopes.
+func ExpandScopes(scopes map[string]Scope, names []string) ([]string, error) {
+	var toplevel []string
+	seen := map[string]bool{}
+	for _, name := range names {
+		scope, ok := scopes[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown scope %q (available: %s)", name, strings.Join(ScopeNames(scopes), ", "))
+		}
+		for _, value := range scope.Toplevel {
+			if !seen[value] {
+				seen[value] = true
+				toplevel = append(toplevel, value)
+			}
+		}
+	}
+	return toplevel, nil
+}
@@ -0,0 +1,88 @@
+package searchdocs
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDefaultScopes(t *testing.T) {
+	scopes, err := ParseScopes(defaultScopes)
+	if err != nil {
+		t.Fatalf("Built-in scopes failed to parse: %v", err)
+	}
+	for _, name := range []string{"admin", "developer", "security"} {
+		if _, ok := scopes[name]; !ok {
+			t.Errorf("Expected built-in scope %q", name)
+		}
+	}
+}
+
+func TestLoadScopesWithUserFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	if err := os.MkdirAll(filepath.Join(dir, "gh-search-docs"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	custom := `
+admin:
+  toplevel: [admin]
+platform:
+  description: Our platform team
+  toplevel: [actions, packages]
+`
+	if err := os.WriteFile(filepath.Join(dir, "gh-search-docs", "scopes.yml"), []byte(custom), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	scopes, err := LoadScopes()
+	if err != nil {
+		t.Fatalf("LoadScopes() error: %v", err)
+	}
+	if got := scopes["admin"].Toplevel; !reflect.DeepEqual(got, []string{"admin"}) {
+		t.Errorf("Expected the user file to override admin, got %v", got)
+	}
+	if got := scopes["platform"].Toplevel; !reflect.DeepEqual(got, []string{"actions", "packages"}) {
+		t.Errorf("Expected the user scope to be added, got %v", got)
+	}
+	if _, ok := scopes["developer"]; !ok {
+		t.Error("Expected built-in scopes to remain available")
+	}
+}
+
+func TestLoadScopesInvalidUserFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	if err := os.MkdirAll(filepath.Join(dir, "gh-search-docs"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "gh-search-docs", "scopes.yml"), []byte("empty:\n  description: nothing\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadScopes(); err == nil || !strings.Contains(err.Error(), "no toplevel values") {
+		t.Errorf("Expected an error for a scope without toplevel values, got %v", err)
+	}
+}
+
+func TestExpandScopes(t *testing.T) {
+	scopes := map[string]Scope{
+		"admin":    {Toplevel: []string{"admin", "billing"}},
+		"security": {Toplevel: []string{"code-security", "admin"}},
+	}
+
+	got, err := ExpandScopes(scopes, []string{"admin", "security"})
+	if err != nil {
+		t.Fatalf("ExpandScopes() error: %v", err)
+	}
+	if expected := []string{"admin", "billing", "code-security"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("ExpandScopes() = %v, want %v", got, expected)
+	}
+
+	_, err = ExpandScopes(scopes, []string{"ops"})
+	if err == nil || !strings.Contains(err.Error(), `unknown scope "ops" (available: admin, security)`) {
+		t.Errorf("Expected an unknown scope error listing the available scopes, got %v", err)
+	}
+}
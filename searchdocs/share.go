@@ -0,0 +1,90 @@
+package searchdocs
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// SearchPageURL returns the docs.github.com search page URL for a query, using the site's
+// versioned URL scheme: /<lang>/search for free-pro-team, /<lang>/enterprise-cloud@latest/search
+// for enterprise cloud, and /<lang>/enterprise-server@<version>/search for enterprise server.
+// The version should already be normalized with NormalizeVersion.
+func SearchPageURL(query, version, language string) string {
+	if language == "" {
+		language = "en"
+	}
+
+	path := "/" + language
+	switch {
+	case version == "enterprise-cloud":
+		path += "/enterprise-cloud@latest"
+	case strings.HasPrefix(version, "enterprise-server@"):
+		path += "/" + version
+	}
+	path += "/search"
+
+	u, _ := url.Parse(DocsBaseURL)
+	u.Path = path
+	u.RawQuery = url.Values{"query": {query}}.Encode()
+	return u.String()
+}
+
+// launcherCommand returns the command used to open a URL with the default browser on goos
+func launcherCommand(goos, target string) []string {
+	switch goos {
+	case "darwin":
+		return []string{"open", target}
+	case "windows":
+		// Not "cmd /c start": cmd treats the & between query parameters as a command separator
+		return []string{"rundll32", "url.dll,FileProtocolHandler", target}
+	default:
+		return []string{"xdg-open", target}
+	}
+}
+
+// OpenURL opens a URL in the system default browser
+func OpenURL(target string) error {
+	args := launcherCommand(runtime.GOOS, target)
+	path, err := exec.LookPath(args[0])
+	if err != nil {
+		return fmt.Errorf("no browser launcher found (%s is not installed); open %s manually", args[0], target)
+	}
+	// #nosec G204 -- the launcher is fixed per platform and the URL is passed as a single argument
+	return exec.Command(path, args[1:]...).Start()
+}
+
+// clipboardCommands returns the candidate commands that copy stdin to the clipboard on goos,
+// in order of preference
+func clipboardCommands(goos string) [][]string {
+	switch goos {
+	case "darwin":
+		return [][]string{{"pbcopy"}}
+	case "windows":
+		return [][]string{{"clip"}}
+	default:
+		return [][]string{
+			{"wl-copy"},
+			{"xclip", "-selection", "clipboard"},
+			{"xsel", "--clipboard", "--input"},
+		}
+	}
+}
+
+// CopyToClipboard copies text to the system clipboard
+func CopyToClipboard(text string) error {
+	for _, args := range clipboardCommands(runtime.GOOS) {
+		path, err := exec.LookPath(args[0])
+		if err != nil {
+			continue
+		}
+		// #nosec G204 -- clipboard commands are fixed per platform
+		cmd := exec.Command(path, args[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		return cmd.Run()
+	}
+	return errors.New("no clipboard command found (install wl-copy, xclip, or xsel)")
+}
@@ -0,0 +1,98 @@
+package searchdocs
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestSearchPageURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		version  string
+		language string
+		expected string
+	}{
+		{
+			name:     "free-pro-team",
+			query:    "pull request",
+			version:  "free-pro-team",
+			language: "en",
+			expected: "https://docs.github.com/en/search?query=pull+request",
+		},
+		{
+			name:     "enterprise cloud",
+			query:    "SAML SSO",
+			version:  "enterprise-cloud",
+			language: "en",
+			expected: "https://docs.github.com/en/enterprise-cloud@latest/search?query=SAML+SSO",
+		},
+		{
+			name:     "enterprise server",
+			query:    "LDAP",
+			version:  "enterprise-server@3.17",
+			language: "en",
+			expected: "https://docs.github.com/en/enterprise-server@3.17/search?query=LDAP",
+		},
+		{
+			name:     "other language",
+			query:    "actions",
+			version:  "free-pro-team",
+			language: "ja",
+			expected: "https://docs.github.com/ja/search?query=actions",
+		},
+		{
+			name:     "empty language defaults to english",
+			query:    "actions",
+			version:  "free-pro-team",
+			language: "",
+			expected: "https://docs.github.com/en/search?query=actions",
+		},
+		{
+			name:     "special characters are encoded",
+			query:    "c++ & \"quotes\" #1",
+			version:  "free-pro-team",
+			language: "en",
+			expected: "https://docs.github.com/en/search?query=c%2B%2B+%26+%22quotes%22+%231",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SearchPageURL(tt.query, tt.version, tt.language)
+			if got != tt.expected {
+				t.Errorf("SearchPageURL() = %q, want %q", got, tt.expected)
+			}
+
+			parsed, err := url.Parse(got)
+			if err != nil {
+				t.Fatalf("Generated URL is not valid: %v", err)
+			}
+			if parsed.Query().Get("query") != tt.query {
+				t.Errorf("Query did not round-trip: got %q, want %q", parsed.Query().Get("query"), tt.query)
+			}
+		})
+	}
+}
+
+func TestLauncherCommand(t *testing.T) {
+	target := "https://docs.github.com/en/search?query=x&version=enterprise-cloud%40latest"
+	tests := []struct {
+		goos     string
+		expected []string
+	}{
+		{"darwin", []string{"open", target}},
+		{"windows", []string{"rundll32", "url.dll,FileProtocolHandler", target}},
+		{"linux", []string{"xdg-open", target}},
+		{"freebsd", []string{"xdg-open", target}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.goos, func(t *testing.T) {
+			if got := launcherCommand(tt.goos, target); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("launcherCommand(%q) = %q, want %q", tt.goos, got, tt.expected)
+			}
+		})
+	}
+}
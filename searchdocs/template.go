@@ -0,0 +1,115 @@
+package searchdocs
+
+import (
+	"io"
+	"net/url"
+	"reflect"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// TemplateData is the dot of a template that formats a whole search at once: the response
+// meta, the hits, and what was searched
+type TemplateData struct {
+	Meta     SearchMeta
+	Hits     []SearchItem
+	Query    string
+	Version  string
+	Language string
+}
+
+// templateEscapes turns the \t and \n a shell passes through literally into tabs and newlines
+var templateEscapes = strings.NewReplacer(`\t`, "\t", `\n`, "\n")
+
+// TemplateFuncs returns the helper functions every output template can call: upper, lower,
+// trim, truncate N, stripHTML, and urlEncode.
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"upper":     strings.ToUpper,
+		"lower":     strings.ToLower,
+		"trim":      strings.TrimSpace,
+		"truncate":  truncateRunes,
+		"stripHTML": func(s string) string { return StripTags(s) },
+		"urlEncode": url.QueryEscape,
+	}
+}
+
+// ParseTemplate parses an output template with the TemplateFuncs and any extra functions,
+// reading \t and \n as a tab and a newline. Errors name the line and column of the mistake.
+func ParseTemplate(text string, extra template.FuncMap) (*template.Template, error) {
+	return template.New("template").Funcs(TemplateFuncs()).Funcs(extra).Parse(templateEscapes.Replace(text))
+}
+
+// RenderTemplate parses tmplStr and executes it once with data as the dot
+func RenderTemplate(tmplStr string, data TemplateData, w io.Writer) error {
+	tmpl, err := ParseTemplate(tmplStr, nil)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(w, data)
+}
+
+// UsesTemplateData reports whether tmpl reads a TemplateData field such as .Hits or .Query
+// from its top-level dot, so it's meant to run once for the whole search rather than per hit
+func UsesTemplateData(tmpl *template.Template) bool {
+	if tmpl.Tree == nil {
+		return false
+	}
+	fields := map[string]bool{}
+	for _, f := range reflect.VisibleFields(reflect.TypeFor[TemplateData]()) {
+		fields[f.Name] = true
+	}
+	return readsDotField(tmpl.Tree.Root, fields)
+}
+
+// readsDotField walks node looking for one of fields read from the top-level dot. The bodies
+// of range and with are skipped, since the dot changes inside them.
+func readsDotField(node parse.Node, fields map[string]bool) bool {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return false
+		}
+		for _, child := range n.Nodes {
+			if readsDotField(child, fields) {
+				return true
+			}
+		}
+	case *parse.ActionNode:
+		return readsDotField(n.Pipe, fields)
+	case *parse.IfNode:
+		return readsDotField(n.Pipe, fields) || readsDotField(n.List, fields) || readsDotField(n.ElseList, fields)
+	case *parse.RangeNode:
+		return readsDotField(n.Pipe, fields)
+	case *parse.WithNode:
+		return readsDotField(n.Pipe, fields)
+	case *parse.PipeNode:
+		if n == nil {
+			return false
+		}
+		for _, cmd := range n.Cmds {
+			if readsDotField(cmd, fields) {
+				return true
+			}
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			if readsDotField(arg, fields) {
+				return true
+			}
+		}
+	case *parse.FieldNode:
+		return fields[n.Ident[0]]
+	}
+	return false
+}
+
+// truncateRunes cuts s to at most n characters, marking the cut with an ellipsis
+func truncateRunes(n int, s string) string {
+	runes := []rune(s)
+	if n < 1 || len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
@@ -0,0 +1,76 @@
+package searchdocs
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	data := TemplateData{
+		Hits:     []SearchItem{{Title: "Managing <mark>secrets</mark>", URL: "/en/actions/secrets"}, {Title: "Webhooks", URL: "/en/webhooks"}},
+		Query:    "secrets & keys",
+		Version:  "free-pro-team",
+		Language: "en",
+	}
+	data.Meta.Found.Value = 2
+
+	tests := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{"hits", `{{range .Hits}}{{.URL}}\n{{end}}`, "/en/actions/secrets\n/en/webhooks\n"},
+		{"search fields", `{{.Query}} {{.Version}}/{{.Language}} {{.Meta.Found.Value}}`, "secrets & keys free-pro-team/en 2"},
+		{"case and trim", `{{upper "a"}}{{lower "B"}}[{{trim "  c  "}}]`, "Ab[c]"},
+		{"truncate", `{{truncate 5 "Managing"}}|{{truncate 10 "short"}}`, "Mana…|short"},
+		{"strip html", `{{stripHTML (index .Hits 0).Title}}`, "Managing secrets"},
+		{"url encode", `{{urlEncode .Query}}`, "secrets+%26+keys"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			if err := RenderTemplate(tt.template, data, &out); err != nil {
+				t.Fatalf("RenderTemplate() error: %v", err)
+			}
+			if out.String() != tt.expected {
+				t.Errorf("RenderTemplate() = %q, want %q", out.String(), tt.expected)
+			}
+		})
+	}
+
+	t.Run("errors name the line", func(t *testing.T) {
+		for _, tmpl := range []string{"ok\n{{.Query", "ok\n{{.Nope}}"} {
+			err := RenderTemplate(tmpl, data, &strings.Builder{})
+			if err == nil || !strings.Contains(err.Error(), "template:2:") {
+				t.Errorf("RenderTemplate(%q) error = %v, want one naming line 2", tmpl, err)
+			}
+		}
+	})
+}
+
+func TestUsesTemplateData(t *testing.T) {
+	tests := []struct {
+		template string
+		expected bool
+	}{
+		{`{{.Title}}`, false},
+		{`{{range .Highlights}}{{.}}{{end}}`, false},
+		{`{{with .Intro}}{{.}}{{end}}`, false},
+		{`{{range .Hits}}{{.Title}}{{end}}`, true},
+		{`{{if .Query}}{{.Query}}{{end}}`, true},
+		{`{{len .Hits}}`, true},
+		{`{{.Meta.Found}}`, true},
+		{`{{with .Intro}}{{.Query}}{{end}}`, false},
+	}
+
+	for _, tt := range tests {
+		tmpl, err := ParseTemplate(tt.template, nil)
+		if err != nil {
+			t.Fatalf("ParseTemplate(%q) error: %v", tt.template, err)
+		}
+		if got := UsesTemplateData(tmpl); got != tt.expected {
+			t.Errorf("UsesTemplateData(%q) = %v, want %v", tt.template, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package searchdocs
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/cases"
+)
+
+// stopwords are common English words that carry no meaning when matching query terms
+var stopwords = map[string]bool{
+	"a": true, "about": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "by": true, "can": true, "do": true, "for": true, "from": true, "how": true,
+	"i": true, "in": true, "is": true, "it": true, "my": true, "of": true, "on": true,
+	"or": true, "the": true, "this": true, "to": true, "what": true, "when": true,
+	"where": true, "which": true, "who": true, "why": true, "with": true, "you": true,
+	"your": true,
+}
+
+// FoldCase returns the Unicode case-folded form of s for case-insensitive comparisons
+func FoldCase(s string) string {
+	return cases.Fold().String(s)
+}
+
+// QueryTerms splits a query into case-folded terms, dropping punctuation, stopwords, and
+// duplicate terms while preserving the order in which terms first appear
+func QueryTerms(query string) []string {
+	words := strings.FieldsFunc(FoldCase(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '-' && r != '_'
+	})
+
+	seen := make(map[string]bool, len(words))
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		word = strings.Trim(word, "-_")
+		if word == "" || stopwords[word] || seen[word] {
+			continue
+		}
+		seen[word] = true
+		terms = append(terms, word)
+	}
+	return terms
+}
+
+// ContainsTerms reports whether text contains every term, or at least one term when matchAny is
+// true. Matching is case-insensitive; an empty term list always matches.
+func ContainsTerms(text string, terms []string, matchAny bool) bool {
+	if len(terms) == 0 {
+		return true
+	}
+
+	folded := FoldCase(text)
+	for _, term := range terms {
+		found := strings.Contains(folded, term)
+		if matchAny && found {
+			return true
+		}
+		if !matchAny && !found {
+			return false
+		}
+	}
+	return !matchAny
+}
@@ -0,0 +1,59 @@
+package searchdocs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQueryTerms(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{"simple", "pull request", []string{"pull", "request"}},
+		{"stopwords removed", "how to create a pull request", []string{"create", "pull", "request"}},
+		{"case folded", "GitHub Actions", []string{"github", "actions"}},
+		{"punctuation", "ssh-key, (setup)!", []string{"ssh-key", "setup"}},
+		{"duplicates", "actions Actions ACTIONS", []string{"actions"}},
+		{"unicode folding", "STRASSE Straße", []string{"strasse"}},
+		{"non-latin", "プルリクエスト 作成", []string{"プルリクエスト", "作成"}},
+		{"only stopwords", "how to", []string{}},
+		{"empty", "", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := QueryTerms(tt.query); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("QueryTerms(%q) = %q, want %q", tt.query, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestContainsTerms(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		terms    []string
+		matchAny bool
+		expected bool
+	}{
+		{"all present", "Creating a pull request", []string{"pull", "request"}, false, true},
+		{"one missing", "Creating a pull request", []string{"pull", "template"}, false, false},
+		{"any with one present", "Creating a pull request", []string{"pull", "template"}, true, true},
+		{"any with none present", "Creating a pull request", []string{"issue", "template"}, true, false},
+		{"case insensitive", "About GITHUB ACTIONS", []string{"github", "actions"}, false, true},
+		{"unicode folding", "Straße names", []string{"strasse"}, false, true},
+		{"no terms", "Anything", []string{}, false, true},
+		{"no terms any", "Anything", nil, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ContainsTerms(tt.text, tt.terms, tt.matchAny); got != tt.expected {
+				t.Errorf("ContainsTerms(%q, %q, %v) = %v, want %v", tt.text, tt.terms, tt.matchAny, got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package searchdocs
+
+import (
+	"net/http"
+	"strings"
+)
+
+// SwapLanguage replaces the leading language segment of a docs path, e.g.
+// SwapLanguage("/en/actions/quickstart", "ja") returns "/ja/actions/quickstart"
+func SwapLanguage(path, language string) string {
+	trimmed := strings.TrimPrefix(path, "/")
+	if i := strings.Index(trimmed, "/"); i >= 0 {
+		return "/" + language + trimmed[i:]
+	}
+	return "/" + language
+}
+
+// TranslationChecker probes docs.github.com for translated copies of pages
+type TranslationChecker struct {
+	prober *PageProber
+}
+
+// NewTranslationChecker returns a checker issuing requests with client against baseURL
+func NewTranslationChecker(client *http.Client, baseURL string, concurrency int) *TranslationChecker {
+	return &TranslationChecker{prober: NewPageProber(client, baseURL, concurrency)}
+}
+
+// Check reports, for each path, whether a translation exists in each language. The result
+// has one map per path keyed by language. A redirect back to English counts as missing. If
+// any probe fails its language is left out of the map, since it is unknown rather than
+// missing, and the first error is returned alongside the results.
+func (c *TranslationChecker) Check(paths, languages []string) ([]map[string]bool, error) {
+	probes := make([]string, 0, len(paths)*len(languages))
+	for _, path := range paths {
+		for _, language := range languages {
+			probes = append(probes, SwapLanguage(path, language))
+		}
+	}
+
+	exists, errs := c.prober.CheckAll(probes)
+
+	results := make([]map[string]bool, len(paths))
+	for i := range paths {
+		results[i] = make(map[string]bool, len(languages))
+		for j, language := range languages {
+			if k := i*len(languages) + j; errs[k] == nil {
+				results[i][language] = exists[k]
+			}
+		}
+	}
+	return results, firstError(errs)
+}
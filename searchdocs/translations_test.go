@@ -0,0 +1,95 @@
+package searchdocs
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSwapLanguage(t *testing.T) {
+	tests := []struct {
+		path     string
+		language string
+		expected string
+	}{
+		{"/en/actions/quickstart", "ja", "/ja/actions/quickstart"},
+		{"/en/enterprise-server@3.17/admin", "pt", "/pt/enterprise-server@3.17/admin"},
+		{"en/actions", "ko", "/ko/actions"},
+		{"/en", "es", "/es"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := SwapLanguage(tt.path, tt.language); got != tt.expected {
+				t.Errorf("SwapLanguage(%q, %q) = %q, want %q", tt.path, tt.language, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTranslationCheckerCheck(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Method != http.MethodHead {
+			t.Errorf("Expected HEAD request, got %s", r.Method)
+		}
+		switch r.URL.Path {
+		case "/ja/translated", "/ko/translated":
+			w.WriteHeader(http.StatusOK)
+		case "/ja/redirected-in-language":
+			http.Redirect(w, r, "/ja/new-location", http.StatusMovedPermanently)
+		case "/ja/english-only", "/ko/english-only":
+			http.Redirect(w, r, "/en/english-only", http.StatusFound)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	checker := NewTranslationChecker(server.Client(), server.URL, 2)
+	paths := []string{"/en/translated", "/en/english-only", "/en/redirected-in-language", "/en/missing", "/en/translated"}
+
+	results, err := checker.Check(paths, []string{"ja", "ko"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []map[string]bool{
+		{"ja": true, "ko": true},
+		{"ja": false, "ko": false},
+		{"ja": true, "ko": false},
+		{"ja": false, "ko": false},
+		{"ja": true, "ko": true},
+	}
+	if !reflect.DeepEqual(results, expected) {
+		t.Errorf("Check() = %v, want %v", results, expected)
+	}
+
+	// A second check is served entirely from the cache
+	before := requests.Load()
+	if _, err := checker.Check(paths[:1], []string{"ja"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if requests.Load() != before {
+		t.Errorf("Expected cached results to avoid new requests, got %d new", requests.Load()-before)
+	}
+}
+
+func TestTranslationCheckerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	checker := NewTranslationChecker(server.Client(), server.URL, 1)
+	results, err := checker.Check([]string{"/en/page"}, []string{"ja"})
+	if err == nil {
+		t.Error("Expected an error for a server failure")
+	}
+	if _, known := results[0]["ja"]; known {
+		t.Error("Expected failed probe to be left out as unknown, not reported as missing")
+	}
+}
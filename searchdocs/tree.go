@@ -0,0 +1,92 @@
+package searchdocs
+
+import (
+	"io"
+	"strings"
+)
+
+// MaxTreeDepth is how many levels of breadcrumbs a tree shows. Deeper segments are joined
+// into the last level.
+const MaxTreeDepth = 4
+
+// TreeNode is a branch of a tree of items grouped by their breadcrumb paths, or a leaf
+// holding one item. Children keep the order their first item was added in, so a tree built
+// from ranked items lists the most relevant branches and leaves first.
+type TreeNode[T any] struct {
+	Name     string
+	Item     *T
+	Children []*TreeNode[T]
+}
+
+// BuildTree groups items under the segments path returns for each, sharing common prefixes.
+// Items with no path are leaves of the root. Paths longer than MaxTreeDepth are collapsed.
+func BuildTree[T any](items []T, path func(T) []string) *TreeNode[T] {
+	root := &TreeNode[T]{}
+	for i := range items {
+		segments := path(items[i])
+		if len(segments) > MaxTreeDepth {
+			collapsed := strings.Join(segments[MaxTreeDepth-1:], " / ")
+			segments = append(segments[:MaxTreeDepth-1:MaxTreeDepth-1], collapsed)
+		}
+		node := root
+		for _, segment := range segments {
+			node = node.branch(segment)
+		}
+		node.Children = append(node.Children, &TreeNode[T]{Item: &items[i]})
+	}
+	return root
+}
+
+// branch returns the child branch named name, adding it if there isn't one yet
+func (n *TreeNode[T]) branch(name string) *TreeNode[T] {
+	for _, child := range n.Children {
+		if child.Item == nil && child.Name == name {
+			return child
+		}
+	}
+	child := &TreeNode[T]{Name: name}
+	n.Children = append(n.Children, child)
+	return child
+}
+
+// treeGlyphs are the connectors drawn in front of tree entries: a middle entry, the last
+// entry, the continuation of a branch with more entries below, and the space under a last one
+type treeGlyphs struct{ tee, elbow, pipe, space string }
+
+var (
+	unicodeTree = treeGlyphs{"├── ", "└── ", "│   ", "    "}
+	asciiTree   = treeGlyphs{"|-- ", "`-- ", "|   ", "    "}
+)
+
+// WriteTree draws root with box-drawing characters, or plain ASCII with ascii, one entry per
+// line: its name if it has one, then everything under it. Branches show their name and
+// leaves the text leaf returns.
+func WriteTree[T any](w io.Writer, root *TreeNode[T], leaf func(T) string, ascii bool) error {
+	glyphs := unicodeTree
+	if ascii {
+		glyphs = asciiTree
+	}
+	var b strings.Builder
+	if root.Name != "" {
+		b.WriteString(root.Name + "\n")
+	}
+	writeTreeChildren(&b, root, "", glyphs, leaf)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeTreeChildren writes each child of n behind prefix, recursing into branches
+func writeTreeChildren[T any](b *strings.Builder, n *TreeNode[T], prefix string, glyphs treeGlyphs, leaf func(T) string) {
+	for i, child := range n.Children {
+		connector, indent := glyphs.tee, glyphs.pipe
+		if i == len(n.Children)-1 {
+			connector, indent = glyphs.elbow, glyphs.space
+		}
+		text := child.Name
+		if child.Item != nil {
+			text = leaf(*child.Item)
+		}
+		b.WriteString(prefix + connector + text + "\n")
+		writeTreeChildren(b, child, prefix+indent, glyphs, leaf)
+	}
+}
@@ -0,0 +1,61 @@
+package searchdocs
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteTree(t *testing.T) {
+	items := []string{
+		"Actions / Security / Secrets|Using secrets",
+		"|Quickstart",
+		"Actions / Security|Hardening",
+		"Pages|About Pages",
+		"Actions / Security / Secrets|Secrets reference",
+		"A / B / C / D / E|Deep",
+	}
+	path := func(item string) []string {
+		crumbs, _, _ := strings.Cut(item, "|")
+		return BreadcrumbSegments(crumbs)
+	}
+	leaf := func(item string) string {
+		_, title, _ := strings.Cut(item, "|")
+		return title
+	}
+	root := BuildTree(items, path)
+	root.Name = "secrets"
+
+	var out bytes.Buffer
+	if err := WriteTree(&out, root, leaf, false); err != nil {
+		t.Fatal(err)
+	}
+	want := `secrets
+├── Actions
+│   └── Security
+│       ├── Secrets
+│       │   ├── Using secrets
+│       │   └── Secrets reference
+│       └── Hardening
+├── Quickstart
+├── Pages
+│   └── About Pages
+└── A
+    └── B
+        └── C
+            └── D / E
+                └── Deep
+`
+	if out.String() != want {
+		t.Errorf("Unexpected tree:\n%s\nwant:\n%s", out.String(), want)
+	}
+
+	out.Reset()
+	if err := WriteTree(&out, BuildTree(items[1:4], path), leaf, true); err != nil {
+		t.Fatal(err)
+	}
+	want = "|-- Quickstart\n|-- Actions\n|   `-- Security\n|       `-- Hardening\n`-- Pages\n    `-- About Pages\n"
+	if out.String() != want {
+		t.Errorf("Unexpected ASCII tree:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
@@ -0,0 +1,256 @@
+package searchdocs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// TUIResult is one search result shown in the interactive search
+type TUIResult struct {
+	Title       string
+	URL         string
+	Breadcrumbs string
+	// Preview is Markdown shown in the preview pane, usually the intro or matched content
+	Preview string
+}
+
+// tuiItem adapts a TUIResult to the bubbles list
+type tuiItem struct{ result TUIResult }
+
+func (i tuiItem) Title() string       { return i.result.Title }
+func (i tuiItem) Description() string { return i.result.URL }
+func (i tuiItem) FilterValue() string { return i.result.Title }
+
+// tuiResultsMsg delivers the results of a search started from the input
+type tuiResultsMsg struct {
+	query   string
+	results []TUIResult
+	err     error
+}
+
+// tuiOpenedMsg reports the outcome of opening a result in the browser
+type tuiOpenedMsg struct {
+	url string
+	err error
+}
+
+var (
+	tuiStatusStyle = lipgloss.NewStyle().Faint(true)
+	tuiErrorStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	tuiRuleStyle   = lipgloss.NewStyle().Faint(true)
+)
+
+// TUIModel is the bubbletea model behind --interactive: a query input at the top, the
+// results in the middle, and a preview of the selected result at the bottom. Searches run
+// when the query is submitted with Enter, never per keystroke.
+type TUIModel struct {
+	// Search runs a query and returns its results
+	Search func(query string) ([]TUIResult, error)
+	// Open opens a result URL in the browser
+	Open func(url string) error
+	// Render renders preview Markdown for the given width; nil shows the Markdown as is
+	Render func(md string, width int) (string, error)
+
+	input   textinput.Model
+	results list.Model
+	preview viewport.Model
+
+	width, height int
+	searching     bool
+	status        string
+	err           error
+}
+
+// NewTUIModel returns a model that searches for query as soon as it starts, if it isn't empty
+func NewTUIModel(query string, search func(string) ([]TUIResult, error), open func(string) error) TUIModel {
+	input := textinput.New()
+	input.Prompt = "Search GitHub Docs: "
+	input.SetValue(query)
+
+	delegate := list.NewDefaultDelegate()
+	results := list.New(nil, delegate, 0, 0)
+	results.SetShowTitle(false)
+	results.SetShowStatusBar(false)
+	results.SetShowHelp(false)
+	results.SetFilteringEnabled(false)
+	results.DisableQuitKeybindings()
+
+	m := TUIModel{
+		Search:  search,
+		Open:    open,
+		input:   input,
+		results: results,
+		preview: viewport.New(0, 0),
+	}
+	if query == "" {
+		m.input.Focus()
+	}
+	return m
+}
+
+// Init starts the initial search, or waits for a query
+func (m TUIModel) Init() tea.Cmd {
+	if query := strings.TrimSpace(m.input.Value()); query != "" {
+		return m.search(query)
+	}
+	return textinput.Blink
+}
+
+// search returns a command running query through Search
+func (m TUIModel) search(query string) tea.Cmd {
+	search := m.Search
+	return func() tea.Msg {
+		results, err := search(query)
+		return tuiResultsMsg{query: query, results: results, err: err}
+	}
+}
+
+// Update handles key presses, window resizes, and finished searches
+func (m TUIModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.resize()
+		m.updatePreview()
+		return m, nil
+
+	case tuiResultsMsg:
+		m.searching = false
+		m.err = msg.err
+		if msg.err != nil {
+			return m, nil
+		}
+		items := make([]list.Item, len(msg.results))
+		for i, r := range msg.results {
+			items[i] = tuiItem{result: r}
+		}
+		m.results.SetItems(items)
+		m.results.Select(0)
+		m.status = fmt.Sprintf("%d results for %q", len(items), msg.query)
+		m.input.Blur()
+		m.updatePreview()
+		return m, nil
+
+	case tuiOpenedMsg:
+		m.err = msg.err
+		if msg.err == nil {
+			m.status = "Opened " + msg.url
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		if msg.Type == tea.KeyCtrlC {
+			return m, tea.Quit
+		}
+		if m.input.Focused() {
+			return m.updateInput(msg)
+		}
+		return m.updateResults(msg)
+	}
+	return m, nil
+}
+
+// updateInput handles keys while the query input has focus
+func (m TUIModel) updateInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEnter:
+		query := strings.TrimSpace(m.input.Value())
+		if query == "" || m.searching {
+			return m, nil
+		}
+		m.searching = true
+		m.err = nil
+		m.status = fmt.Sprintf("Searching for %q...", query)
+		return m, m.search(query)
+	case tea.KeyEsc:
+		if len(m.results.Items()) > 0 {
+			m.input.Blur()
+		}
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// updateResults handles keys while the result list has focus
+func (m TUIModel) updateResults(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q":
+		return m, tea.Quit
+	case "/":
+		m.input.Focus()
+		m.input.CursorEnd()
+		return m, textinput.Blink
+	case "enter":
+		item, ok := m.results.SelectedItem().(tuiItem)
+		if !ok || m.Open == nil {
+			return m, nil
+		}
+		open, url := m.Open, item.result.URL
+		return m, func() tea.Msg {
+			return tuiOpenedMsg{url: url, err: open(url)}
+		}
+	}
+	var cmd tea.Cmd
+	m.results, cmd = m.results.Update(msg)
+	m.updatePreview()
+	return m, cmd
+}
+
+// resize splits the window between the input, the results, and the preview
+func (m *TUIModel) resize() {
+	// One line each for the input, the rule above the preview, and the status line
+	available := max(m.height-3, 2)
+	listHeight := available / 2
+	m.input.Width = max(m.width-len(m.input.Prompt)-1, 1)
+	m.results.SetSize(m.width, listHeight)
+	m.preview.Width = m.width
+	m.preview.Height = available - listHeight
+}
+
+// updatePreview renders the selected result into the preview pane
+func (m *TUIModel) updatePreview() {
+	item, ok := m.results.SelectedItem().(tuiItem)
+	if !ok {
+		m.preview.SetContent("")
+		return
+	}
+
+	var md strings.Builder
+	fmt.Fprintf(&md, "## %s\n\n", item.result.Title)
+	if item.result.Breadcrumbs != "" {
+		fmt.Fprintf(&md, "%s\n\n", item.result.Breadcrumbs)
+	}
+	md.WriteString(item.result.Preview)
+
+	content := md.String()
+	if m.Render != nil {
+		if rendered, err := m.Render(content, m.width); err == nil {
+			content = rendered
+		}
+	}
+	m.preview.SetContent(content)
+	m.preview.GotoTop()
+}
+
+// View draws the three panes and a status line
+func (m TUIModel) View() string {
+	status := tuiStatusStyle.Render(m.status + "  ↑/↓ move · enter open · / search · q quit")
+	if m.err != nil {
+		status = tuiErrorStyle.Render("Error: " + m.err.Error())
+	}
+	return lipgloss.JoinVertical(lipgloss.Left,
+		m.input.View(),
+		m.results.View(),
+		tuiRuleStyle.Render(strings.Repeat("─", max(m.width, 1))),
+		m.preview.View(),
+		status,
+	)
+}
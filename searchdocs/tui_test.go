@@ -0,0 +1,122 @@
+package searchdocs
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// tuiUpdate applies msg to m and returns the updated model and command
+func tuiUpdate(t *testing.T, m TUIModel, msg tea.Msg) (TUIModel, tea.Cmd) {
+	t.Helper()
+	model, cmd := m.Update(msg)
+	return model.(TUIModel), cmd
+}
+
+func tuiKey(key string) tea.KeyMsg {
+	switch key {
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "down":
+		return tea.KeyMsg{Type: tea.KeyDown}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+}
+
+func TestTUIModelSearch(t *testing.T) {
+	var queries []string
+	search := func(query string) ([]TUIResult, error) {
+		queries = append(queries, query)
+		return []TUIResult{
+			{Title: "First", URL: "https://docs.github.com/en/first", Preview: "First intro"},
+			{Title: "Second", URL: "https://docs.github.com/en/second", Preview: "Second intro"},
+		}, nil
+	}
+	var opened []string
+	open := func(url string) error {
+		opened = append(opened, url)
+		return nil
+	}
+
+	m := NewTUIModel("", search, open)
+	m, _ = tuiUpdate(t, m, tea.WindowSizeMsg{Width: 80, Height: 24})
+	if m.Init() == nil || !m.input.Focused() {
+		t.Fatal("Expected the input to be focused without a query")
+	}
+
+	// Typing doesn't search; submitting does
+	for _, r := range "ssh" {
+		m, _ = tuiUpdate(t, m, tuiKey(string(r)))
+	}
+	if len(queries) != 0 {
+		t.Fatalf("Expected no searches while typing, got %v", queries)
+	}
+	m, cmd := tuiUpdate(t, m, tuiKey("enter"))
+	if cmd == nil {
+		t.Fatal("Expected Enter to start a search")
+	}
+	m, _ = tuiUpdate(t, m, cmd())
+	if len(queries) != 1 || queries[0] != "ssh" {
+		t.Fatalf("Expected one search for ssh, got %v", queries)
+	}
+	if m.input.Focused() {
+		t.Error("Expected focus to move to the results")
+	}
+	if view := m.View(); !strings.Contains(view, "First intro") || !strings.Contains(view, "Second") {
+		t.Errorf("Expected the results and a preview of the first, got:\n%s", view)
+	}
+
+	// Moving down previews the second result, and Enter opens it
+	m, _ = tuiUpdate(t, m, tuiKey("down"))
+	if !strings.Contains(m.View(), "Second intro") {
+		t.Errorf("Expected the preview to follow the selection, got:\n%s", m.View())
+	}
+	m, cmd = tuiUpdate(t, m, tuiKey("enter"))
+	if cmd == nil {
+		t.Fatal("Expected Enter to open the result")
+	}
+	m, _ = tuiUpdate(t, m, cmd())
+	if len(opened) != 1 || opened[0] != "https://docs.github.com/en/second" {
+		t.Errorf("Opened %v, want the second result", opened)
+	}
+
+	// / returns to the input, where q is just a letter
+	m, _ = tuiUpdate(t, m, tuiKey("/"))
+	if !m.input.Focused() {
+		t.Fatal("Expected / to focus the input")
+	}
+	m, cmd = tuiUpdate(t, m, tuiKey("q"))
+	if cmd != nil {
+		if _, quit := cmd().(tea.QuitMsg); quit {
+			t.Error("Expected q to be typed into the input, not quit")
+		}
+	}
+	if m.input.Value() != "sshq" {
+		t.Errorf("Input = %q, want sshq", m.input.Value())
+	}
+
+	m.input.Blur()
+	if _, cmd := tuiUpdate(t, m, tuiKey("q")); cmd == nil {
+		t.Error("Expected q to quit from the results")
+	} else if _, quit := cmd().(tea.QuitMsg); !quit {
+		t.Error("Expected q to quit from the results")
+	}
+}
+
+func TestTUIModelInitialQuery(t *testing.T) {
+	search := func(query string) ([]TUIResult, error) {
+		return nil, nil
+	}
+	m := NewTUIModel("webhooks", search, nil)
+	if m.input.Focused() {
+		t.Error("Expected the input not to be focused when starting with a query")
+	}
+	cmd := m.Init()
+	if cmd == nil {
+		t.Fatal("Expected Init to start a search")
+	}
+	if msg, ok := cmd().(tuiResultsMsg); !ok || msg.query != "webhooks" {
+		t.Errorf("Expected a search for webhooks, got %#v", msg)
+	}
+}
@@ -6,9 +6,13 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/rivo/uniseg"
 	"golang.org/x/term"
 )
 
@@ -19,24 +23,49 @@ type SupportedVersions struct {
 	LatestVersion     string   `json:"latestVersion"`
 }
 
-// LoadSupportedVersions loads the supported enterprise versions from the JSON file
-func LoadSupportedVersions() (*SupportedVersions, error) {
-	// Get the executable path
+// VersionsFile, when set, is the supported versions file read instead of looking in
+// GH_SEARCH_DOCS_VERSIONS_FILE and the DefaultVersionsPaths. --versions-file sets it.
+var VersionsFile string
+
+// DefaultVersionsPaths returns where the supported versions file is looked for, in order:
+// data/supported-versions.json next to the executable, then in the current directory (for
+// development)
+func DefaultVersionsPaths() []string {
+	relative := filepath.Join("data", "supported-versions.json")
 	execPath, err := os.Executable()
 	if err != nil {
-		return nil, err
+		return []string{relative}
 	}
+	return []string{filepath.Join(filepath.Dir(execPath), relative), relative}
+}
 
-	// Build path to data directory relative to executable
-	dataPath := filepath.Join(filepath.Dir(execPath), "data", "supported-versions.json")
-
-	// If that doesn't exist, try relative to current working directory (for development)
-	if _, statErr := os.Stat(dataPath); os.IsNotExist(statErr) {
-		dataPath = filepath.Join("data", "supported-versions.json")
+// VersionsPath returns the supported versions file to read: VersionsFile, then
+// GH_SEARCH_DOCS_VERSIONS_FILE, then the first of the DefaultVersionsPaths that exists
+func VersionsPath() string {
+	if VersionsFile != "" {
+		return VersionsFile
+	}
+	if path := os.Getenv("GH_SEARCH_DOCS_VERSIONS_FILE"); path != "" {
+		return path
 	}
+	paths := DefaultVersionsPaths()
+	for _, path := range paths {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return paths[len(paths)-1]
+}
+
+// LoadSupportedVersions loads the supported enterprise versions from the file at VersionsPath
+func LoadSupportedVersions() (*SupportedVersions, error) {
+	return LoadSupportedVersionsFromPath(VersionsPath())
+}
 
-	// Read the file
-	data, err := os.ReadFile(dataPath)
+// LoadSupportedVersionsFromPath loads the supported enterprise versions from a JSON file
+func LoadSupportedVersionsFromPath(path string) (*SupportedVersions, error) {
+	// #nosec G304 -- the path is the bundled data file or one the user chose
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
@@ -44,32 +73,25 @@ func LoadSupportedVersions() (*SupportedVersions, error) {
 	// Parse JSON
 	var versions SupportedVersions
 	if err := json.Unmarshal(data, &versions); err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%s: %w", path, err)
 	}
 
 	return &versions, nil
 }
 
-// IsVersionSupported checks if a given enterprise server version is supported
-func IsVersionSupported(version string) bool {
+// SupportedServerVersions returns the supported enterprise server versions, e.g. "3.17"
+func SupportedServerVersions() []string {
 	versions, err := LoadSupportedVersions()
 	if err != nil {
 		// Fallback to hardcoded versions if file loading fails
-		hardcodedVersions := []string{"3.14", "3.15", "3.16", "3.17"}
-		for _, v := range hardcodedVersions {
-			if v == version {
-				return true
-			}
-		}
-		return false
+		return []string{"3.14", "3.15", "3.16", "3.17"}
 	}
+	return versions.SupportedVersions
+}
 
-	for _, v := range versions.SupportedVersions {
-		if v == version {
-			return true
-		}
-	}
-	return false
+// IsVersionSupported checks if a given enterprise server version is supported
+func IsVersionSupported(version string) bool {
+	return slices.Contains(SupportedServerVersions(), version)
 }
 
 // NormalizeVersion normalizes version strings for the search API
@@ -90,13 +112,7 @@ func NormalizeVersion(v string) string {
 		}
 
 		// If version is not supported, fall back to latest supported version
-		versions, err := LoadSupportedVersions()
-		if err == nil && versions.LatestVersion != "" {
-			return "enterprise-server@" + versions.LatestVersion
-		}
-
-		// Ultimate fallback
-		return "enterprise-server@3.17"
+		return LatestServerVersion()
 	}
 
 	return "free-pro-team"
@@ -161,6 +177,61 @@ func IsLight() bool {
 	}
 }
 
+// IsTerminal reports whether the given file descriptor is connected to a terminal
+func IsTerminal(fd uintptr) bool {
+	return term.IsTerminal(int(fd))
+}
+
+// FormatFoundCount formats the number of results found, adding a + when relation is "gte":
+// the API stops counting at a limit and reports the count as a lower bound
+func FormatFoundCount(value int, relation string) string {
+	count := strconv.Itoa(value)
+	if relation == "gte" {
+		count += "+"
+	}
+	return count
+}
+
+// FormatTiming formats the time the search API reports a search took, e.g.
+// "API timing: query=12ms, total=34ms"
+func FormatTiming(t struct{ QueryMsec, TotalMsec int }) string {
+	return fmt.Sprintf("API timing: query=%dms, total=%dms", t.QueryMsec, t.TotalMsec)
+}
+
+// TruncateString cuts s to maxChars characters, adding "..." when anything was cut. It
+// counts grapheme clusters rather than bytes or runes, so multi-byte characters, emoji
+// sequences, and accented letters are never split. The cut backs up to the end of the last
+// whole word, dropping any separator before the ellipsis, unless the first word alone is
+// longer than maxChars. A maxChars of 0 or less means no limit.
+func TruncateString(s string, maxChars int) string {
+	if maxChars <= 0 {
+		return s
+	}
+	state, rest, n := -1, s, 0
+	wordEnd := 0
+	for rest != "" {
+		// A space right at the limit counts too, so a word ending there is kept
+		if r, _ := utf8.DecodeRuneInString(rest); unicode.IsSpace(r) {
+			wordEnd = len(s) - len(rest)
+		}
+		if n == maxChars {
+			if cut := strings.TrimRightFunc(s[:wordEnd], isWordSeparator); cut != "" {
+				return cut + "..."
+			}
+			return s[:len(s)-len(rest)] + "..."
+		}
+		_, rest, _, state = uniseg.FirstGraphemeClusterInString(rest, state)
+		n++
+	}
+	return s
+}
+
+// isWordSeparator reports whether r can be dropped from the end of a truncated string:
+// spaces, dashes, and punctuation that joins clauses
+func isWordSeparator(r rune) bool {
+	return unicode.IsSpace(r) || unicode.Is(unicode.Pd, r) || strings.ContainsRune(",;:", r)
+}
+
 // GetTerminalWidth returns the width of the terminal, or a default value if detection fails
 func GetTerminalWidth() int {
 	// Try to get terminal width from stdout
@@ -184,6 +255,23 @@ func GetTerminalWidth() int {
 	return 120
 }
 
+// GetTerminalHeight returns the height of the terminal in lines, or a default value if
+// detection fails
+func GetTerminalHeight() int {
+	if _, height, err := term.GetSize(int(os.Stdout.Fd())); err == nil && height > 0 {
+		return height
+	}
+	if _, height, err := term.GetSize(int(os.Stderr.Fd())); err == nil && height > 0 {
+		return height
+	}
+	if lines := os.Getenv("LINES"); lines != "" {
+		if height, err := strconv.Atoi(lines); err == nil && height > 0 {
+			return height
+		}
+	}
+	return 24
+}
+
 // Fatal prints an error message and exits with status 1
 func Fatal(err error) {
 	fmt.Fprintln(os.Stderr, "error:", err)
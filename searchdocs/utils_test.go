@@ -2,9 +2,13 @@ package searchdocs
 
 import (
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -361,6 +365,70 @@ func TestIsLightPlatformDefaults(t *testing.T) {
 	}
 }
 
+func TestFormatFoundCount(t *testing.T) {
+	tests := []struct {
+		value    int
+		relation string
+		expected string
+	}{
+		{42, "eq", "42"},
+		{5000, "gte", "5000+"},
+		{0, "", "0"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatFoundCount(tt.value, tt.relation); got != tt.expected {
+			t.Errorf("FormatFoundCount(%d, %q) = %q, want %q", tt.value, tt.relation, got, tt.expected)
+		}
+	}
+}
+
+func TestFormatTiming(t *testing.T) {
+	got := FormatTiming(struct{ QueryMsec, TotalMsec int }{QueryMsec: 12, TotalMsec: 34})
+	if want := "API timing: query=12ms, total=34ms"; got != want {
+		t.Errorf("FormatTiming() = %q, want %q", got, want)
+	}
+}
+
+func TestTruncateString(t *testing.T) {
+	tests := []struct {
+		input    string
+		maxChars int
+		expected string
+	}{
+		{"Use a token", 5, "Use a..."},
+		{"Use a token", 11, "Use a token"},
+		{"Use a token", 0, "Use a token"},
+		{"Use a token", -1, "Use a token"},
+		{"トークンを使う", 4, "トークン..."},
+		{"Keys 🔑🔑 here", 6, "Keys..."},
+		{"Keys 🔑🔑 here", 7, "Keys 🔑🔑..."},
+		{"Team 👩‍💻👩‍💻 docs", 6, "Team..."},
+		{"Flags 🇯🇵🇺🇸", 7, "Flags..."},
+		{"Cafe\u0301 menu", 6, "Cafe\u0301..."},
+		{"“Quoted” — text", 10, "“Quoted”..."},
+		{"トークン", 4, "トークン"},
+		{"", 3, ""},
+
+		// Word boundaries
+		{"configure your repository settings", 20, "configure your..."},
+		{"configure your repository settings", 14, "configure your..."},
+		{"configure your repository settings", 15, "configure your..."},
+		{"configure your repository settings", 25, "configure your repository..."},
+		{"configure your repository settings", 26, "configure your repository..."},
+		{"configure your repository settings", 34, "configure your repository settings"},
+		{"configuration", 6, "config..."},
+		{"configure, then push", 12, "configure..."},
+		{"  leading spaces", 4, "  le..."},
+	}
+
+	for _, tt := range tests {
+		if got := TruncateString(tt.input, tt.maxChars); got != tt.expected {
+			t.Errorf("TruncateString(%q, %d) = %q, want %q", tt.input, tt.maxChars, got, tt.expected)
+		}
+	}
+}
+
 func TestGetTerminalWidth(t *testing.T) {
 	// Save original environment
 	originalColumns := os.Getenv("COLUMNS")
@@ -537,3 +605,119 @@ func TestNormalizeVersionWithCustomFile(t *testing.T) {
 		t.Errorf("NormalizeVersion with custom file = %q, want %q", result, expected)
 	}
 }
+
+func TestVersionsPath(t *testing.T) {
+	t.Setenv("GH_SEARCH_DOCS_VERSIONS_FILE", "")
+	paths := DefaultVersionsPaths()
+	if len(paths) != 2 || paths[1] != filepath.Join("data", "supported-versions.json") {
+		t.Fatalf("DefaultVersionsPaths() = %v, want the executable's data directory, then the current one", paths)
+	}
+
+	// Neither default exists in an empty directory, so the last one is reported
+	oldDir, _ := os.Getwd()
+	defer func() { _ = os.Chdir(oldDir) }()
+	_ = os.Chdir(t.TempDir())
+	if got := VersionsPath(); got != paths[1] {
+		t.Errorf("VersionsPath() = %q, want %q", got, paths[1])
+	}
+
+	t.Setenv("GH_SEARCH_DOCS_VERSIONS_FILE", "/env/versions.json")
+	if got := VersionsPath(); got != "/env/versions.json" {
+		t.Errorf("VersionsPath() = %q, want GH_SEARCH_DOCS_VERSIONS_FILE", got)
+	}
+
+	VersionsFile = "/flag/versions.json"
+	defer func() { VersionsFile = "" }()
+	if got := VersionsPath(); got != "/flag/versions.json" {
+		t.Errorf("VersionsPath() = %q, want VersionsFile over the environment", got)
+	}
+}
+
+func TestLoadSupportedVersionsFromPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "versions.json")
+	if err := os.WriteFile(path, []byte(`{"supportedVersions": ["3.18"], "latestVersion": "3.18"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	versions, err := LoadSupportedVersionsFromPath(path)
+	if err != nil {
+		t.Fatalf("LoadSupportedVersionsFromPath() error: %v", err)
+	}
+	if versions.LatestVersion != "3.18" {
+		t.Errorf("Expected latest version 3.18, got %s", versions.LatestVersion)
+	}
+
+	if err := os.WriteFile(path, []byte(`{`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSupportedVersionsFromPath(path); err == nil || !strings.Contains(err.Error(), path) {
+		t.Errorf("Expected an error naming the file, got %v", err)
+	}
+}
+
+func TestFetchLatestVersions(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr bool
+	}{
+		{"valid", http.StatusOK, `{"supportedVersions": ["3.18", "3.19"], "latestVersion": "3.19"}`, false},
+		{"server error", http.StatusInternalServerError, ``, true},
+		{"invalid json", http.StatusOK, `{`, true},
+		{"latest not listed", http.StatusOK, `{"supportedVersions": ["3.18"], "latestVersion": "3.19"}`, true},
+		{"no versions", http.StatusOK, `{}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			versions, err := FetchLatestVersions(server.URL, server.Client())
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected an error, got %+v", versions)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FetchLatestVersions() error: %v", err)
+			}
+			if versions.LatestVersion != "3.19" || len(versions.SupportedVersions) != 2 {
+				t.Errorf("Unexpected versions: %+v", versions)
+			}
+		})
+	}
+}
+
+func TestDiffVersions(t *testing.T) {
+	prev := &SupportedVersions{SupportedVersions: []string{"3.14", "3.15", "3.16"}}
+	next := &SupportedVersions{SupportedVersions: []string{"3.15", "3.16", "3.17"}}
+	added, removed := DiffVersions(prev, next)
+	if !slices.Equal(added, []string{"3.17"}) || !slices.Equal(removed, []string{"3.14"}) {
+		t.Errorf("DiffVersions() = %v, %v, want [3.17], [3.14]", added, removed)
+	}
+
+	added, removed = DiffVersions(nil, next)
+	if !slices.Equal(added, next.SupportedVersions) || len(removed) != 0 {
+		t.Errorf("DiffVersions(nil) = %v, %v, want every version added", added, removed)
+	}
+}
+
+func TestWriteSupportedVersions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "supported-versions.json")
+	want := &SupportedVersions{LastUpdated: "2026-01-01T00:00:00Z", SupportedVersions: []string{"3.19"}, LatestVersion: "3.19"}
+	if err := WriteSupportedVersions(path, want); err != nil {
+		t.Fatalf("WriteSupportedVersions() error: %v", err)
+	}
+	got, err := LoadSupportedVersionsFromPath(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.LastUpdated != want.LastUpdated || !slices.Equal(got.SupportedVersions, want.SupportedVersions) {
+		t.Errorf("Read back %+v, want %+v", got, want)
+	}
+}
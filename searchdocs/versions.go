@@ -0,0 +1,141 @@
+package searchdocs
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// versionSegment returns the URL path segment for a docs version. free-pro-team pages have
+// no version segment.
+func versionSegment(version string) string {
+	switch {
+	case version == "" || version == "free-pro-team" || version == "free-pro-team@latest":
+		return ""
+	case version == "enterprise-cloud":
+		return "enterprise-cloud@latest"
+	default:
+		return version
+	}
+}
+
+// isVersionSegment reports whether a path segment names a docs version
+func isVersionSegment(segment string) bool {
+	return strings.HasPrefix(segment, "enterprise-server@") ||
+		strings.HasPrefix(segment, "enterprise-cloud@") ||
+		strings.HasPrefix(segment, "free-pro-team@")
+}
+
+// SplitDocsPath splits a docs path into its language, version segment, and the remaining
+// page path, e.g. "/en/enterprise-server@3.17/admin/overview" gives "en",
+// "enterprise-server@3.17", and "/admin/overview". The version is empty for free-pro-team.
+func SplitDocsPath(path string) (language, version, rest string) {
+	segments := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 3)
+	language = segments[0]
+	segments = segments[1:]
+	if len(segments) > 0 && isVersionSegment(segments[0]) {
+		version = segments[0]
+		segments = segments[1:]
+	}
+	if len(segments) > 0 {
+		rest = "/" + strings.Join(segments, "/")
+	}
+	return language, version, rest
+}
+
+// RewriteVersion returns the equivalent docs path for another version, e.g.
+// RewriteVersion("/en/actions/quickstart", "enterprise-cloud") returns
+// "/en/enterprise-cloud@latest/actions/quickstart"
+func RewriteVersion(path, version string) string {
+	language, _, rest := SplitDocsPath(path)
+	if segment := versionSegment(version); segment != "" {
+		return "/" + language + "/" + segment + rest
+	}
+	return "/" + language + rest
+}
+
+// LatestServerVersion returns the newest supported enterprise server version, e.g.
+// "enterprise-server@3.17"
+func LatestServerVersion() string {
+	versions, err := LoadSupportedVersions()
+	if err == nil && versions.LatestVersion != "" {
+		return "enterprise-server@" + versions.LatestVersion
+	}
+	return "enterprise-server@3.17"
+}
+
+// DefaultVersionsURL is where --update-versions fetches the supported versions from: the data
+// file on the repository's main branch, kept current by a scheduled workflow
+const DefaultVersionsURL = "https://raw.githubusercontent.com/Ebonsignori/gh-search-docs/main/data/supported-versions.json"
+
+// FetchLatestVersions downloads a supported versions file from sourceURL. The file must list
+// at least one version, including its latest version.
+func FetchLatestVersions(sourceURL string, client *http.Client) (*SupportedVersions, error) {
+	resp, err := client.Get(sourceURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned status %d", sourceURL, resp.StatusCode)
+	}
+
+	var versions SupportedVersions
+	if err := json.NewDecoder(resp.Body).Decode(&versions); err != nil {
+		return nil, fmt.Errorf("%s: %w", sourceURL, err)
+	}
+	if len(versions.SupportedVersions) == 0 || !slices.Contains(versions.SupportedVersions, versions.LatestVersion) {
+		return nil, fmt.Errorf("%s doesn't list its supported versions and the latest one", sourceURL)
+	}
+	return &versions, nil
+}
+
+// DiffVersions returns the versions in next that aren't in prev, and the ones in prev that
+// aren't in next. A nil prev has no versions.
+func DiffVersions(prev, next *SupportedVersions) (added, removed []string) {
+	var old []string
+	if prev != nil {
+		old = prev.SupportedVersions
+	}
+	for _, v := range next.SupportedVersions {
+		if !slices.Contains(old, v) {
+			added = append(added, v)
+		}
+	}
+	for _, v := range old {
+		if !slices.Contains(next.SupportedVersions, v) {
+			removed = append(removed, v)
+		}
+	}
+	return added, removed
+}
+
+// WriteSupportedVersions writes versions to path as indented JSON, creating its directory.
+// The file is replaced atomically so a failed write never leaves it half written.
+func WriteSupportedVersions(path string, versions *SupportedVersions) error {
+	data, err := json.MarshalIndent(versions, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "supported-versions-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,52 @@
+package searchdocs
+
+import "testing"
+
+func TestSplitDocsPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		language string
+		version  string
+		rest     string
+	}{
+		{"/en/actions/quickstart", "en", "", "/actions/quickstart"},
+		{"/en/enterprise-server@3.17/admin/overview", "en", "enterprise-server@3.17", "/admin/overview"},
+		{"/ja/enterprise-cloud@latest/admin", "ja", "enterprise-cloud@latest", "/admin"},
+		{"/en/free-pro-team@latest/actions", "en", "free-pro-team@latest", "/actions"},
+		{"/en", "en", "", ""},
+		{"/en/enterprise-server@3.17", "en", "enterprise-server@3.17", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			language, version, rest := SplitDocsPath(tt.path)
+			if language != tt.language || version != tt.version || rest != tt.rest {
+				t.Errorf("SplitDocsPath(%q) = (%q, %q, %q), want (%q, %q, %q)",
+					tt.path, language, version, rest, tt.language, tt.version, tt.rest)
+			}
+		})
+	}
+}
+
+func TestRewriteVersion(t *testing.T) {
+	tests := []struct {
+		path     string
+		version  string
+		expected string
+	}{
+		{"/en/actions/quickstart", "enterprise-cloud", "/en/enterprise-cloud@latest/actions/quickstart"},
+		{"/en/actions/quickstart", "enterprise-server@3.17", "/en/enterprise-server@3.17/actions/quickstart"},
+		{"/en/actions/quickstart", "free-pro-team", "/en/actions/quickstart"},
+		{"/en/enterprise-server@3.16/admin", "enterprise-server@3.17", "/en/enterprise-server@3.17/admin"},
+		{"/ja/enterprise-cloud@latest/admin", "free-pro-team", "/ja/admin"},
+		{"/en/free-pro-team@latest/actions", "enterprise-cloud", "/en/enterprise-cloud@latest/actions"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path+" "+tt.version, func(t *testing.T) {
+			if got := RewriteVersion(tt.path, tt.version); got != tt.expected {
+				t.Errorf("RewriteVersion(%q, %q) = %q, want %q", tt.path, tt.version, got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package searchdocs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// ClearScreen moves the cursor home and clears the terminal
+const ClearScreen = "\033[H\033[2J"
+
+// WatchContext returns the context RunWatch refreshes until, which Ctrl+C cancels. It is a
+// variable so tests can stop watching.
+var WatchContext = func() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt)
+}
+
+// RunWatch fetches and renders results to out, then every interval clears the terminal and
+// does it again until the WatchContext is cancelled by Ctrl+C. A status line below the
+// results says when the next refresh is. Only a failure of the first fetch is returned; later
+// failures are shown in place of the results and retried at the next refresh.
+func RunWatch(fetch func() (*SearchResult, error), render func(*SearchResult, io.Writer), interval time.Duration, out io.Writer) error {
+	ctx, stop := WatchContext()
+	defer stop()
+
+	for refresh := 0; ; refresh++ {
+		result, err := fetch()
+		if err != nil && refresh == 0 {
+			return err
+		}
+		if refresh > 0 {
+			fmt.Fprint(out, ClearScreen)
+		}
+		if err != nil {
+			fmt.Fprintf(out, "Error: %v\n", err)
+		} else {
+			render(result, out)
+		}
+		fmt.Fprintf(out, "Refreshing in %s... (Ctrl+C to stop)\n", interval)
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package searchdocs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withWatchContext makes RunWatch refresh until ctx is cancelled
+func withWatchContext(t *testing.T, ctx context.Context) {
+	t.Helper()
+	old := WatchContext
+	WatchContext = func() (context.Context, context.CancelFunc) { return ctx, func() {} }
+	t.Cleanup(func() { WatchContext = old })
+}
+
+func TestRunWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	withWatchContext(t, ctx)
+
+	var fetches int
+	fetch := func() (*SearchResult, error) {
+		fetches++
+		if fetches == 2 {
+			return nil, errors.New("API returned status 503")
+		}
+		return &SearchResult{Hits: make([]SearchItem, fetches)}, nil
+	}
+	render := func(result *SearchResult, w io.Writer) {
+		fmt.Fprintf(w, "result %d\n", len(result.Hits))
+		if len(result.Hits) == 3 {
+			cancel()
+		}
+	}
+
+	var out strings.Builder
+	if err := RunWatch(fetch, render, time.Millisecond, &out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	status := "Refreshing in 1ms... (Ctrl+C to stop)\n"
+	want := "result 1\n" + status +
+		ClearScreen + "Error: API returned status 503\n" + status +
+		ClearScreen + "result 3\n" + status
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
+
+func TestRunWatchFirstFetchFails(t *testing.T) {
+	withWatchContext(t, context.Background())
+	fetch := func() (*SearchResult, error) { return nil, errors.New("connection refused") }
+	render := func(*SearchResult, io.Writer) { t.Error("Expected nothing to be rendered") }
+
+	var out strings.Builder
+	if err := RunWatch(fetch, render, time.Hour, &out); err == nil || out.Len() != 0 {
+		t.Errorf("Expected the error and no output, got %v and %q", err, out.String())
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Ebonsignori/gh-search-docs/searchdocs"
+)
+
+// runStats implements the stats subcommand, summarizing the local search history
+func runStats(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	since := fs.String("since", "", "only include history since a duration ago (e.g. 7d, 4w, 48h) or a date (YYYY-MM-DD)")
+	format := fs.String("format", "table", "output format: table (default), json")
+	limit := fs.Int("limit", 10, "number of entries to show in each top list")
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: gh search-docs stats [flags]\n\n")
+		fmt.Fprintf(stderr, "Summarize your local search history. Nothing is sent over the network.\n\n")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(stderr, "Error: unexpected argument %q\n\n", fs.Arg(0))
+		fs.Usage()
+		return 2
+	}
+
+	var cutoff time.Time
+	if *since != "" {
+		var err error
+		if cutoff, err = parseSince(*since, time.Now()); err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+
+	path, err := searchdocs.HistoryPath()
+	if err != nil {
+		fmt.Fprintln(stderr, "error:", err)
+		return 1
+	}
+	entries, err := searchdocs.LoadHistoryFile(path)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error reading history: %v\n", err)
+		return 1
+	}
+
+	stats := searchdocs.ComputeHistoryStats(entries, cutoff, *limit)
+
+	switch *format {
+	case "json":
+		output, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			fmt.Fprintln(stderr, "error:", err)
+			return 1
+		}
+		fmt.Fprintln(stdout, string(output))
+	case "table":
+		printStats(stdout, stats)
+	default:
+		fmt.Fprintf(stderr, "Error: unknown --format %q (use table or json)\n", *format)
+		return 1
+	}
+	return 0
+}
+
+// recordSearch adds a search to the local history read by the stats subcommand. Like
+// --log-file, recording is best-effort and never changes the outcome of the search.
+func recordSearch(stderr io.Writer, opts *options, query, version string, results int) {
+	err := searchdocs.AppendHistory(searchdocs.HistoryEntry{
+		Query:       query,
+		Version:     version,
+		Language:    opts.language,
+		Timestamp:   time.Now().UTC(),
+		ResultCount: results,
+		Action:      searchdocs.HistorySearch,
+	})
+	if err != nil {
+		fmt.Fprintf(stderr, "Warning: could not record search history: %v\n", err)
+	}
+}
+
+// recordOpen appends a history entry for a doc opened from the results
+func recordOpen(stderr io.Writer, docURL string) {
+	err := searchdocs.AppendHistory(searchdocs.HistoryEntry{
+		Timestamp: time.Now().UTC(),
+		Action:    searchdocs.HistoryOpen,
+		URL:       docURL,
+	})
+	if err != nil {
+		fmt.Fprintf(stderr, "Warning: could not record search history: %v\n", err)
+	}
+}
+
+// parseSince parses a --since value relative to now. It accepts day and week counts
+// ("7d", "4w"), Go durations ("48h"), and dates ("2026-01-31").
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if date, err := time.ParseInLocation("2006-01-02", value, now.Location()); err == nil {
+		return date, nil
+	}
+
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, err := strconv.Atoi(strings.TrimSuffix(value, suffix)); err == nil && strings.HasSuffix(value, suffix) && n >= 0 {
+			return now.Add(-time.Duration(n) * unit), nil
+		}
+	}
+
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid --since value %q (use e.g. 7d, 4w, 48h, or 2026-01-31)", value)
+}
+
+// printStats renders history statistics as a small set of tables
+func printStats(w io.Writer, stats searchdocs.HistoryStats) {
+	if stats.Searches == 0 && len(stats.TopDocs) == 0 {
+		fmt.Fprintln(w, "No search history recorded yet.")
+		return
+	}
+
+	fmt.Fprintf(w, "Searches: %d\n", stats.Searches)
+	fmt.Fprintf(w, "Zero-result searches: %d (%.1f%%)\n", stats.ZeroResults, stats.ZeroResultRate*100)
+
+	printCountTable(w, "Top queries", "QUERY", stats.TopQueries)
+	printCountTable(w, "Top opened and bookmarked docs", "URL", stats.TopDocs)
+	printCountTable(w, "Searches per week", "WEEK", stats.SearchesPerWeek)
+	printCountTable(w, "Versions", "VERSION", stats.Versions)
+	printCountTable(w, "Languages", "LANGUAGE", stats.Languages)
+}
+
+// printCountTable renders a titled two-column table, skipping it when there are no rows
+func printCountTable(w io.Writer, title, keyHeader string, counts []searchdocs.Count) {
+	if len(counts) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "\n%s\n", title)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "  %s\tCOUNT\n", keyHeader)
+	for _, c := range counts {
+		fmt.Fprintf(tw, "  %s\t%d\n", c.Key, c.Count)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/Ebonsignori/gh-search-docs/searchdocs"
+)
+
+// parseHitTemplate parses a --template, which is executed once per hit with the SearchItem
+// as the dot. meta returns the response meta; fullurl and stripmarks join the shared
+// searchdocs.TemplateFuncs to help shape the fields.
+func parseHitTemplate(text string) (*template.Template, error) {
+	return searchdocs.ParseTemplate(text, template.FuncMap{
+		// Replaced with the real meta before each execution
+		"meta":       func() any { return nil },
+		"fullurl":    searchdocs.HitURL,
+		"stripmarks": stripMarks,
+	})
+}
+
+// writeTemplate executes the --template once per hit, ending each with a newline unless the
+// template already did. A template that reads .Hits, .Query, or another searchdocs.TemplateData
+// field runs once for the whole search instead.
+func writeTemplate(w io.Writer, tmpl *template.Template, opts *options, query string, result *SearchResult) error {
+	tmpl = tmpl.Funcs(template.FuncMap{"meta": func() any { return result.Meta }})
+	if searchdocs.UsesTemplateData(tmpl) {
+		var out strings.Builder
+		err := tmpl.Execute(&out, searchdocs.TemplateData{
+			Meta:     result.Meta,
+			Hits:     result.Hits,
+			Query:    query,
+			Version:  opts.version,
+			Language: opts.language,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, out.String())
+		return err
+	}
+	for _, item := range result.Hits {
+		var out strings.Builder
+		if err := tmpl.Execute(&out, item); err != nil {
+			return err
+		}
+		line := out.String()
+		if !strings.HasSuffix(line, "\n") {
+			line += "\n"
+		}
+		if _, err := io.WriteString(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stripMarks removes the <mark> tags the API puts around matched terms, and any other tags
+func stripMarks(s string) string {
+	return searchdocs.StripTags(s)
+}
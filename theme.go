@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/Ebonsignori/gh-search-docs/searchdocs"
+)
+
+// resolveTheme settles opts.theme and opts.styleFile from the flags, GH_SEARCH_DOCS_THEME, and
+// GLAMOUR_STYLE. An unknown theme is an error; a style file that can't be used is only a
+// warning, and the theme is used instead.
+func resolveTheme(stderr io.Writer, opts *options) bool {
+	theme, source := opts.theme, "--theme"
+	if theme == "" {
+		theme, source = os.Getenv("GH_SEARCH_DOCS_THEME"), "GH_SEARCH_DOCS_THEME theme"
+	}
+	if theme == "" {
+		theme = themeAuto
+	}
+	if !slices.Contains(themeNames, theme) {
+		fmt.Fprintf(stderr, "Error: unknown %s %q (use %s).\n", source, theme, strings.Join(themeNames, ", "))
+		return false
+	}
+	opts.theme = theme
+	// GLAMOUR_STYLE names a style file, or one of glamour's styles as --theme would
+	styleFile := opts.styleFile
+	if glamourStyle := os.Getenv("GLAMOUR_STYLE"); styleFile == "" && glamourStyle != "" {
+		if !slices.Contains(themeNames, glamourStyle) {
+			styleFile = glamourStyle
+		} else if theme == themeAuto {
+			opts.theme = glamourStyle
+		}
+	}
+	if styleFile != "" {
+		// A broken style file shouldn't stop the search, so it's checked once up front
+		if _, err := searchdocs.NewRendererFromFile(styleFile, 0); err != nil {
+			fmt.Fprintf(stderr, "Warning: can't use style file %s, using the %s theme instead: %v\n", styleFile, opts.theme, err)
+			styleFile = ""
+		}
+	}
+	opts.styleFile = styleFile
+	return true
+}
+
+// dumpTheme writes the JSON of the style pretty output would use for --dump-theme: the style
+// file if one is in use, otherwise the built-in theme, with auto settled on dark or light
+func dumpTheme(stdout, stderr io.Writer, opts *options) int {
+	var (
+		data []byte
+		err  error
+	)
+	switch {
+	case opts.styleFile != "":
+		data, err = os.ReadFile(opts.styleFile)
+	case opts.theme == themeAuto:
+		data, err = searchdocs.ThemeJSON(detectedTheme())
+	default:
+		data, err = searchdocs.ThemeJSON(opts.theme)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	if _, err := stdout.Write(data); err != nil {
+		fmt.Fprintln(stderr, "error:", err)
+		return 1
+	}
+	return 0
+}
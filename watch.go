@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/Ebonsignori/gh-search-docs/searchdocs"
+)
+
+// runWatch runs the search again every --watch interval, redrawing the results each time,
+// until interrupted. Each refresh is processed like a single search, but the search goes into
+// history once, and the results are never paged since a pager would stop the refreshes.
+func runWatch(stdout, stderr io.Writer, client *http.Client, opts *options, query, version string) int {
+	watched := *opts
+	watched.noPager = true
+	opts = &watched
+
+	cache, err := searchCache(opts)
+	if err != nil {
+		fmt.Fprintln(stderr, "error:", err)
+		return 1
+	}
+	fetch := func() (*SearchResult, error) {
+		return requestSearch(stderr, client, opts, cache, query, version)
+	}
+	recorded := false
+	render := func(result *SearchResult, w io.Writer) {
+		search := processSearch(stderr, client, opts, query, version, *result)
+		if !recorded {
+			search.record(stderr, opts, query, version)
+			recorded = true
+		}
+		writeSearch(w, stderr, opts, query, search)
+	}
+	if err := searchdocs.RunWatch(fetch, render, opts.watch, stdout); err != nil {
+		printRequestError(stderr, opts, "Error", err)
+		return 1
+	}
+	return 0
+}